@@ -634,6 +634,12 @@ var (
 	updateAbortOpaqueData = updateAbort.Flag("opaque-data",
 		"opaque data provided by the user").Default("").String()
 
+	// command to abort the running update of a job
+	updateAbortJob           = update.Command("abort-job", "abort the running update of a job")
+	updateAbortJobID         = updateAbortJob.Arg("job", "job identifier").Required().String()
+	updateAbortJobOpaqueData = updateAbortJob.Flag("opaque-data",
+		"opaque data provided by the user").Default("").String()
+
 	// command to pause an update
 	updatePause           = update.Command("pause", "pause a job update")
 	updatePauseID         = updatePause.Arg("update-id", "update identifier").Required().String()
@@ -646,6 +652,18 @@ var (
 	updateResumeOpaqueData = updateResume.Flag("opaque-data",
 		"opaque data provided by the user").Default("").String()
 
+	// command to pause the running updates of all jobs in a resource pool
+	updatePausePool           = update.Command("pause-pool", "pause the running updates of all jobs in a resource pool")
+	updatePausePoolPath       = updatePausePool.Arg("respool", "complete path of the resource pool starting from the root").Required().String()
+	updatePausePoolOpaqueData = updatePausePool.Flag("opaque-data",
+		"opaque data provided by the user").Default("").String()
+
+	// command to resume the paused updates of all jobs in a resource pool
+	updateResumePool           = update.Command("resume-pool", "resume the paused updates of all jobs in a resource pool")
+	updateResumePoolPath       = updateResumePool.Arg("respool", "complete path of the resource pool starting from the root").Required().String()
+	updateResumePoolOpaqueData = updateResumePool.Flag("opaque-data",
+		"opaque data provided by the user").Default("").String()
+
 	// Top level hostmgr command
 	hostmgr = app.Command("hostmgr", "top level command for hostmgr")
 
@@ -1027,10 +1045,16 @@ func main() {
 		err = client.UpdateGetCacheAction(*updateCacheID)
 	case updateAbort.FullCommand():
 		err = client.UpdateAbortAction(*updateAbortID, *updateAbortOpaqueData)
+	case updateAbortJob.FullCommand():
+		err = client.UpdateAbortForJobAction(*updateAbortJobID, *updateAbortJobOpaqueData)
 	case updatePause.FullCommand():
 		err = client.UpdatePauseAction(*updatePauseID, *updatePauseOpaqueData)
 	case updateResume.FullCommand():
 		err = client.UpdateResumeAction(*updateResumeID, *updateResumeOpaqueData)
+	case updatePausePool.FullCommand():
+		err = client.UpdatePausePoolAction(*updatePausePoolPath, *updatePausePoolOpaqueData)
+	case updateResumePool.FullCommand():
+		err = client.UpdateResumePoolAction(*updateResumePoolPath, *updateResumePoolOpaqueData)
 	case offers.FullCommand():
 		err = client.OffersGetAction()
 	case getHosts.FullCommand():
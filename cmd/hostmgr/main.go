@@ -706,6 +706,7 @@ func main() {
 	hostCache = hostcache.New(
 		hostEventCh,
 		backgroundManager,
+		cfg.HostManager.HostCacheTTL,
 		rootScope,
 	)
 
@@ -399,6 +399,7 @@ func main() {
 		store, // store implements JobStore
 		store, // store implements TaskStore
 		*cfg.ResManager.PreemptionConfig)
+	tree.SetDeadLetterThreshold(cfg.ResManager.DeadLetterThreshold)
 
 	// Initialize resource pool service handlers
 	respoolsvc.InitServiceHandler(
@@ -20,6 +20,7 @@ import (
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
 	updatesvc "github.com/uber/peloton/.gen/peloton/api/v0/update/svc"
 
@@ -32,6 +33,10 @@ const (
 		"NumberTasksFailed\tNumberTasksRemaining\n"
 	updateListFormatBody = "%s\t%s\t%d\t%d\t%d\n"
 	invalidVersionError  = "invalid job configuration version"
+
+	// number of jobs in a resource pool queried per page, when pausing
+	// or resuming the updates of all the jobs in the pool
+	poolJobsQueryLimit = 1000
 )
 
 // isUpdateTerminated returns true if update is complete or abortee
@@ -45,12 +50,17 @@ func (c *Client) isUpdateTerminated(updateID *peloton.UpdateID) (bool, error) {
 		return false, err
 	}
 
-	switch response.GetUpdateInfo().GetStatus().GetState() {
+	return isUpdateStateTerminal(response.GetUpdateInfo().GetStatus().GetState()), nil
+}
+
+// isUpdateStateTerminal returns true if the update state is complete or aborted
+func isUpdateStateTerminal(state update.State) bool {
+	switch state {
 	case update.State_SUCCEEDED, update.State_ABORTED,
 		update.State_FAILED, update.State_ROLLED_BACK:
-		return true, nil
+		return true
 	}
-	return false, nil
+	return false
 }
 
 // UpdateCreateAction will create a new job update.
@@ -251,6 +261,44 @@ func (c *Client) UpdateAbortAction(updateID string, opaqueData string) error {
 	return nil
 }
 
+// UpdateAbortForJobAction aborts all the non-terminal updates of a job
+func (c *Client) UpdateAbortForJobAction(jobID string, opaqueData string) error {
+	var request = &updatesvc.ListUpdatesRequest{
+		JobID: &peloton.JobID{
+			Value: jobID,
+		},
+	}
+
+	response, err := c.updateClient.ListUpdates(c.ctx, request)
+	if err != nil {
+		return err
+	}
+
+	defer tabWriter.Flush()
+
+	// the listed updates carry their state, so the updates are not read
+	// again one by one to find the running ones
+	aborted := 0
+	for _, updateInfo := range response.GetUpdateInfo() {
+		if isUpdateStateTerminal(updateInfo.GetStatus().GetState()) {
+			continue
+		}
+
+		if err := c.UpdateAbortAction(
+			updateInfo.GetUpdateId().GetValue(), opaqueData); err != nil {
+			return err
+		}
+		fmt.Fprintf(tabWriter, "aborted update: %v\n",
+			updateInfo.GetUpdateId().GetValue())
+		aborted++
+	}
+
+	if aborted == 0 {
+		fmt.Fprintf(tabWriter, "no running update found for job: %v\n", jobID)
+	}
+	return nil
+}
+
 // UpdateResumeAction resumes a given update
 func (c *Client) UpdateResumeAction(updateID string, opaqueData string) error {
 	var opaque *peloton.OpaqueData
@@ -293,6 +341,145 @@ func (c *Client) UpdatePauseAction(updateID string, opaqueData string) error {
 	return nil
 }
 
+// UpdatePausePoolAction pauses all the running updates of the jobs in a
+// resource pool
+func (c *Client) UpdatePausePoolAction(respoolPath string, opaqueData string) error {
+	return c.updateActionForPool(
+		respoolPath,
+		"pause",
+		func(state update.State) bool {
+			switch state {
+			case update.State_INITIALIZED, update.State_ROLLING_FORWARD,
+				update.State_ROLLING_BACKWARD:
+				return true
+			}
+			return false
+		},
+		func(updateID string) error {
+			return c.UpdatePauseAction(updateID, opaqueData)
+		},
+	)
+}
+
+// UpdateResumePoolAction resumes all the paused updates of the jobs in a
+// resource pool
+func (c *Client) UpdateResumePoolAction(respoolPath string, opaqueData string) error {
+	return c.updateActionForPool(
+		respoolPath,
+		"resume",
+		func(state update.State) bool {
+			return state == update.State_PAUSED
+		},
+		func(updateID string) error {
+			return c.UpdateResumeAction(updateID, opaqueData)
+		},
+	)
+}
+
+// queryJobsInPool returns the summaries of all the jobs in a resource pool,
+// querying them page by page until all of them are read
+func (c *Client) queryJobsInPool(
+	respoolID *peloton.ResourcePoolID,
+	respoolPath string,
+) ([]*job.JobSummary, error) {
+	var summaries []*job.JobSummary
+	for offset := uint32(0); ; offset += poolJobsQueryLimit {
+		// the query reads at most MaxLimit jobs before skipping offset
+		// of them, so the jobs are exhausted once fewer are read
+		maxLimit := offset + poolJobsQueryLimit
+		response, err := c.jobClient.Query(c.ctx, &job.QueryRequest{
+			RespoolID: respoolID,
+			Spec: &job.QuerySpec{
+				Pagination: &query.PaginationSpec{
+					Offset:   offset,
+					Limit:    poolJobsQueryLimit,
+					MaxLimit: maxLimit,
+				},
+			},
+			SummaryOnly: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if response.GetError() != nil {
+			return nil, fmt.Errorf(
+				"failed to query jobs in resource pool %s: %v",
+				respoolPath, response.GetError())
+		}
+
+		summaries = append(summaries, response.GetResults()...)
+		if response.GetPagination().GetTotal() < maxLimit {
+			return summaries, nil
+		}
+	}
+}
+
+// updateActionForPool applies the action to the updates, in the states
+// selected by shouldApply, of all the jobs in a resource pool. It reports
+// the result for each update, and returns an error if the action failed
+// for any of them.
+func (c *Client) updateActionForPool(
+	respoolPath string,
+	actionName string,
+	shouldApply func(state update.State) bool,
+	action func(updateID string) error,
+) error {
+	respoolID, err := c.LookupResourcePoolID(respoolPath)
+	if err != nil {
+		return err
+	}
+	if respoolID == nil {
+		return fmt.Errorf("unable to find resource pool ID for "+
+			":%s", respoolPath)
+	}
+
+	summaries, err := c.queryJobsInPool(respoolID, respoolPath)
+	if err != nil {
+		return err
+	}
+
+	defer tabWriter.Flush()
+
+	applied, failed := 0, 0
+	for _, summary := range summaries {
+		updates, err := c.updateClient.ListUpdates(c.ctx,
+			&updatesvc.ListUpdatesRequest{JobID: summary.GetId()})
+		if err != nil {
+			fmt.Fprintf(tabWriter, "failed to list updates of job %v: %v\n",
+				summary.GetId().GetValue(), err)
+			failed++
+			continue
+		}
+
+		for _, updateInfo := range updates.GetUpdateInfo() {
+			if !shouldApply(updateInfo.GetStatus().GetState()) {
+				continue
+			}
+
+			updateID := updateInfo.GetUpdateId().GetValue()
+			if err := action(updateID); err != nil {
+				fmt.Fprintf(tabWriter, "failed to %s update %v of job %v: %v\n",
+					actionName, updateID, summary.GetId().GetValue(), err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(tabWriter, "%s update %v of job %v: succeeded\n",
+				actionName, updateID, summary.GetId().GetValue())
+			applied++
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("failed to %s %d updates in resource pool %s",
+			actionName, failed, respoolPath)
+	}
+	if applied == 0 {
+		fmt.Fprintf(tabWriter, "no update to %s found in resource pool: %v\n",
+			actionName, respoolPath)
+	}
+	return nil
+}
+
 // printUpdateCreateResponse prints the update identifier returned in the
 // create job update response.
 func printUpdateCreateResponse(resp *updatesvc.CreateUpdateResponse, debug bool) {
@@ -22,6 +22,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	jobmocks "github.com/uber/peloton/.gen/peloton/api/v0/job/mocks"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/query"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	respoolmocks "github.com/uber/peloton/.gen/peloton/api/v0/respool/mocks"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
@@ -708,6 +709,76 @@ func (suite *updateActionsTestSuite) TestClientUpdateAbort() {
 	}
 }
 
+// TestClientUpdateAbortForJob tests aborting the running update of a job
+func (suite *updateActionsTestSuite) TestClientUpdateAbortForJob() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	oldUpdateID := &peloton.UpdateID{Value: uuid.New()}
+	oldUpdate := &update.UpdateInfo{
+		UpdateId: oldUpdateID,
+		JobId:    suite.jobID,
+		Status: &update.UpdateStatus{
+			State: update.State_SUCCEEDED,
+		},
+	}
+	runningUpdate := &update.UpdateInfo{
+		UpdateId: suite.updateID,
+		JobId:    suite.jobID,
+		Status: &update.UpdateStatus{
+			State: update.State_ROLLING_FORWARD,
+		},
+	}
+
+	suite.mockUpdate.EXPECT().
+		ListUpdates(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.ListUpdatesRequest) {
+			suite.Equal(suite.jobID.GetValue(), req.GetJobID().GetValue())
+		}).
+		Return(&svc.ListUpdatesResponse{
+			UpdateInfo: []*update.UpdateInfo{oldUpdate, runningUpdate},
+		}, nil)
+	suite.mockUpdate.EXPECT().
+		AbortUpdate(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.AbortUpdateRequest) {
+			suite.Equal(suite.updateID.GetValue(), req.GetUpdateId().GetValue())
+		}).
+		Return(&svc.AbortUpdateResponse{}, nil)
+
+	suite.NoError(c.UpdateAbortForJobAction(suite.jobID.GetValue(), ""))
+}
+
+// TestClientUpdateAbortForJobNoRunningUpdate tests that aborting the running
+// update of a job with only terminal updates is a no-op
+func (suite *updateActionsTestSuite) TestClientUpdateAbortForJobNoRunningUpdate() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	abortedUpdate := &update.UpdateInfo{
+		UpdateId: suite.updateID,
+		JobId:    suite.jobID,
+		Status: &update.UpdateStatus{
+			State: update.State_ABORTED,
+		},
+	}
+
+	suite.mockUpdate.EXPECT().
+		ListUpdates(context.Background(), gomock.Any()).
+		Return(&svc.ListUpdatesResponse{
+			UpdateInfo: []*update.UpdateInfo{abortedUpdate},
+		}, nil)
+
+	suite.NoError(c.UpdateAbortForJobAction(suite.jobID.GetValue(), ""))
+}
+
 // TestClientUpdatePause tests pausing a job update
 func (suite *updateActionsTestSuite) TestClientUpdatePause() {
 	c := Client{
@@ -781,3 +852,283 @@ func (suite *updateActionsTestSuite) TestClientUpdateResume() {
 		}
 	}
 }
+
+// TestClientUpdatePausePool tests pausing the running updates of all the
+// jobs in a resource pool while skipping the terminal ones
+func (suite *updateActionsTestSuite) TestClientUpdatePausePool() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		resClient:    suite.mockRespool,
+		jobClient:    suite.mockJob,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	respoolPath := "/DefaultResPool"
+	respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+
+	updateStates := []update.State{
+		update.State_ROLLING_FORWARD,
+		update.State_SUCCEEDED,
+		update.State_ROLLING_BACKWARD,
+		update.State_ABORTED,
+	}
+	var summaries []*job.JobSummary
+	var pausedUpdates []string
+	for _, state := range updateStates {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		updateID := &peloton.UpdateID{Value: uuid.New()}
+		summaries = append(summaries, &job.JobSummary{Id: jobID})
+		if state == update.State_ROLLING_FORWARD ||
+			state == update.State_ROLLING_BACKWARD {
+			pausedUpdates = append(pausedUpdates, updateID.GetValue())
+		}
+
+		suite.mockUpdate.EXPECT().
+			ListUpdates(context.Background(), &svc.ListUpdatesRequest{
+				JobID: jobID,
+			}).
+			Return(&svc.ListUpdatesResponse{
+				UpdateInfo: []*update.UpdateInfo{{
+					UpdateId: updateID,
+					JobId:    jobID,
+					Status:   &update.UpdateStatus{State: state},
+				}},
+			}, nil)
+	}
+
+	suite.mockRespool.EXPECT().
+		LookupResourcePoolID(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *respool.LookupRequest) {
+			suite.Equal(respoolPath, req.GetPath().GetValue())
+		}).
+		Return(&respool.LookupResponse{Id: respoolID}, nil)
+	suite.mockJob.EXPECT().
+		Query(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *job.QueryRequest) {
+			suite.Equal(respoolID.GetValue(), req.GetRespoolID().GetValue())
+		}).
+		Return(&job.QueryResponse{Results: summaries}, nil)
+
+	var paused []string
+	suite.mockUpdate.EXPECT().
+		PauseUpdate(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.PauseUpdateRequest) {
+			paused = append(paused, req.GetUpdateId().GetValue())
+		}).
+		Return(&svc.PauseUpdateResponse{}, nil).
+		Times(len(pausedUpdates))
+
+	suite.NoError(c.UpdatePausePoolAction(respoolPath, ""))
+	suite.Equal(pausedUpdates, paused)
+}
+
+// TestClientUpdatePausePoolFailure tests that a failure to pause one of the
+// updates in a resource pool is reported after trying all of them
+func (suite *updateActionsTestSuite) TestClientUpdatePausePoolFailure() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		resClient:    suite.mockRespool,
+		jobClient:    suite.mockJob,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	respoolPath := "/DefaultResPool"
+	jobIDs := []*peloton.JobID{{Value: uuid.New()}, {Value: uuid.New()}}
+
+	suite.mockRespool.EXPECT().
+		LookupResourcePoolID(context.Background(), gomock.Any()).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: uuid.New()},
+		}, nil)
+	suite.mockJob.EXPECT().
+		Query(context.Background(), gomock.Any()).
+		Return(&job.QueryResponse{
+			Results: []*job.JobSummary{{Id: jobIDs[0]}, {Id: jobIDs[1]}},
+		}, nil)
+	for _, jobID := range jobIDs {
+		suite.mockUpdate.EXPECT().
+			ListUpdates(context.Background(), &svc.ListUpdatesRequest{
+				JobID: jobID,
+			}).
+			Return(&svc.ListUpdatesResponse{
+				UpdateInfo: []*update.UpdateInfo{{
+					UpdateId: &peloton.UpdateID{Value: uuid.New()},
+					JobId:    jobID,
+					Status: &update.UpdateStatus{
+						State: update.State_ROLLING_FORWARD,
+					},
+				}},
+			}, nil)
+	}
+	gomock.InOrder(
+		suite.mockUpdate.EXPECT().
+			PauseUpdate(context.Background(), gomock.Any()).
+			Return(nil, errors.New("update cannot be paused")),
+		suite.mockUpdate.EXPECT().
+			PauseUpdate(context.Background(), gomock.Any()).
+			Return(&svc.PauseUpdateResponse{}, nil),
+	)
+
+	suite.Error(c.UpdatePausePoolAction(respoolPath, ""))
+}
+
+// TestClientUpdatePausePoolPaging tests that the jobs of a resource pool
+// are queried page by page until all of them are read
+func (suite *updateActionsTestSuite) TestClientUpdatePausePoolPaging() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		resClient:    suite.mockRespool,
+		jobClient:    suite.mockJob,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	respoolPath := "/DefaultResPool"
+	jobIDs := []*peloton.JobID{{Value: uuid.New()}, {Value: uuid.New()}}
+
+	suite.mockRespool.EXPECT().
+		LookupResourcePoolID(context.Background(), gomock.Any()).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: uuid.New()},
+		}, nil)
+
+	// the first page is full, and the second one reads the last job
+	var offsets []uint32
+	gomock.InOrder(
+		suite.mockJob.EXPECT().
+			Query(context.Background(), gomock.Any()).
+			Do(func(_ context.Context, req *job.QueryRequest) {
+				offsets = append(offsets,
+					req.GetSpec().GetPagination().GetOffset())
+			}).
+			Return(&job.QueryResponse{
+				Results: []*job.JobSummary{{Id: jobIDs[0]}},
+				Pagination: &query.Pagination{
+					Total: poolJobsQueryLimit,
+				},
+			}, nil),
+		suite.mockJob.EXPECT().
+			Query(context.Background(), gomock.Any()).
+			Do(func(_ context.Context, req *job.QueryRequest) {
+				offsets = append(offsets,
+					req.GetSpec().GetPagination().GetOffset())
+			}).
+			Return(&job.QueryResponse{
+				Results: []*job.JobSummary{{Id: jobIDs[1]}},
+				Pagination: &query.Pagination{
+					Total: poolJobsQueryLimit + 1,
+				},
+			}, nil),
+	)
+
+	var pausedUpdates []string
+	for _, jobID := range jobIDs {
+		updateID := &peloton.UpdateID{Value: uuid.New()}
+		pausedUpdates = append(pausedUpdates, updateID.GetValue())
+		suite.mockUpdate.EXPECT().
+			ListUpdates(context.Background(), &svc.ListUpdatesRequest{
+				JobID: jobID,
+			}).
+			Return(&svc.ListUpdatesResponse{
+				UpdateInfo: []*update.UpdateInfo{{
+					UpdateId: updateID,
+					JobId:    jobID,
+					Status: &update.UpdateStatus{
+						State: update.State_ROLLING_FORWARD,
+					},
+				}},
+			}, nil)
+	}
+
+	var paused []string
+	suite.mockUpdate.EXPECT().
+		PauseUpdate(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.PauseUpdateRequest) {
+			paused = append(paused, req.GetUpdateId().GetValue())
+		}).
+		Return(&svc.PauseUpdateResponse{}, nil).
+		Times(len(pausedUpdates))
+
+	suite.NoError(c.UpdatePausePoolAction(respoolPath, ""))
+	suite.Equal([]uint32{0, poolJobsQueryLimit}, offsets)
+	suite.Equal(pausedUpdates, paused)
+}
+
+// TestClientUpdateResumePool tests resuming only the paused updates of
+// all the jobs in a resource pool
+func (suite *updateActionsTestSuite) TestClientUpdateResumePool() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		resClient:    suite.mockRespool,
+		jobClient:    suite.mockJob,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	respoolPath := "/DefaultResPool"
+	pausedUpdateID := &peloton.UpdateID{Value: uuid.New()}
+
+	suite.mockRespool.EXPECT().
+		LookupResourcePoolID(context.Background(), gomock.Any()).
+		Return(&respool.LookupResponse{
+			Id: &peloton.ResourcePoolID{Value: uuid.New()},
+		}, nil)
+	suite.mockJob.EXPECT().
+		Query(context.Background(), gomock.Any()).
+		Return(&job.QueryResponse{
+			Results: []*job.JobSummary{{Id: suite.jobID}},
+		}, nil)
+	suite.mockUpdate.EXPECT().
+		ListUpdates(context.Background(), gomock.Any()).
+		Return(&svc.ListUpdatesResponse{
+			UpdateInfo: []*update.UpdateInfo{
+				{
+					UpdateId: &peloton.UpdateID{Value: uuid.New()},
+					JobId:    suite.jobID,
+					Status: &update.UpdateStatus{
+						State: update.State_ROLLING_FORWARD,
+					},
+				},
+				{
+					UpdateId: pausedUpdateID,
+					JobId:    suite.jobID,
+					Status: &update.UpdateStatus{
+						State: update.State_PAUSED,
+					},
+				},
+			},
+		}, nil)
+	suite.mockUpdate.EXPECT().
+		ResumeUpdate(context.Background(), gomock.Any()).
+		Do(func(_ context.Context, req *svc.ResumeUpdateRequest) {
+			suite.Equal(pausedUpdateID.GetValue(), req.GetUpdateId().GetValue())
+		}).
+		Return(&svc.ResumeUpdateResponse{}, nil)
+
+	suite.NoError(c.UpdateResumePoolAction(respoolPath, ""))
+}
+
+// TestClientUpdatePausePoolLookupError tests failing to pause the updates
+// in a resource pool which cannot be found
+func (suite *updateActionsTestSuite) TestClientUpdatePausePoolLookupError() {
+	c := Client{
+		Debug:        false,
+		updateClient: suite.mockUpdate,
+		resClient:    suite.mockRespool,
+		jobClient:    suite.mockJob,
+		dispatcher:   nil,
+		ctx:          suite.ctx,
+	}
+
+	suite.mockRespool.EXPECT().
+		LookupResourcePoolID(context.Background(), gomock.Any()).
+		Return(&respool.LookupResponse{Id: nil}, nil)
+
+	suite.Error(c.UpdatePausePoolAction("/DefaultResPool", ""))
+}
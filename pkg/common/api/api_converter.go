@@ -498,6 +498,7 @@ func ConvertRuntimeInfoToJobStatus(
 		runtime.GetWorkflowVersion(),
 	)
 	result.WorkflowStatus = ConvertUpdateModelToWorkflowStatus(runtime, updateInfo)
+	result.KillReason = runtime.GetKillReason()
 
 	for configVersion, taskStats := range runtime.GetTaskStatsByConfigurationVersion() {
 		entityVersion := versionutil.GetPodEntityVersion(configVersion)
@@ -61,6 +61,18 @@ func NewRetryPolicy(maxAttempts int, retryInterval time.Duration) RetryPolicy {
 	}
 }
 
+// NewExponentialRetryPolicy is used to create a new instance of RetryPolicy
+// whose delay starts at baseInterval and doubles after every attempt.
+func NewExponentialRetryPolicy(
+	maxAttempts int,
+	baseInterval time.Duration,
+) RetryPolicy {
+	return &exponentialRetryPolicy{
+		maxAttempts:  maxAttempts,
+		baseInterval: baseInterval,
+	}
+}
+
 type retryPolicy struct {
 	maxAttempts   int
 	retryInterval time.Duration
@@ -74,3 +86,16 @@ func (p *retryPolicy) CalculateNextDelay(attempts int) time.Duration {
 	}
 	return p.retryInterval
 }
+
+type exponentialRetryPolicy struct {
+	maxAttempts  int
+	baseInterval time.Duration
+}
+
+// CalculateNextDelay returns next delay.
+func (p *exponentialRetryPolicy) CalculateNextDelay(attempts int) time.Duration {
+	if attempts >= p.maxAttempts {
+		return done
+	}
+	return p.baseInterval << uint(attempts-1)
+}
@@ -48,3 +48,13 @@ func (s *RetryTestSuite) TestRetryMaxAttempts() {
 	}
 	s.Equal(next, done)
 }
+
+func (s *RetryTestSuite) TestExponentialRetryNextBackOff() {
+	policy := NewExponentialRetryPolicy(5, 5*time.Millisecond)
+	r := NewRetrier(policy)
+	s.Equal(5*time.Millisecond, r.NextBackOff())
+	s.Equal(10*time.Millisecond, r.NextBackOff())
+	s.Equal(20*time.Millisecond, r.NextBackOff())
+	s.Equal(40*time.Millisecond, r.NextBackOff())
+	s.Equal(done, r.NextBackOff())
+}
@@ -88,7 +88,7 @@ func createJobRuntime(
 		},
 		ConfigurationVersion: 1,
 	}
-	return jobRuntimeOps.Upsert(ctx, jobID, &runtime)
+	return jobRuntimeOps.Upsert(ctx, jobID, &runtime, pb_job.JobState_UNKNOWN)
 }
 
 func createJobConfig(
@@ -155,7 +155,7 @@ func createJob(
 	jobRuntime.State = state
 	jobRuntime.GoalState = goalState
 
-	if err := jobRuntimeOps.Upsert(ctx, jobID, jobRuntime); err != nil {
+	if err := jobRuntimeOps.Upsert(ctx, jobID, jobRuntime, pb_job.JobState_UNKNOWN); err != nil {
 		return nil, err
 	}
 	return jobID, nil
@@ -61,6 +61,10 @@ type Config struct {
 	// Period for which to wait for host in PLACING state before reset.
 	HostPlacingOfferStatusTimeout time.Duration `yaml:"host_placing_offer_status_sec"`
 
+	// Time after which a host not refreshed by the cluster manager is
+	// evicted from the host cache. Eviction is disabled if it is zero.
+	HostCacheTTL time.Duration `yaml:"host_cache_ttl"`
+
 	// Backoff Retry Count to register background worker for Host Manager
 	HostMgrBackoffRetryCount int `yaml:"hostmgr_backoff_retry_count"`
 
@@ -38,6 +38,7 @@ import (
 	"github.com/uber/peloton/pkg/hostmgr/offer/offerpool"
 	mesosplugins "github.com/uber/peloton/pkg/hostmgr/p2k/plugins/mesos"
 	"github.com/uber/peloton/pkg/hostmgr/prune"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
 	"github.com/uber/peloton/pkg/hostmgr/watchevent"
 
 	"github.com/pborman/uuid"
@@ -73,6 +74,10 @@ type EventHandler interface {
 	// GetOfferPool returns the underlying Pool holding the offers.
 	GetOfferPool() offerpool.Pool
 
+	// GetAvailableResources returns the sum of resources of all the offers
+	// currently held in the offer pool.
+	GetAvailableResources() scalar.Resources
+
 	// Get the handler for eventstream
 	GetEventStreamHandler() *eventstream.Handler
 
@@ -554,6 +559,20 @@ func (h *eventHandler) GetOfferPool() offerpool.Pool {
 	return h.offerPool
 }
 
+// GetAvailableResources returns the sum of resources of all the offers
+// currently held in the offer pool. The offers are read from a single
+// snapshot of the pool, so hosts added or pruned concurrently are either
+// fully counted or not counted at all.
+func (h *eventHandler) GetAvailableResources() scalar.Resources {
+	hostOffers, _ := h.offerPool.GetAllOffers()
+
+	var available scalar.Resources
+	for _, offers := range hostOffers {
+		available = available.Add(scalar.FromOfferMap(offers))
+	}
+	return available
+}
+
 // Start runs startup related procedures
 func (h *eventHandler) Start() error {
 	// Start offer pruner
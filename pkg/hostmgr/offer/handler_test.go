@@ -17,6 +17,7 @@ package offer
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 	"testing"
@@ -31,12 +32,14 @@ import (
 	"github.com/uber/peloton/pkg/common/background"
 	"github.com/uber/peloton/pkg/common/cirbuf"
 	"github.com/uber/peloton/pkg/common/rpc"
+	"github.com/uber/peloton/pkg/common/util"
 	binpacking "github.com/uber/peloton/pkg/hostmgr/binpacking"
 	config "github.com/uber/peloton/pkg/hostmgr/config"
 	hostmgr_mesos "github.com/uber/peloton/pkg/hostmgr/mesos"
 	"github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb"
 	mpb_mocks "github.com/uber/peloton/pkg/hostmgr/mesos/yarpc/encoding/mpb/mocks"
 	mesosmanager "github.com/uber/peloton/pkg/hostmgr/p2k/plugins/mesos"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
 	watchmocks "github.com/uber/peloton/pkg/hostmgr/watchevent/mocks"
 	storage_mocks "github.com/uber/peloton/pkg/storage/mocks"
 
@@ -386,6 +389,47 @@ func createEvent(_uuid string, offset int) *pb_eventstream.Event {
 	}
 }
 
+// TestGetAvailableResources tests that the available resources match the
+// sum of the resources of all the offers in the pool.
+func (s *HostMgrOfferHandlerTestSuite) TestGetAvailableResources() {
+	eh := GetEventHandler()
+	defer eh.GetOfferPool().Clear()
+
+	var offers []*mesos.Offer
+	allOffers := make(map[string]*mesos.Offer)
+	for i := 0; i < 5; i++ {
+		hostname := fmt.Sprintf("hostname-%d", i)
+		for j := 0; j < 2; j++ {
+			offerID := fmt.Sprintf("%s-offer-%d", hostname, j)
+			agentID := fmt.Sprintf("%s-agent", hostname)
+			offer := &mesos.Offer{
+				Id:       &mesos.OfferID{Value: &offerID},
+				AgentId:  &mesos.AgentID{Value: &agentID},
+				Hostname: &hostname,
+				Resources: util.CreateMesosScalarResources(map[string]float64{
+					"cpus": float64(i + 1),
+					"mem":  100.0,
+					"disk": 1000.0,
+					"gpus": float64(j),
+				}, "*"),
+			}
+			offers = append(offers, offer)
+			allOffers[offerID] = offer
+		}
+	}
+	eh.GetOfferPool().AddOffers(s.context, offers)
+
+	available := eh.GetAvailableResources()
+	expected := scalar.FromOfferMap(allOffers)
+	s.Equal(expected, available)
+	s.Equal(30.0, available.GetCPU())
+	s.Equal(1000.0, available.GetMem())
+	s.Equal(5.0, available.GetGPU())
+
+	eh.GetOfferPool().Clear()
+	s.Equal(scalar.Resources{}, eh.GetAvailableResources())
+}
+
 func TestHostMgrOfferHander(t *testing.T) {
 	suite.Run(t, new(HostMgrOfferHandlerTestSuite))
 }
@@ -15,6 +15,8 @@
 package hostcache
 
 import (
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,10 +42,12 @@ import (
 )
 
 const (
-	_hostCacheMetricsRefresh       = "hostCacheMetricsRefresh"
-	_hostCacheMetricsRefreshPeriod = 10 * time.Second
-	_hostCachePruneHeldHosts       = "hostCachePruneHeldHosts"
-	_hostCachePruneHeldHostsPeriod = 180 * time.Second
+	_hostCacheMetricsRefresh        = "hostCacheMetricsRefresh"
+	_hostCacheMetricsRefreshPeriod  = 10 * time.Second
+	_hostCachePruneHeldHosts        = "hostCachePruneHeldHosts"
+	_hostCachePruneHeldHostsPeriod  = 180 * time.Second
+	_hostCacheEvictStaleHosts       = "hostCacheEvictStaleHosts"
+	_hostCacheEvictStaleHostsPeriod = 60 * time.Second
 )
 
 // HostCache manages cluster resources, and provides necessary abstractions to
@@ -52,6 +56,17 @@ type HostCache interface {
 	// AcquireLeases acquires leases on hosts that match the filter constraints.
 	AcquireLeases(hostFilter *hostmgr.HostFilter) ([]*hostmgr.HostLease, map[string]uint32)
 
+	// TryMatchHost acquires a lease on the given host only, if it matches
+	// the filter constraints.
+	TryMatchHost(
+		hostname string,
+		hostFilter *hostmgr.HostFilter,
+	) (*hostmgr.HostLease, hostmgr.HostFilterResult, error)
+
+	// EvaluateFilter matches the filter against all the hosts without
+	// leasing any of them, and explains why the hosts do not match.
+	EvaluateFilter(hostFilter *hostmgr.HostFilter) *FilterEvaluation
+
 	// TerminateLease is called when the lease is not going to be used, and we
 	// want to release the lock on the host.
 	TerminateLease(hostname string, leaseID string) error
@@ -63,6 +78,18 @@ type HostCache interface {
 	// GetClusterCapacity gets the total capacity and allocation of the cluster.
 	GetClusterCapacity() (capacity, allocation hmscalar.Resources)
 
+	// GetReadyHostCount returns the number of hosts in Ready status.
+	GetReadyHostCount() int
+
+	// GetTotalAvailable returns the total available resources on the hosts
+	// in Ready status.
+	GetTotalAvailable() hmscalar.Resources
+
+	// GetMaxAvailable returns, for each resource type, the most available
+	// on any single host in Ready status. A pod asking for more than this
+	// cannot currently be placed anywhere.
+	GetMaxAvailable() hmscalar.Resources
+
 	// Start will start the goroutine that listens for host events.
 	Start()
 
@@ -73,6 +100,15 @@ type HostCache interface {
 	// managing.
 	GetSummaries() (summaries []hostsummary.HostSummary)
 
+	// ListHostsByStatus returns the host summaries which are in the given
+	// status.
+	ListHostsByStatus(status hostsummary.HostStatus) []hostsummary.HostSummary
+
+	// FindHostsWithoutPodLabel returns the hosts which do not run any pod
+	// with a label matching the selector, for example to spread the pods
+	// of a job across hosts.
+	FindHostsWithoutPodLabel(selector *peloton.Label) []string
+
 	// HandlePodEvent is called by pod events manager on receiving a pod event.
 	HandlePodEvent(event *scalar.PodEvent)
 
@@ -80,6 +116,16 @@ type HostCache interface {
 	// the helds have expired and returns the hostnames which got reset.
 	ResetExpiredHeldHostSummaries(now time.Time) []string
 
+	// EvictStaleHosts evicts the hosts without pods which have not been
+	// refreshed since the deadline, releasing their leases and holds, and
+	// returns the hostnames which got evicted.
+	EvictStaleHosts(deadline time.Time) []string
+
+	// ReleaseAgentPods releases all the pods on the host once its agent is
+	// lost, and removes the host from the cache. It returns the IDs of the
+	// pods released.
+	ReleaseAgentPods(hostname string) ([]*peloton.PodID, error)
+
 	// GetHostHeldForPod returns the host that is held for the pod.
 	GetHostHeldForPod(podID *peloton.PodID) string
 
@@ -89,6 +135,16 @@ type HostCache interface {
 	// ReleaseHoldForPods release the hold of host for the pods specified.
 	ReleaseHoldForPods(hostname string, podIDs []*peloton.PodID) error
 
+	// ReserveCapacity reserves resources on the host before the pods using
+	// them are known, and returns the token of the reservation. The
+	// resources are not available to placement until the reservation is
+	// released with ReleaseReservation, or expires like a hold. Reservations
+	// are not part of the snapshot, so they are lost on failover.
+	ReserveCapacity(hostname string, amount hmscalar.Resources) (string, error)
+
+	// ReleaseReservation releases a reservation made with ReserveCapacity.
+	ReleaseReservation(hostname string, token string) error
+
 	// CompleteLaunchPod is called when a pod is successfully launched.
 	// This is for things like removing pods allocated to the pod
 	// from available ports. This is called after successful launch
@@ -110,6 +166,38 @@ type HostCache interface {
 	// AddPodsToHost is a temporary method to add host entries in host cache.
 	// It would be removed after CompleteLease is called when launching pod.
 	AddPodsToHost(tasks []*hostsvc.LaunchableTask, hostname string)
+
+	// Snapshot returns a serializable view of all the host summaries
+	// and the pods on them.
+	Snapshot() (*Snapshot, error)
+
+	// Restore rebuilds the host cache from a snapshot, with all the
+	// hosts in ready state and their pods accounted for.
+	Restore(snapshot *Snapshot) error
+}
+
+// _nearMissRank ranks the results of a failed match by how far the host
+// went through the checks of the filter before failing them. The host with
+// the highest rank is the closest to matching the filter.
+var _nearMissRank = map[hostmgr.HostFilterResult]int{
+	hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS:        1,
+	hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES: 2,
+	hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_CONSTRAINTS:   3,
+}
+
+// FilterEvaluation is the result of matching a host filter against all the
+// hosts in the host cache.
+type FilterEvaluation struct {
+	// Map of filtering result string (i.e. host_filter_match) to number
+	// of hosts per result.
+	FilterCounts map[string]uint32
+
+	// Names of the hosts that match the filter.
+	MatchedHosts []string
+
+	// The host which failed the filter but came the closest to matching
+	// it, nil if no host failed the filter.
+	NearMiss *hostsummary.Match
 }
 
 // hostCache is an implementation of HostCache interface.
@@ -132,14 +220,29 @@ type hostCache struct {
 	// background manager.
 	backgroundMgr background.Manager
 
+	// Time after which a host which has not been refreshed is evicted from
+	// the cache. Eviction is disabled if it is zero.
+	hostTTL time.Duration
+
 	// Metrics.
 	metrics *Metrics
+
+	// Lock for placingSince, which is updated by callers holding only
+	// the read lock on the cache.
+	placingMu sync.Mutex
+
+	// Map of hostname to the time at which the host was leased.
+	placingSince map[string]time.Time
+
+	// Clock used to time the leases.
+	now func() time.Time
 }
 
 // New returns a new instance of host cache.
 func New(
 	hostEventCh chan *scalar.HostEvent,
 	backgroundMgr background.Manager,
+	hostTTL time.Duration,
 	parent tally.Scope,
 ) HostCache {
 	return &hostCache{
@@ -149,6 +252,9 @@ func New(
 		lifecycle:     lifecycle.NewLifeCycle(),
 		metrics:       NewMetrics(parent),
 		backgroundMgr: backgroundMgr,
+		hostTTL:       hostTTL,
+		placingSince:  make(map[string]time.Time),
+		now:           time.Now,
 	}
 }
 
@@ -163,6 +269,24 @@ func (c *hostCache) GetSummaries() []hostsummary.HostSummary {
 	return summaries
 }
 
+// ListHostsByStatus returns the host summaries which are in the given status.
+// The status of each host is read under the host summary lock, so a host
+// is listed only if it was in the status at the time it was visited.
+func (c *hostCache) ListHostsByStatus(
+	status hostsummary.HostStatus,
+) []hostsummary.HostSummary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var summaries []hostsummary.HostSummary
+	for _, summary := range c.hostIndex {
+		if summary.GetHostStatus() == status {
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries
+}
+
 // AcquireLeases acquires leases on hosts that match the filter constraints.
 // The lease will be held until Jobmgr actively launches pods using the leaseID.
 // Returns:
@@ -200,6 +324,7 @@ func (c *hostCache) AcquireLeases(
 	for _, hostname := range matcher.GetHostNames() {
 		hs := c.hostIndex[hostname]
 		hostLeases = append(hostLeases, hs.GetHostLease())
+		c.startPlacing(hostname)
 	}
 
 	if !hostLimitReached {
@@ -213,14 +338,104 @@ func (c *hostCache) AcquireLeases(
 	return hostLeases, matcher.GetFilterCounts()
 }
 
+// FindHostsWithoutPodLabel returns the sorted names of the hosts which do
+// not run any pod with a label matching the selector. A selector with an
+// empty value matches any label with the same key.
+func (c *hostCache) FindHostsWithoutPodLabel(selector *peloton.Label) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var hostnames []string
+	for hostname, hs := range c.hostIndex {
+		if !hs.HasPodWithLabel(selector) {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	sort.Strings(hostnames)
+	return hostnames
+}
+
+// TryMatchHost atomically matches the filter against the given host only,
+// and leases the host if it matches. It is used to place a pod back on the
+// host it is pinned to, such as the host of its persistent volume. The
+// lease is nil and the result explains the mismatch if the host does not
+// match the filter. Returns an error if the host is not in the cache.
+func (c *hostCache) TryMatchHost(
+	hostname string,
+	hostFilter *hostmgr.HostFilter,
+) (*hostmgr.HostLease, hostmgr.HostFilterResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hs, err := c.getSummary(hostname)
+	if err != nil {
+		return nil, hostmgr.HostFilterResult_HOST_FILTER_INVALID, err
+	}
+
+	match := hs.TryMatch(hostFilter)
+	if match.Result != hostmgr.HostFilterResult_HOST_FILTER_MATCH {
+		log.WithFields(log.Fields{
+			"host_filter": hostFilter,
+			"host":        hostname,
+			"match":       match,
+		}).Debug("Pinned host does not match the filter")
+		return nil, match.Result, nil
+	}
+
+	c.startPlacing(hostname)
+	return hs.GetHostLease(), match.Result, nil
+}
+
+// EvaluateFilter matches the filter against all the hosts in the cache
+// without changing their status, so that the hosts are not leased. The
+// max hosts limit of the filter is ignored, as every host is evaluated.
+// It is used to explain why a placement does not find any host.
+func (c *hostCache) EvaluateFilter(
+	hostFilter *hostmgr.HostFilter,
+) *FilterEvaluation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	eval := &FilterEvaluation{
+		FilterCounts: make(map[string]uint32),
+	}
+	for hostname, hs := range c.hostIndex {
+		match := hs.Matches(hostFilter)
+		if name, ok := hostmgr.HostFilterResult_name[int32(match.Result)]; ok {
+			eval.FilterCounts[strings.ToLower(name)]++
+		}
+
+		if match.Result == hostmgr.HostFilterResult_HOST_FILTER_MATCH {
+			eval.MatchedHosts = append(eval.MatchedHosts, hostname)
+			continue
+		}
+
+		// Break ties on the hostname, so that the near miss does not
+		// depend on the order of iteration of the host index.
+		if nm := eval.NearMiss; nm == nil ||
+			_nearMissRank[match.Result] > _nearMissRank[nm.Result] ||
+			(_nearMissRank[match.Result] == _nearMissRank[nm.Result] &&
+				hostname < nm.HostName) {
+			eval.NearMiss = &hostsummary.Match{
+				Result:   match.Result,
+				HostName: hostname,
+			}
+		}
+	}
+	sort.Strings(eval.MatchedHosts)
+
+	return eval
+}
+
 // TerminateLease is called when a lease that was previously acquired, and a
 // host locked, is no longer in use. The leaseID of the acquired host should be
 // supplied in this call so that the hostcache can match the leaseID.
 // At this point, the existing lease is terminated and the host can be used for
 // further placement.
 // Error cases:
-//		LeaseID doesn't match
-//		Host is not in Placing status
+//
+//	LeaseID doesn't match
+//	Host is not in Placing status
 func (c *hostCache) TerminateLease(
 	hostname string,
 	leaseID string,
@@ -236,6 +451,7 @@ func (c *hostCache) TerminateLease(
 		// TODO: metrics
 		return err
 	}
+	c.stopPlacing(hs)
 	return nil
 }
 
@@ -247,9 +463,10 @@ func (c *hostCache) TerminateLease(
 // At this point, the existing lease is Completed and the host can be used for
 // further placement.
 // Error cases:
-// 		LeaseID doesn't match
-//		Host is not in Placing status
-// 		There are insufficient resources on the requested host
+//
+//	LeaseID doesn't match
+//	Host is not in Placing status
+//	There are insufficient resources on the requested host
 func (c *hostCache) CompleteLease(
 	hostname string,
 	leaseID string,
@@ -262,7 +479,10 @@ func (c *hostCache) CompleteLease(
 	if err != nil {
 		return err
 	}
-	if err := hs.CompleteLease(leaseID, podToSpecMap); err != nil {
+	_, err = hs.CompleteLease(leaseID, podToSpecMap)
+	// The lease is released even if the pods could not be added to the host.
+	c.stopPlacing(hs)
+	if err != nil {
 		// TODO: metrics
 		return err
 	}
@@ -288,6 +508,61 @@ func (c *hostCache) GetClusterCapacity() (
 	return
 }
 
+// GetReadyHostCount returns the number of hosts in Ready status, and
+// updates the ready hosts gauge.
+func (c *hostCache) GetReadyHostCount() int {
+	count, _ := c.getReadyCapacity()
+	return count
+}
+
+// GetTotalAvailable returns the total available resources on the hosts in
+// Ready status, and updates the ready resources gauges. The resources
+// allocated to pods or reserved for held pods are not available.
+func (c *hostCache) GetTotalAvailable() hmscalar.Resources {
+	_, available := c.getReadyCapacity()
+	return available
+}
+
+// GetMaxAvailable returns the maximum available resources of the hosts in
+// Ready status, taken per resource type. The maximums may come from
+// different hosts, so the result is an upper bound on the largest pod that
+// can be placed, used to reject impossible requests early.
+func (c *hostCache) GetMaxAvailable() hmscalar.Resources {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var maxAvailable hmscalar.Resources
+	for _, hs := range c.hostIndex {
+		if hs.GetHostStatus() != hostsummary.ReadyHost {
+			continue
+		}
+		maxAvailable = hmscalar.Maximum(
+			maxAvailable, hs.GetAvailable().NonSlack)
+	}
+	return maxAvailable
+}
+
+// getReadyCapacity returns the number of hosts in Ready status and their
+// total available resources, computed from the same view of the hosts.
+// It updates the corresponding gauges.
+func (c *hostCache) getReadyCapacity() (int, hmscalar.Resources) {
+	c.mu.RLock()
+	var count int
+	var available hmscalar.Resources
+	for _, hs := range c.hostIndex {
+		if hs.GetHostStatus() != hostsummary.ReadyHost {
+			continue
+		}
+		count++
+		available = available.Add(hs.GetAvailable().NonSlack)
+	}
+	c.mu.RUnlock()
+
+	c.metrics.ReadyHosts.Update(float64(count))
+	c.metrics.ReadyAvailable.Update(available)
+	return count, available
+}
+
 // ResetExpiredHeldHostSummaries resets the status of each hostSummary if
 // the holds have expired and returns the hostnames which got reset.
 func (c *hostCache) ResetExpiredHeldHostSummaries(deadline time.Time) []string {
@@ -308,6 +583,78 @@ func (c *hostCache) ResetExpiredHeldHostSummaries(deadline time.Time) []string {
 	return pruned
 }
 
+// EvictStaleHosts evicts the hosts which have not been refreshed since the
+// deadline. The lease on a host in Placing status is terminated and the
+// holds on the host are released before it is removed from the cache.
+// Hosts with pods are kept, since a host fully used by its pods receives
+// no offers to refresh it; such hosts are removed by ReleaseAgentPods once
+// their agent is lost.
+func (c *hostCache) EvictStaleHosts(deadline time.Time) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var evicted []string
+	for hostname, hs := range c.hostIndex {
+		if !hs.GetLastSeen().Before(deadline) || len(hs.GetPods()) > 0 {
+			continue
+		}
+
+		c.releaseHost(hostname, hs)
+		evicted = append(evicted, hostname)
+	}
+
+	c.metrics.EvictedHosts.Inc(int64(len(evicted)))
+	log.WithField("hosts", evicted).Debug("Stale hosts evicted")
+	return evicted
+}
+
+// ReleaseAgentPods releases all the pods on the host once its agent is lost,
+// reclaiming their resources. The lease on the host is terminated and the
+// holds on the host are released before it is removed from the cache.
+func (c *hostCache) ReleaseAgentPods(hostname string) ([]*peloton.PodID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hs, err := c.getSummary(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	c.releaseHost(hostname, hs)
+	released := hs.ReleasePods()
+
+	c.metrics.LostHosts.Inc(1)
+	log.WithFields(log.Fields{
+		"hostname": hostname,
+		"pods":     len(released),
+	}).Info("Pods released from lost host")
+	return released, nil
+}
+
+// releaseHost terminates the lease on the host and releases its holds,
+// before removing it from the cache. It must be called with c.mu held.
+func (c *hostCache) releaseHost(
+	hostname string,
+	hs hostsummary.HostSummary,
+) {
+	if leaseID := hs.GetActiveLeaseID(); leaseID != "" {
+		if err := hs.TerminateLease(leaseID); err != nil {
+			log.WithFields(log.Fields{
+				"hostname": hostname,
+				"lease_id": leaseID,
+			}).WithError(err).Warn("failed to terminate lease of released host")
+		}
+	}
+
+	for _, id := range hs.GetHeldPods() {
+		hs.ReleaseHoldForPod(id)
+		c.removePodHold(id)
+	}
+
+	delete(c.hostIndex, hostname)
+	c.stopPlacing(hs)
+}
+
 func (c *hostCache) GetHostHeldForPod(podID *peloton.PodID) string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -343,6 +690,34 @@ func (c *hostCache) HoldForPods(hostname string, podIDs []*peloton.PodID) error
 	return nil
 }
 
+// ReserveCapacity reserves resources on the host, independently of the pod
+// lifecycle.
+func (c *hostCache) ReserveCapacity(
+	hostname string,
+	amount hmscalar.Resources,
+) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hs, err := c.getSummary(hostname)
+	if err != nil {
+		return "", err
+	}
+	return hs.ReserveCapacity(amount)
+}
+
+// ReleaseReservation releases a reservation made with ReserveCapacity.
+func (c *hostCache) ReleaseReservation(hostname string, token string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	hs, err := c.getSummary(hostname)
+	if err != nil {
+		return err
+	}
+	return hs.ReleaseReservation(token)
+}
+
 func (c *hostCache) ReleaseHoldForPods(hostname string, podIDs []*peloton.PodID) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -362,7 +737,6 @@ func (c *hostCache) ReleaseHoldForPods(hostname string, podIDs []*peloton.PodID)
 func (c *hostCache) RefreshMetrics() {
 	totalAvailable := hmscalar.Resources{}
 	totalAllocated := hmscalar.Resources{}
-	readyHosts := float64(0)
 	placingHosts := float64(0)
 	heldHosts := float64(0)
 
@@ -374,10 +748,7 @@ func (c *hostCache) RefreshMetrics() {
 		totalAllocated = totalAllocated.Add(allocated.NonSlack)
 		totalAvailable = totalAvailable.Add(available)
 
-		switch h.GetHostStatus() {
-		case hostsummary.ReadyHost:
-			readyHosts++
-		case hostsummary.PlacingHost:
+		if h.GetHostStatus() == hostsummary.PlacingHost {
 			placingHosts++
 		}
 		if len(h.GetHeldPods()) > 0 {
@@ -387,10 +758,12 @@ func (c *hostCache) RefreshMetrics() {
 
 	c.metrics.Available.Update(totalAvailable)
 	c.metrics.Allocated.Update(totalAllocated)
-	c.metrics.ReadyHosts.Update(readyHosts)
 	c.metrics.PlacingHosts.Update(placingHosts)
 	c.metrics.HeldHosts.Update(heldHosts)
 	c.metrics.AvailableHosts.Update(float64(len(hosts)))
+
+	// ready hosts and their available resources
+	c.getReadyCapacity()
 }
 
 // addPodHold add a pod to podHeldIndex. Replace the old host if exists.
@@ -411,6 +784,37 @@ func (c *hostCache) removePodHold(id *peloton.PodID) {
 	delete(c.podHeldIndex, id.GetValue())
 }
 
+// startPlacing records the time at which the host was leased.
+func (c *hostCache) startPlacing(hostname string) {
+	c.placingMu.Lock()
+	defer c.placingMu.Unlock()
+
+	c.placingSince[hostname] = c.now()
+}
+
+// stopPlacing records the time spent by the host in Placing status once it
+// is no longer leased, or once it is deleted from the cache.
+// This function assumes the cache lock is held before calling.
+func (c *hostCache) stopPlacing(hs hostsummary.HostSummary) {
+	// The lock is taken before checking the status of the host, so that a
+	// lease acquired concurrently is not recorded as released.
+	c.placingMu.Lock()
+	defer c.placingMu.Unlock()
+
+	hostname := hs.GetHostname()
+	if _, ok := c.hostIndex[hostname]; ok &&
+		hs.GetHostStatus() == hostsummary.PlacingHost {
+		return
+	}
+
+	since, ok := c.placingSince[hostname]
+	if !ok {
+		return
+	}
+	delete(c.placingSince, hostname)
+	c.metrics.PlacingDuration.RecordDuration(c.now().Sub(since))
+}
+
 func (c *hostCache) CompleteLaunchPod(hostname string, pod *models.LaunchablePod) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -596,7 +1000,10 @@ func (c *hostCache) deleteHost(event *scalar.HostEvent) {
 		}
 	}
 
-	delete(c.hostIndex, hostInfo.GetHostName())
+	if existing, ok := c.hostIndex[hostInfo.GetHostName()]; ok {
+		delete(c.hostIndex, hostInfo.GetHostName())
+		c.stopPlacing(existing)
+	}
 	log.WithFields(log.Fields{
 		"hostname": hostInfo.GetHostName(),
 		"capacity": hostInfo.GetCapacity(),
@@ -684,6 +1091,18 @@ func (c *hostCache) Start() {
 		},
 	)
 
+	if c.hostTTL > 0 {
+		c.backgroundMgr.RegisterWorks(
+			background.Work{
+				Name: _hostCacheEvictStaleHosts,
+				Func: func(_ *uatomic.Bool) {
+					c.EvictStaleHosts(c.now().Add(-c.hostTTL))
+				},
+				Period: _hostCacheEvictStaleHostsPeriod,
+			},
+		)
+	}
+
 	go c.waitForHostEvents()
 
 	log.Warn("hostCache started")
@@ -26,8 +26,10 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	hostmgr "github.com/uber/peloton/.gen/peloton/private/hostmgr/v1alpha"
+	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/hostmgr/models"
 	"github.com/uber/peloton/pkg/hostmgr/p2k/hostcache/hostsummary"
+	p2kscalar "github.com/uber/peloton/pkg/hostmgr/p2k/scalar"
 	"github.com/uber/peloton/pkg/hostmgr/scalar"
 
 	"github.com/pborman/uuid"
@@ -35,6 +37,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -151,8 +156,10 @@ func (suite *HostCacheTestSuite) TestAcquireLeases() {
 		// are in ReadyHost state
 		hosts := hostsummary.GenerateFakeHostSummaries(10)
 		hc := &hostCache{
-			hostIndex: make(map[string]hostsummary.HostSummary),
-			metrics:   NewMetrics(tally.NoopScope),
+			hostIndex:    make(map[string]hostsummary.HostSummary),
+			metrics:      NewMetrics(tally.NoopScope),
+			placingSince: make(map[string]time.Time),
+			now:          time.Now,
 		}
 		// initialize host cache with these 10 hosts
 		for _, s := range hosts {
@@ -252,7 +259,10 @@ func (suite *HostCacheTestSuite) TestTerminateLease() {
 		// Generate 1 host summary with 10 CPU and 100 Mem
 		hosts := hostsummary.GenerateFakeHostSummaries(1)
 		hc := &hostCache{
-			hostIndex: make(map[string]hostsummary.HostSummary),
+			hostIndex:    make(map[string]hostsummary.HostSummary),
+			metrics:      NewMetrics(tally.NoopScope),
+			placingSince: make(map[string]time.Time),
+			now:          time.Now,
 		}
 		// initialize host cache with this host
 		for _, s := range hosts {
@@ -322,7 +332,10 @@ func (suite *HostCacheTestSuite) TestCompleteLease() {
 		// Generate 1 host summary with 10 CPU and 100 Mem
 		hosts := hostsummary.GenerateFakeHostSummaries(1)
 		hc := &hostCache{
-			hostIndex: make(map[string]hostsummary.HostSummary),
+			hostIndex:    make(map[string]hostsummary.HostSummary),
+			metrics:      NewMetrics(tally.NoopScope),
+			placingSince: make(map[string]time.Time),
+			now:          time.Now,
 		}
 		// initialize host cache with this host
 		for _, s := range hosts {
@@ -351,7 +364,10 @@ func (suite *HostCacheTestSuite) TestCompleteLeaseErrors() {
 	// Generate 1 host summary with 10 CPU and 100 Mem.
 	hosts := hostsummary.GenerateFakeHostSummaries(1)
 	hc := &hostCache{
-		hostIndex: make(map[string]hostsummary.HostSummary),
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
 	}
 	// Initialize host cache with this host.
 	for _, s := range hosts {
@@ -424,7 +440,10 @@ func (suite *HostCacheTestSuite) TestAcquireLeasesParallel() {
 	numHosts := 255
 	hosts := hostsummary.GenerateFakeHostSummaries(numHosts)
 	hc := &hostCache{
-		hostIndex: make(map[string]hostsummary.HostSummary),
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
 	}
 	// Initialize host cache with this host.
 	for _, s := range hosts {
@@ -596,3 +615,625 @@ func TestResetExpiredHeldHostSummaries(t *testing.T) {
 	require.Equal(hs.GetHostname(), ret[0])
 	require.Empty(hc.podHeldIndex)
 }
+
+// TestEvictStaleHosts tests that a host which is not refreshed within the
+// TTL is evicted along with its lease and holds, while a recently seen host
+// and a stale host with pods remain in the cache.
+func TestEvictStaleHosts(t *testing.T) {
+	require := require.New(t)
+	hosts := hostsummary.GenerateFakeHostSummaries(3)
+	staleHost, freshHost, busyHost := hosts[0], hosts[1], hosts[2]
+	podID := &peloton.PodID{Value: uuid.New()}
+	hc := &hostCache{
+		hostIndex: map[string]hostsummary.HostSummary{
+			staleHost.GetHostname(): staleHost,
+			freshHost.GetHostname(): freshHost,
+			busyHost.GetHostname():  busyHost,
+		},
+		podHeldIndex: map[string]string{},
+		metrics:      NewMetrics(tally.NoopScope),
+	}
+
+	ttl := 10 * time.Minute
+	now := time.Now()
+	clock := func() time.Time { return now }
+	staleHost.SetClock(clock)
+	freshHost.SetClock(clock)
+	busyHost.SetClock(clock)
+
+	// The stale and busy hosts are last refreshed twice the TTL ago, and
+	// the fresh host half the TTL ago.
+	now = now.Add(-2 * ttl)
+	staleHost.SetCapacity(staleHost.GetCapacity())
+	busyHost.SetCapacity(busyHost.GetCapacity())
+	now = now.Add(3 * ttl / 2)
+	freshHost.SetCapacity(freshHost.GetCapacity())
+	now = now.Add(ttl / 2)
+
+	require.NoError(hc.HoldForPods(staleHost.GetHostname(), []*peloton.PodID{podID}))
+	require.NoError(staleHost.CasStatus(hostsummary.ReadyHost, hostsummary.PlacingHost))
+
+	// The busy host runs a pod, so it is not evicted even though it
+	// receives no offers.
+	busyHost.RecoverPodInfo(
+		&peloton.PodID{Value: uuid.New()},
+		pbpod.PodState_POD_STATE_RUNNING,
+		&pbpod.PodSpec{},
+	)
+
+	evicted := hc.EvictStaleHosts(now.Add(-ttl))
+	require.Equal([]string{staleHost.GetHostname()}, evicted)
+	require.Equal(hostsummary.ReadyHost, staleHost.GetHostStatus())
+	require.Empty(staleHost.GetHeldPods())
+	require.Empty(hc.podHeldIndex)
+	require.Equal(2, len(hc.GetSummaries()))
+
+	// Refreshing the fresh host keeps it in the cache.
+	now = now.Add(ttl)
+	freshHost.SetCapacity(freshHost.GetCapacity())
+	require.Empty(hc.EvictStaleHosts(now.Add(-ttl)))
+	require.Equal(2, len(hc.GetSummaries()))
+}
+
+// TestReleaseAgentPods tests that all the pods on a lost host are released
+// along with its holds, and that the host is removed from the cache.
+func TestReleaseAgentPods(t *testing.T) {
+	require := require.New(t)
+	capacity := models.HostResources{NonSlack: hostsummary.CreateResource(10.0, 100.0)}
+	hs := hostsummary.NewKubeletHostSummary(_hostname, capacity, "1")
+	other := hostsummary.GenerateFakeHostSummaries(1)[0]
+	hc := &hostCache{
+		hostIndex: map[string]hostsummary.HostSummary{
+			_hostname:           hs,
+			other.GetHostname(): other,
+		},
+		podHeldIndex: map[string]string{},
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	// Place pods on the host.
+	podToSpecMap := hostsummary.GeneratePodSpecWithRes(4, 1.0, 10.0)
+	match := hs.TryMatch(&hostmgr.HostFilter{})
+	require.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	require.NoError(hc.CompleteLease(_hostname, hs.GetActiveLeaseID(), podToSpecMap))
+	require.Equal(hostsummary.CreateResource(4.0, 40.0), hs.GetAllocated().NonSlack)
+
+	heldPodID := &peloton.PodID{Value: uuid.New()}
+	require.NoError(hc.HoldForPods(_hostname, []*peloton.PodID{heldPodID}))
+
+	// The agent is lost.
+	released, err := hc.ReleaseAgentPods(_hostname)
+	require.NoError(err)
+	var releasedIDs []string
+	for _, id := range released {
+		releasedIDs = append(releasedIDs, id.GetValue())
+	}
+	var podIDs []string
+	for id := range podToSpecMap {
+		podIDs = append(podIDs, id)
+	}
+	require.ElementsMatch(podIDs, releasedIDs)
+
+	require.Empty(hs.GetPods())
+	require.Equal(scalar.Resources{}, hs.GetAllocated().NonSlack)
+	require.Equal(capacity, hs.GetAvailable())
+	require.Empty(hs.GetHeldPods())
+	require.Empty(hc.podHeldIndex)
+	require.Equal(
+		[]hostsummary.HostSummary{other},
+		hc.GetSummaries(),
+	)
+
+	_, err = hc.ReleaseAgentPods(_hostname)
+	require.True(yarpcerrors.IsNotFound(err))
+}
+
+// TestFindHostsWithoutPodLabel tests that only the hosts which do not run
+// any pod with a label matching the selector are returned.
+func TestFindHostsWithoutPodLabel(t *testing.T) {
+	require := require.New(t)
+	hosts := hostsummary.GenerateFakeHostSummaries(3)
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+	for _, hs := range hosts {
+		hc.hostIndex[hs.GetHostname()] = hs
+	}
+
+	// place pods of job "a" on the first host, and of job "b" on the
+	// second one, the third host has no pods
+	for i, job := range []string{"a", "b"} {
+		podToSpecMap := hostsummary.GeneratePodSpecWithRes(2, 1.0, 10.0)
+		for _, spec := range podToSpecMap {
+			spec.Labels = []*peloton.Label{{Key: "job", Value: job}}
+		}
+		match := hosts[i].TryMatch(&hostmgr.HostFilter{})
+		require.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+		require.NoError(hc.CompleteLease(
+			hosts[i].GetHostname(), hosts[i].GetActiveLeaseID(), podToSpecMap))
+	}
+
+	require.Equal(
+		[]string{hosts[1].GetHostname(), hosts[2].GetHostname()},
+		hc.FindHostsWithoutPodLabel(&peloton.Label{Key: "job", Value: "a"}))
+	require.Equal(
+		[]string{hosts[0].GetHostname(), hosts[2].GetHostname()},
+		hc.FindHostsWithoutPodLabel(&peloton.Label{Key: "job", Value: "b"}))
+
+	// a selector without value matches any value of the key
+	require.Equal(
+		[]string{hosts[2].GetHostname()},
+		hc.FindHostsWithoutPodLabel(&peloton.Label{Key: "job"}))
+
+	// no pod carries the label
+	require.Equal(
+		[]string{
+			hosts[0].GetHostname(),
+			hosts[1].GetHostname(),
+			hosts[2].GetHostname(),
+		},
+		hc.FindHostsWithoutPodLabel(&peloton.Label{Key: "team", Value: "a"}))
+}
+
+// TestListHostsByStatus tests that listing hosts by status returns exactly
+// the hosts in that status.
+func TestListHostsByStatus(t *testing.T) {
+	require := require.New(t)
+	hosts := hostsummary.GenerateFakeHostSummaries(5)
+	hc := &hostCache{
+		hostIndex:    map[string]hostsummary.HostSummary{},
+		podHeldIndex: map[string]string{},
+	}
+	for _, hs := range hosts {
+		hc.hostIndex[hs.GetHostname()] = hs
+	}
+
+	require.NoError(hosts[1].CasStatus(hostsummary.ReadyHost, hostsummary.PlacingHost))
+	require.NoError(hosts[3].CasStatus(hostsummary.ReadyHost, hostsummary.PlacingHost))
+	require.NoError(hosts[4].CasStatus(hostsummary.ReadyHost, hostsummary.ReservedHost))
+
+	hostnames := func(summaries []hostsummary.HostSummary) []string {
+		var names []string
+		for _, hs := range summaries {
+			names = append(names, hs.GetHostname())
+		}
+		return names
+	}
+
+	require.ElementsMatch(
+		[]string{hosts[0].GetHostname(), hosts[2].GetHostname()},
+		hostnames(hc.ListHostsByStatus(hostsummary.ReadyHost)),
+	)
+	require.ElementsMatch(
+		[]string{hosts[1].GetHostname(), hosts[3].GetHostname()},
+		hostnames(hc.ListHostsByStatus(hostsummary.PlacingHost)),
+	)
+	require.ElementsMatch(
+		[]string{hosts[4].GetHostname()},
+		hostnames(hc.ListHostsByStatus(hostsummary.ReservedHost)),
+	)
+
+	// a host moving out of a status is no longer listed in it
+	require.NoError(hosts[1].CasStatus(hostsummary.PlacingHost, hostsummary.ReadyHost))
+	require.ElementsMatch(
+		[]string{hosts[3].GetHostname()},
+		hostnames(hc.ListHostsByStatus(hostsummary.PlacingHost)),
+	)
+	require.Len(hc.ListHostsByStatus(hostsummary.ReadyHost), 3)
+}
+
+// TestLeasePlacingDurationMetrics tests that the time spent by hosts in
+// Placing status is recorded when their leases are completed or terminated,
+// or when the leased hosts are deleted.
+func TestLeasePlacingDurationMetrics(t *testing.T) {
+	require := require.New(t)
+	scope := tally.NewTestScope("", map[string]string{})
+	now := time.Now()
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(scope),
+		placingSince: make(map[string]time.Time),
+		now:          func() time.Time { return now },
+	}
+	for _, s := range hostsummary.GenerateFakeHostSummaries(3) {
+		hc.hostIndex[s.GetHostname()] = s
+	}
+
+	leasedHosts := func() int {
+		hc.placingMu.Lock()
+		defer hc.placingMu.Unlock()
+		return len(hc.placingSince)
+	}
+	placingDurations := func() map[time.Duration]int64 {
+		counts := make(map[time.Duration]int64)
+		histogram := scope.Snapshot().Histograms()["hostcache.lease.placing_duration+"]
+		for upperBound, count := range histogram.Durations() {
+			if count > 0 {
+				counts[upperBound] = count
+			}
+		}
+		return counts
+	}
+
+	leases, _ := hc.AcquireLeases(&hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum: &pod.ResourceSpec{CpuLimit: 1.0, MemLimitMb: 10.0},
+		},
+		MaxHosts: 3,
+	})
+	require.Len(leases, 3)
+	require.Equal(3, leasedHosts())
+	require.Empty(placingDurations())
+
+	// Complete the first lease after 5 seconds.
+	now = now.Add(5 * time.Second)
+	require.NoError(hc.CompleteLease(
+		leases[0].GetHostSummary().GetHostname(),
+		leases[0].GetLeaseId().GetValue(),
+		hostsummary.GeneratePodSpecWithRes(1, 1.0, 10.0),
+	))
+	require.Equal(2, leasedHosts())
+	require.Equal(
+		map[time.Duration]int64{5120 * time.Millisecond: 1},
+		placingDurations(),
+	)
+
+	// Terminate the second lease after 10 seconds.
+	now = now.Add(5 * time.Second)
+	require.NoError(hc.TerminateLease(
+		leases[1].GetHostSummary().GetHostname(),
+		leases[1].GetLeaseId().GetValue(),
+	))
+	require.Equal(1, leasedHosts())
+	require.Equal(
+		map[time.Duration]int64{
+			5120 * time.Millisecond:  1,
+			10240 * time.Millisecond: 1,
+		},
+		placingDurations(),
+	)
+
+	// A failed termination does not release the lease.
+	require.Error(hc.TerminateLease(
+		leases[2].GetHostSummary().GetHostname(),
+		uuid.New(),
+	))
+	require.Equal(1, leasedHosts())
+	require.Len(placingDurations(), 2)
+
+	// Deleting the leased host releases the lease.
+	event, err := p2kscalar.BuildHostEventFromNode(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            leases[2].GetHostSummary().GetHostname(),
+			ResourceVersion: "99999",
+		},
+	}, p2kscalar.DeleteHost)
+	require.NoError(err)
+	hc.deleteHost(event)
+	require.Equal(0, leasedHosts())
+	require.Equal(
+		map[time.Duration]int64{
+			5120 * time.Millisecond:  1,
+			10240 * time.Millisecond: 2,
+		},
+		placingDurations(),
+	)
+}
+
+// TestReadyHostCountAndTotalAvailable tests that only the hosts in Ready
+// status are counted, and that their total available resources exclude
+// the allocated resources, with both exported as gauges.
+func TestReadyHostCountAndTotalAvailable(t *testing.T) {
+	require := require.New(t)
+	scope := tally.NewTestScope("", map[string]string{})
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(scope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	hosts := hostsummary.GenerateFakeHostSummaries(4)
+	for _, s := range hosts {
+		hc.hostIndex[s.GetHostname()] = s
+	}
+
+	// the second ready host has part of its capacity allocated
+	allocated := hostsummary.CreateResource(4.0, 40.0)
+	hosts[1].SetAllocated(allocated)
+	hosts[1].SetAvailable(models.HostResources{
+		NonSlack: hosts[1].GetCapacity().NonSlack.Subtract(allocated),
+	})
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.ReadyHost, hostsummary.PlacingHost))
+	require.NoError(hosts[3].CasStatus(
+		hostsummary.ReadyHost, hostsummary.ReservedHost))
+
+	require.Equal(2, hc.GetReadyHostCount())
+	require.Equal(
+		hostsummary.CreateResource(16.0, 160.0),
+		hc.GetTotalAvailable(),
+	)
+
+	gauges := scope.Snapshot().Gauges()
+	require.Equal(float64(2), gauges["hostcache.hosts.ready+"].Value())
+	require.Equal(float64(16), gauges["hostcache.ready_resource.cpu+"].Value())
+	require.Equal(float64(160), gauges["hostcache.ready_resource.mem+"].Value())
+
+	// releasing the placing host makes it count again
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.PlacingHost, hostsummary.ReadyHost))
+	hc.RefreshMetrics()
+	gauges = scope.Snapshot().Gauges()
+	require.Equal(float64(3), gauges["hostcache.hosts.ready+"].Value())
+	require.Equal(float64(26), gauges["hostcache.ready_resource.cpu+"].Value())
+}
+
+// TestGetMaxAvailable tests that the maximum available resources of the
+// Ready hosts are reported per resource type, and follow allocations.
+func TestGetMaxAvailable(t *testing.T) {
+	require := require.New(t)
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	require.Equal(scalar.Resources{}, hc.GetMaxAvailable())
+
+	hosts := hostsummary.GenerateFakeHostSummaries(4)
+	for _, s := range hosts {
+		hc.hostIndex[s.GetHostname()] = s
+	}
+	allocate := func(s *hostsummary.FakeHostSummary, r scalar.Resources) {
+		s.SetAllocated(r)
+		s.SetAvailable(models.HostResources{
+			NonSlack: s.GetCapacity().NonSlack.Subtract(r),
+		})
+	}
+
+	// host0 has the most cpu left and host1 the most memory, host2 has
+	// the most of both but is being placed on and host3 is reserved
+	allocate(hosts[0], hostsummary.CreateResource(2.0, 60.0))
+	allocate(hosts[1], hostsummary.CreateResource(6.0, 20.0))
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.ReadyHost, hostsummary.PlacingHost))
+	allocate(hosts[3], hostsummary.CreateResource(0.0, 0.0))
+	require.NoError(hosts[3].CasStatus(
+		hostsummary.ReadyHost, hostsummary.ReservedHost))
+
+	require.Equal(
+		hostsummary.CreateResource(8.0, 80.0),
+		hc.GetMaxAvailable(),
+	)
+
+	// allocating more on host0 lowers the maximum cpu
+	allocate(hosts[0], hostsummary.CreateResource(7.0, 60.0))
+	require.Equal(
+		hostsummary.CreateResource(4.0, 80.0),
+		hc.GetMaxAvailable(),
+	)
+
+	// a host becoming ready again counts with its whole capacity
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.PlacingHost, hostsummary.ReadyHost))
+	require.Equal(
+		hosts[2].GetCapacity().NonSlack,
+		hc.GetMaxAvailable(),
+	)
+}
+
+// TestReserveCapacity tests reserving and releasing capacity on a host
+// without assigning it to a pod.
+func TestReserveCapacity(t *testing.T) {
+	require := require.New(t)
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	host := hostsummary.GenerateFakeHostSummaries(1)[0]
+	hostname := host.GetHostname()
+	hc.hostIndex[hostname] = host
+	capacity := host.GetCapacity().NonSlack
+
+	token1, err := hc.ReserveCapacity(
+		hostname, hostsummary.CreateResource(4.0, 40.0))
+	require.NoError(err)
+	token2, err := hc.ReserveCapacity(
+		hostname, hostsummary.CreateResource(2.0, 20.0))
+	require.NoError(err)
+	require.NotEqual(token1, token2)
+
+	// available reflects the outstanding reservations
+	require.Equal(
+		hostsummary.CreateResource(4.0, 40.0),
+		host.GetAvailable().NonSlack,
+	)
+	require.Equal(
+		hostsummary.CreateResource(4.0, 40.0),
+		hc.GetTotalAvailable(),
+	)
+
+	// more than what is left cannot be reserved
+	_, err = hc.ReserveCapacity(
+		hostname, hostsummary.CreateResource(5.0, 10.0))
+	require.True(yarpcerrors.IsInvalidArgument(err))
+	_, err = hc.ReserveCapacity(hostname, hostsummary.CreateResource(0, 0))
+	require.True(yarpcerrors.IsInvalidArgument(err))
+	_, err = hc.ReserveCapacity(
+		"unknown-host", hostsummary.CreateResource(1.0, 10.0))
+	require.True(yarpcerrors.IsNotFound(err))
+
+	// releasing a reservation frees its resources
+	require.NoError(hc.ReleaseReservation(hostname, token1))
+	require.Equal(
+		hostsummary.CreateResource(8.0, 80.0),
+		host.GetAvailable().NonSlack,
+	)
+	_, err = hc.ReserveCapacity(
+		hostname, hostsummary.CreateResource(5.0, 10.0))
+	require.NoError(err)
+
+	// a reservation can only be released once
+	require.NoError(hc.ReleaseReservation(hostname, token2))
+	require.True(yarpcerrors.IsNotFound(
+		hc.ReleaseReservation(hostname, token2)))
+	require.Equal(
+		capacity.Subtract(hostsummary.CreateResource(5.0, 10.0)),
+		host.GetAvailable().NonSlack,
+	)
+
+	// reservations which are not released expire like holds
+	hc.ResetExpiredHeldHostSummaries(time.Now())
+	require.Equal(
+		capacity.Subtract(hostsummary.CreateResource(5.0, 10.0)),
+		host.GetAvailable().NonSlack,
+	)
+	hc.ResetExpiredHeldHostSummaries(time.Now().Add(time.Hour))
+	require.Equal(capacity, host.GetAvailable().NonSlack)
+}
+
+// TestEvaluateFilter tests that evaluating a filter which no host satisfies
+// attributes the failure of each host to the right reason, reports the
+// closest near miss, and leaves the hosts unleased.
+func TestEvaluateFilter(t *testing.T) {
+	require := require.New(t)
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	hosts := hostsummary.GenerateFakeHostSummaries(5)
+	for _, s := range hosts {
+		hc.hostIndex[s.GetHostname()] = s
+	}
+
+	// host0 and host1 do not have enough resources left
+	allocated := hostsummary.CreateResource(9.0, 90.0)
+	for _, s := range hosts[:2] {
+		s.SetAllocated(allocated)
+		s.SetAvailable(models.HostResources{
+			NonSlack: s.GetCapacity().NonSlack.Subtract(allocated),
+		})
+	}
+	// host2 is being placed on
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.ReadyHost, hostsummary.PlacingHost))
+	// host3 and host4 are exclusive hosts
+	for _, s := range hosts[3:] {
+		s.SetLabels([]*peloton.Label{
+			{Key: common.PelotonExclusiveNodeLabel, Value: "web"},
+		})
+	}
+
+	filter := &hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum: &pod.ResourceSpec{
+				CpuLimit:   2.0,
+				MemLimitMb: 20.0,
+			},
+		},
+	}
+	eval := hc.EvaluateFilter(filter)
+	require.Empty(eval.MatchedHosts)
+	require.Equal(map[string]uint32{
+		strings.ToLower("HOST_FILTER_INSUFFICIENT_RESOURCES"): 2,
+		strings.ToLower("HOST_FILTER_MISMATCH_STATUS"):        1,
+		strings.ToLower("HOST_FILTER_MISMATCH_CONSTRAINTS"):   2,
+	}, eval.FilterCounts)
+	require.Equal(&hostsummary.Match{
+		Result:   hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_CONSTRAINTS,
+		HostName: hosts[3].GetHostname(),
+	}, eval.NearMiss)
+
+	// evaluating the filter does not lease any host
+	for _, i := range []int{0, 1, 3, 4} {
+		require.Equal(hostsummary.ReadyHost, hosts[i].GetHostStatus())
+	}
+
+	// once host2 is released and the filter is relaxed, only the
+	// exclusive hosts do not match
+	require.NoError(hosts[2].CasStatus(
+		hostsummary.PlacingHost, hostsummary.ReadyHost))
+	filter.ResourceConstraint.Minimum.CpuLimit = 1.0
+	filter.ResourceConstraint.Minimum.MemLimitMb = 10.0
+	eval = hc.EvaluateFilter(filter)
+	require.Equal([]string{
+		hosts[0].GetHostname(),
+		hosts[1].GetHostname(),
+		hosts[2].GetHostname(),
+	}, eval.MatchedHosts)
+	require.Equal(&hostsummary.Match{
+		Result:   hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_CONSTRAINTS,
+		HostName: hosts[3].GetHostname(),
+	}, eval.NearMiss)
+}
+
+// TestTryMatchHost tests matching and leasing a single pinned host.
+func TestTryMatchHost(t *testing.T) {
+	require := require.New(t)
+	hc := &hostCache{
+		hostIndex:    make(map[string]hostsummary.HostSummary),
+		metrics:      NewMetrics(tally.NoopScope),
+		placingSince: make(map[string]time.Time),
+		now:          time.Now,
+	}
+
+	hosts := hostsummary.GenerateFakeHostSummaries(2)
+	for _, s := range hosts {
+		hc.hostIndex[s.GetHostname()] = s
+	}
+	pinned := hosts[0].GetHostname()
+
+	// the pinned host cannot satisfy the resource constraint
+	filter := &hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum: &pod.ResourceSpec{
+				CpuLimit:   100.0,
+				MemLimitMb: 100.0,
+			},
+		},
+	}
+	lease, result, err := hc.TryMatchHost(pinned, filter)
+	require.NoError(err)
+	require.Nil(lease)
+	require.Equal(
+		hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES, result)
+	require.Equal(hostsummary.ReadyHost, hosts[0].GetHostStatus())
+
+	// the pinned host is leased once the constraint can be satisfied, and
+	// no other host is
+	filter.ResourceConstraint.Minimum.CpuLimit = 2.0
+	filter.ResourceConstraint.Minimum.MemLimitMb = 20.0
+	lease, result, err = hc.TryMatchHost(pinned, filter)
+	require.NoError(err)
+	require.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, result)
+	require.Equal(pinned, lease.GetHostSummary().GetHostname())
+	require.Equal(hostsummary.PlacingHost, hosts[0].GetHostStatus())
+	require.Equal(hostsummary.ReadyHost, hosts[1].GetHostStatus())
+
+	// the leased host cannot be matched again until the lease ends
+	_, result, err = hc.TryMatchHost(pinned, filter)
+	require.NoError(err)
+	require.Equal(hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS, result)
+
+	require.NoError(hc.TerminateLease(pinned, lease.GetLeaseId().GetValue()))
+	_, result, err = hc.TryMatchHost(pinned, filter)
+	require.NoError(err)
+	require.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, result)
+
+	// a host which is not in the cache cannot be matched
+	_, _, err = hc.TryMatchHost("unknown", filter)
+	require.True(yarpcerrors.IsNotFound(err))
+}
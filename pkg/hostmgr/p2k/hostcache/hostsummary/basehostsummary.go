@@ -23,11 +23,13 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	hostmgr "github.com/uber/peloton/.gen/peloton/private/hostmgr/v1alpha"
+	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/common/util"
 	"github.com/uber/peloton/pkg/common/v1alpha/constraints"
 	"github.com/uber/peloton/pkg/hostmgr/models"
 	p2kscalar "github.com/uber/peloton/pkg/hostmgr/p2k/scalar"
 	"github.com/uber/peloton/pkg/hostmgr/scalar"
+	rmscalar "github.com/uber/peloton/pkg/resmgr/scalar"
 
 	"github.com/pborman/uuid"
 	log "github.com/sirupsen/logrus"
@@ -103,6 +105,11 @@ type baseHostSummary struct {
 	// available resources on the host
 	available models.HostResources
 
+	// Ratio, per resource kind, of the capacity offered to best-effort pods
+	// to the capacity of the host. The capacity above the raw capacity is
+	// offered as slack resources.
+	oversubscriptionRatio map[string]float64
+
 	// A map to present tasks assigned or running on this host.
 	// Key is the tasks id, value is the pod spec and current status.
 	pods *podInfoMap
@@ -110,6 +117,31 @@ type baseHostSummary struct {
 	// A map of podIDs for which the host is held.
 	// Key is the podID, value is the expiration time of the hold.
 	heldPodIDs map[string]time.Time
+
+	// A map of podIDs for which the host is held to the resources of the
+	// pod on the host at the time of the hold. Once such a pod leaves the
+	// host, its resources stay reserved until the hold is released.
+	heldPodResources map[string]models.HostResources
+
+	// A map of reservation tokens to the resources reserved on the host
+	// without a pod, until the reservation is released or expires.
+	capacityReservations map[string]*capacityReservation
+
+	// Last time the host was refreshed by the underlying cluster manager.
+	// Used by the host cache to evict hosts which are not seen anymore.
+	lastSeen time.Time
+
+	// now returns the current time, used to record when the host was
+	// last refreshed.
+	now func() time.Time
+}
+
+// capacityReservation is the resources reserved on a host without a pod.
+type capacityReservation struct {
+	// The reserved resources.
+	resources models.HostResources
+	// The expiration time of the reservation.
+	expiration time.Time
 }
 
 // newBaseHostSummary returns a zero initialized HostSummary object.
@@ -117,16 +149,22 @@ func newBaseHostSummary(
 	hostname string,
 	version string,
 ) *baseHostSummary {
-	return &baseHostSummary{
-		status:     ReadyHost,
-		hostname:   hostname,
-		heldPodIDs: make(map[string]time.Time),
-		version:    version,
-		strategy:   &noopHostStrategy{},
-		pods:       newPodInfoMap(),
+	s := &baseHostSummary{
+		status:           ReadyHost,
+		hostname:         hostname,
+		heldPodIDs:       make(map[string]time.Time),
+		heldPodResources: make(map[string]models.HostResources),
+		version:          version,
+		strategy:         &noopHostStrategy{},
+		pods:             newPodInfoMap(),
+		now:              time.Now,
+
+		capacityReservations: make(map[string]*capacityReservation),
 		// TODO: make the initial port range configs.
 		ports: []*pbhost.PortRange{{Begin: 31000, End: 32000}},
 	}
+	s.lastSeen = s.now()
+	return s
 }
 
 // TryMatch atomically tries to match the current host with given HostFilter,
@@ -140,12 +178,58 @@ func (a *baseHostSummary) TryMatch(
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if a.status != ReadyHost {
+	result := a.match(filter)
+	if result != hostmgr.HostFilterResult_HOST_FILTER_MATCH {
+		return Match{Result: result}
+	}
+
+	// TODO: Handle oversubscription
+
+	// Setting status to `PlacingHost`: this ensures proper state tracking of
+	// resources on the host and also ensures that this host will not be used by
+	// another placement engine before it is released.
+	err := a.casStatus(a.status, PlacingHost)
+	if err != nil {
 		return Match{
 			Result: hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS,
 		}
 	}
 
+	return Match{
+		Result:   hostmgr.HostFilterResult_HOST_FILTER_MATCH,
+		HostName: a.hostname,
+	}
+}
+
+// Matches tries to match the current host with given HostFilter, without
+// locking the host if it does. The status of the host remains unchanged.
+func (a *baseHostSummary) Matches(
+	filter *hostmgr.HostFilter,
+) Match {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := a.match(filter)
+	if result != hostmgr.HostFilterResult_HOST_FILTER_MATCH {
+		return Match{Result: result}
+	}
+
+	return Match{
+		Result:   hostmgr.HostFilterResult_HOST_FILTER_MATCH,
+		HostName: a.hostname,
+	}
+}
+
+// match determines whether given HostFilter matches the host, taking the
+// status of the host and the pods it is held for into account.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) match(
+	filter *hostmgr.HostFilter,
+) hostmgr.HostFilterResult {
+	if a.status != ReadyHost {
+		return hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS
+	}
+
 	// For a host held pods, we anticipate in place upgrades to happen. So, it
 	// is only a match when the hint contains the host and we temporarily
 	// reject any additional pod placements on the host.
@@ -159,61 +243,39 @@ func (a *baseHostSummary) TryMatch(
 		}
 
 		if !hintFound {
-			return Match{
-				Result: hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS,
-			}
+			return hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS
 		}
 	}
 
-	result := a.matchHostFilter(filter)
-
-	if result != hostmgr.HostFilterResult_HOST_FILTER_MATCH {
-		return Match{Result: result}
-	}
-
-	// TODO: Handle oversubscription
-
-	// Setting status to `PlacingHost`: this ensures proper state tracking of
-	// resources on the host and also ensures that this host will not be used by
-	// another placement engine before it is released.
-	err := a.casStatus(a.status, PlacingHost)
-	if err != nil {
-		return Match{
-			Result: hostmgr.HostFilterResult_HOST_FILTER_MISMATCH_STATUS,
-		}
-	}
-
-	return Match{
-		Result:   hostmgr.HostFilterResult_HOST_FILTER_MATCH,
-		HostName: a.hostname,
-	}
+	return a.matchHostFilter(filter)
 }
 
 // CompleteLease verifies that the leaseID on this host is still valid.
 // It checks that current baseHostSummary is in Placing status, updates pods
 // to the host summary, recalculates allocated resources and set the host status
-// to Ready.
+// to Ready. It returns the available resources after the pods are added,
+// less the resources reserved for held pods and capacity reservations.
 func (a *baseHostSummary) CompleteLease(
 	leaseID string,
 	podToSpecMap map[string]*pbpod.PodSpec,
-) error {
+) (models.HostResources, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	if a.status != PlacingHost {
-		return yarpcerrors.InvalidArgumentErrorf("host status is not Placing")
+		return models.HostResources{}, yarpcerrors.InvalidArgumentErrorf("host status is not Placing")
 	}
 
 	if a.leaseID != leaseID {
-		return yarpcerrors.InvalidArgumentErrorf("host leaseID does not match")
+		return models.HostResources{}, yarpcerrors.InvalidArgumentErrorf("host leaseID does not match")
 	}
 
 	if err := a.casStatus(PlacingHost, ReadyHost); err != nil {
-		return yarpcerrors.InvalidArgumentErrorf("failed to unlock host: %s", err)
+		return models.HostResources{}, yarpcerrors.InvalidArgumentErrorf("failed to unlock host: %s", err)
 	}
 
 	if err := a.validatePodsNotExist(podToSpecMap); err != nil {
-		return err
+		return models.HostResources{}, err
 	}
 
 	// Add to pod map, so postCompleteLease can work on the up-to-date data.
@@ -223,7 +285,7 @@ func (a *baseHostSummary) CompleteLease(
 		for id := range podToSpecMap {
 			a.pods.RemovePod(id)
 		}
-		return err
+		return models.HostResources{}, err
 	}
 
 	log.WithFields(log.Fields{
@@ -231,9 +293,80 @@ func (a *baseHostSummary) CompleteLease(
 		"pods":     podToSpecMap,
 	}).Debug("pods added to the host for launch")
 
+	return a.getUnreservedAvailable(nil), nil
+}
+
+// SwapPod atomically replaces the old pod on the host with the new pod, so
+// that the host never looks free in between, for example during an in-place
+// restart. The new pod may use the resources of the old pod, and any
+// additional resources it needs must be available on the host.
+func (a *baseHostSummary) SwapPod(
+	oldPodID, newPodID *peloton.PodID,
+	newSpec *pbpod.PodSpec,
+) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	oldInfo, ok := a.pods.GetPodInfo(oldPodID.GetValue())
+	if !ok {
+		return yarpcerrors.NotFoundErrorf(
+			"pod %s not found on host %s", oldPodID.GetValue(), a.hostname)
+	}
+	if newPodID.GetValue() != oldPodID.GetValue() {
+		if _, ok := a.pods.GetPodInfo(newPodID.GetValue()); ok {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"pod %s already exists on host %s",
+				newPodID.GetValue(), a.hostname)
+		}
+	}
+
+	available := a.getUnreservedAvailable(nil).Add(models.HostResources{
+		NonSlack: scalar.FromPodSpec(oldInfo.spec),
+	})
+	if !available.Contains(models.HostResources{
+		NonSlack: scalar.FromPodSpec(newSpec),
+	}) {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"host %s has insufficient resources to swap pod %s with %s",
+			a.hostname, oldPodID.GetValue(), newPodID.GetValue())
+	}
+
+	a.pods.RemovePod(oldPodID.GetValue())
+	a.pods.AddPodSpec(newPodID.GetValue(), newSpec)
+	a.strategy.postSwapPod()
+
+	log.WithFields(log.Fields{
+		"hostname":   a.hostname,
+		"old_pod_id": oldPodID.GetValue(),
+		"new_pod_id": newPodID.GetValue(),
+	}).Debug("pod swapped on the host")
 	return nil
 }
 
+// ReleasePods removes all the pods from the host, and resets the allocated
+// resources of the host. It returns the IDs of the pods released.
+func (a *baseHostSummary) ReleasePods() []*peloton.PodID {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var released []*peloton.PodID
+	a.pods.RangePods(func(id string, _ *podInfo) error {
+		released = append(released, &peloton.PodID{Value: id})
+		return nil
+	})
+	for _, id := range released {
+		a.pods.RemovePod(id.GetValue())
+	}
+	a.allocated = models.HostResources{}
+	a.strategy.postReleasePods()
+
+	log.WithFields(log.Fields{
+		"hostname": a.hostname,
+		"pods":     len(released),
+	}).Debug("pods released from the host")
+	return released
+}
+
 // validatePodsNotExist will return an error if
 // the pod already exists on the host map.
 func (a *baseHostSummary) validatePodsNotExist(
@@ -259,6 +392,14 @@ func (a *baseHostSummary) CasStatus(old, new HostStatus) error {
 	return nil
 }
 
+// GetLastSeen returns the last time the host was refreshed.
+func (a *baseHostSummary) GetLastSeen() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.lastSeen
+}
+
 // GetVersion returns the version of the host.
 func (a *baseHostSummary) GetVersion() string {
 	a.mu.RLock()
@@ -283,6 +424,22 @@ func (a *baseHostSummary) GetHostname() string {
 	return a.hostname
 }
 
+// GetLabels returns the labels of the host.
+func (a *baseHostSummary) GetLabels() []*peloton.Label {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.labels
+}
+
+// SetLabels sets the labels of the host.
+func (a *baseHostSummary) SetLabels(labels []*peloton.Label) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.labels = labels
+}
+
 // GetHostStatus returns the HostStatus of the host.
 func (a *baseHostSummary) GetHostStatus() HostStatus {
 	a.mu.RLock()
@@ -310,6 +467,18 @@ func (a *baseHostSummary) GetHostLease() *hostmgr.HostLease {
 	}
 }
 
+// GetActiveLeaseID returns the leaseID of the host if it is in
+// PlacingHost state, and an empty string otherwise.
+func (a *baseHostSummary) GetActiveLeaseID() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.status != PlacingHost {
+		return emptyLeaseID
+	}
+	return a.leaseID
+}
+
 // TerminateLease is called when terminating the lease on a host.
 // This will be called when host in PLACING state is not used, and placement
 // engine decides to terminate its lease and set the host back to Ready.
@@ -361,6 +530,11 @@ func (a *baseHostSummary) HoldForPod(id *peloton.PodID) error {
 
 	if _, ok := a.heldPodIDs[id.GetValue()]; !ok {
 		a.heldPodIDs[id.GetValue()] = time.Now().Add(hostHeldStatusTimeout)
+		if info, ok := a.pods.GetPodInfo(id.GetValue()); ok {
+			a.heldPodResources[id.GetValue()] = models.HostResources{
+				NonSlack: scalar.FromPodSpec(info.spec),
+			}
+		}
 	}
 
 	log.WithFields(log.Fields{
@@ -395,8 +569,8 @@ func (a *baseHostSummary) GetHeldPods() []*peloton.PodID {
 	return result
 }
 
-// DeleteExpiredHolds deletes expired held pods in a hostSummary, returns
-// whether the hostSummary is free of helds,
+// DeleteExpiredHolds deletes expired held pods and capacity reservations
+// in a hostSummary, returns whether the hostSummary is free of helds,
 // available resource,
 // and the pods held expired.
 func (a *baseHostSummary) DeleteExpiredHolds(
@@ -404,6 +578,16 @@ func (a *baseHostSummary) DeleteExpiredHolds(
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	for token, r := range a.capacityReservations {
+		if deadline.After(r.expiration) {
+			delete(a.capacityReservations, token)
+			log.WithFields(log.Fields{
+				"hostname": a.hostname,
+				"token":    token,
+			}).Info("Capacity reservation expired on host")
+		}
+	}
+
 	var expired []*peloton.PodID
 	for id, expirationTime := range a.heldPodIDs {
 		if deadline.After(expirationTime) {
@@ -412,7 +596,7 @@ func (a *baseHostSummary) DeleteExpiredHolds(
 			expired = append(expired, pod)
 		}
 	}
-	return !a.isHeld(), a.available, expired
+	return !a.isHeld(), a.getUnreservedAvailable(nil), expired
 }
 
 func (a *baseHostSummary) releaseHoldForPod(id *peloton.PodID) {
@@ -427,6 +611,7 @@ func (a *baseHostSummary) releaseHoldForPod(id *peloton.PodID) {
 	}
 
 	delete(a.heldPodIDs, id.GetValue())
+	delete(a.heldPodResources, id.GetValue())
 
 	log.WithFields(log.Fields{
 		"hostname": a.hostname,
@@ -434,19 +619,247 @@ func (a *baseHostSummary) releaseHoldForPod(id *peloton.PodID) {
 	}).Debug("Release hold for pod")
 }
 
+// ReserveCapacity reserves resources on the host which are not assigned to
+// any pod yet, for example as headroom for an imminent scale up. The
+// reserved resources are not available to placement until the reservation
+// is released, or expires after the same timeout as a hold for a pod. It
+// returns the token identifying the reservation.
+func (a *baseHostSummary) ReserveCapacity(amount scalar.Resources) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if amount.Empty() {
+		return "", yarpcerrors.InvalidArgumentErrorf(
+			"cannot reserve empty resources on host %s", a.hostname)
+	}
+
+	reservation := models.HostResources{NonSlack: amount}
+	if !a.getUnreservedAvailable(nil).Contains(reservation) {
+		return "", yarpcerrors.InvalidArgumentErrorf(
+			"host %s has insufficient resources to reserve %s",
+			a.hostname, amount)
+	}
+
+	token := uuid.New()
+	a.capacityReservations[token] = &capacityReservation{
+		resources:  reservation,
+		expiration: time.Now().Add(hostHeldStatusTimeout),
+	}
+
+	log.WithFields(log.Fields{
+		"hostname": a.hostname,
+		"token":    token,
+		"amount":   amount,
+	}).Debug("Capacity reserved on host")
+	return token, nil
+}
+
+// ReleaseReservation releases the resources reserved with ReserveCapacity.
+func (a *baseHostSummary) ReleaseReservation(token string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.capacityReservations[token]; !ok {
+		return yarpcerrors.NotFoundErrorf(
+			"reservation %s not found on host %s", token, a.hostname)
+	}
+	delete(a.capacityReservations, token)
+
+	log.WithFields(log.Fields{
+		"hostname": a.hostname,
+		"token":    token,
+	}).Debug("Capacity reservation released on host")
+	return nil
+}
+
 // isHeld is true when number of held PodIDs is greater than zero.
 func (a *baseHostSummary) isHeld() bool {
 	return len(a.heldPodIDs) > 0
 }
 
-// GetAvailable returns the available resources of the host.
+// getReserved returns the resources reserved for held pods which are no
+// longer on the host, skipping the pods in exclude, and the resources
+// reserved with ReserveCapacity.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) getReserved(
+	exclude map[string]bool,
+) models.HostResources {
+	var reserved models.HostResources
+	for _, r := range a.capacityReservations {
+		reserved = reserved.Add(r.resources)
+	}
+	for id, r := range a.heldPodResources {
+		if exclude[id] {
+			continue
+		}
+		if _, ok := a.pods.GetPodInfo(id); ok {
+			// resources of the pod are still allocated on the host.
+			continue
+		}
+		reserved = reserved.Add(r)
+	}
+	return reserved
+}
+
+// getUnreservedAvailable returns the available resources of the host
+// minus the resources reserved for held pods not in exclude.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) getUnreservedAvailable(
+	exclude map[string]bool,
+) models.HostResources {
+	available, ok := a.available.TrySubtract(a.getReserved(exclude))
+	if !ok {
+		return models.HostResources{}
+	}
+	return available
+}
+
+// getOversubscribed returns the capacity offered to best-effort pods on
+// top of the capacity of the host.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) getOversubscribed() scalar.Resources {
+	extra := func(kind string, capacity float64) float64 {
+		ratio, ok := a.oversubscriptionRatio[kind]
+		if !ok {
+			return 0
+		}
+		return capacity * (ratio - 1)
+	}
+
+	return scalar.Resources{
+		CPU:  extra(common.CPU, a.capacity.NonSlack.GetCPU()),
+		Mem:  extra(common.MEMORY, a.capacity.NonSlack.GetMem()),
+		Disk: extra(common.DISK, a.capacity.NonSlack.GetDisk()),
+		GPU:  extra(common.GPU, a.capacity.NonSlack.GetGPU()),
+	}
+}
+
+// getOfferedCapacity returns the capacity of the host plus the
+// oversubscribed capacity as slack resources.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) getOfferedCapacity() models.HostResources {
+	return a.capacity.Add(models.HostResources{
+		Slack: a.getOversubscribed(),
+	})
+}
+
+// revocableOnSlack returns true if revocable pods run on the slack
+// resources of the host, which is only the case once an oversubscription
+// ratio is set on the host. Otherwise revocable pods run on the non-slack
+// resources like the other pods.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) revocableOnSlack() bool {
+	return len(a.oversubscriptionRatio) > 0
+}
+
+// SetOversubscriptionRatio sets the ratio, per resource kind, of the
+// capacity offered to best-effort pods to the capacity of the host. The
+// capacity above the capacity of the host is added to the available slack
+// resources, minus the resources of the revocable pods on the host, while
+// the capacity and the available non-slack resources used by guaranteed
+// pods are left unchanged. Resource kinds not in perKind are not
+// oversubscribed.
+func (a *baseHostSummary) SetOversubscriptionRatio(
+	perKind map[string]float64,
+) error {
+	ratios := make(map[string]float64, len(perKind))
+	for kind, ratio := range perKind {
+		switch kind {
+		case common.CPU, common.MEMORY, common.DISK, common.GPU:
+		default:
+			return yarpcerrors.InvalidArgumentErrorf(
+				"unknown resource kind %s", kind)
+		}
+		if ratio < 1 {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"oversubscription ratio %v of %s is less than 1", ratio, kind)
+		}
+		ratios[kind] = ratio
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.oversubscriptionRatio = ratios
+	a.strategy.postSetOversubscriptionRatio()
+
+	log.WithFields(log.Fields{
+		"hostname": a.hostname,
+		"ratios":   ratios,
+	}).Info("Oversubscription ratio set on the host")
+	return nil
+}
+
+// GetAvailable returns the available resources of the host, excluding
+// the resources reserved for held pods.
 func (a *baseHostSummary) GetAvailable() models.HostResources {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
+	return a.getUnreservedAvailable(nil)
+}
+
+// GetRawAvailable returns the available resources of the host, including
+// the resources reserved for held pods and capacity reservations.
+func (a *baseHostSummary) GetRawAvailable() models.HostResources {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	return a.available
 }
 
+// GetPods returns a snapshot of the pods on the host, mapped from the pod ID
+// to the resources of the pod. The returned map is a copy, so it can be
+// modified by the caller without affecting the host summary.
+func (a *baseHostSummary) GetPods() map[string]scalar.Resources {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	pods := make(map[string]scalar.Resources)
+	a.pods.RangePods(func(id string, info *podInfo) error {
+		pods[id] = scalar.FromPodSpec(info.spec)
+		return nil
+	})
+	return pods
+}
+
+// GetPodInfos returns a copy of the spec and state of the pods on the host,
+// keyed by the pod ID.
+func (a *baseHostSummary) GetPodInfos() map[string]PodInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	pods := make(map[string]PodInfo)
+	a.pods.RangePods(func(id string, info *podInfo) error {
+		pods[id] = PodInfo{Spec: info.spec, State: info.state}
+		return nil
+	})
+	return pods
+}
+
+// HasPodWithLabel returns whether any pod on the host carries a label
+// matching the selector. A selector with an empty value matches any label
+// with the same key.
+func (a *baseHostSummary) HasPodWithLabel(selector *peloton.Label) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var found bool
+	a.pods.RangePods(func(_ string, info *podInfo) error {
+		for _, label := range info.spec.GetLabels() {
+			if label.GetKey() != selector.GetKey() {
+				continue
+			}
+			if selector.GetValue() == "" ||
+				label.GetValue() == selector.GetValue() {
+				found = true
+			}
+		}
+		return nil
+	})
+	return found
+}
+
 // HandlePodEvent update host to pod map in baseHostSummary,
 // corresponding subclasses could overwrite the method, but need to
 // call the superclass method manually
@@ -497,6 +910,7 @@ func (a *baseHostSummary) SetCapacity(r models.HostResources) {
 	defer a.mu.Unlock()
 
 	a.capacity = r
+	a.lastSeen = a.now()
 }
 
 // SetAvailable sets the available resources of the host.
@@ -505,6 +919,7 @@ func (a *baseHostSummary) SetAvailable(r models.HostResources) {
 	defer a.mu.Unlock()
 
 	a.available = r
+	a.lastSeen = a.now()
 }
 
 // casStatus lock-freely sets the status to new value and update lease ID if
@@ -530,6 +945,17 @@ func (a *baseHostSummary) casStatus(oldStatus, newStatus HostStatus) error {
 	return nil
 }
 
+// toResmgrResources converts host manager scalar resources into resource
+// manager scalar resources.
+func toResmgrResources(r scalar.Resources) *rmscalar.Resources {
+	return &rmscalar.Resources{
+		CPU:    r.GetCPU(),
+		MEMORY: r.GetMem(),
+		DISK:   r.GetDisk(),
+		GPU:    r.GetGPU(),
+	}
+}
+
 // matchHostFilter determines whether given HostFilter matches the host.
 // This function assumes baseHostSummary lock is held before calling.
 func (a *baseHostSummary) matchHostFilter(
@@ -538,9 +964,35 @@ func (a *baseHostSummary) matchHostFilter(
 
 	min := c.GetResourceConstraint().GetMinimum()
 	if min != nil {
-		// Get min required resources.
+		// Resources reserved for the pods hinted to this host can be
+		// used by the placement.
+		hinted := make(map[string]bool)
+		for _, hostHint := range c.GetHint().GetHostHint() {
+			if hostHint.GetHostname() == a.hostname {
+				hinted[hostHint.GetPodId().GetValue()] = true
+			}
+		}
+		available := a.getUnreservedAvailable(hinted)
+
+		// Get min required resources. Revocable pods run on the slack
+		// resources of an oversubscribed host.
 		minRes := scalar.FromResourceSpec(min)
-		if !a.available.NonSlack.Contains(minRes) {
+		free := available.NonSlack
+		if c.GetResourceConstraint().GetRevocable() && a.revocableOnSlack() {
+			free = available.Slack
+		}
+		if !free.Contains(minRes) {
+			// the shortfall is only explained when it is logged, as hosts
+			// are filtered on the placement hot path
+			if log.GetLevel() >= log.DebugLevel {
+				log.WithFields(log.Fields{
+					"hostname": a.hostname,
+					"shortfall": rmscalar.ExplainShortfall(
+						toResmgrResources(minRes),
+						toResmgrResources(free),
+					).String(),
+				}).Debug("Skipped host with insufficient resources")
+			}
 			return hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES
 		}
 	}
@@ -607,6 +1059,15 @@ func (a *baseHostSummary) RecoverPodInfo(
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	a.recoverPodInfo(id, state, spec)
+}
+
+// recoverPodInfo adds, updates or removes the pod info on the host.
+// This function assumes baseHostSummary lock is held before calling.
+func (a *baseHostSummary) recoverPodInfo(
+	id *peloton.PodID,
+	state pbpod.PodState,
+	spec *pbpod.PodSpec) {
 	if util.IsPelotonPodStateTerminal(state) {
 		a.pods.RemovePod(id.GetValue())
 		return
@@ -630,3 +1091,9 @@ type noopHostStrategy struct{}
 func (s *noopHostStrategy) postCompleteLease(podToSpecMap map[string]*pbpod.PodSpec) error {
 	return nil
 }
+
+func (s *noopHostStrategy) postSwapPod() {}
+
+func (s *noopHostStrategy) postReleasePods() {}
+
+func (s *noopHostStrategy) postSetOversubscriptionRatio() {}
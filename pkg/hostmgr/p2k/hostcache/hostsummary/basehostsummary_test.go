@@ -165,6 +165,35 @@ func (suite *HostSummaryTestSuite) TestTryMatchReadyHost() {
 	}
 }
 
+// TestHostSummaryGetActiveLeaseID tests that GetActiveLeaseID returns the
+// lease of a placing host and is empty otherwise
+func (suite *HostSummaryTestSuite) TestHostSummaryGetActiveLeaseID() {
+	s := NewFakeHostSummary(_hostname, _version, _capacity)
+	suite.Equal(ReadyHost, s.GetHostStatus())
+	suite.Equal(emptyLeaseID, s.GetActiveLeaseID())
+
+	// matching a ready host moves it into placing state with a new lease
+	match := s.TryMatch(&hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum: &pod.ResourceSpec{
+				CpuLimit:   1.0,
+				MemLimitMb: 1.0,
+			},
+		},
+	})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	suite.Equal(PlacingHost, s.GetHostStatus())
+
+	leaseID := s.GetActiveLeaseID()
+	suite.NotEmpty(leaseID)
+	suite.Equal(s.GetHostLease().GetLeaseId().GetValue(), leaseID)
+
+	// terminating the lease moves the host back to ready
+	suite.NoError(s.TerminateLease(leaseID))
+	suite.Equal(ReadyHost, s.GetHostStatus())
+	suite.Equal(emptyLeaseID, s.GetActiveLeaseID())
+}
+
 // TestHostSummaryTerminateLease tests TerminateLease function of host summary
 func (suite *HostSummaryTestSuite) TestHostSummaryTerminateLease() {
 	testTable := map[string]struct {
@@ -279,7 +308,7 @@ func (suite *HostSummaryTestSuite) TestHostSummaryCompleteLease() {
 		s.leaseID = tt.leaseID
 		s.capacity.NonSlack = _capacity
 
-		err := s.CompleteLease(tt.inputLeaseID, nil)
+		_, err := s.CompleteLease(tt.inputLeaseID, nil)
 		if tt.errExpected {
 			suite.Error(err)
 			suite.Equal(tt.errMsg, err.Error(), "test case: %s", ttName)
@@ -22,6 +22,7 @@ import (
 	hostmgr "github.com/uber/peloton/.gen/peloton/private/hostmgr/v1alpha"
 	"github.com/uber/peloton/pkg/hostmgr/models"
 	p2kscalar "github.com/uber/peloton/pkg/hostmgr/p2k/scalar"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
 )
 
 type HostSummary interface {
@@ -29,8 +30,28 @@ type HostSummary interface {
 	// HostFilter, and lock the host if it does.
 	TryMatch(filter *hostmgr.HostFilter) Match
 
+	// Matches tries to match the current host with given HostFilter,
+	// without locking the host if it does.
+	Matches(filter *hostmgr.HostFilter) Match
+
 	// CompleteLease verifies that the leaseID on this host is still valid.
-	CompleteLease(leaseID string, podToSpecMap map[string]*pbpod.PodSpec) error
+	// It returns the unreserved available resources on the host after the
+	// pods are added, computed under the same lock.
+	CompleteLease(
+		leaseID string,
+		podToSpecMap map[string]*pbpod.PodSpec,
+	) (models.HostResources, error)
+
+	// SwapPod atomically replaces a pod on the host with a new pod, for
+	// example to restart it in place. It fails if the old pod is not on the
+	// host, or if the host does not have enough resources for the new pod
+	// once the resources of the old pod are released.
+	SwapPod(oldPodID, newPodID *peloton.PodID, newSpec *pbpod.PodSpec) error
+
+	// ReleasePods removes all the pods from the host and releases their
+	// resources, for example once the agent is lost. It returns the IDs
+	// of the pods released.
+	ReleasePods() []*peloton.PodID
 
 	// CasStatus sets the status to new value if current value is old, otherwise
 	// returns error.
@@ -45,12 +66,40 @@ type HostSummary interface {
 	// GetAvailable returns the available resources of the host.
 	GetAvailable() models.HostResources
 
+	// GetRawAvailable returns the available resources of the host,
+	// including the resources reserved for held pods and capacity
+	// reservations.
+	GetRawAvailable() models.HostResources
+
+	// GetPods returns a snapshot of the pods on the host, mapped from the
+	// pod ID to the resources of the pod.
+	GetPods() map[string]scalar.Resources
+
+	// GetPodInfos returns a copy of the spec and state of the pods on
+	// the host, keyed by the pod ID.
+	GetPodInfos() map[string]PodInfo
+
+	// HasPodWithLabel returns whether any pod on the host carries a label
+	// matching the selector. A selector with an empty value matches any
+	// label with the same key.
+	HasPodWithLabel(selector *peloton.Label) bool
+
 	// SetCapacity sets the capacity of the host.
 	SetCapacity(r models.HostResources)
 
 	// SetAvailable sets the available resource of the host.
 	SetAvailable(r models.HostResources)
 
+	// SetOversubscriptionRatio sets the ratio, per resource kind, of the
+	// capacity offered to best-effort pods to the capacity of the host.
+	// The raw capacity of the host is left unchanged.
+	SetOversubscriptionRatio(perKind map[string]float64) error
+
+	// GetLastSeen returns the last time the host was refreshed by the
+	// underlying cluster manager, either by a capacity or available
+	// resources update.
+	GetLastSeen() time.Time
+
 	// GetVersion returns the version of the host.
 	GetVersion() string
 
@@ -60,12 +109,22 @@ type HostSummary interface {
 	// GetHostname returns the hostname of the host.
 	GetHostname() string
 
+	// GetLabels returns the labels of the host.
+	GetLabels() []*peloton.Label
+
+	// SetLabels sets the labels of the host.
+	SetLabels(labels []*peloton.Label)
+
 	// GetHostStatus returns the HostStatus of the host.
 	GetHostStatus() HostStatus
 
 	// GetHostLease creates and returns a host lease.
 	GetHostLease() *hostmgr.HostLease
 
+	// GetActiveLeaseID returns the leaseID of the host if it is in
+	// PlacingHost state, and an empty string otherwise.
+	GetActiveLeaseID() string
+
 	// TerminateLease is called when terminating the lease on a host.
 	TerminateLease(leaseID string) error
 
@@ -81,10 +140,20 @@ type HostSummary interface {
 	// ReleaseHoldForPod release the hold of host for the pod specified.
 	ReleaseHoldForPod(id *peloton.PodID)
 
+	// ReserveCapacity reserves resources on the host without assigning
+	// them to a pod, and returns the token of the reservation. It fails if
+	// the host does not have enough available resources. The reservation
+	// expires like a hold if it is not released.
+	ReserveCapacity(amount scalar.Resources) (string, error)
+
+	// ReleaseReservation releases the resources reserved with the token.
+	ReleaseReservation(token string) error
+
 	// GetHeldPods returns a slice of pods that puts the host in held.
 	GetHeldPods() []*peloton.PodID
 
-	// DeleteExpiredHolds deletes expired held pods in a hostSummary, returns
+	// DeleteExpiredHolds deletes expired held pods and capacity
+	// reservations in a hostSummary, returns
 	// whether the hostSummary is free of helds,
 	// available resource,
 	// and the pods held expired.
@@ -105,4 +174,14 @@ type HostSummary interface {
 type hostStrategy interface {
 	// postCompleteLease handles actions after lease is completed.
 	postCompleteLease(podToSpecMap map[string]*pbpod.PodSpec) error
+
+	// postSwapPod handles actions after a pod is swapped.
+	postSwapPod()
+
+	// postReleasePods handles actions after all the pods are released.
+	postReleasePods()
+
+	// postSetOversubscriptionRatio handles actions after the
+	// oversubscription ratio of the host changes.
+	postSetOversubscriptionRatio()
 }
@@ -19,6 +19,7 @@ import (
 	"sort"
 
 	pbhost "github.com/uber/peloton/.gen/peloton/api/v1alpha/host"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	"github.com/uber/peloton/pkg/hostmgr/models"
 	p2kscalar "github.com/uber/peloton/pkg/hostmgr/p2k/scalar"
@@ -87,6 +88,19 @@ func (a *kubeletHostSummary) HandlePodEvent(event *p2kscalar.PodEvent) {
 	}
 }
 
+// RecoverPodInfo updates pods info on the host by calling parent class,
+// and recalculates available resources upon the change.
+func (a *kubeletHostSummary) RecoverPodInfo(
+	id *peloton.PodID,
+	state pbpod.PodState,
+	spec *pbpod.PodSpec) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.baseHostSummary.recoverPodInfo(id, state, spec)
+	a.calculateAllocated()
+}
+
 // SetCapacity sets the capacity of the host.
 // For k8s, capacity is updated by host event, and allocation is
 // calculated when pod is launched/killed. Therefore, whenever capacity
@@ -98,6 +112,7 @@ func (a *kubeletHostSummary) SetCapacity(r models.HostResources) {
 	// once capacity changes, need to recalculate available resources
 	a.capacity = r
 	a.available = a.calculateAvailable()
+	a.lastSeen = a.now()
 }
 
 // SetAvailable is noop for k8s agent, since it is calculated on-flight
@@ -111,7 +126,7 @@ func (a *kubeletHostSummary) SetAvailable(r models.HostResources) {
 }
 
 func (a *kubeletHostSummary) calculateAvailable() models.HostResources {
-	available, ok := a.capacity.TrySubtract(a.allocated)
+	available, ok := a.getOfferedCapacity().TrySubtract(a.allocated)
 	if !ok {
 		// Continue with available set to scalar.Resources{}. This would
 		// organically fail in the following steps.
@@ -143,22 +158,37 @@ func (a *kubeletHostSummary) postCompleteLease(podToSpecMap map[string]*pbpod.Po
 	return nil
 }
 
+// postSetOversubscriptionRatio recalculates the available resources once
+// the oversubscribed capacity changes.
+func (a *kubeletHostSummary) postSetOversubscriptionRatio() {
+	a.calculateAllocated()
+}
+
+// postSwapPod recalculates the allocated resources once a pod is swapped.
+func (a *kubeletHostSummary) postSwapPod() {
+	a.calculateAllocated()
+}
+
+// postReleasePods recalculates the available resources once all the pods
+// are released.
+func (a *kubeletHostSummary) postReleasePods() {
+	a.calculateAllocated()
+}
+
 // validateEnoughResToLaunch will return an error if:
 // a. The host has insufficient resources to place new pods.
 // This function assumes baseHostSummary lock is held before calling.
 func (a *kubeletHostSummary) validateEnoughResToLaunch(
 	podToSpecMap map[string]*pbpod.PodSpec,
 ) error {
-	// TODO validate slack too.
 	var slackNeeded, nonSlackNeeded scalar.Resources
 
-	podToResMap := make(map[string]scalar.Resources)
-	for id, spec := range podToSpecMap {
-		podToResMap[id] = scalar.FromPodSpec(spec)
-	}
-
-	for _, res := range podToResMap {
-		nonSlackNeeded = nonSlackNeeded.Add(res)
+	for _, spec := range podToSpecMap {
+		if spec.GetRevocable() && a.revocableOnSlack() {
+			slackNeeded = slackNeeded.Add(scalar.FromPodSpec(spec))
+			continue
+		}
+		nonSlackNeeded = nonSlackNeeded.Add(scalar.FromPodSpec(spec))
 	}
 
 	if !a.available.Contains(models.HostResources{
@@ -177,13 +207,21 @@ func (a *kubeletHostSummary) calculateAllocated() {
 	var slackAllocated, nonSlackallocated scalar.Resources
 	var ok bool
 
-	// calculate current allocation based on the new pods map
-	// TODO: populate slackAllocated from this map.
-	for _, r := range a.getPodToResMap() {
-		nonSlackallocated = nonSlackallocated.Add(r)
+	// calculate current allocation based on the new pods map, revocable
+	// pods are allocated on the slack resources of an oversubscribed host.
+	a.pods.RangePods(func(_ string, p *podInfo) error {
+		if p.spec.GetRevocable() && a.revocableOnSlack() {
+			slackAllocated = slackAllocated.Add(scalar.FromPodSpec(p.spec))
+			return nil
+		}
+		nonSlackallocated = nonSlackallocated.Add(scalar.FromPodSpec(p.spec))
+		return nil
+	})
+	a.allocated = models.HostResources{
+		Slack:    slackAllocated,
+		NonSlack: nonSlackallocated,
 	}
-	a.allocated.NonSlack = nonSlackallocated
-	a.available, ok = a.capacity.TrySubtract(models.HostResources{
+	a.available, ok = a.getOfferedCapacity().TrySubtract(models.HostResources{
 		Slack:    slackAllocated,
 		NonSlack: nonSlackallocated,
 	})
@@ -19,6 +19,7 @@ import (
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	pbhost "github.com/uber/peloton/.gen/peloton/api/v1alpha/host"
@@ -32,6 +33,7 @@ import (
 
 	"github.com/pborman/uuid"
 	"go.uber.org/atomic"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // TestKubeletHostSummarySetCapacity
@@ -197,7 +199,7 @@ func (suite *HostSummaryTestSuite) TestKubeletHostSummaryHandlePodEvent() {
 					continue
 				}
 				matches.Inc()
-				err := s.CompleteLease(s.leaseID, specMap)
+				_, err := s.CompleteLease(s.leaseID, specMap)
 				suite.NoError(err)
 
 				if old := eventHandled.Swap(true); old {
@@ -222,6 +224,401 @@ func (suite *HostSummaryTestSuite) TestKubeletHostSummaryHandlePodEvent() {
 	suite.Equal(int32(998), failures.Load())
 }
 
+// TestKubeletHostSummaryGetPods tests that GetPods returns the pods placed
+// on the host with their resources, and that the result is a copy.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummaryGetPods() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+	suite.Empty(s.GetPods())
+
+	specMap := GeneratePodSpecWithRes(3, 1.0, 10.0)
+	for id, spec := range GeneratePodSpecWithRes(2, 2.0, 20.0) {
+		specMap[id] = spec
+	}
+	match := s.TryMatch(&hostmgr.HostFilter{})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	_, err := s.CompleteLease(s.leaseID, specMap)
+	suite.NoError(err)
+
+	pods := s.GetPods()
+	suite.Len(pods, len(specMap))
+	for id, spec := range specMap {
+		suite.Equal(
+			CreateResource(
+				spec.GetContainers()[0].GetResource().GetCpuLimit(),
+				spec.GetContainers()[0].GetResource().GetMemLimitMb()),
+			pods[id])
+	}
+
+	// mutating the result doesn't affect the host summary
+	expected := s.GetPods()
+	for id := range pods {
+		pods[id] = CreateResource(100.0, 1000.0)
+	}
+	pods["podid-other"] = CreateResource(1.0, 10.0)
+	suite.Equal(expected, s.GetPods())
+	suite.Equal(CreateResource(7.0, 70.0), s.GetAllocated().NonSlack)
+}
+
+// TestKubeletHostSummaryHeldPodReservation tests that the resources of a
+// held pod stay reserved once the pod leaves the host, and are freed when
+// the hold expires.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummaryHeldPodReservation() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+
+	podID := &peloton.PodID{Value: "podid1"}
+	specMap := map[string]*pbpod.PodSpec{
+		podID.GetValue(): {
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{
+					CpuLimit:   4.0,
+					MemLimitMb: 40.0,
+				}},
+			},
+		},
+	}
+	reserved := models.HostResources{NonSlack: CreateResource(4.0, 40.0)}
+	unreserved := models.HostResources{NonSlack: CreateResource(6.0, 60.0)}
+	full := models.HostResources{NonSlack: _capacity}
+
+	match := s.TryMatch(&hostmgr.HostFilter{})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	_, err := s.CompleteLease(s.leaseID, specMap)
+	suite.NoError(err)
+	suite.Equal(unreserved, s.GetAvailable())
+
+	// Holding the host for a pod still on it doesn't change the available
+	// resources, since they are already allocated to the pod.
+	suite.NoError(s.HoldForPod(podID))
+	suite.Equal(reserved, s.heldPodResources[podID.GetValue()])
+	suite.Equal(unreserved, s.GetAvailable())
+
+	// Once the pod is deleted, its resources are reserved for it.
+	s.HandlePodEvent(&p2kscalar.PodEvent{
+		EventType: p2kscalar.DeletePod,
+		Event:     &pod.PodEvent{PodId: podID},
+	})
+	suite.Equal(full, s.available)
+	suite.Equal(unreserved, s.GetAvailable())
+
+	// A placement hinted for other pods can't use the reserved resources.
+	filter := &hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum: &pod.ResourceSpec{
+				CpuLimit:   8.0,
+				MemLimitMb: 80.0,
+			},
+		},
+		Hint: &hostmgr.FilterHint{
+			HostHint: []*hostmgr.FilterHint_Host{
+				{Hostname: _hostname, PodId: &peloton.PodID{Value: "podid2"}},
+			},
+		},
+	}
+	match = s.TryMatch(filter)
+	suite.Equal(
+		hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES,
+		match.Result)
+
+	// A placement hinted for the held pod can use them.
+	filter.Hint.HostHint[0].PodId = podID
+	match = s.TryMatch(filter)
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	suite.NoError(s.TerminateLease(s.leaseID))
+
+	// Completing a lease for another pod returns the available resources
+	// less the reservation.
+	match = s.TryMatch(&hostmgr.HostFilter{})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	available, err := s.CompleteLease(s.leaseID, map[string]*pbpod.PodSpec{
+		"podid2": {
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{
+					CpuLimit:   1.0,
+					MemLimitMb: 10.0,
+				}},
+			},
+		},
+	})
+	suite.NoError(err)
+	suite.Equal(models.HostResources{NonSlack: CreateResource(5.0, 50.0)}, available)
+	suite.Equal(available, s.GetAvailable())
+
+	// The reservation is freed when the hold expires.
+	isFree, available, expired := s.DeleteExpiredHolds(
+		time.Now().Add(2 * hostHeldStatusTimeout))
+	suite.True(isFree)
+	suite.Len(expired, 1)
+	suite.Equal(models.HostResources{NonSlack: CreateResource(9.0, 90.0)}, available)
+	suite.Equal(available, s.GetAvailable())
+	suite.Empty(s.heldPodResources)
+}
+
+// TestKubeletHostSummarySwapPod tests swapping pods with equal, smaller and
+// larger resources on a host.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummarySwapPod() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+
+	podSpec := func(cpu, mem float64) *pbpod.PodSpec {
+		return &pbpod.PodSpec{
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{
+					CpuLimit:   cpu,
+					MemLimitMb: mem,
+				}},
+			},
+		}
+	}
+	allocated := func(cpu, mem float64) models.HostResources {
+		return models.HostResources{NonSlack: CreateResource(cpu, mem)}
+	}
+
+	oldPodID := &peloton.PodID{Value: "podid1"}
+	match := s.TryMatch(&hostmgr.HostFilter{})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	_, err := s.CompleteLease(s.leaseID, map[string]*pbpod.PodSpec{
+		oldPodID.GetValue(): podSpec(4.0, 40.0),
+		"podid0":            podSpec(2.0, 20.0),
+	})
+	suite.NoError(err)
+	suite.Equal(allocated(6.0, 60.0), s.GetAllocated())
+
+	testTable := []struct {
+		msg         string
+		cpu, mem    float64
+		errExpected bool
+		allocated   models.HostResources
+	}{
+		{
+			msg:       "equal resources",
+			cpu:       4.0,
+			mem:       40.0,
+			allocated: allocated(6.0, 60.0),
+		},
+		{
+			msg:       "smaller resources",
+			cpu:       2.0,
+			mem:       20.0,
+			allocated: allocated(4.0, 40.0),
+		},
+		{
+			msg:       "larger resources",
+			cpu:       6.0,
+			mem:       60.0,
+			allocated: allocated(8.0, 80.0),
+		},
+		{
+			msg:         "larger resources than available",
+			cpu:         9.0,
+			mem:         90.0,
+			errExpected: true,
+			allocated:   allocated(8.0, 80.0),
+		},
+	}
+	for i, tt := range testTable {
+		newPodID := &peloton.PodID{Value: fmt.Sprintf("newpodid%d", i)}
+		err := s.SwapPod(oldPodID, newPodID, podSpec(tt.cpu, tt.mem))
+		suite.Equal(tt.allocated, s.GetAllocated(), tt.msg)
+		suite.Equal(
+			models.HostResources{NonSlack: _capacity}.Subtract(tt.allocated),
+			s.GetAvailable(),
+			tt.msg)
+
+		_, oldExists := s.pods.GetPodInfo(oldPodID.GetValue())
+		_, newExists := s.pods.GetPodInfo(newPodID.GetValue())
+		if tt.errExpected {
+			suite.Error(err, tt.msg)
+			suite.True(oldExists, tt.msg)
+			suite.False(newExists, tt.msg)
+			continue
+		}
+		suite.NoError(err, tt.msg)
+		suite.False(oldExists, tt.msg)
+		suite.True(newExists, tt.msg)
+		oldPodID = newPodID
+	}
+
+	// swapping a pod which is not on the host fails
+	err = s.SwapPod(
+		&peloton.PodID{Value: "podid2"},
+		&peloton.PodID{Value: "podid3"},
+		podSpec(1.0, 10.0))
+	suite.True(yarpcerrors.IsNotFound(err))
+
+	// swapping to a pod which is already on the host fails
+	err = s.SwapPod(oldPodID, &peloton.PodID{Value: "podid0"}, podSpec(1.0, 10.0))
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestKubeletHostSummarySetOversubscriptionRatio tests that changing the
+// oversubscription ratio scales the slack resources available to best-effort
+// pods, while the capacity and the resources available to guaranteed pods
+// stay unchanged.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummarySetOversubscriptionRatio() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+
+	match := s.TryMatch(&hostmgr.HostFilter{})
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH, match.Result)
+	_, err := s.CompleteLease(s.leaseID, map[string]*pbpod.PodSpec{
+		"podid1": {
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{
+					CpuLimit:   4.0,
+					MemLimitMb: 40.0,
+				}},
+			},
+		},
+	})
+	suite.NoError(err)
+	guaranteed := CreateResource(6.0, 60.0)
+
+	testTable := []struct {
+		msg   string
+		ratio map[string]float64
+		slack scalar.Resources
+	}{
+		{
+			msg:   "no oversubscription",
+			ratio: map[string]float64{},
+			slack: scalar.Resources{},
+		},
+		{
+			msg:   "cpu oversubscription",
+			ratio: map[string]float64{"cpu": 1.5},
+			slack: CreateResource(5.0, 0),
+		},
+		{
+			msg:   "raised cpu and memory oversubscription",
+			ratio: map[string]float64{"cpu": 2.0, "memory": 1.25},
+			slack: CreateResource(10.0, 25.0),
+		},
+		{
+			msg:   "oversubscription removed",
+			ratio: nil,
+			slack: scalar.Resources{},
+		},
+	}
+	for _, tt := range testTable {
+		suite.NoError(s.SetOversubscriptionRatio(tt.ratio), tt.msg)
+
+		available := s.GetAvailable()
+		suite.Equal(guaranteed, available.NonSlack, tt.msg)
+		suite.Equal(tt.slack, available.Slack, tt.msg)
+		suite.Equal(models.HostResources{NonSlack: _capacity}, s.GetCapacity(), tt.msg)
+	}
+
+	// invalid ratios are rejected and keep the current ratios
+	suite.NoError(s.SetOversubscriptionRatio(map[string]float64{"cpu": 2.0}))
+	suite.True(yarpcerrors.IsInvalidArgument(
+		s.SetOversubscriptionRatio(map[string]float64{"cpu": 0.5})))
+	suite.True(yarpcerrors.IsInvalidArgument(
+		s.SetOversubscriptionRatio(map[string]float64{"ports": 2.0})))
+	suite.Equal(CreateResource(10.0, 0), s.GetAvailable().Slack)
+}
+
+// TestKubeletHostSummaryRevocablePods tests that revocable pods are
+// allocated on, and matched against, the oversubscribed slack resources.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummaryRevocablePods() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+	suite.NoError(s.SetOversubscriptionRatio(map[string]float64{"cpu": 1.5}))
+
+	filter := func(cpu float64, revocable bool) *hostmgr.HostFilter {
+		return &hostmgr.HostFilter{
+			ResourceConstraint: &hostmgr.ResourceConstraint{
+				Minimum:   &pbpod.ResourceSpec{CpuLimit: cpu},
+				Revocable: revocable,
+			},
+		}
+	}
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH,
+		s.matchHostFilter(filter(4.0, true)))
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES,
+		s.matchHostFilter(filter(6.0, true)))
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH,
+		s.matchHostFilter(filter(6.0, false)))
+
+	s.RecoverPodInfo(
+		&peloton.PodID{Value: "podid1"},
+		pbpod.PodState_POD_STATE_RUNNING,
+		&pbpod.PodSpec{
+			Revocable: true,
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{CpuLimit: 3.0}},
+			},
+		},
+	)
+	suite.Equal(models.HostResources{
+		Slack:    CreateResource(2.0, 0),
+		NonSlack: _capacity,
+	}, s.GetAvailable())
+	suite.Equal(models.HostResources{
+		Slack: CreateResource(3.0, 0),
+	}, s.GetAllocated())
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_INSUFFICIENT_RESOURCES,
+		s.matchHostFilter(filter(3.0, true)))
+
+	// revocable pods are limited by the slack resources at launch
+	suite.Error(s.validateEnoughResToLaunch(map[string]*pbpod.PodSpec{
+		"podid2": {
+			Revocable: true,
+			Containers: []*pbpod.ContainerSpec{
+				{Resource: &pbpod.ResourceSpec{CpuLimit: 3.0}},
+			},
+		},
+	}))
+}
+
+// TestKubeletHostSummaryRevocablePodsNotOversubscribed tests that revocable
+// pods are allocated on, and matched against, the non-slack resources of a
+// host with no oversubscription ratio, which offers no slack resources.
+func (suite *HostSummaryTestSuite) TestKubeletHostSummaryRevocablePodsNotOversubscribed() {
+	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version).(*kubeletHostSummary)
+	s.SetCapacity(models.HostResources{
+		NonSlack: _capacity,
+	})
+
+	filter := &hostmgr.HostFilter{
+		ResourceConstraint: &hostmgr.ResourceConstraint{
+			Minimum:   &pbpod.ResourceSpec{CpuLimit: 4.0},
+			Revocable: true,
+		},
+	}
+	suite.Equal(hostmgr.HostFilterResult_HOST_FILTER_MATCH,
+		s.matchHostFilter(filter))
+
+	spec := &pbpod.PodSpec{
+		Revocable: true,
+		Containers: []*pbpod.ContainerSpec{
+			{Resource: &pbpod.ResourceSpec{CpuLimit: 4.0}},
+		},
+	}
+	suite.NoError(s.validateEnoughResToLaunch(map[string]*pbpod.PodSpec{
+		"podid1": spec,
+	}))
+	s.RecoverPodInfo(
+		&peloton.PodID{Value: "podid1"},
+		pbpod.PodState_POD_STATE_RUNNING,
+		spec,
+	)
+	suite.Equal(models.HostResources{
+		NonSlack: CreateResource(4.0, 0),
+	}, s.GetAllocated())
+}
+
 func (suite *HostSummaryTestSuite) TestCompleteLaunchPod() {
 	s := NewKubeletHostSummary(_hostname, models.HostResources{}, _version)
 	s.CompleteLaunchPod(&models.LaunchablePod{
@@ -363,7 +760,7 @@ func (suite *HostSummaryTestSuite) TestKubeletHostSummaryCompleteLease() {
 			tt.podToSpecMap[_podID] = nil
 		}
 
-		err := s.CompleteLease(tt.inputLeaseID, tt.podToSpecMap)
+		available, err := s.CompleteLease(tt.inputLeaseID, tt.podToSpecMap)
 		if tt.errExpected {
 			// complete with error, only the previous pods should exist in host summary
 			suite.Equal(s.pods.GetSize(), 1, "test case: %s", ttName)
@@ -383,6 +780,12 @@ func (suite *HostSummaryTestSuite) TestKubeletHostSummaryCompleteLease() {
 				)
 			}
 			suite.NoError(err, "test case: %s", ttName)
+			// the returned available resources account for the new pods
+			suite.Equal(
+				_capacity.Subtract(tt.afterAllocated),
+				available.NonSlack,
+				"test case: %s", ttName)
+			suite.Equal(s.GetAvailable(), available, "test case: %s", ttName)
 		}
 		suite.Equal(tt.afterAllocated, s.allocated.NonSlack, "test case: %s", ttName)
 		suite.Equal(tt.afterStatus, s.GetHostStatus(), "test case: %s", ttName)
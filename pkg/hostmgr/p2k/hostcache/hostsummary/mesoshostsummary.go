@@ -19,6 +19,7 @@ import (
 	"github.com/uber/peloton/pkg/hostmgr/models"
 
 	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // makes sure mesosHostSummary implements HostSummary
@@ -31,6 +32,13 @@ type mesosHostSummary struct {
 	*baseHostSummary
 }
 
+// IsMesosHostSummary returns true if the host summary is backed by a
+// mesos agent.
+func IsMesosHostSummary(s HostSummary) bool {
+	_, ok := s.(*mesosHostSummary)
+	return ok
+}
+
 func NewMesosHostSummary(hostname string) HostSummary {
 	ms := &mesosHostSummary{
 		// mesos does not has concept of host version
@@ -49,6 +57,7 @@ func (a *mesosHostSummary) SetCapacity(r models.HostResources) {
 
 	// TODO: figure out how to handle available/allocated resources
 	a.capacity = r
+	a.lastSeen = a.now()
 }
 
 // SetAvailable sets available resources on a host
@@ -59,6 +68,7 @@ func (a *mesosHostSummary) SetAvailable(r models.HostResources) {
 	var ok bool
 
 	a.available = r
+	a.lastSeen = a.now()
 	a.allocated, ok = a.capacity.TrySubtract(r)
 	if !ok {
 		// continue with available set to scalar.Resources{}. This would
@@ -73,8 +83,33 @@ func (a *mesosHostSummary) SetAvailable(r models.HostResources) {
 	}
 }
 
+// SetOversubscriptionRatio is not supported for mesos agents, which offer
+// their own revocable resources.
+func (a *mesosHostSummary) SetOversubscriptionRatio(
+	perKind map[string]float64,
+) error {
+	return yarpcerrors.UnimplementedErrorf(
+		"oversubscription ratio is not supported on mesos host %s", a.hostname)
+}
+
 // postCompleteLease handles actions after lease is completed
 func (a *mesosHostSummary) postCompleteLease(podToSpecMap map[string]*pbpod.PodSpec) error {
 	// noop for mesos
 	return nil
 }
+
+// postSwapPod handles actions after a pod is swapped
+func (a *mesosHostSummary) postSwapPod() {
+	// noop for mesos, available resources are updated from the offers
+}
+
+// postReleasePods handles actions after all the pods are released
+func (a *mesosHostSummary) postReleasePods() {
+	// noop for mesos, available resources are updated from the offers
+}
+
+// postSetOversubscriptionRatio handles actions after the oversubscription
+// ratio changes
+func (a *mesosHostSummary) postSetOversubscriptionRatio() {
+	// noop for mesos, oversubscription is not supported
+}
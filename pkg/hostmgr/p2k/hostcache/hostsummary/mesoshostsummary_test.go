@@ -17,6 +17,8 @@ package hostsummary
 import (
 	"github.com/uber/peloton/pkg/hostmgr/models"
 	"github.com/uber/peloton/pkg/hostmgr/scalar"
+
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // TestMesosHostSummarySetCapacity tests for mesos change capacity
@@ -55,6 +57,14 @@ func (suite *HostSummaryTestSuite) TestMesosHostSummarySetCapacity() {
 
 // TestMesosHostSummarySetAvailable
 // test set available would update allocated resources
+// TestMesosHostSummarySetOversubscriptionRatio tests that oversubscription
+// is rejected on mesos hosts, which offer their own revocable resources.
+func (suite *HostSummaryTestSuite) TestMesosHostSummarySetOversubscriptionRatio() {
+	s := NewMesosHostSummary(_hostname)
+	err := s.SetOversubscriptionRatio(map[string]float64{"cpu": 1.5})
+	suite.True(yarpcerrors.IsUnimplemented(err))
+}
+
 func (suite *HostSummaryTestSuite) TestMesosHostSummarySetAvailable() {
 	testTable := map[string]struct {
 		capacity          scalar.Resources
@@ -26,6 +26,12 @@ type podInfo struct {
 	state pbpod.PodState
 }
 
+// PodInfo is an exported copy of the spec and current state of a pod.
+type PodInfo struct {
+	Spec  *pbpod.PodSpec
+	State pbpod.PodState
+}
+
 // newPodInfo creates new podInfo object with given spec, assuming it's in
 // "Launched" state.
 func newPodInfo(spec *pbpod.PodSpec) *podInfo {
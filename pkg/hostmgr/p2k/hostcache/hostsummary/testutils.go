@@ -16,10 +16,11 @@ package hostsummary
 
 import (
 	"fmt"
-	"github.com/uber/peloton/pkg/hostmgr/models"
+	"time"
 
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
 	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+	"github.com/uber/peloton/pkg/hostmgr/models"
 	"github.com/uber/peloton/pkg/hostmgr/scalar"
 
 	"github.com/pborman/uuid"
@@ -51,6 +52,11 @@ func (f *FakeHostSummary) SetAllocated(allocated scalar.Resources) {
 	}
 }
 
+// SetClock sets the clock used to record when the host was last refreshed.
+func (f *FakeHostSummary) SetClock(now func() time.Time) {
+	f.now = now
+}
+
 func (f *FakeHostSummary) GetPodInfo(
 	podID *peloton.PodID,
 ) (pbpod.PodState, *pbpod.PodSpec, bool) {
@@ -15,22 +15,41 @@
 package hostcache
 
 import (
+	"time"
+
 	"github.com/uber/peloton/pkg/common/scalar"
 
 	"github.com/uber-go/tally"
 )
 
+// Buckets of the histogram of time spent by hosts in Placing status.
+var _placingDurationBuckets = tally.MustMakeExponentialDurationBuckets(
+	10*time.Millisecond, 2, 20)
+
 // Metrics tracks various metrics at offer hostCache level.
 type Metrics struct {
 	// Available and Allocated resources in host cache.
 	Available scalar.GaugeMaps
 	Allocated scalar.GaugeMaps
 
+	// Available resources on the hosts in Ready status.
+	ReadyAvailable scalar.GaugeMaps
+
 	// Metrics for number of hosts on each status.
 	ReadyHosts     tally.Gauge
 	PlacingHosts   tally.Gauge
 	HeldHosts      tally.Gauge
 	AvailableHosts tally.Gauge
+
+	// Number of hosts evicted for not being refreshed within the TTL.
+	EvictedHosts tally.Counter
+
+	// Number of hosts removed after their agent was lost.
+	LostHosts tally.Counter
+
+	// Time spent by hosts in Placing status before the lease is completed
+	// or terminated.
+	PlacingDuration tally.Histogram
 }
 
 // NewMetrics returns a new Metrics struct, with all metrics initialized
@@ -41,13 +60,20 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	// resources in ready & placing host status
 	resourceScope := hostCacheScope.SubScope("resource")
 	hostsScope := hostCacheScope.SubScope("hosts")
+	leaseScope := hostCacheScope.SubScope("lease")
 
 	return &Metrics{
 		Available:      scalar.NewGaugeMaps(resourceScope),
 		Allocated:      scalar.NewGaugeMaps(resourceScope),
+		ReadyAvailable: scalar.NewGaugeMaps(hostCacheScope.SubScope("ready_resource")),
 		ReadyHosts:     hostsScope.Gauge("ready"),
 		PlacingHosts:   hostsScope.Gauge("placing"),
 		HeldHosts:      hostsScope.Gauge("held"),
 		AvailableHosts: hostsScope.Gauge("available"),
+		EvictedHosts:   hostsScope.Counter("evicted"),
+		LostHosts:      hostsScope.Counter("lost"),
+
+		PlacingDuration: leaseScope.Histogram(
+			"placing_duration", _placingDurationBuckets),
 	}
 }
@@ -0,0 +1,154 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostcache
+
+import (
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+	"github.com/uber/peloton/pkg/hostmgr/models"
+	"github.com/uber/peloton/pkg/hostmgr/p2k/hostcache/hostsummary"
+
+	"github.com/gogo/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+// HostSnapshot is a serializable view of a single host summary.
+type HostSnapshot struct {
+	// Hostname of the host.
+	Hostname string `json:"hostname"`
+
+	// Resource version of the host.
+	Version string `json:"version"`
+
+	// Mesos is true if the host is a mesos agent, and false if it is
+	// a kubelet.
+	Mesos bool `json:"mesos"`
+
+	// Capacity of the host.
+	Capacity models.HostResources `json:"capacity"`
+
+	// Available resources on the host, including the resources reserved
+	// for held pods and capacity reservations.
+	Available models.HostResources `json:"available"`
+
+	// Labels on the host.
+	Labels []*peloton.Label `json:"labels"`
+
+	// Pods on the host.
+	Pods []*PodSnapshot `json:"pods"`
+}
+
+// PodSnapshot is a serializable view of a pod on a host.
+type PodSnapshot struct {
+	// ID of the pod.
+	PodID string `json:"pod_id"`
+
+	// Current state of the pod.
+	State pbpod.PodState `json:"state"`
+
+	// Serialized pod spec.
+	Spec []byte `json:"spec"`
+}
+
+// Snapshot is a serializable view of all the hosts in the host cache.
+// It is used to warm up the host cache of a new leader on failover
+// instead of waiting for the full host sync.
+type Snapshot struct {
+	Hosts []*HostSnapshot `json:"hosts"`
+}
+
+// Snapshot returns a serializable view of all the host summaries,
+// including the pods on each host. Leases, holds and capacity reservations
+// are not part of the snapshot, so they are lost on failover.
+func (c *hostCache) Snapshot() (*Snapshot, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshot := &Snapshot{}
+	for _, hs := range c.hostIndex {
+		h := &HostSnapshot{
+			Hostname:  hs.GetHostname(),
+			Version:   hs.GetVersion(),
+			Mesos:     hostsummary.IsMesosHostSummary(hs),
+			Capacity:  hs.GetCapacity(),
+			Available: hs.GetRawAvailable(),
+			Labels:    hs.GetLabels(),
+		}
+		for id, info := range hs.GetPodInfos() {
+			spec, err := proto.Marshal(info.Spec)
+			if err != nil {
+				return nil, err
+			}
+			h.Pods = append(h.Pods, &PodSnapshot{
+				PodID: id,
+				State: info.State,
+				Spec:  spec,
+			})
+		}
+		snapshot.Hosts = append(snapshot.Hosts, h)
+	}
+	return snapshot, nil
+}
+
+// Restore rebuilds the host cache from the snapshot, replacing all
+// the hosts currently in the cache. The pods on each host are restored
+// so that the allocation of the host is accounted for. All the restored
+// hosts are in ReadyHost state since leases and holds do not survive
+// a failover.
+func (c *hostCache) Restore(snapshot *Snapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hostIndex = make(map[string]hostsummary.HostSummary)
+	c.podHeldIndex = make(map[string]string)
+	c.placingSince = make(map[string]time.Time)
+
+	for _, h := range snapshot.GetHosts() {
+		var hs hostsummary.HostSummary
+		if h.Mesos {
+			hs = hostsummary.NewMesosHostSummary(h.Hostname)
+			hs.SetCapacity(h.Capacity)
+			hs.SetAvailable(h.Available)
+		} else {
+			// Available resources of a kubelet are calculated from
+			// its capacity and the pods on it.
+			hs = hostsummary.NewKubeletHostSummary(h.Hostname, h.Capacity, h.Version)
+			hs.SetCapacity(h.Capacity)
+		}
+		hs.SetLabels(h.Labels)
+		for _, p := range h.Pods {
+			spec := &pbpod.PodSpec{}
+			if err := proto.Unmarshal(p.Spec, spec); err != nil {
+				return err
+			}
+			hs.RecoverPodInfo(&peloton.PodID{Value: p.PodID}, p.State, spec)
+		}
+		c.hostIndex[h.Hostname] = hs
+	}
+
+	log.WithField("num_hosts", len(c.hostIndex)).
+		Info("Restored host cache from snapshot")
+	return nil
+}
+
+// GetHosts returns the hosts in the snapshot.
+func (s *Snapshot) GetHosts() []*HostSnapshot {
+	if s == nil {
+		return nil
+	}
+	return s.Hosts
+}
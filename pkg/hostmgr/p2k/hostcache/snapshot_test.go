@@ -0,0 +1,132 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostcache
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/peloton"
+	pbpod "github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
+	"github.com/uber/peloton/pkg/hostmgr/models"
+	"github.com/uber/peloton/pkg/hostmgr/p2k/hostcache/hostsummary"
+	"github.com/uber/peloton/pkg/hostmgr/scalar"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+// TestSnapshotRestore tests that a snapshot of a populated host cache can
+// be serialized and restored into a fresh host cache, keeping capacity,
+// labels and pods while resetting the host statuses to ready and dropping
+// the reservations.
+func TestSnapshotRestore(t *testing.T) {
+	require := require.New(t)
+
+	kubeletCapacity := models.HostResources{
+		NonSlack: scalar.Resources{CPU: 4.0, Mem: 1024.0},
+	}
+	kubeletHost := hostsummary.NewKubeletHostSummary(
+		"kubelet-host", kubeletCapacity, "1")
+	kubeletHost.SetLabels([]*peloton.Label{{Key: "zone", Value: "dca1"}})
+	for id, spec := range hostsummary.GeneratePodSpecWithRes(1, 1.0, 256.0) {
+		kubeletHost.RecoverPodInfo(
+			&peloton.PodID{Value: id},
+			pbpod.PodState_POD_STATE_RUNNING,
+			spec,
+		)
+	}
+	kubeletAvailable := models.HostResources{
+		NonSlack: scalar.Resources{CPU: 3.0, Mem: 768.0},
+	}
+	require.Equal(kubeletAvailable, kubeletHost.GetAvailable())
+
+	mesosCapacity := models.HostResources{
+		NonSlack: scalar.Resources{CPU: 8.0, Mem: 2048.0},
+	}
+	mesosAvailable := models.HostResources{
+		NonSlack: scalar.Resources{CPU: 2.0, Mem: 512.0},
+	}
+	mesosHost := hostsummary.NewMesosHostSummary("mesos-host")
+	mesosHost.SetCapacity(mesosCapacity)
+	mesosHost.SetAvailable(mesosAvailable)
+	mesosHost.SetLabels([]*peloton.Label{{Key: "rack", Value: "r1"}})
+	require.NoError(mesosHost.CasStatus(
+		hostsummary.ReadyHost, hostsummary.PlacingHost))
+
+	hc := &hostCache{
+		hostIndex: map[string]hostsummary.HostSummary{
+			kubeletHost.GetHostname(): kubeletHost,
+			mesosHost.GetHostname():   mesosHost,
+		},
+		podHeldIndex: map[string]string{},
+		metrics:      NewMetrics(tally.NoopScope),
+	}
+	require.NoError(hc.HoldForPods(
+		kubeletHost.GetHostname(),
+		[]*peloton.PodID{{Value: uuid.New()}},
+	))
+
+	// Reservations do not survive a failover, so the snapshot must keep
+	// the resources they reserved available.
+	_, err := hc.ReserveCapacity(
+		mesosHost.GetHostname(),
+		scalar.Resources{CPU: 1.0, Mem: 128.0},
+	)
+	require.NoError(err)
+	require.Equal(models.HostResources{
+		NonSlack: scalar.Resources{CPU: 1.0, Mem: 384.0},
+	}, mesosHost.GetAvailable())
+
+	snapshot, err := hc.Snapshot()
+	require.NoError(err)
+	require.Len(snapshot.GetHosts(), 2)
+
+	// The snapshot must survive a serialization round trip.
+	data, err := json.Marshal(snapshot)
+	require.NoError(err)
+	restored := &Snapshot{}
+	require.NoError(json.Unmarshal(data, restored))
+
+	newHC := &hostCache{
+		hostIndex:    map[string]hostsummary.HostSummary{},
+		podHeldIndex: map[string]string{},
+		metrics:      NewMetrics(tally.NoopScope),
+	}
+	require.NoError(newHC.Restore(restored))
+	require.Len(newHC.GetSummaries(), 2)
+	require.Empty(newHC.podHeldIndex)
+
+	hs, err := newHC.getSummary(kubeletHost.GetHostname())
+	require.NoError(err)
+	require.False(hostsummary.IsMesosHostSummary(hs))
+	require.Equal(kubeletCapacity, hs.GetCapacity())
+	require.Equal(kubeletAvailable, hs.GetAvailable())
+	require.Equal(kubeletHost.GetPods(), hs.GetPods())
+	require.Equal("1", hs.GetVersion())
+	require.Equal(kubeletHost.GetLabels(), hs.GetLabels())
+	require.Equal(hostsummary.ReadyHost, hs.GetHostStatus())
+	require.Empty(hs.GetHeldPods())
+
+	hs, err = newHC.getSummary(mesosHost.GetHostname())
+	require.NoError(err)
+	require.True(hostsummary.IsMesosHostSummary(hs))
+	require.Equal(mesosCapacity, hs.GetCapacity())
+	require.Equal(mesosAvailable, hs.GetAvailable())
+	require.Equal(mesosHost.GetLabels(), hs.GetLabels())
+	require.Equal(hostsummary.ReadyHost, hs.GetHostStatus())
+	require.Empty(hs.GetActiveLeaseID())
+}
@@ -162,11 +162,79 @@ func (m *MesosManager) LaunchPods(
 	ctx context.Context,
 	pods []*models.LaunchablePod,
 	hostname string,
+) ([]*models.LaunchablePod, error) {
+	return m.launch(
+		ctx,
+		pods,
+		hostname,
+		func(mesosTasks []*mesos.TaskInfo) *mesos.Offer_Operation {
+			opType := mesos.Offer_Operation_LAUNCH
+			return &mesos.Offer_Operation{
+				Type: &opType,
+				Launch: &mesos.Offer_Operation_Launch{
+					TaskInfos: mesosTasks,
+				},
+			}
+		},
+	)
+}
+
+// LaunchPodGroup launches a list of pods on a host as a single Mesos
+// task group. All the pods are atomically delivered to the given executor,
+// which is shared by every task in the group, so the pods are co-scheduled
+// on the host (used for gang/pod scheduling).
+func (m *MesosManager) LaunchPodGroup(
+	ctx context.Context,
+	pods []*models.LaunchablePod,
+	hostname string,
+	executor *mesos.ExecutorInfo,
+) ([]*models.LaunchablePod, error) {
+	if len(pods) == 0 {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"no pods provided to launch as a group on %s", hostname)
+	}
+
+	if executor == nil {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"no executor provided to launch pod group on %s", hostname)
+	}
+
+	return m.launch(
+		ctx,
+		pods,
+		hostname,
+		func(mesosTasks []*mesos.TaskInfo) *mesos.Offer_Operation {
+			// tasks in a task group must not specify their own executor,
+			// the executor of the group is used for all of them.
+			for _, mesosTask := range mesosTasks {
+				mesosTask.Executor = nil
+			}
+
+			opType := mesos.Offer_Operation_LAUNCH_GROUP
+			return &mesos.Offer_Operation{
+				Type: &opType,
+				LaunchGroup: &mesos.Offer_Operation_LaunchGroup{
+					Executor: executor,
+					TaskGroup: &mesos.TaskGroupInfo{
+						Tasks: mesosTasks,
+					},
+				},
+			}
+		},
+	)
+}
+
+// launch builds the mesos tasks for the pods using the offers held for the
+// host, and accepts the offers with the operation returned by buildOp.
+func (m *MesosManager) launch(
+	ctx context.Context,
+	pods []*models.LaunchablePod,
+	hostname string,
+	buildOp func(mesosTasks []*mesos.TaskInfo) *mesos.Offer_Operation,
 ) ([]*models.LaunchablePod, error) {
 	var offerIds []*mesos.OfferID
 	var mesosResources []*mesos.Resource
 	var mesosTasks []*mesos.TaskInfo
-	var mesosTaskIds []string
 
 	offers := m.offerManager.GetOffers(hostname)
 
@@ -196,24 +264,15 @@ func (m *MesosManager) LaunchPods(
 		}
 		mesosTask.AgentId = agentID
 		mesosTasks = append(mesosTasks, mesosTask)
-		mesosTaskIds = append(mesosTaskIds, mesosTask.GetTaskId().GetValue())
 	}
 
 	callType := sched.Call_ACCEPT
-	opType := mesos.Offer_Operation_LAUNCH
 	msg := &sched.Call{
 		FrameworkId: m.frameworkInfoProvider.GetFrameworkID(ctx),
 		Type:        &callType,
 		Accept: &sched.Call_Accept{
-			OfferIds: offerIds,
-			Operations: []*mesos.Offer_Operation{
-				{
-					Type: &opType,
-					Launch: &mesos.Offer_Operation_Launch{
-						TaskInfos: mesosTasks,
-					},
-				},
-			},
+			OfferIds:   offerIds,
+			Operations: []*mesos.Offer_Operation{buildOp(mesosTasks)},
 		},
 	}
 
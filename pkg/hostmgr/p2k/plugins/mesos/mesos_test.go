@@ -168,6 +168,116 @@ func (suite *MesosManagerTestSuite) TestMesosManagerLaunchPodSuccess() {
 	suite.Equal(1, len(launched))
 }
 
+// TestMesosManagerLaunchPodGroupSuccess tests launching a list of pods
+// as a single mesos task group sharing the same executor
+func (suite *MesosManagerTestSuite) TestMesosManagerLaunchPodGroupSuccess() {
+	testPodNames := []string{
+		"bca875f5-322a-4439-b0c9-63e3cf9f982e-1-1",
+		"bca875f5-322a-4439-b0c9-63e3cf9f982e-2-1",
+	}
+	testHostName := "test_host"
+	streamID := "streamID"
+	frameID := "frameID"
+	executorID := "executorID"
+	executorType := mesos.ExecutorInfo_DEFAULT
+	uuid1 := uuid.New()
+
+	executor := &mesos.ExecutorInfo{
+		Type:       &executorType,
+		ExecutorId: &mesos.ExecutorID{Value: &executorID},
+	}
+
+	// add enough resources in offer pool
+	suite.mesosManager.Offers(context.Background(), &sched.Event{
+		Offers: &sched.Event_Offers{
+			Offers: []*mesos.Offer{
+				{Resources: []*mesos.Resource{
+					util.NewMesosResourceBuilder().
+						WithName(common.MesosCPU).
+						WithValue(2.0).
+						Build(),
+					util.NewMesosResourceBuilder().
+						WithName(common.MesosMem).
+						WithValue(200.0).
+						Build(),
+				},
+					Hostname: &testHostName,
+					Id:       &mesos.OfferID{Value: &uuid1},
+				},
+			},
+		},
+	})
+
+	var pods []*models.LaunchablePod
+	for _, podName := range testPodNames {
+		pods = append(pods, &models.LaunchablePod{
+			PodId: &peloton.PodID{Value: podName},
+			Spec:  newTestPelotonPodSpec(podName),
+		})
+	}
+
+	suite.provider.
+		EXPECT().
+		GetFrameworkID(gomock.Any()).
+		Return(&mesos.FrameworkID{
+			Value: &frameID,
+		})
+	suite.provider.
+		EXPECT().
+		GetMesosStreamID(gomock.Any()).
+		Return(streamID)
+	suite.schedulerClient.
+		EXPECT().
+		Call(streamID, gomock.Any()).
+		Do(func(mesosStreamID string, call *sched.Call) {
+			suite.Equal(call.GetType(), sched.Call_ACCEPT)
+			suite.Len(call.GetAccept().GetOfferIds(), 1)
+			suite.Len(call.GetAccept().GetOperations(), 1)
+
+			op := call.GetAccept().GetOperations()[0]
+			suite.Equal(mesos.Offer_Operation_LAUNCH_GROUP, op.GetType())
+			suite.Nil(op.GetLaunch())
+			suite.Equal(executor, op.GetLaunchGroup().GetExecutor())
+
+			tasks := op.GetLaunchGroup().GetTaskGroup().GetTasks()
+			suite.Len(tasks, len(testPodNames))
+			for i, task := range tasks {
+				suite.Equal(testPodNames[i], task.GetTaskId().GetValue())
+				suite.Nil(task.GetExecutor())
+			}
+		}).
+		Return(nil)
+
+	launched, err := suite.mesosManager.LaunchPodGroup(
+		context.Background(),
+		pods,
+		testHostName,
+		executor,
+	)
+	suite.NoError(err)
+	suite.Equal(len(testPodNames), len(launched))
+	suite.Empty(suite.mesosManager.offerManager.GetOffers(testHostName))
+}
+
+// TestMesosManagerLaunchPodGroupNoExecutor tests launching a pod group
+// without an executor fails
+func (suite *MesosManagerTestSuite) TestMesosManagerLaunchPodGroupNoExecutor() {
+	testPodName := "bca875f5-322a-4439-b0c9-63e3cf9f982e-1-1"
+
+	_, err := suite.mesosManager.LaunchPodGroup(
+		context.Background(),
+		[]*models.LaunchablePod{
+			{
+				PodId: &peloton.PodID{Value: testPodName},
+				Spec:  newTestPelotonPodSpec(testPodName),
+			},
+		},
+		"test_host",
+		nil,
+	)
+	suite.Error(err)
+}
+
 func (suite *MesosManagerTestSuite) TestMesosManagerKillPodSuccess() {
 	podID := "test_pod"
 	streamID := "streamID"
@@ -354,6 +354,15 @@ func Minimum(r1, r2 Resources) (m Resources) {
 	return m
 }
 
+// Maximum returns maximum amount of resources in each type.
+func Maximum(r1, r2 Resources) (m Resources) {
+	m.CPU = math.Max(r1.CPU, r2.CPU)
+	m.Mem = math.Max(r1.Mem, r2.Mem)
+	m.Disk = math.Max(r1.Disk, r2.Disk)
+	m.GPU = math.Max(r1.GPU, r2.GPU)
+	return m
+}
+
 // AtomicResources is a wrapper around `Resources` provide thread safety.
 type AtomicResources struct {
 	sync.RWMutex
@@ -442,6 +442,25 @@ func TestMinimum(t *testing.T) {
 	assert.InDelta(t, 0.0, r1.GPU, _zeroDelta)
 }
 
+func TestMaximum(t *testing.T) {
+	r1 := Maximum(
+		Resources{
+			CPU: 1.0,
+			Mem: 2.0,
+		},
+		Resources{
+			CPU:  2.0,
+			Mem:  2.0,
+			Disk: 2.0,
+		},
+	)
+
+	assert.InDelta(t, 2.0, r1.CPU, _zeroDelta)
+	assert.InDelta(t, 2.0, r1.Mem, _zeroDelta)
+	assert.InDelta(t, 2.0, r1.Disk, _zeroDelta)
+	assert.InDelta(t, 0.0, r1.GPU, _zeroDelta)
+}
+
 func TestNonEmptyFields(t *testing.T) {
 	r1 := Resources{}
 	assert.True(t, r1.Empty())
@@ -751,11 +751,13 @@ func (j *job) Create(
 	jobTypeCopy = j.jobType
 
 	// both config and runtime are created, move the state to INITIALIZED
+	previousState := j.runtime.GetState()
 	j.runtime.State = pbjob.JobState_INITIALIZED
 	if err := j.jobFactory.jobRuntimeOps.Upsert(
 		ctx,
 		j.id,
-		j.runtime); err != nil {
+		j.runtime,
+		previousState); err != nil {
 		j.invalidateCache()
 		return err
 	}
@@ -894,11 +896,13 @@ func (j *job) RollingCreate(
 	jobTypeCopy = j.jobType
 
 	// both config and runtime are created, move the state to PENDING
+	previousState := j.runtime.GetState()
 	j.runtime.State = pbjob.JobState_PENDING
 	if err := j.jobFactory.jobRuntimeOps.Upsert(
 		ctx,
 		j.id,
-		j.runtime); err != nil {
+		j.runtime,
+		previousState); err != nil {
 		j.invalidateCache()
 		return err
 	}
@@ -973,6 +977,7 @@ func (j *job) CompareAndSetRuntime(ctx context.Context, jobRuntime *pbjob.Runtim
 		ctx,
 		j.id,
 		&newRuntime,
+		j.runtime.GetState(),
 	); err != nil {
 		j.invalidateCache()
 		return nil, err
@@ -1151,6 +1156,7 @@ func (j *job) Update(
 	}
 
 	var updatedRuntime *pbjob.RuntimeInfo
+	var previousState pbjob.JobState
 	if jobInfo.GetRuntime() != nil {
 		updatedRuntime, err = j.getUpdatedJobRuntimeCache(ctx, jobInfo.GetRuntime(), req)
 		if err != nil {
@@ -1160,6 +1166,7 @@ func (j *job) Update(
 			return err
 		}
 		if updatedRuntime != nil {
+			previousState = j.runtime.GetState()
 			j.runtime = updatedRuntime
 		}
 	}
@@ -1186,7 +1193,8 @@ func (j *job) Update(
 		}
 
 		if updatedRuntime != nil {
-			err := j.jobFactory.jobRuntimeOps.Upsert(ctx, j.ID(), updatedRuntime)
+			err := j.jobFactory.jobRuntimeOps.Upsert(
+				ctx, j.ID(), updatedRuntime, previousState)
 			if err != nil {
 				j.invalidateCache()
 				return err
@@ -1458,6 +1466,7 @@ func (j *job) createJobRuntime(ctx context.Context, config *pbjob.JobConfig, upd
 		ctx,
 		j.ID(),
 		initialJobRuntime,
+		pbjob.JobState_UNKNOWN,
 	); err != nil {
 		return err
 	}
@@ -2395,7 +2404,8 @@ func (j *job) CreateWorkflow(
 			// make sure user takes the correct action based on update-to-date opaque data.
 			j.runtime.WorkflowVersion++
 			newRuntime := j.mergeRuntime(&pbjob.RuntimeInfo{WorkflowVersion: j.runtime.GetWorkflowVersion()})
-			if err := j.jobFactory.jobRuntimeOps.Upsert(ctx, j.id, newRuntime); err != nil {
+			if err := j.jobFactory.jobRuntimeOps.Upsert(
+				ctx, j.id, newRuntime, j.runtime.GetState()); err != nil {
 				j.invalidateCache()
 				return currentUpdate.GetUpdateID(),
 					nil,
@@ -3109,6 +3119,7 @@ func (j *job) updateJobRuntime(
 		ctx,
 		j.id,
 		runtime,
+		j.runtime.GetState(),
 	); err != nil {
 		j.invalidateCache()
 		return err
@@ -3388,7 +3399,8 @@ func (j *job) updateWorkflowVersion(
 	runtimeDiff := &pbjob.RuntimeInfo{WorkflowVersion: workflowVersion + 1}
 	newRuntime := j.mergeRuntime(runtimeDiff)
 
-	if err := j.jobFactory.jobRuntimeOps.Upsert(ctx, j.id, newRuntime); err != nil {
+	if err := j.jobFactory.jobRuntimeOps.Upsert(
+		ctx, j.id, newRuntime, j.runtime.GetState()); err != nil {
 		j.runtime = nil
 		return err
 	}
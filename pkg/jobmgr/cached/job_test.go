@@ -411,8 +411,8 @@ func (suite *jobTestSuite) TestJobDBError() {
 		}, nil)
 	// Test updating job runtime in DB and cache
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.State)
 			suite.Equal(runtime.GoalState, jobRuntime.GoalState)
 		}).
@@ -435,7 +435,7 @@ func (suite *jobTestSuite) TestJobDBError() {
 
 	// Test error in DB while update job runtime
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
 		Return(dbError)
 	jobRuntime.State = pbjob.JobState_SUCCEEDED
 	err = suite.job.Update(
@@ -465,8 +465,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimeWithNoCache() {
 		}, nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.State)
 			suite.Equal(runtime.GoalState, jobRuntime.GoalState)
 		}).
@@ -532,8 +532,8 @@ func (suite *jobTestSuite) TestJobCompareAndSetRuntimeWithCache() {
 	suite.Equal(suite.job.runtime.GoalState, jobRuntime.GetGoalState())
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.GetState())
 			suite.Equal(runtime.GoalState, jobRuntime.GetGoalState())
 		}).
@@ -576,8 +576,8 @@ func (suite *jobTestSuite) TestJobUpdateCompareAndSetRuntimeMixedUsage() {
 	}
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntimeUpdate.State)
 		}).
 		Return(nil)
@@ -656,8 +656,8 @@ func (suite *jobTestSuite) TestJobCompareAndSetRuntimeNoCache() {
 		}, nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.State)
 			suite.Equal(runtime.GoalState, jobRuntime.GoalState)
 		}).
@@ -705,8 +705,8 @@ func (suite *jobTestSuite) TestJobCompareAndSetRuntimeUpdateRuntimeFailure() {
 		}, nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.State)
 			suite.Equal(runtime.GoalState, jobRuntime.GoalState)
 		}).
@@ -789,7 +789,7 @@ func (suite *jobTestSuite) TestJobUpdateConfig() {
 		Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
 		Return(nil)
 	suite.jobIndexOps.EXPECT().
 		Update(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
@@ -911,8 +911,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimeAndConfig() {
 		Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, jobRuntime.State)
 			suite.Equal(runtime.GoalState, jobRuntime.GoalState)
 		}).
@@ -1204,8 +1204,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimeAndRecoverRuntime() {
 		Get(context.Background(), suite.jobID).
 		Return(&initialRuntime, nil)
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, pbjob.JobState_SUCCEEDED)
 			suite.Equal(runtime.ConfigurationVersion, uint64(1))
 			suite.Equal(runtime.Revision.Version, uint64(2))
@@ -1272,8 +1272,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimeAndRecoverConfig() {
 		Get(context.Background(), suite.jobID).
 		Return(&initialRuntime, nil)
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, pbjob.JobState_SUCCEEDED)
 			suite.Equal(runtime.ConfigurationVersion, uint64(1))
 			suite.Equal(runtime.Revision.Version, uint64(2))
@@ -1340,8 +1340,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimeAndRecoverConfigPlusRuntime() {
 		Get(context.Background(), suite.jobID).
 		Return(&initialRuntime, nil)
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, pbjob.JobState_SUCCEEDED)
 			suite.Equal(runtime.ConfigurationVersion, uint64(1))
 			suite.Equal(runtime.Revision.Version, uint64(2))
@@ -1415,8 +1415,8 @@ func (suite *jobTestSuite) TestJobUpdateRuntimePlusConfigAndRecover() {
 		GetMaxJobConfigVersion(gomock.Any(), suite.jobID.GetValue()).
 		Return(initialConfig.ChangeLog.Version, nil)
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.State, pbjob.JobState_SUCCEEDED)
 		}).
 		Return(nil)
@@ -1557,8 +1557,8 @@ func (suite *jobTestSuite) TestJobCreate() {
 		Create(gomock.Any(), suite.jobID).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(initialRuntime.State, pbjob.JobState_UNINITIALIZED)
 			suite.Equal(initialRuntime.GoalState, pbjob.JobState_SUCCEEDED)
 			suite.Equal(initialRuntime.Revision.Version, uint64(1))
@@ -1569,8 +1569,8 @@ func (suite *jobTestSuite) TestJobCreate() {
 		Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.GetState(), pbjob.JobState_INITIALIZED)
 		}).Return(nil)
 
@@ -3258,7 +3258,7 @@ func (suite *jobTestSuite) TestJobCreateWorkflowWithDifferentOpaqueDateSuccess()
 		Return(updateModel, nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
 		Return(nil)
 
 	suite.updateStore.EXPECT().
@@ -3388,7 +3388,7 @@ func (suite *jobTestSuite) TestJobCreateWorkflowSuccess() {
 			Return(nil),
 
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.jobID, gomock.Any()).
+			Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
 			Return(nil),
 		suite.jobIndexOps.EXPECT().
 			Update(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
@@ -3516,8 +3516,8 @@ func (suite *jobTestSuite) TestJobCreateWorkflowWithStartTasksForStoppedJobSucce
 			Return(nil),
 
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetGoalState(), pbjob.JobState_RUNNING)
 			}).
 			Return(nil),
@@ -3821,7 +3821,7 @@ func (suite *jobTestSuite) TestJobCreateWorkflowUpdateRuntimeFailure() {
 			Return(nil),
 
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.jobID, gomock.Any()).
+			Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
 			Return(yarpcerrors.InternalErrorf("test error")),
 	)
 
@@ -4019,8 +4019,8 @@ func (suite *jobTestSuite) TestResumeWorkflowSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4112,8 +4112,8 @@ func (suite *jobTestSuite) TestResumeWorkflowNotExistInCacheSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4184,8 +4184,8 @@ func (suite *jobTestSuite) TestPauseWorkflowSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4275,8 +4275,8 @@ func (suite *jobTestSuite) TestPauseWorkflowNotExistInCacheSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4346,8 +4346,8 @@ func (suite *jobTestSuite) TestAbortWorkflowSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4442,8 +4442,8 @@ func (suite *jobTestSuite) TestAbortWorkflowNotExistInCacheSuccess() {
 
 	gomock.InOrder(
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.job.ID(), gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.job.ID(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.GetConfigurationVersion(), oldConfigVersion)
 				suite.Equal(runtime.GetWorkflowVersion(), oldWorkflowVersion+1)
 			}).Return(nil),
@@ -4690,8 +4690,8 @@ func (suite *jobTestSuite) TestRollbackWorkflowSuccess() {
 		}).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.ConfigurationVersion, jobVersion+1)
 		}).
 		Return(nil)
@@ -5200,8 +5200,8 @@ func (suite *jobTestSuite) TestRollbackWorkflowSuccessAfterModifyUpdateFails() {
 			}).Return(nil),
 
 		suite.jobRuntimeOps.EXPECT().
-			Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+			Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 				suite.Equal(runtime.ConfigurationVersion, newMaxJobVersion+1)
 			}).
 			Return(nil),
@@ -5344,8 +5344,8 @@ func (suite *jobTestSuite) TestRollbackWorkflowSuccessAfterJobRuntimeUpdateDBWri
 		}).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.ConfigurationVersion, jobVersion+1)
 		}).
 		Return(yarpcerrors.InternalErrorf("test error"))
@@ -5362,8 +5362,8 @@ func (suite *jobTestSuite) TestRollbackWorkflowSuccessAfterJobRuntimeUpdateDBWri
 		}, nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.ConfigurationVersion, newMaxJobVersion)
 		}).
 		Return(nil)
@@ -5505,8 +5505,8 @@ func (suite *jobTestSuite) TestRollbackWorkflowSuccessAfterJobRuntimeDBWriteSucc
 		}).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.ConfigurationVersion, jobVersion+1)
 		}).
 		Return(yarpcerrors.InternalErrorf("test error"))
@@ -5881,8 +5881,8 @@ func (suite *jobTestSuite) TestJobRollingCreateSuccess() {
 		Create(gomock.Any(), suite.jobID).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(initialRuntime.State, pbjob.JobState_UNINITIALIZED)
 			suite.Equal(initialRuntime.GoalState, pbjob.JobState_RUNNING)
 			suite.Equal(initialRuntime.Revision.Version, uint64(1))
@@ -5970,8 +5970,8 @@ func (suite *jobTestSuite) TestJobRollingCreateSuccess() {
 		Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, runtime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(runtime.GetState(), pbjob.JobState_PENDING)
 		}).Return(nil)
 	suite.jobIndexOps.EXPECT().
@@ -6108,8 +6108,8 @@ func (suite *jobTestSuite) TestJobRollingCreateJobRuntimeFailure() {
 		Create(gomock.Any(), suite.jobID).Return(nil)
 
 	suite.jobRuntimeOps.EXPECT().
-		Upsert(gomock.Any(), suite.jobID, gomock.Any()).
-		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo) {
+		Upsert(gomock.Any(), suite.jobID, gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *peloton.JobID, initialRuntime *pbjob.RuntimeInfo, _ pbjob.JobState) {
 			suite.Equal(initialRuntime.State, pbjob.JobState_UNINITIALIZED)
 			suite.Equal(initialRuntime.GoalState, pbjob.JobState_RUNNING)
 			suite.Equal(initialRuntime.Revision.Version, uint64(1))
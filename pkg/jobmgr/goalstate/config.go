@@ -28,6 +28,7 @@ const (
 	_defaultJobRuntimeUpdateInterval = 1 * time.Second
 	_defaultInitialTaskBackoff       = 30 * time.Second
 	_defaultMaxTaskBackoff           = 60 * time.Minute
+	_defaultTaskActionRetryBackoff   = 100 * time.Millisecond
 
 	// Job worker threads should be small because job create and job kill
 	// actions create 1000 parallel threads to update the DB, and if too
@@ -92,6 +93,17 @@ type Config struct {
 	// Default to 1h.
 	MaxTaskBackoff time.Duration `yaml:"max_task_backoff"`
 
+	// TaskActionMaxRetries is the number of times a task action failing
+	// with a transient error, such as Mesos being busy, is retried within
+	// the same goal state cycle before the task is rescheduled with
+	// FailureRetryDelay. Default to 0, which disables in-cycle retries.
+	TaskActionMaxRetries int `yaml:"task_action_max_retries"`
+
+	// TaskActionRetryBackoff is the delay before the first in-cycle retry
+	// of a task action, doubled on every subsequent retry. The retries are
+	// bounded by the timeout of the task action. Default to 100ms.
+	TaskActionRetryBackoff time.Duration `yaml:"task_action_retry_backoff"`
+
 	// RateLimiterConfig defines rate limiter config
 	RateLimiterConfig RateLimiterConfig `yaml:"rate_limit"`
 }
@@ -155,6 +167,10 @@ func (c *Config) normalize() {
 		c.MaxTaskBackoff = _defaultMaxTaskBackoff
 	}
 
+	if c.TaskActionRetryBackoff == 0 {
+		c.TaskActionRetryBackoff = _defaultTaskActionRetryBackoff
+	}
+
 	if c.RateLimiterConfig.TaskKill.Rate <= 0 || c.RateLimiterConfig.TaskKill.Burst <= 0 {
 		c.RateLimiterConfig.TaskKill.Rate = rate.Inf
 	}
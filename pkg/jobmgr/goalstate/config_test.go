@@ -34,4 +34,6 @@ func TestConfigNormalize(t *testing.T) {
 	assert.Equal(t, _defaultJobWorkerThreads, c.NumWorkerJobThreads)
 	assert.Equal(t, _defaultTaskWorkerThreads, c.NumWorkerTaskThreads)
 	assert.Equal(t, _defaultUpdateWorkerThreads, c.NumWorkerUpdateThreads)
+	assert.Equal(t, 0, c.TaskActionMaxRetries)
+	assert.Equal(t, _defaultTaskActionRetryBackoff, c.TaskActionRetryBackoff)
 }
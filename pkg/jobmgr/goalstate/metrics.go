@@ -49,6 +49,7 @@ type TaskMetrics struct {
 	RetryFailedLaunchTotal tally.Counter
 	RetryFailedTasksTotal  tally.Counter
 	RetryLostTasksTotal    tally.Counter
+	TaskActionRetry        tally.Counter
 }
 
 // UpdateMetrics contains all counters to track
@@ -108,6 +109,7 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		RetryFailedLaunchTotal: taskScope.Counter("retry_system_failure_total"),
 		RetryFailedTasksTotal:  taskScope.Counter("retry_failed_total"),
 		RetryLostTasksTotal:    taskScope.Counter("retry_lost_total"),
+		TaskActionRetry:        taskScope.Counter("action_retry"),
 	}
 
 	updateMetrics := &UpdateMetrics{
@@ -243,7 +243,7 @@ func (t *taskEntity) GetActionList(
 	if action != nil {
 		actions = append(actions, goalstate.Action{
 			Name:    string(actionStr),
-			Execute: action,
+			Execute: retryTransientErrors(action),
 		})
 	}
 
@@ -0,0 +1,62 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goalstate
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/goalstate"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// retryTransientErrors wraps a task action so that it is retried within the
+// same goal state cycle when it fails with a transient error, for example
+// when Mesos is busy, instead of waiting for the task to be rescheduled.
+// Other errors, and transient errors left once the retries are exhausted,
+// are returned to the goal state engine which reschedules the task.
+func retryTransientErrors(action goalstate.ActionExecute) goalstate.ActionExecute {
+	return func(ctx context.Context, entity goalstate.Entity) error {
+		err := action(ctx, entity)
+		if err == nil || !common.IsTransientError(err) {
+			return err
+		}
+
+		goalStateDriver := entity.(*taskEntity).driver
+		backoff := goalStateDriver.cfg.TaskActionRetryBackoff
+		for retry := 1; retry <= goalStateDriver.cfg.TaskActionMaxRetries; retry++ {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+
+			log.WithError(err).
+				WithField("task_id", entity.GetID()).
+				WithField("retry", retry).
+				Info("retrying task action after transient error")
+			goalStateDriver.mtx.taskMetrics.TaskActionRetry.Inc(1)
+
+			err = action(ctx, entity)
+			if err == nil || !common.IsTransientError(err) {
+				return err
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goalstate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/pkg/common/goalstate"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// newTestTaskEntityWithRetries returns a task entity whose goal state driver
+// retries transient task action errors up to maxRetries times.
+func newTestTaskEntityWithRetries(
+	maxRetries int,
+	scope tally.Scope,
+) goalstate.Entity {
+	goalStateDriver := &driver{
+		mtx: NewMetrics(scope),
+		cfg: &Config{
+			TaskActionMaxRetries:   maxRetries,
+			TaskActionRetryBackoff: time.Millisecond,
+		},
+	}
+	goalStateDriver.cfg.normalize()
+
+	return &taskEntity{
+		jobID:      &peloton.JobID{Value: uuid.NewRandom().String()},
+		instanceID: 0,
+		driver:     goalStateDriver,
+	}
+}
+
+// failingAction returns an action which fails with the given errors, one
+// per call, and succeeds once they are exhausted.
+func failingAction(calls *int, errs ...error) goalstate.ActionExecute {
+	return func(ctx context.Context, entity goalstate.Entity) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+// TestRetryTransientErrorsSucceedsWithinCycle tests that an action failing
+// transiently is retried within the cycle until it succeeds.
+func TestRetryTransientErrorsSucceedsWithinCycle(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	entity := newTestTaskEntityWithRetries(3, scope)
+
+	var calls int
+	action := retryTransientErrors(failingAction(
+		&calls,
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+	))
+
+	assert.NoError(t, action(context.Background(), entity))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(2),
+		scope.Snapshot().Counters()["task.action_retry+"].Value())
+}
+
+// TestRetryTransientErrorsExhausted tests that the transient error is
+// returned, so that the task is rescheduled, once the retries are exhausted.
+func TestRetryTransientErrorsExhausted(t *testing.T) {
+	entity := newTestTaskEntityWithRetries(2, tally.NoopScope)
+
+	var calls int
+	action := retryTransientErrors(failingAction(
+		&calls,
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+	))
+
+	err := action(context.Background(), entity)
+	assert.True(t, yarpcerrors.IsUnavailable(err))
+	assert.Equal(t, 3, calls)
+}
+
+// TestRetryTransientErrorsNotRetried tests that actions are not retried
+// within the cycle on non-transient errors, or when retries are disabled.
+func TestRetryTransientErrorsNotRetried(t *testing.T) {
+	entity := newTestTaskEntityWithRetries(3, tally.NoopScope)
+
+	var calls int
+	action := retryTransientErrors(failingAction(
+		&calls,
+		errors.New("launch failed"),
+	))
+	assert.Error(t, action(context.Background(), entity))
+	assert.Equal(t, 1, calls)
+
+	entity = newTestTaskEntityWithRetries(0, tally.NoopScope)
+	calls = 0
+	action = retryTransientErrors(failingAction(
+		&calls,
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+	))
+	assert.Error(t, action(context.Background(), entity))
+	assert.Equal(t, 1, calls)
+}
+
+// TestRetryTransientErrorsContextDone tests that the retries stop once the
+// context of the action is done.
+func TestRetryTransientErrorsContextDone(t *testing.T) {
+	entity := newTestTaskEntityWithRetries(3, tally.NoopScope)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int
+	action := retryTransientErrors(failingAction(
+		&calls,
+		yarpcerrors.UnavailableErrorf("mesos busy"),
+	))
+	assert.Error(t, action(ctx, entity))
+	assert.Equal(t, 1, calls)
+}
@@ -465,6 +465,20 @@ func (h *serviceHandler) Get(
 		}, nil
 	}
 
+	// A kill reason is only recorded when the goal state of the job is set
+	// to KILLED, and it is informational, so failing to read it does not
+	// fail the request.
+	if jobRuntime.GetGoalState() == job.JobState_KILLED {
+		killReason, err := h.jobRuntimeOps.GetKillReason(ctx, req.GetId())
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", req.Id.Value).
+				Warn("failed to get job kill reason")
+		} else if killReason != nil {
+			jobRuntime.KillReason = killReason.Reason
+		}
+	}
+
 	jobConfig, _, err := h.jobConfigOps.Get(
 		ctx,
 		req.GetId(),
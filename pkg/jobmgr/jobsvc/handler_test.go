@@ -44,6 +44,7 @@ import (
 	goalstatemocks "github.com/uber/peloton/pkg/jobmgr/goalstate/mocks"
 	jobmgrtask "github.com/uber/peloton/pkg/jobmgr/task"
 	storemocks "github.com/uber/peloton/pkg/storage/mocks"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
 
 	"github.com/golang/mock/gomock"
@@ -1236,6 +1237,7 @@ func (suite *JobHandlerTestSuite) TestGetJob() {
 	suite.NoError(err)
 	suite.NotNil(resp)
 	suite.Equal(0, len(resp.GetSecrets()))
+	suite.Empty(resp.GetJobInfo().GetRuntime().GetKillReason())
 
 	secretID := &peloton.SecretID{
 		Value: uuid.New(),
@@ -1304,6 +1306,50 @@ func (suite *JobHandlerTestSuite) TestGetJobFailure() {
 	suite.Equal(expectedErr, resp.GetError())
 }
 
+// TestGetKilledJob tests that Job Get API returns the kill reason of a job
+// being killed, and still succeeds if the kill reason cannot be read
+func (suite *JobHandlerTestSuite) TestGetKilledJob() {
+	jobID := &peloton.JobID{
+		Value: uuid.New(),
+	}
+	jobRuntime := &job.RuntimeInfo{
+		State:     job.JobState_KILLING,
+		GoalState: job.JobState_KILLED,
+	}
+
+	suite.mockedJobFactory.EXPECT().GetJob(jobID).
+		Return(suite.mockedCachedJob).Times(2)
+	suite.mockedCachedJob.EXPECT().GetRuntime(gomock.Any()).
+		Return(jobRuntime, nil).Times(2)
+	suite.mockedJobConfigOps.EXPECT().
+		Get(gomock.Any(), jobID, gomock.Any()).
+		Return(&job.JobConfig{}, &models.ConfigAddOn{}, nil).Times(2)
+
+	suite.mockedJobRuntimeOps.EXPECT().
+		GetKillReason(gomock.Any(), jobID).
+		Return(&ormobjects.JobKill{
+			Reason: ormobjects.JobKillReasonUserRequest,
+			Time:   time.Now(),
+		}, nil)
+	resp, err := suite.handler.Get(suite.context, &job.GetRequest{Id: jobID})
+	suite.NoError(err)
+	suite.Nil(resp.GetError())
+	suite.Equal(
+		ormobjects.JobKillReasonUserRequest,
+		resp.GetJobInfo().GetRuntime().GetKillReason(),
+	)
+
+	// simulate GetKillReason failure
+	jobRuntime.KillReason = ""
+	suite.mockedJobRuntimeOps.EXPECT().
+		GetKillReason(gomock.Any(), jobID).
+		Return(nil, errors.New("kill reason error"))
+	resp, err = suite.handler.Get(suite.context, &job.GetRequest{Id: jobID})
+	suite.NoError(err)
+	suite.Nil(resp.GetError())
+	suite.Empty(resp.GetJobInfo().GetRuntime().GetKillReason())
+}
+
 // TestUpdateJobWithSecrets tests different success/failure scenarios
 // for Job Update API for jobs that have secrets
 func (suite *JobHandlerTestSuite) TestUpdateJobWithSecrets() {
@@ -811,6 +811,18 @@ func (h *serviceHandler) StopJob(
 			return nil, errors.Wrap(err, "fail to update job runtime")
 		}
 
+		// The kill is already under way, so failing to record its reason
+		// does not fail the request.
+		if err := h.jobRuntimeOps.SetKillReason(
+			ctx,
+			cachedJob.ID(),
+			ormobjects.JobKillReasonUserRequest,
+		); err != nil {
+			log.WithError(err).
+				WithField("job_id", cachedJob.ID().GetValue()).
+				Warn("failed to record job kill reason")
+		}
+
 		h.goalStateDriver.EnqueueJob(cachedJob.ID(), time.Now())
 		return &svc.StopJobResponse{
 			Version: versionutil.GetJobEntityVersion(
@@ -993,8 +1005,29 @@ func (h *serviceHandler) GetJob(
 	var jobConfig *pbjob.JobConfig
 	var updateInfo *models.UpdateModel
 	var workflowEvents []*stateless.WorkflowEvent
+	var killReason *ormobjects.JobKill
 	errs := make(chan error)
 
+	// A kill reason is only recorded when the goal state of the job is set
+	// to KILLED, and it is informational, so failing to read it does not
+	// fail the request.
+	if jobRuntime.GetGoalState() == pbjob.JobState_KILLED {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var er error
+			if killReason, er = h.jobRuntimeOps.GetKillReason(
+				ctx,
+				pelotonJobID,
+			); er != nil {
+				log.WithError(er).
+					WithField("job_id", pelotonJobID.GetValue()).
+					Warn("failed to get job kill reason")
+			}
+		}()
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -1057,6 +1090,10 @@ func (h *serviceHandler) GetJob(
 		return nil, err
 	}
 
+	if killReason != nil {
+		jobRuntime.KillReason = killReason.Reason
+	}
+
 	return &svc.GetJobResponse{
 		JobInfo: &stateless.JobInfo{
 			JobId:  req.GetJobId(),
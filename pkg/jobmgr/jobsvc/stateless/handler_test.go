@@ -319,8 +319,16 @@ func (suite *statelessHandlerTestSuite) TestGetJobSuccess() {
 			testPelotonJobID,
 		).
 		Return(&pbjob.RuntimeInfo{
-			State:    pbjob.JobState_RUNNING,
-			UpdateID: &peloton.UpdateID{Value: testUpdateID},
+			State:     pbjob.JobState_RUNNING,
+			GoalState: pbjob.JobState_KILLED,
+			UpdateID:  &peloton.UpdateID{Value: testUpdateID},
+		}, nil)
+
+	suite.jobRuntimeOps.EXPECT().
+		GetKillReason(gomock.Any(), testPelotonJobID).
+		Return(&ormobjects.JobKill{
+			Reason: ormobjects.JobKillReasonUserRequest,
+			Time:   time.Now(),
 		}, nil)
 
 	suite.jobConfigOps.EXPECT().
@@ -371,6 +379,10 @@ func (suite *statelessHandlerTestSuite) TestGetJobSuccess() {
 		resp.GetJobInfo().GetStatus().GetWorkflowStatus().GetState(),
 		stateless.WorkflowState_WORKFLOW_STATE_ROLLING_FORWARD,
 	)
+	suite.Equal(
+		ormobjects.JobKillReasonUserRequest,
+		resp.GetJobInfo().GetStatus().GetKillReason(),
+	)
 	suite.Equal(uint32(0), resp.GetWorkflowInfo().GetInstancesUpdated()[0].GetFrom())
 	suite.Equal(uint32(2), resp.GetWorkflowInfo().GetInstancesUpdated()[0].GetTo())
 	suite.Equal(uint32(3), resp.GetWorkflowInfo().GetInstancesAdded()[0].GetFrom())
@@ -405,6 +417,40 @@ func (suite *statelessHandlerTestSuite) TestGetJobConfigGetError() {
 	suite.Nil(resp)
 }
 
+// TestGetJobKillReasonGetError tests that GetJob API succeeds without
+// the kill reason when the kill reason cannot be read
+func (suite *statelessHandlerTestSuite) TestGetJobKillReasonGetError() {
+	suite.jobRuntimeOps.EXPECT().
+		Get(
+			gomock.Any(),
+			testPelotonJobID,
+		).
+		Return(&pbjob.RuntimeInfo{
+			State:     pbjob.JobState_KILLING,
+			GoalState: pbjob.JobState_KILLED,
+		}, nil)
+
+	suite.jobRuntimeOps.EXPECT().
+		GetKillReason(gomock.Any(), testPelotonJobID).
+		Return(nil, errors.New("fake DB error"))
+
+	suite.jobConfigOps.EXPECT().
+		Get(
+			gomock.Any(),
+			testPelotonJobID,
+			gomock.Any(),
+		).
+		Return(&pbjob.JobConfig{}, &models.ConfigAddOn{}, nil)
+
+	resp, err := suite.handler.GetJob(context.Background(),
+		&statelesssvc.GetJobRequest{
+			JobId: &v1alphapeloton.JobID{Value: testJobID},
+		})
+
+	suite.NoError(err)
+	suite.Empty(resp.GetJobInfo().GetStatus().GetKillReason())
+}
+
 // TestGetJobRuntimeGetError tests invoking GetJob API to get job
 // configuration, runtime and workflow information with DB error
 // when trying to fetch job runtime
@@ -3041,6 +3087,13 @@ func (suite *statelessHandlerTestSuite) TestStopJobSuccess() {
 		WorkflowVersion:      testWorkflowVersion,
 	}, nil)
 
+	suite.jobRuntimeOps.EXPECT().
+		SetKillReason(
+			gomock.Any(),
+			&peloton.JobID{Value: testJobID},
+			ormobjects.JobKillReasonUserRequest).
+		Return(nil)
+
 	suite.goalStateDriver.EXPECT().
 		EnqueueJob(&peloton.JobID{Value: testJobID}, gomock.Any())
 
@@ -3166,6 +3219,14 @@ func (suite *statelessHandlerTestSuite) TestStopJobSuccessWithCompareAndSetRunti
 		WorkflowVersion:      testWorkflowVersion,
 	}, nil)
 
+	// failing to record the kill reason does not fail the request
+	suite.jobRuntimeOps.EXPECT().
+		SetKillReason(
+			gomock.Any(),
+			&peloton.JobID{Value: testJobID},
+			ormobjects.JobKillReasonUserRequest).
+		Return(errors.New("random error"))
+
 	suite.goalStateDriver.EXPECT().
 		EnqueueJob(&peloton.JobID{Value: testJobID}, gomock.Any())
 
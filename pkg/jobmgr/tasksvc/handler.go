@@ -768,6 +768,18 @@ func (m *serviceHandler) stopJob(
 		break
 	}
 
+	// The kill is already under way, so failing to record its reason
+	// does not fail the request.
+	if err := m.jobRuntimeOps.SetKillReason(
+		ctx,
+		jobID,
+		ormobjects.JobKillReasonUserRequest,
+	); err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			Warn("failed to record job kill reason")
+	}
+
 	m.goalStateDriver.EnqueueJob(jobID, time.Now())
 
 	m.metrics.TaskStop.Inc(int64(instanceCount))
@@ -39,6 +39,7 @@ import (
 	logmanagermocks "github.com/uber/peloton/pkg/jobmgr/logmanager/mocks"
 	activermtaskmocks "github.com/uber/peloton/pkg/jobmgr/task/activermtask/mocks"
 	storemocks "github.com/uber/peloton/pkg/storage/mocks"
+	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
 
 	"github.com/uber/peloton/pkg/common/util"
@@ -78,6 +79,7 @@ type TaskHandlerTestSuite struct {
 	mockedCachedTask     *cachedmocks.MockTask
 	mockedGoalStateDrive *goalstatemocks.MockDriver
 	jobConfigOps         *objectmocks.MockJobConfigOps
+	jobRuntimeOps        *objectmocks.MockJobRuntimeOps
 
 	mockedTaskStore          *storemocks.MockTaskStore
 	mockedUpdateStore        *storemocks.MockUpdateStore
@@ -117,6 +119,7 @@ func (suite *TaskHandlerTestSuite) SetupTest() {
 
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.jobConfigOps = objectmocks.NewMockJobConfigOps(suite.ctrl)
+	suite.jobRuntimeOps = objectmocks.NewMockJobRuntimeOps(suite.ctrl)
 	suite.mockedJobFactory = cachedmocks.NewMockJobFactory(suite.ctrl)
 	suite.mockedCachedJob = cachedmocks.NewMockJob(suite.ctrl)
 	suite.mockedCachedTask = cachedmocks.NewMockTask(suite.ctrl)
@@ -133,6 +136,7 @@ func (suite *TaskHandlerTestSuite) SetupTest() {
 	suite.mockedPodEventsOps = objectmocks.NewMockPodEventsOps(suite.ctrl)
 
 	suite.handler.jobConfigOps = suite.jobConfigOps
+	suite.handler.jobRuntimeOps = suite.jobRuntimeOps
 	suite.handler.taskStore = suite.mockedTaskStore
 	suite.handler.updateStore = suite.mockedUpdateStore
 	suite.handler.podEventsOps = suite.mockedPodEventsOps
@@ -363,6 +367,12 @@ func (suite *TaskHandlerTestSuite) TestStopAllTasks() {
 		suite.mockedCachedJob.EXPECT().
 			CompareAndSetRuntime(gomock.Any(), expectedJobRuntime).
 			Return(expectedJobRuntime, nil),
+		suite.jobRuntimeOps.EXPECT().
+			SetKillReason(
+				gomock.Any(),
+				suite.testJobID,
+				ormobjects.JobKillReasonUserRequest).
+			Return(nil),
 		suite.mockedGoalStateDrive.EXPECT().
 			EnqueueJob(suite.testJobID, gomock.Any()).Return(),
 	)
@@ -29,7 +29,8 @@ import (
 func PlacementNeedsToHostFilter(needs plugins.PlacementNeeds) *hostmgr.HostFilter {
 	filter := &hostmgr.HostFilter{
 		ResourceConstraint: &hostmgr.ResourceConstraint{
-			NumPorts: uint32(needs.Ports),
+			NumPorts:  uint32(needs.Ports),
+			Revocable: needs.Revocable,
 			Minimum: &pod.ResourceSpec{
 				CpuLimit:    needs.Resources.CPU,
 				MemLimitMb:  needs.Resources.Mem,
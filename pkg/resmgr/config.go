@@ -45,6 +45,10 @@ type Config struct {
 	// Config for task preemption
 	PreemptionConfig *common.PreemptionConfig `yaml:"preemption"`
 
+	// Number of failed admission attempts after which a gang exceeding
+	// the limit of its resource pool is dead-lettered, disabled if zero.
+	DeadLetterThreshold int `yaml:"dead_letter_threshold"`
+
 	// Period to run host drainer
 	HostDrainerPeriod time.Duration `yaml:"host_drainer_period"`
 
@@ -0,0 +1,39 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"github.com/uber-go/tally"
+)
+
+// Metrics tracks the gangs which went through a queue over its lifetime.
+type Metrics struct {
+	// Number of gangs enqueued.
+	Enqueued tally.Counter
+	// Number of gangs dequeued.
+	Dequeued tally.Counter
+	// Number of gangs which could not be enqueued.
+	Rejected tally.Counter
+}
+
+// NewMetrics returns a new Metrics struct, with all metrics initialized
+// and rooted at the given tally.Scope
+func NewMetrics(scope tally.Scope) *Metrics {
+	return &Metrics{
+		Enqueued: scope.Counter("enqueued"),
+		Dequeued: scope.Counter("dequeued"),
+		Rejected: scope.Counter("rejected"),
+	}
+}
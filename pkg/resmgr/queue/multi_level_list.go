@@ -78,6 +78,10 @@ type MultiLevelList interface {
 	Size() int
 	// GetHighestLevel returns the highest level from the list
 	GetHighestLevel() int
+	// SetLimit changes the max size of the list. A negative limit removes
+	// the size bound. It returns an error if the list holds more items than
+	// the new limit.
+	SetLimit(limit int64) error
 }
 
 // multiLevelList struct is implementing MultiLevelList
@@ -338,6 +342,23 @@ func (p *multiLevelList) Size() int {
 	return p.size()
 }
 
+// SetLimit changes the max size of the list. A negative limit removes
+// the size bound. It returns an error if the list holds more items than
+// the new limit.
+func (p *multiLevelList) SetLimit(limit int64) error {
+	p.Lock()
+	defer p.Unlock()
+
+	size := int64(p.size())
+	if limit >= 0 && limit < size {
+		return fmt.Errorf(
+			"list size %d exceeds new limit %d, %d items need to be dropped",
+			size, limit, size-limit)
+	}
+	p.limit = limit
+	return nil
+}
+
 // calculateHighestLevel returns highest level in the multilevel list
 func (p *multiLevelList) calculateHighestLevel() int {
 	// TODO: we also can use heap for index to scan it Which can help getting the highest level in in O(1)
@@ -17,23 +17,36 @@ package queue
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
 
+	"github.com/gogo/protobuf/proto"
 	log "github.com/sirupsen/logrus"
+	"github.com/uber-go/tally"
 )
 
 // PriorityQueue is FIFO queue which remove the highest priority task item entered first in the queue
 type PriorityQueue struct {
 	sync.RWMutex
 	list MultiLevelList
+
+	// Number of gangs enqueued, dequeued and rejected over the lifetime
+	// of the queue.
+	enqueued uint64
+	dequeued uint64
+	rejected uint64
+
+	metrics *Metrics
 }
 
 // NewPriorityQueue intializes the fifo queue and returns the pointer
-func NewPriorityQueue(limit int64) *PriorityQueue {
+func NewPriorityQueue(limit int64, scope tally.Scope) *PriorityQueue {
 	fq := PriorityQueue{
-		list: NewMultiLevelList("list", limit),
+		list:    NewMultiLevelList("list", limit),
+		metrics: NewMetrics(scope),
 	}
 	return &fq
 }
@@ -44,12 +57,26 @@ func (f *PriorityQueue) Enqueue(gang *resmgrsvc.Gang) error {
 	defer f.Unlock()
 
 	if (gang == nil) || (len(gang.Tasks) == 0) {
+		f.reject()
 		return errors.New("enqueue of empty list")
 	}
 
 	tasks := gang.GetTasks()
 	priority := tasks[0].Priority
-	return f.list.Push(int(priority), gang)
+	if err := f.list.Push(int(priority), gang); err != nil {
+		f.reject()
+		return err
+	}
+
+	f.enqueued++
+	f.metrics.Enqueued.Inc(1)
+	return nil
+}
+
+// reject records a gang which could not be enqueued.
+func (f *PriorityQueue) reject() {
+	f.rejected++
+	f.metrics.Rejected.Inc(1)
 }
 
 // Dequeue dequeues the gang (task list gang) based on the priority and order
@@ -79,6 +106,8 @@ func (f *PriorityQueue) Dequeue() (*resmgrsvc.Gang, error) {
 	}
 
 	res := item.(*resmgrsvc.Gang)
+	f.dequeued++
+	f.metrics.Dequeued.Inc(1)
 	return res, nil
 }
 
@@ -86,6 +115,16 @@ func (f *PriorityQueue) Dequeue() (*resmgrsvc.Gang, error) {
 // they came into the queue.
 // It will return an `ErrorQueueEmpty` if there is no gangs in the queue
 func (f *PriorityQueue) Peek(limit uint32) ([]*resmgrsvc.Gang, error) {
+	return f.PeekWithMinPriority(limit, 0)
+}
+
+// PeekWithMinPriority peeks the limit number of gangs with priority
+// greater than or equal to minPriority based on the priority and order
+// they came into the queue.
+// It will return an `ErrorQueueEmpty` if there is no such gang in the queue
+func (f *PriorityQueue) PeekWithMinPriority(
+	limit uint32,
+	minPriority uint32) ([]*resmgrsvc.Gang, error) {
 	// TODO: optimize the write lock here with potential read lock
 	f.Lock()
 	defer f.Unlock()
@@ -95,10 +134,10 @@ func (f *PriorityQueue) Peek(limit uint32) ([]*resmgrsvc.Gang, error) {
 	itemsLeft := int(limit)
 
 	// start at the highest priority
-	// keep going down until priority 0 or until limit is satisfied
+	// keep going down until minPriority or until limit is satisfied
 	for {
-		if priority < 0 {
-			// min priority is 0; we are done
+		if priority < int(minPriority) {
+			// no more gangs at or above the floor; we are done
 			break
 		}
 
@@ -167,3 +206,155 @@ func (f *PriorityQueue) Len(priority int) int {
 func (f *PriorityQueue) Size() int {
 	return f.list.Size()
 }
+
+// MaxPriority returns the priority of the highest priority gang in the
+// PriorityQueue, or NoPriority if it is empty.
+func (f *PriorityQueue) MaxPriority() int {
+	f.RLock()
+	defer f.RUnlock()
+
+	if f.list.Size() == 0 {
+		return NoPriority
+	}
+	return f.list.GetHighestLevel()
+}
+
+// Enqueued returns the number of gangs enqueued over the lifetime of the
+// PriorityQueue.
+func (f *PriorityQueue) Enqueued() uint64 {
+	f.RLock()
+	defer f.RUnlock()
+	return f.enqueued
+}
+
+// Dequeued returns the number of gangs dequeued over the lifetime of the
+// PriorityQueue. Gangs removed with Remove are not counted.
+func (f *PriorityQueue) Dequeued() uint64 {
+	f.RLock()
+	defer f.RUnlock()
+	return f.dequeued
+}
+
+// Rejected returns the number of gangs which could not be enqueued over
+// the lifetime of the PriorityQueue.
+func (f *PriorityQueue) Rejected() uint64 {
+	f.RLock()
+	defer f.RUnlock()
+	return f.rejected
+}
+
+// Reprioritize moves the gang containing the task to newPriority. The
+// priority of all the tasks in the gang is updated, and the gang is queued
+// behind the gangs already at newPriority to keep FIFO order.
+func (f *PriorityQueue) Reprioritize(
+	taskID *peloton.TaskID,
+	newPriority uint32) error {
+	f.Lock()
+	defer f.Unlock()
+
+	for _, level := range f.list.Levels() {
+		items, err := f.list.PeekItems(level, f.list.Len(level))
+		if err != nil {
+			continue
+		}
+		for _, gang := range toGang(items) {
+			if !gangHasTask(gang, taskID) {
+				continue
+			}
+			if err := f.list.Remove(level, gang); err != nil {
+				return err
+			}
+			for _, t := range gang.GetTasks() {
+				t.Priority = newPriority
+			}
+			return f.list.Push(int(newPriority), gang)
+		}
+	}
+	return ErrorQueueEmpty(
+		fmt.Sprintf("no gang found for task %s", taskID.GetValue()))
+}
+
+// gangHasTask returns true if the gang contains the task.
+func gangHasTask(gang *resmgrsvc.Gang, taskID *peloton.TaskID) bool {
+	for _, t := range gang.GetTasks() {
+		if t.GetId().GetValue() == taskID.GetValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// Resize changes the capacity of the PriorityQueue. A negative limit removes
+// the size bound. Shrinking below the current size is rejected.
+func (f *PriorityQueue) Resize(newLimit int64) error {
+	f.Lock()
+	defer f.Unlock()
+	return f.list.SetLimit(newLimit)
+}
+
+// Export returns a snapshot of all the gangs pending in the PriorityQueue,
+// in the order they would be dequeued. The queue itself is not modified,
+// and the gangs of the snapshot are copies of the queued gangs.
+func (f *PriorityQueue) Export() *Snapshot {
+	f.RLock()
+	defer f.RUnlock()
+
+	levels := f.list.Levels()
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+
+	snapshot := &Snapshot{}
+	for _, level := range levels {
+		items, err := f.list.PeekItems(level, f.list.Len(level))
+		if err != nil {
+			continue
+		}
+		for seq, gang := range toGang(items) {
+			snapshot.Gangs = append(snapshot.Gangs, &SnapshotGang{
+				Priority: uint32(level),
+				Sequence: uint64(seq),
+				Gang:     proto.Clone(gang).(*resmgrsvc.Gang),
+			})
+		}
+	}
+	return snapshot
+}
+
+// Import rebuilds the PriorityQueue from a snapshot returned by Export.
+// Gangs are queued at their snapshot priority in sequence order, so that
+// they are dequeued in the same order as from the exported queue. Import
+// is only allowed on an empty queue, and gangs imported are not counted
+// as enqueued.
+func (f *PriorityQueue) Import(snapshot *Snapshot) error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.list.Size() != 0 {
+		return errors.New("import into a non empty queue")
+	}
+
+	gangs := make([]*SnapshotGang, len(snapshot.GetGangs()))
+	copy(gangs, snapshot.GetGangs())
+	sort.SliceStable(gangs, func(i, j int) bool {
+		if gangs[i].Priority != gangs[j].Priority {
+			return gangs[i].Priority > gangs[j].Priority
+		}
+		return gangs[i].Sequence < gangs[j].Sequence
+	})
+
+	for _, g := range gangs {
+		if len(g.Gang.GetTasks()) == 0 {
+			return errors.New("import of empty list")
+		}
+	}
+
+	for i, g := range gangs {
+		if err := f.list.Push(int(g.Priority), g.Gang); err != nil {
+			// leave the queue empty rather than partially imported
+			for _, pushed := range gangs[:i] {
+				f.list.Remove(int(pushed.Priority), pushed.Gang)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -15,9 +15,11 @@
 package queue
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"testing"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
@@ -29,6 +31,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 )
 
 type FifoQueueTestSuite struct {
@@ -37,7 +40,7 @@ type FifoQueueTestSuite struct {
 }
 
 func (suite *FifoQueueTestSuite) SetupTest() {
-	suite.fq = NewPriorityQueue(math.MaxInt64)
+	suite.fq = NewPriorityQueue(math.MaxInt64, tally.NoopScope)
 	// TODO: Add tests for concurency behavior
 	suite.AddTasks()
 }
@@ -162,7 +165,7 @@ func (suite *FifoQueueTestSuite) TestPeek() {
 }
 
 func (suite *FifoQueueTestSuite) TestPeekWithLimit() {
-	q := NewPriorityQueue(1000)
+	q := NewPriorityQueue(1000, tally.NoopScope)
 
 	// add 4 tasks with different priorities
 	for i := 0; i < 4; i++ {
@@ -194,6 +197,220 @@ func (suite *FifoQueueTestSuite) TestPeekWithLimit() {
 	suite.Equal(uint32(3), gangs[0].Tasks[0].GetPriority())
 }
 
+func (suite *FifoQueueTestSuite) TestPeekWithMinPriority() {
+	// only the two gangs with priority 2 are at or above the floor
+	gangs, err := suite.fq.PeekWithMinPriority(10, 2)
+	suite.NoError(err)
+	suite.Equal(2, len(gangs))
+	for _, gang := range gangs {
+		suite.Equal(uint32(2), gang.Tasks[0].GetPriority())
+	}
+
+	// the floor still respects the limit
+	gangs, err = suite.fq.PeekWithMinPriority(2, 1)
+	suite.NoError(err)
+	suite.Equal(2, len(gangs))
+
+	gangs, err = suite.fq.PeekWithMinPriority(10, 1)
+	suite.NoError(err)
+	suite.Equal(3, len(gangs))
+	suite.Equal(uint32(1), gangs[2].Tasks[0].GetPriority())
+
+	// no gangs above the floor
+	_, err = suite.fq.PeekWithMinPriority(10, 3)
+	suite.Error(err)
+	_, ok := err.(ErrorQueueEmpty)
+	suite.True(ok)
+
+	// peeking does not remove gangs from the queue
+	suite.Equal(4, suite.fq.Size())
+}
+
+func (suite *FifoQueueTestSuite) TestReprioritize() {
+	// move the lowest priority gang up behind the other priority 2 gangs
+	suite.NoError(suite.fq.Reprioritize(&peloton.TaskID{Value: "job1-1"}, 2))
+	suite.Equal(0, suite.fq.Len(0))
+	suite.Equal(1, suite.fq.Len(1))
+	suite.Equal(3, suite.fq.Len(2))
+
+	// move a highest priority gang down
+	suite.NoError(suite.fq.Reprioritize(&peloton.TaskID{Value: "job2-1"}, 0))
+	suite.Equal(1, suite.fq.Len(0))
+	suite.Equal(1, suite.fq.Len(1))
+	suite.Equal(2, suite.fq.Len(2))
+	suite.Equal(4, suite.fq.Size())
+
+	var dequeued []string
+	for i := 0; i < 4; i++ {
+		gang, err := suite.fq.Dequeue()
+		suite.NoError(err)
+		dequeued = append(dequeued, gang.GetTasks()[0].GetId().GetValue())
+	}
+	suite.Equal([]string{"job2-2", "job1-1", "job1-2", "job2-1"}, dequeued)
+
+	err := suite.fq.Reprioritize(&peloton.TaskID{Value: "job1-1"}, 1)
+	suite.Error(err)
+	_, ok := err.(ErrorQueueEmpty)
+	suite.True(ok)
+}
+
+func (suite *FifoQueueTestSuite) TestMaxPriority() {
+	suite.Equal(2, suite.fq.MaxPriority())
+
+	// enqueueing a higher priority gang raises the maximum
+	suite.NoError(suite.fq.Enqueue(&resmgrsvc.Gang{
+		Tasks: []*resmgr.Task{CreateResmgrTask(
+			&peloton.JobID{Value: "job3"},
+			&peloton.TaskID{Value: "job3-1"},
+			5)},
+	}))
+	suite.Equal(5, suite.fq.MaxPriority())
+
+	// the maximum follows the gangs as they are dequeued
+	var maxPriorities []int
+	for i := 0; i < 5; i++ {
+		_, err := suite.fq.Dequeue()
+		suite.NoError(err)
+		maxPriorities = append(maxPriorities, suite.fq.MaxPriority())
+	}
+	suite.Equal([]int{2, 2, 1, 0, NoPriority}, maxPriorities)
+}
+
+// TestMaxPriorityConcurrent tests reading the maximum priority while gangs
+// are enqueued and dequeued concurrently, for the race detector
+func (suite *FifoQueueTestSuite) TestMaxPriorityConcurrent() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			suite.NoError(suite.fq.Enqueue(&resmgrsvc.Gang{
+				Tasks: []*resmgr.Task{CreateResmgrTask(
+					&peloton.JobID{Value: "job3"},
+					&peloton.TaskID{Value: fmt.Sprintf("job3-%d", i)},
+					uint32(i%4))},
+			}))
+			_, err := suite.fq.Dequeue()
+			suite.NoError(err)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		p := suite.fq.MaxPriority()
+		suite.True(p >= 0 && p <= 3, "max priority %d", p)
+	}
+	wg.Wait()
+}
+
+func (suite *FifoQueueTestSuite) TestExportImport() {
+	snapshot := suite.fq.Export()
+	suite.Len(snapshot.GetGangs(), 4)
+	// exporting does not drain the queue
+	suite.Equal(4, suite.fq.Size())
+
+	// the snapshot does not share the gangs of the queue
+	queued, err := suite.fq.Peek(1)
+	suite.NoError(err)
+	suite.Equal(queued[0], snapshot.Gangs[0].Gang)
+	snapshot.Gangs[0].Gang.Tasks[0].Priority++
+	suite.NotEqual(queued[0], snapshot.Gangs[0].Gang)
+	snapshot.Gangs[0].Gang.Tasks[0].Priority--
+
+	// the snapshot survives a round trip through its serialized form
+	buf, err := json.Marshal(snapshot)
+	suite.NoError(err)
+	restored := &Snapshot{}
+	suite.NoError(json.Unmarshal(buf, restored))
+
+	fq := NewPriorityQueue(math.MaxInt64, tally.NoopScope)
+	suite.NoError(fq.Import(restored))
+	suite.Equal(4, fq.Size())
+	suite.Equal(1, fq.Len(0))
+	suite.Equal(1, fq.Len(1))
+	suite.Equal(2, fq.Len(2))
+	suite.Equal(uint64(0), fq.Enqueued())
+
+	var dequeued []string
+	for i := 0; i < 4; i++ {
+		gang, err := fq.Dequeue()
+		suite.NoError(err)
+		dequeued = append(dequeued, gang.GetTasks()[0].GetId().GetValue())
+	}
+	suite.Equal([]string{"job2-1", "job2-2", "job1-2", "job1-1"}, dequeued)
+
+	// gangs are restored in sequence order regardless of snapshot order
+	shuffled := &Snapshot{Gangs: []*SnapshotGang{
+		snapshot.Gangs[3], snapshot.Gangs[1],
+		snapshot.Gangs[2], snapshot.Gangs[0],
+	}}
+	fq = NewPriorityQueue(math.MaxInt64, tally.NoopScope)
+	suite.NoError(fq.Import(shuffled))
+	gangs, err := fq.Peek(4)
+	suite.NoError(err)
+	suite.Equal("job2-1", gangs[0].GetTasks()[0].GetId().GetValue())
+	suite.Equal("job2-2", gangs[1].GetTasks()[0].GetId().GetValue())
+}
+
+func (suite *FifoQueueTestSuite) TestImportError() {
+	snapshot := suite.fq.Export()
+
+	// importing into a non empty queue is rejected
+	suite.Error(suite.fq.Import(snapshot))
+	suite.Equal(4, suite.fq.Size())
+
+	// a snapshot larger than the queue limit leaves the queue empty
+	fq := NewPriorityQueue(3, tally.NoopScope)
+	suite.Error(fq.Import(snapshot))
+	suite.Equal(0, fq.Size())
+
+	// empty gangs are rejected
+	fq = NewPriorityQueue(math.MaxInt64, tally.NoopScope)
+	suite.Error(fq.Import(&Snapshot{Gangs: []*SnapshotGang{
+		{Priority: 1, Gang: &resmgrsvc.Gang{}},
+	}}))
+	suite.Equal(0, fq.Size())
+
+	// an empty snapshot imports nothing
+	suite.NoError(fq.Import(nil))
+	suite.Equal(0, fq.Size())
+}
+
+func (suite *FifoQueueTestSuite) TestResize() {
+	fq := NewPriorityQueue(2, tally.NoopScope)
+	newGang := func(i int) *resmgrsvc.Gang {
+		return &resmgrsvc.Gang{
+			Tasks: []*resmgr.Task{
+				CreateResmgrTask(
+					&peloton.JobID{Value: "job1"},
+					&peloton.TaskID{
+						Value: fmt.Sprintf("%s-%d", "job1", i)},
+					0),
+			},
+		}
+	}
+
+	suite.NoError(fq.Enqueue(newGang(1)))
+	suite.NoError(fq.Enqueue(newGang(2)))
+	suite.Error(fq.Enqueue(newGang(3)))
+
+	// growing a full queue lets it accept more gangs
+	suite.NoError(fq.Resize(3))
+	suite.NoError(fq.Enqueue(newGang(3)))
+	suite.Equal(3, fq.Size())
+
+	// shrinking below the current size is rejected
+	err := fq.Resize(1)
+	suite.EqualError(err,
+		"list size 3 exceeds new limit 1, 2 items need to be dropped")
+	suite.Error(fq.Enqueue(newGang(4)))
+	suite.Equal(3, fq.Size())
+
+	// a negative limit removes the bound
+	suite.NoError(fq.Resize(-1))
+	suite.NoError(fq.Enqueue(newGang(4)))
+	suite.Equal(4, fq.Size())
+}
+
 func (suite *FifoQueueTestSuite) TestRemove() {
 	gangs, err := suite.fq.Peek(1)
 	suite.NoError(err)
@@ -271,3 +488,53 @@ func (suite *FifoQueueTestSuite) createQueueWithMultiLevelList() (*PriorityQueue
 		list: list,
 	}, list
 }
+
+func (suite *FifoQueueTestSuite) TestLifetimeCounters() {
+	scope := tally.NewTestScope("", map[string]string{})
+	fq := NewPriorityQueue(2, scope)
+	newGang := func(i int) *resmgrsvc.Gang {
+		return &resmgrsvc.Gang{
+			Tasks: []*resmgr.Task{
+				CreateResmgrTask(
+					&peloton.JobID{Value: "job1"},
+					&peloton.TaskID{
+						Value: fmt.Sprintf("%s-%d", "job1", i)},
+					0),
+			},
+		}
+	}
+	counters := func() map[string]int64 {
+		values := make(map[string]int64)
+		for _, c := range scope.Snapshot().Counters() {
+			values[c.Name()] = c.Value()
+		}
+		return values
+	}
+
+	suite.NoError(fq.Enqueue(newGang(1)))
+	suite.NoError(fq.Enqueue(newGang(2)))
+	// the queue is full
+	suite.Error(fq.Enqueue(newGang(3)))
+	// empty gangs are rejected
+	suite.Error(fq.Enqueue(&resmgrsvc.Gang{}))
+
+	_, err := fq.Dequeue()
+	suite.NoError(err)
+	suite.NoError(fq.Enqueue(newGang(3)))
+	_, err = fq.Dequeue()
+	suite.NoError(err)
+	_, err = fq.Dequeue()
+	suite.NoError(err)
+	// dequeue from an empty queue is not counted
+	_, err = fq.Dequeue()
+	suite.Error(err)
+
+	suite.Equal(uint64(3), fq.Enqueued())
+	suite.Equal(uint64(3), fq.Dequeued())
+	suite.Equal(uint64(2), fq.Rejected())
+	suite.Equal(map[string]int64{
+		"enqueued": 3,
+		"dequeued": 3,
+		"rejected": 2,
+	}, counters())
+}
@@ -16,11 +16,19 @@ package queue
 
 import (
 	"errors"
+	"math"
 
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/private/resmgrsvc"
+
+	"github.com/uber-go/tally"
 )
 
+// NoPriority is the priority reported by MaxPriority for an empty queue.
+// It is lower than the priority of any gang.
+const NoPriority = math.MinInt32
+
 // Queue is the interface implemented by all the the queues
 type Queue interface {
 	// Enqueue queues a gang (task list gang) based on its priority into FIFO queue
@@ -33,18 +41,66 @@ type Queue interface {
 	// limit is the number of gangs to peek.
 	// It will return an error if there is no gang in the queue
 	Peek(limit uint32) ([]*resmgrsvc.Gang, error)
+	// PeekWithMinPriority is like Peek but skips the gangs whose
+	// priority is below minPriority.
+	PeekWithMinPriority(limit uint32, minPriority uint32) ([]*resmgrsvc.Gang, error)
 	// Remove removes the item from the queue
 	Remove(item *resmgrsvc.Gang) error
 	// Size returns the total number of items in the queue
 	Size() int
+	// MaxPriority returns the priority of the highest priority gang in
+	// the queue, or NoPriority if the queue is empty.
+	MaxPriority() int
+	// Resize changes the capacity of the queue. A negative limit removes
+	// the size bound. It returns an error if the queue holds more items
+	// than the new limit.
+	Resize(newLimit int64) error
+	// Reprioritize moves the gang containing the task to newPriority,
+	// behind the gangs already queued at that priority. It returns an
+	// ErrorQueueEmpty error if no gang in the queue contains the task.
+	Reprioritize(taskID *peloton.TaskID, newPriority uint32) error
+	// Export returns a snapshot of all the gangs pending in the queue,
+	// which can be persisted to recover the queue after a restart.
+	Export() *Snapshot
+	// Import rebuilds an empty queue from a snapshot returned by Export.
+	Import(snapshot *Snapshot) error
+}
+
+// Snapshot is a serializable copy of the gangs pending in a queue.
+type Snapshot struct {
+	// Gangs holds the pending gangs in the order they would be dequeued.
+	Gangs []*SnapshotGang `json:"gangs"`
+}
+
+// GetGangs returns the gangs in the snapshot, or nil for a nil snapshot.
+func (s *Snapshot) GetGangs() []*SnapshotGang {
+	if s == nil {
+		return nil
+	}
+	return s.Gangs
+}
+
+// SnapshotGang is a gang pending in a queue along with its position.
+type SnapshotGang struct {
+	// Priority the gang is queued at.
+	Priority uint32 `json:"priority"`
+	// Sequence is the order in which the gang was enqueued relative to
+	// the other gangs at the same priority.
+	Sequence uint64 `json:"sequence"`
+	// Gang is the pending gang.
+	Gang *resmgrsvc.Gang `json:"gang"`
 }
 
-// CreateQueue is factory method to create the specified queue
-func CreateQueue(policy respool.SchedulingPolicy, limit int64) (Queue, error) {
+// CreateQueue is factory method to create the specified queue, with its
+// metrics rooted at the given tally.Scope
+func CreateQueue(
+	policy respool.SchedulingPolicy,
+	limit int64,
+	scope tally.Scope) (Queue, error) {
 	// Factory method to create specific queue object based on policy
 	switch policy {
 	case respool.SchedulingPolicy_PriorityFIFO:
-		return NewPriorityQueue(limit), nil
+		return NewPriorityQueue(limit, scope), nil
 	default:
 		//if type is invalid, return an error
 		return nil, errors.New("invalid queue type")
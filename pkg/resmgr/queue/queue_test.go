@@ -20,6 +20,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 )
 
 // QueueTestSuite is the struct for Queue Tests
@@ -33,14 +34,14 @@ func TestQueue(t *testing.T) {
 
 // TestCreateQueue tests the Create Queue
 func (suite *QueueTestSuite) TestCreateQueueSuccess() {
-	q, err := CreateQueue(respool.SchedulingPolicy_PriorityFIFO, 100)
+	q, err := CreateQueue(respool.SchedulingPolicy_PriorityFIFO, 100, tally.NoopScope)
 	suite.NoError(err)
 	suite.NotNil(q)
 }
 
 // TestCreateQueue tests the Create Queue
 func (suite *QueueTestSuite) TestCreateQueueError() {
-	q, err := CreateQueue(2, 100)
+	q, err := CreateQueue(2, 100, tally.NoopScope)
 	suite.Nil(q)
 	suite.Error(err)
 	suite.EqualError(err, "invalid queue type")
@@ -93,9 +93,23 @@ func entitlementAdmitter(gang *resmgrsvc.Gang, pool *resPool) bool {
 		"resources_required": neededResources,
 	}).Debug("checking entitlement")
 
-	return currentAllocation.
-		Add(neededResources).
-		LessThanOrEqual(currentEntitlement)
+	required := currentAllocation.Add(neededResources)
+	if required.LessThanOrEqual(currentEntitlement) {
+		return true
+	}
+
+	// the shortfall is only explained when it is logged, as gangs are
+	// admitted on the dequeue hot path
+	if log.GetLevel() >= log.DebugLevel {
+		log.WithFields(log.Fields{
+			"respool_id": pool.id,
+			"shortfall": scalar.ExplainShortfall(
+				required,
+				currentEntitlement,
+			).String(),
+		}).Debug("gang exceeds entitlement")
+	}
+	return false
 }
 
 // returns true if a controller gang can be admitted to the pool
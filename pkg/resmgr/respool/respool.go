@@ -16,6 +16,7 @@ package respool
 
 import (
 	"container/list"
+	"fmt"
 	"math"
 	"sync"
 
@@ -64,6 +65,72 @@ type node interface {
 	IsRoot() bool
 }
 
+// ChildInfo is a lightweight descriptor of a child resource pool.
+type ChildInfo struct {
+	// ID of the child resource pool.
+	ID string
+	// Name of the child resource pool.
+	Name string
+	// Scheduling policy of the child resource pool.
+	Policy respool.SchedulingPolicy
+}
+
+// DeadLetteredGang is a gang which was moved out of the queues of the
+// resource pool because it can never be admitted.
+type DeadLetteredGang struct {
+	// The gang which was dead-lettered.
+	Gang *resmgrsvc.Gang
+	// The queue from which the gang was dead-lettered.
+	Queue QueueType
+	// The reason the gang can never be admitted.
+	Reason string
+}
+
+// State is a point in time snapshot of a resource pool, for debugging.
+// All of its fields are captured under the same lock, so the allocation,
+// entitlement and demand are consistent with each other and with the
+// queued gangs.
+type State struct {
+	// ID of the resource pool.
+	ID string
+	// Path of the resource pool.
+	Path string
+	// Config of the resource pool.
+	Config *respool.ResourcePoolConfig
+	// Whether the resource pool is being drained.
+	Draining bool
+
+	// Entitlement of the resource pool.
+	Entitlement *scalar.Resources
+	// Entitlement of the resource pool for non-revocable tasks.
+	NonSlackEntitlement *scalar.Resources
+	// Entitlement of the resource pool for revocable tasks.
+	SlackEntitlement *scalar.Resources
+
+	// Allocation of the resource pool across all the task dimensions.
+	Allocation *scalar.Allocation
+
+	// Demand of the gangs waiting to be admitted.
+	Demand *scalar.Resources
+	// Demand of the revocable gangs waiting to be admitted.
+	SlackDemand *scalar.Resources
+
+	// Number of gangs waiting in each queue of the resource pool.
+	QueueSizes map[QueueType]int
+	// Priority of the highest priority gang waiting to be admitted, or
+	// queue.NoPriority if no gang is waiting.
+	MaxPendingPriority int
+}
+
+// admissionFailure is the number of failed admission attempts of the gang
+// at the head of a queue.
+type admissionFailure struct {
+	// The first task ID of the gang.
+	key string
+	// The number of failed admission attempts.
+	count int
+}
+
 // ResPool is a node in a resource pool hierarchy.
 type ResPool interface {
 	node
@@ -81,15 +148,38 @@ type ResPool interface {
 
 	// Aggregates the child reservations by resource type.
 	AggregatedChildrenReservations() (map[string]float64, error)
+	// Returns the ID, name and scheduling policy of each child, in
+	// the order of Children.
+	GetChildrenInfo() []*ChildInfo
 
 	// Enqueues gang (task list) into resource pool pending queue.
 	EnqueueGang(gang *resmgrsvc.Gang) error
 	// Dequeues gangs (task list) from the resource pool.
 	DequeueGangs(int) ([]*resmgrsvc.Gang, error)
+	// DequeueGangsWithMinPriority is like DequeueGangs but only dequeues
+	// the gangs with priority greater than or equal to minPriority.
+	DequeueGangsWithMinPriority(int, uint32) ([]*resmgrsvc.Gang, error)
 	// PeekGangs returns a list of gangs from the resource pool's queue based
 	// on the queue type. limit determines the max number of gangs to be
 	// returned.
 	PeekGangs(qt QueueType, limit uint32) ([]*resmgrsvc.Gang, error)
+	// GetMaxPendingPriority returns the priority of the highest priority
+	// gang waiting in any of the queues of the resource pool, or
+	// queue.NoPriority if no gang is waiting.
+	GetMaxPendingPriority() int
+	// ReprioritizeGang moves the queued gang containing the task to
+	// newPriority, behind the gangs already queued at that priority.
+	ReprioritizeGang(taskID *peloton.TaskID, newPriority uint32) error
+	// SetDeadLetterThreshold sets the number of failed admission attempts
+	// after which a gang exceeding the limit of the resource pool, or of
+	// any of its ancestors, is dead-lettered. Dead-lettering is disabled
+	// if it is zero.
+	SetDeadLetterThreshold(threshold int)
+	// GetDeadLetteredGangs returns the gangs which were dead-lettered, in
+	// the order they were dead-lettered.
+	GetDeadLetteredGangs() []*DeadLetteredGang
+	// GetState returns a consistent snapshot of the resource pool.
+	GetState() *State
 
 	// SetEntitlement sets the entitlement of non-revocable resources
 	// for non-revocable tasks + revocable tasks for this resource pool.
@@ -114,6 +204,9 @@ type ResPool interface {
 	AddToAllocation(*scalar.Allocation) error
 	// SubtractFromAllocation recaptures the resources from task.
 	SubtractFromAllocation(*scalar.Allocation) error
+	// MarkItDoneBatch recaptures the resources from a batch of tasks,
+	// recomputing the allocation only once.
+	MarkItDoneBatch([]*scalar.Allocation) error
 
 	// GetTotalAllocatedResources returns the total resource allocation for the resource
 	// pool.
@@ -122,6 +215,9 @@ type ResPool interface {
 	GetSlackAllocatedResources() *scalar.Resources
 	// GetNonSlackAllocatedResources returns resources allocated to non-revocable tasks.
 	GetNonSlackAllocatedResources() *scalar.Resources
+	// GetSlack returns the headroom of the resource pool, which is its
+	// entitlement minus its total allocation, clamped at zero.
+	GetSlack() *scalar.Resources
 
 	// CalculateTotalAllocatedResources calculates the total allocation recursively for
 	// all the children.
@@ -165,6 +261,18 @@ type ResPool interface {
 	// UpdateResourceMetrics updates metrics for this resource pool
 	// on each entitlement cycle calculation (15s)
 	UpdateResourceMetrics()
+
+	// Drain stops the resource pool from accepting new gangs. Gangs
+	// already in the queues can still be admitted and their allocations
+	// released, until the resource pool is drained. The draining state
+	// is only kept in memory, so it is lost when resmgr restarts or loses
+	// leadership, and Drain has to be called again on the new leader.
+	Drain()
+	// IsDraining returns true if the resource pool is being drained.
+	IsDraining() bool
+	// IsDrained returns true if the resource pool is being drained and
+	// has no queued gangs, demand or allocation left.
+	IsDrained() bool
 }
 
 // resPool implements the ResPool interface.
@@ -243,6 +351,20 @@ type resPool struct {
 	// set of invalid tasks which will be discarded during admission control.
 	invalidTasks map[string]bool
 
+	// draining is set once the resource pool stops accepting new gangs
+	// ahead of its deletion. It is not persisted, see Drain.
+	draining bool
+
+	// number of failed admission attempts after which a gang exceeding the
+	// limit of the pool is dead-lettered, disabled if zero.
+	deadLetterThreshold int
+	// map of the queue type to the failed admission attempts of the gang
+	// at the head of the queue, if it exceeds the limit of the pool.
+	admissionFailures map[QueueType]*admissionFailure
+	// gangs which were moved out of the queues as they can never be
+	// admitted, so that they do not block the head of the queues.
+	deadLetters []*DeadLetteredGang
+
 	metrics *Metrics
 }
 
@@ -259,36 +381,12 @@ func NewRespool(
 			"ResourcePoolConfig is nil", id)
 	}
 
-	pq, err := queue.CreateQueue(config.Policy, math.MaxInt64)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
-	}
-
-	cq, err := queue.CreateQueue(config.Policy, math.MaxInt64)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
-	}
-
-	nq, err := queue.CreateQueue(config.Policy, math.MaxInt64)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
-	}
-
-	rq, err := queue.CreateQueue(config.Policy, math.MaxInt64)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error creating revocable queue %s", id)
-	}
-
 	pool := &resPool{
 		id:                  id,
 		children:            list.New(),
 		parent:              parent,
 		resourceConfigs:     make(map[string]*respool.ResourceConfig),
 		poolConfig:          config,
-		pendingQueue:        pq,
-		controllerQueue:     cq,
-		npQueue:             nq,
-		revocableQueue:      rq,
 		allocation:          scalar.NewAllocation(),
 		entitlement:         &scalar.Resources{},
 		nonSlackEntitlement: &scalar.Resources{},
@@ -298,14 +396,49 @@ func NewRespool(
 		slackLimit:          &scalar.Resources{},
 		reservation:         &scalar.Resources{},
 		invalidTasks:        make(map[string]bool),
+		admissionFailures:   make(map[QueueType]*admissionFailure),
 		preemptionCfg:       preemptionConfig,
 	}
 	pool.path = pool.calculatePath()
 
 	// Initialize metrics
-	pool.metrics = NewMetrics(scope.Tagged(map[string]string{
+	poolScope := scope.Tagged(map[string]string{
 		"path": pool.GetPath(),
-	}))
+	})
+	pool.metrics = NewMetrics(poolScope)
+
+	var err error
+	pool.pendingQueue, err = queue.CreateQueue(
+		config.Policy,
+		math.MaxInt64,
+		poolScope.SubScope("pending_queue"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
+	}
+
+	pool.controllerQueue, err = queue.CreateQueue(
+		config.Policy,
+		math.MaxInt64,
+		poolScope.SubScope("controller_queue"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
+	}
+
+	pool.npQueue, err = queue.CreateQueue(
+		config.Policy,
+		math.MaxInt64,
+		poolScope.SubScope("np_queue"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating resource pool %s", id)
+	}
+
+	pool.revocableQueue, err = queue.CreateQueue(
+		config.Policy,
+		math.MaxInt64,
+		poolScope.SubScope("revocable_queue"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating revocable queue %s", id)
+	}
 
 	// Initialize resources and limits.
 	pool.initialize(config)
@@ -397,18 +530,16 @@ func (n *resPool) EnqueueGang(gang *resmgrsvc.Gang) error {
 		return errors.Errorf("resource pool %s is not a leaf node", n.id)
 	}
 
-	if err := n.pendingQueue.Enqueue(gang); err != nil {
-		return err
-	}
+	n.Lock()
+	defer n.Unlock()
 
-	// if task is revocable then add to slack demand
-	if isRevocable(gang) {
-		n.AddToSlackDemand(scalar.GetGangResources(gang))
-		return nil
+	if n.draining {
+		return errors.Errorf("resource pool %s is draining", n.id)
 	}
 
-	n.AddToDemand(scalar.GetGangResources(gang))
-	return nil
+	// the demand is updated along with the queue, so that the demand
+	// always matches the gangs in the queues.
+	return addGangToQueue(n, PendingQueue, gang)
 }
 
 // DequeueGangs dequeues a list of gangs from the
@@ -423,6 +554,16 @@ func (n *resPool) EnqueueGang(gang *resmgrsvc.Gang) error {
 // 3. Checks the pending queue (
 //    and moves gangs to non preemptible queue or controller queue)
 func (n *resPool) DequeueGangs(limit int) ([]*resmgrsvc.Gang, error) {
+	return n.DequeueGangsWithMinPriority(limit, 0)
+}
+
+// DequeueGangsWithMinPriority dequeues a list of gangs from the resource
+// pool which can be admitted and have priority greater than or equal to
+// minPriority. It can be used during contention to admit only high
+// priority work. Gangs below the floor stay in the queues.
+func (n *resPool) DequeueGangsWithMinPriority(
+	limit int,
+	minPriority uint32) ([]*resmgrsvc.Gang, error) {
 	if limit <= 0 {
 		err := errors.Errorf("limit %d is not valid", limit)
 		return nil, err
@@ -446,7 +587,7 @@ func (n *resPool) DequeueGangs(limit int) ([]*resmgrsvc.Gang, error) {
 		if left == 0 {
 			break
 		}
-		gangs, err := n.dequeue(qt, left)
+		gangs, err := n.dequeue(qt, left, minPriority)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"respool_id": n.id,
@@ -464,7 +605,8 @@ func (n *resPool) DequeueGangs(limit int) ([]*resmgrsvc.Gang, error) {
 // dequeues limit number of gangs from the respool for admission.
 func (n *resPool) dequeue(
 	qt QueueType,
-	limit int) ([]*resmgrsvc.Gang, error) {
+	limit int,
+	minPriority uint32) ([]*resmgrsvc.Gang, error) {
 	var err error
 	var gangList []*resmgrsvc.Gang
 
@@ -473,7 +615,7 @@ func (n *resPool) dequeue(
 	}
 
 	for i := 0; i < limit; i++ {
-		gangs, err := n.queue(qt).Peek(1)
+		gangs, err := n.queue(qt).PeekWithMinPriority(1, minPriority)
 		if err != nil {
 			if _, ok := err.(queue.ErrorQueueEmpty); ok {
 				// queue is empty we are done
@@ -484,6 +626,12 @@ func (n *resPool) dequeue(
 		}
 		gang := gangs[0]
 		err = admission.TryAdmit(gang, n, qt)
+		if err == errResourcePoolFull && n.tryDeadLetter(gang, qt) {
+			// the gang was moved out of the queue, so that the next gang
+			// in the queue can be admitted.
+			err = nil
+			continue
+		}
 		if err != nil {
 			if err == errGangInvalid ||
 				err == errSkipNonPreemptibleGang ||
@@ -509,6 +657,96 @@ func (n *resPool) dequeue(
 	return gangList, err
 }
 
+// tryDeadLetter records a failed admission attempt of a gang which exceeds
+// the limit of the resource pool or of any of its ancestors, and hence can
+// never be admitted. Once the attempts reach the dead letter threshold, the
+// gang is removed from the queue and dead-lettered. Returns true if the gang
+// was dead-lettered.
+func (n *resPool) tryDeadLetter(gang *resmgrsvc.Gang, qt QueueType) bool {
+	if len(gang.GetTasks()) == 0 {
+		return false
+	}
+	reason := n.exceededLimit(scalar.GetGangResources(gang))
+
+	n.Lock()
+	defer n.Unlock()
+
+	if n.deadLetterThreshold <= 0 || reason == "" {
+		// the gang fits in the pool once enough resources are freed
+		delete(n.admissionFailures, qt)
+		return false
+	}
+
+	key := gang.GetTasks()[0].GetId().GetValue()
+	failure, ok := n.admissionFailures[qt]
+	if !ok || failure.key != key {
+		// only the gang at the head of the queue is tracked
+		failure = &admissionFailure{key: key}
+		n.admissionFailures[qt] = failure
+	}
+	failure.count++
+	if failure.count < n.deadLetterThreshold {
+		return false
+	}
+	delete(n.admissionFailures, qt)
+
+	if err := removeGangFromQueue(n, qt, gang); err != nil {
+		log.WithField("respool_id", n.id).
+			WithError(err).
+			Error("failed to remove gang from queue to dead-letter it")
+		return false
+	}
+
+	n.deadLetters = append(n.deadLetters, &DeadLetteredGang{
+		Gang:   gang,
+		Queue:  qt,
+		Reason: reason,
+	})
+	log.WithFields(log.Fields{
+		"respool_id": n.id,
+		"gang":       gang,
+		"queue_type": qt,
+		"reason":     reason,
+	}).Warn("Dead-lettered gang which can never be admitted")
+	return true
+}
+
+// exceededLimit returns why the resources exceed the limit of the resource
+// pool or of the closest of its ancestors below the root, or an empty
+// string if they fit in all the limits.
+func (n *resPool) exceededLimit(res *scalar.Resources) string {
+	var pool ResPool = n
+	for pool != nil && !pool.IsRoot() {
+		shortfall := scalar.ExplainShortfall(res, getLimits(pool.Resources()))
+		if !shortfall.Satisfied() {
+			return fmt.Sprintf(
+				"gang exceeds the limit of the resource pool %s: %s",
+				pool.ID(), shortfall.String())
+		}
+		pool = pool.Parent()
+	}
+	return ""
+}
+
+// SetDeadLetterThreshold sets the number of failed admission attempts after
+// which a gang exceeding the limit of the resource pool is dead-lettered.
+func (n *resPool) SetDeadLetterThreshold(threshold int) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.deadLetterThreshold = threshold
+}
+
+// GetDeadLetteredGangs returns the gangs which were dead-lettered.
+func (n *resPool) GetDeadLetteredGangs() []*DeadLetteredGang {
+	n.RLock()
+	defer n.RUnlock()
+
+	deadLetters := make([]*DeadLetteredGang, len(n.deadLetters))
+	copy(deadLetters, n.deadLetters)
+	return deadLetters
+}
+
 // AggregatedChildrenReservations returns aggregated child reservations by
 // resource kind
 func (n *resPool) AggregatedChildrenReservations() (map[string]float64, error) {
@@ -534,6 +772,30 @@ func (n *resPool) AggregatedChildrenReservations() (map[string]float64, error) {
 	return totalReservation, nil
 }
 
+// GetChildrenInfo returns the ID, name and scheduling policy of each child
+// of the resource pool, for example to build a view of the resource pool
+// tree without traversing the nodes.
+func (n *resPool) GetChildrenInfo() []*ChildInfo {
+	n.RLock()
+	defer n.RUnlock()
+
+	childrenInfo := make([]*ChildInfo, 0, n.children.Len())
+	for e := n.children.Front(); e != nil; e = e.Next() {
+		child, ok := e.Value.(ResPool)
+		if !ok {
+			log.WithField("respool_id", n.id).
+				Warnf("failed to type assert child resource pool %v", e.Value)
+			continue
+		}
+		childrenInfo = append(childrenInfo, &ChildInfo{
+			ID:     child.ID(),
+			Name:   child.Name(),
+			Policy: child.ResourcePoolConfig().GetPolicy(),
+		})
+	}
+	return childrenInfo
+}
+
 // ToResourcePoolInfo converts ResPool to ResourcePoolInfo
 func (n *resPool) ToResourcePoolInfo() *respool.ResourcePoolInfo {
 	n.RLock()
@@ -861,6 +1123,16 @@ func (n *resPool) GetTotalAllocatedResources() *scalar.Resources {
 	return n.allocation.GetByType(scalar.TotalAllocation)
 }
 
+// GetSlack returns the remaining headroom of the resource pool for each
+// resource kind, computed as the entitlement minus the total allocation and
+// clamped at zero when the pool is allocated more than its entitlement.
+// Not to be confused with the slack (revocable) resources of the pool.
+func (n *resPool) GetSlack() *scalar.Resources {
+	n.RLock()
+	defer n.RUnlock()
+	return n.entitlement.Subtract(n.allocation.GetByType(scalar.TotalAllocation))
+}
+
 // GetSlackAllocatedResources gets the resource allocation for the pool
 func (n *resPool) GetSlackAllocatedResources() *scalar.Resources {
 	n.RLock()
@@ -923,6 +1195,49 @@ func (n *resPool) SubtractFromAllocation(allocation *scalar.Allocation) error {
 	return nil
 }
 
+// MarkItDoneBatch subtracts the allocations of a batch of tasks, like the
+// tasks of a gang finishing together, from the allocation of the resource
+// pool in one go. It fails without updating the allocation if the batch
+// would drive any allocation dimension negative.
+func (n *resPool) MarkItDoneBatch(allocations []*scalar.Allocation) error {
+	n.Lock()
+	defer n.Unlock()
+
+	total := scalar.NewAllocation()
+	for _, allocation := range allocations {
+		total = total.Add(allocation)
+	}
+
+	for allocationType, res := range total.Value {
+		if !res.LessThanOrEqual(n.allocation.GetByType(allocationType)) {
+			return errors.Errorf("couldn't update the resources, "+
+				"allocation %v is less than the resources to subtract %v",
+				n.allocation.GetByType(allocationType), res)
+		}
+	}
+
+	newAllocation := n.allocation.Subtract(total)
+	if newAllocation == nil {
+		return errors.Errorf("couldn't update the resources")
+	}
+	n.allocation = newAllocation
+
+	log.WithFields(log.Fields{
+		"respool_id": n.id,
+		"batch_size": len(allocations),
+		"total_alloc": n.allocation.GetByType(
+			scalar.TotalAllocation),
+		"non_preemptible_alloc": n.allocation.GetByType(
+			scalar.NonPreemptibleAllocation),
+		"controller_alloc": n.allocation.GetByType(
+			scalar.ControllerAllocation),
+		"slack_alloc": n.allocation.GetByType(
+			scalar.SlackAllocation),
+	}).Debug("Current Allocation after subtracting batch allocation")
+
+	return nil
+}
+
 // IsRoot returns true if the node is the root in the resource
 // pool hierarchy
 func (n *resPool) IsRoot() bool {
@@ -1088,10 +1403,13 @@ func (n *resPool) updateDynamicResourceMetrics() {
 	n.metrics.NonSlackAllocation.Update(n.allocation.GetByType(
 		scalar.NonSlackAllocation))
 
+	// GPUs can only be handed out in whole units
 	n.metrics.NonSlackAvailable.Update(n.nonSlackEntitlement.
-		Subtract(n.allocation.GetByType(scalar.NonSlackAllocation)))
+		Subtract(n.allocation.GetByType(scalar.NonSlackAllocation)).
+		FloorGPU())
 	n.metrics.SlackAvailable.Update(n.slackEntitlement.
-		Subtract(n.allocation.GetByType(scalar.SlackAllocation)))
+		Subtract(n.allocation.GetByType(scalar.SlackAllocation)).
+		FloorGPU())
 
 	n.metrics.Demand.Update(n.demand)
 	n.metrics.SlackDemand.Update(n.slackDemand)
@@ -1117,6 +1435,90 @@ func (n *resPool) aggregateQueueByType(qt QueueType) int {
 	return queueSize
 }
 
+// Drain stops the resource pool from accepting new gangs. Gangs already
+// in the queues can still be admitted and their allocations released,
+// until the resource pool is drained. The draining state is only kept in
+// memory, and is lost when resmgr restarts or loses leadership.
+func (n *resPool) Drain() {
+	n.Lock()
+	defer n.Unlock()
+
+	n.draining = true
+	log.WithField("respool_id", n.id).Info("Draining resource pool")
+}
+
+// IsDraining returns true if the resource pool is being drained.
+func (n *resPool) IsDraining() bool {
+	n.RLock()
+	defer n.RUnlock()
+
+	return n.draining
+}
+
+// IsDrained returns true if the resource pool is being drained and has
+// no queued gangs, demand or allocation left.
+func (n *resPool) IsDrained() bool {
+	n.RLock()
+	defer n.RUnlock()
+
+	if !n.draining {
+		return false
+	}
+
+	for _, qt := range []QueueType{
+		PendingQueue,
+		ControllerQueue,
+		NonPreemptibleQueue,
+		RevocableQueue,
+	} {
+		if n.aggregateQueueByType(qt) != 0 {
+			return false
+		}
+	}
+
+	return n.demand.LessThanOrEqual(scalar.ZeroResource) &&
+		n.slackDemand.LessThanOrEqual(scalar.ZeroResource) &&
+		n.allocation.GetByType(scalar.TotalAllocation).
+			LessThanOrEqual(scalar.ZeroResource)
+}
+
+// GetState returns a snapshot of the config, entitlement, allocation,
+// demand and queued gangs of the resource pool, all captured under one
+// lock.
+func (n *resPool) GetState() *State {
+	n.RLock()
+	defer n.RUnlock()
+
+	state := &State{
+		ID:                  n.id,
+		Path:                n.path,
+		Config:              n.poolConfig,
+		Draining:            n.draining,
+		Entitlement:         n.entitlement.Clone(),
+		NonSlackEntitlement: n.nonSlackEntitlement.Clone(),
+		SlackEntitlement:    n.slackEntitlement.Clone(),
+		// adding a zero allocation copies the allocation
+		Allocation:         n.allocation.Add(scalar.NewAllocation()),
+		Demand:             n.demand.Clone(),
+		SlackDemand:        n.slackDemand.Clone(),
+		QueueSizes:         make(map[QueueType]int),
+		MaxPendingPriority: queue.NoPriority,
+	}
+
+	for _, qt := range []QueueType{
+		PendingQueue,
+		NonPreemptibleQueue,
+		ControllerQueue,
+		RevocableQueue,
+	} {
+		state.QueueSizes[qt] = n.aggregateQueueByType(qt)
+		if p := n.queue(qt).MaxPriority(); p > state.MaxPendingPriority {
+			state.MaxPendingPriority = p
+		}
+	}
+	return state
+}
+
 // updates all the metrics (static and dynamic)
 func (n *resPool) UpdateResourceMetrics() {
 	n.RLock()
@@ -1154,6 +1556,64 @@ func (n *resPool) PeekGangs(qt QueueType, limit uint32) ([]*resmgrsvc.Gang,
 	return nil, nil
 }
 
+// GetMaxPendingPriority returns the priority of the highest priority gang
+// waiting in the queues of the resource pool. Preemption uses it to decide
+// whether the pool has pending work that outranks running tasks.
+func (n *resPool) GetMaxPendingPriority() int {
+	n.RLock()
+	defer n.RUnlock()
+
+	maxPriority := queue.NoPriority
+	for _, qt := range []QueueType{
+		PendingQueue,
+		NonPreemptibleQueue,
+		ControllerQueue,
+		RevocableQueue,
+	} {
+		if p := n.queue(qt).MaxPriority(); p > maxPriority {
+			maxPriority = p
+		}
+	}
+	return maxPriority
+}
+
+// ReprioritizeGang moves the gang containing the task to newPriority in
+// whichever queue of the resource pool it is waiting in. The demand of
+// the resource pool is unchanged since the gang stays queued.
+func (n *resPool) ReprioritizeGang(
+	taskID *peloton.TaskID,
+	newPriority uint32) error {
+	if !n.isLeaf() {
+		return errors.Errorf("resource pool %s is not a leaf node", n.id)
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	for _, qt := range []QueueType{
+		PendingQueue,
+		NonPreemptibleQueue,
+		ControllerQueue,
+		RevocableQueue,
+	} {
+		err := n.queue(qt).Reprioritize(taskID, newPriority)
+		if err == nil {
+			log.WithFields(log.Fields{
+				"respool_id":   n.id,
+				"task_id":      taskID.GetValue(),
+				"queue":        qt.String(),
+				"new_priority": newPriority,
+			}).Info("Reprioritized gang")
+			return nil
+		}
+		if _, ok := err.(queue.ErrorQueueEmpty); !ok {
+			return err
+		}
+	}
+	return errors.Errorf("task %s is not queued in resource pool %s",
+		taskID.GetValue(), n.id)
+}
+
 func (n *resPool) isPreemptionEnabled() bool {
 	return n.preemptionCfg.Enabled
 }
@@ -17,6 +17,7 @@ package respool
 import (
 	"container/list"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
@@ -396,6 +397,204 @@ func (s *ResPoolSuite) TestResPoolDequeue() {
 	s.Equal(0, priorityQueue.Len(2))
 }
 
+func (s *ResPoolSuite) TestResPoolDequeueWithMinPriority() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+
+	for _, t := range s.getTasks() {
+		resPoolNode.EnqueueGang(makeTaskGang(t))
+	}
+
+	// only the two gangs with priority 2 are admitted
+	dequeuedGangs, err := resPoolNode.DequeueGangsWithMinPriority(10, 2)
+	s.NoError(err)
+	s.Equal(2, len(dequeuedGangs))
+	for _, gang := range dequeuedGangs {
+		s.Equal(uint32(2), gang.GetTasks()[0].GetPriority())
+	}
+
+	// nothing is left at or above the floor
+	dequeuedGangs, err = resPoolNode.DequeueGangsWithMinPriority(10, 2)
+	s.NoError(err)
+	s.Empty(dequeuedGangs)
+
+	// gangs below the floor stay in the queue
+	resPool, ok := resPoolNode.(*resPool)
+	s.True(ok)
+	priorityQueue, ok := resPool.pendingQueue.(*queue.PriorityQueue)
+	s.True(ok)
+	s.Equal(1, priorityQueue.Len(1))
+	s.Equal(1, priorityQueue.Len(0))
+
+	// lowering the floor admits the remaining gangs in priority order
+	dequeuedGangs, err = resPoolNode.DequeueGangsWithMinPriority(10, 0)
+	s.NoError(err)
+	s.Equal(2, len(dequeuedGangs))
+	s.Equal(uint32(1), dequeuedGangs[0].GetTasks()[0].GetPriority())
+	s.Equal(uint32(0), dequeuedGangs[1].GetTasks()[0].GetPriority())
+}
+
+func (s *ResPoolSuite) TestResPoolGetMaxPendingPriority() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+	s.Equal(queue.NoPriority, resPoolNode.GetMaxPendingPriority())
+
+	for _, t := range s.getTasks() {
+		s.NoError(resPoolNode.EnqueueGang(makeTaskGang(t)))
+	}
+	s.Equal(2, resPoolNode.GetMaxPendingPriority())
+
+	// a higher priority gang raises the maximum
+	highTask := s.getTasks()[0]
+	highTask.Id = &peloton.TaskID{Value: "job3-1"}
+	highTask.Priority = 4
+	s.NoError(resPoolNode.EnqueueGang(makeTaskGang(highTask)))
+	s.Equal(4, resPoolNode.GetMaxPendingPriority())
+
+	// the maximum drops as the highest priority gangs are admitted
+	dequeued, err := resPoolNode.DequeueGangsWithMinPriority(10, 2)
+	s.NoError(err)
+	s.Equal(3, len(dequeued))
+	s.Equal(1, resPoolNode.GetMaxPendingPriority())
+
+	dequeued, err = resPoolNode.DequeueGangs(10)
+	s.NoError(err)
+	s.Equal(2, len(dequeued))
+	s.Equal(queue.NoPriority, resPoolNode.GetMaxPendingPriority())
+}
+
+func (s *ResPoolSuite) TestResPoolGetState() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+
+	state := resPoolNode.GetState()
+	s.Equal(_testResPoolName, state.Config.GetName())
+	s.False(state.Draining)
+	s.Equal(queue.NoPriority, state.MaxPendingPriority)
+	s.Equal(0, state.QueueSizes[PendingQueue])
+	s.True(s.getEntitlement().Equal(state.NonSlackEntitlement))
+
+	// resources of the given number of gangs of the first test task
+	gangResources := func(gangs int) *scalar.Resources {
+		return &scalar.Resources{
+			CPU:    float64(gangs),
+			MEMORY: float64(gangs * 100),
+			DISK:   float64(gangs * 10),
+		}
+	}
+
+	numGangs := 50
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numGangs; i++ {
+			t := s.getTasks()[0]
+			t.Id = &peloton.TaskID{Value: fmt.Sprintf("job1-%d", i)}
+			s.NoError(resPoolNode.EnqueueGang(makeTaskGang(t)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numGangs; i++ {
+			resPoolNode.DequeueGangs(1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numGangs; i++ {
+			state := resPoolNode.GetState()
+			queued := 0
+			for _, size := range state.QueueSizes {
+				queued += size
+			}
+			// the demand always matches the queued gangs, and the
+			// allocation never exceeds the entitlement
+			s.True(gangResources(queued).Equal(state.Demand))
+			s.True(state.Allocation.GetByType(scalar.TotalAllocation).
+				LessThanOrEqual(state.NonSlackEntitlement))
+		}
+	}()
+	wg.Wait()
+
+	// every gang is either still queued or admitted
+	state = resPoolNode.GetState()
+	s.True(gangResources(numGangs).Equal(
+		state.Demand.Add(state.Allocation.GetByType(scalar.TotalAllocation))))
+	s.Equal(0, state.MaxPendingPriority)
+}
+
+func (s *ResPoolSuite) TestResPoolDrain() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+	s.False(resPoolNode.IsDraining())
+	s.False(resPoolNode.IsDrained())
+
+	tasks := s.getTasks()
+	for _, t := range tasks {
+		s.NoError(resPoolNode.EnqueueGang(makeTaskGang(t)))
+	}
+
+	// admit one gang before draining
+	admitted, err := resPoolNode.DequeueGangs(1)
+	s.NoError(err)
+	s.Equal(1, len(admitted))
+
+	resPoolNode.Drain()
+	s.True(resPoolNode.IsDraining())
+	s.False(resPoolNode.IsDrained())
+
+	// new gangs are rejected while draining
+	err = resPoolNode.EnqueueGang(makeTaskGang(tasks[0]))
+	s.EqualError(err, fmt.Sprintf(
+		"resource pool %s is draining", resPoolNode.ID()))
+
+	// queued gangs can still be admitted
+	dequeued, err := resPoolNode.DequeueGangs(len(tasks))
+	s.NoError(err)
+	s.Equal(len(tasks)-1, len(dequeued))
+	admitted = append(admitted, dequeued...)
+	s.False(resPoolNode.IsDrained())
+
+	// the pool is drained once all the allocations are released
+	for i, gang := range admitted {
+		s.False(resPoolNode.IsDrained())
+		s.NoError(resPoolNode.SubtractFromAllocation(
+			scalar.GetGangAllocation(gang)), "gang %d", i)
+	}
+	s.True(resPoolNode.IsDrained())
+}
+
+func (s *ResPoolSuite) TestResPoolReprioritizeGang() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+
+	for _, t := range s.getTasks() {
+		s.NoError(resPoolNode.EnqueueGang(makeTaskGang(t)))
+	}
+	demand := resPoolNode.GetDemand()
+
+	// bump the lowest priority gang to the top priority
+	s.NoError(resPoolNode.ReprioritizeGang(
+		&peloton.TaskID{Value: "job1-1"}, 3))
+	s.Equal(demand, resPoolNode.GetDemand())
+
+	gangs, err := resPoolNode.PeekGangs(PendingQueue, 4)
+	s.NoError(err)
+	var ids []string
+	for _, gang := range gangs {
+		ids = append(ids, gang.GetTasks()[0].GetId().GetValue())
+		if gang.GetTasks()[0].GetId().GetValue() == "job1-1" {
+			s.Equal(uint32(3), gang.GetTasks()[0].GetPriority())
+		}
+	}
+	s.Equal([]string{"job1-1", "job2-1", "job2-2", "job1-2"}, ids)
+
+	err = resPoolNode.ReprioritizeGang(&peloton.TaskID{Value: "job3-1"}, 1)
+	s.EqualError(err, fmt.Sprintf(
+		"task job3-1 is not queued in resource pool %s", resPoolNode.ID()))
+}
+
 func (s *ResPoolSuite) TestResPoolDequeueNonLeaf() {
 	resPoolNode := s.createTestResourcePool()
 	children := list.New()
@@ -465,6 +664,119 @@ func (s *ResPoolSuite) TestResPoolTaskCanBeDequeued() {
 	s.Equal(1, len(dequeuedGangs))
 }
 
+// TestResPoolDeadLetterUnadmittableGang tests that a gang exceeding the
+// limit of the resource pool is dead-lettered after the threshold of failed
+// admission attempts, so that the gangs behind it can be admitted, while a
+// gang which only exceeds the entitlement stays queued.
+func (s *ResPoolSuite) TestResPoolDeadLetterUnadmittableGang() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+	resPoolNode.SetDeadLetterThreshold(2)
+
+	makeTask := func(name string, cpu float64) *resmgr.Task {
+		return &resmgr.Task{
+			Name:     name,
+			Priority: 1,
+			JobId:    &peloton.JobID{Value: "job1"},
+			Id:       &peloton.TaskID{Value: name},
+			Resource: &task.ResourceConfig{
+				CpuLimit:    cpu,
+				DiskLimitMb: 10,
+				MemLimitMb:  100,
+			},
+			Preemptible: true,
+		}
+	}
+
+	// the big gang is at the head of the queue and requests more cpu
+	// than the limit of the pool
+	bigGang := makeTaskGang(makeTask("job1-1", 2000))
+	s.NoError(resPoolNode.EnqueueGang(bigGang))
+	s.NoError(resPoolNode.EnqueueGang(makeTaskGang(makeTask("job1-2", 1))))
+
+	// the first failed attempt blocks the head of the queue
+	dequeuedGangs, err := resPoolNode.DequeueGangs(2)
+	s.NoError(err)
+	s.Empty(dequeuedGangs)
+	s.Empty(resPoolNode.GetDeadLetteredGangs())
+
+	// the second failed attempt dead-letters the big gang, and the gang
+	// behind it is admitted
+	dequeuedGangs, err = resPoolNode.DequeueGangs(2)
+	s.NoError(err)
+	s.Len(dequeuedGangs, 1)
+	s.Equal("job1-2", dequeuedGangs[0].GetTasks()[0].GetId().GetValue())
+
+	deadLetters := resPoolNode.GetDeadLetteredGangs()
+	s.Len(deadLetters, 1)
+	s.Equal(bigGang, deadLetters[0].Gang)
+	s.Equal(PendingQueue, deadLetters[0].Queue)
+	s.Contains(deadLetters[0].Reason, "exceeds the limit")
+	// the demand of the dead-lettered gang is removed from the pool
+	s.Equal(float64(0), resPoolNode.GetDemand().GetCPU())
+
+	// a gang exceeding the entitlement but within the limit of the pool
+	// may be admitted later, so it is never dead-lettered
+	s.NoError(resPoolNode.EnqueueGang(makeTaskGang(makeTask("job1-3", 200))))
+	for i := 0; i < 3; i++ {
+		dequeuedGangs, err = resPoolNode.DequeueGangs(1)
+		s.NoError(err)
+		s.Empty(dequeuedGangs)
+	}
+	s.Len(resPoolNode.GetDeadLetteredGangs(), 1)
+}
+
+// TestResPoolDeadLetterGangExceedingParentLimit tests that a gang within
+// the limit of its resource pool, but exceeding the limit of the parent
+// pool, is dead-lettered.
+func (s *ResPoolSuite) TestResPoolDeadLetterGangExceedingParentLimit() {
+	// the parent pool is limited to 100 cpus
+	parentResources := s.getResources()
+	parentResources[0].Limit = 100
+	parent, err := NewRespool(tally.NoopScope, uuid.New(), s.root,
+		&pb_respool.ResourcePoolConfig{
+			Name:      "parent",
+			Parent:    &_rootResPoolID,
+			Resources: parentResources,
+			Policy:    pb_respool.SchedulingPolicy_PriorityFIFO,
+		}, s.cfg)
+	s.NoError(err)
+	parentID := &peloton.ResourcePoolID{Value: parent.ID()}
+	resPoolNode, err := NewRespool(tally.NoopScope, uuid.New(), parent,
+		&pb_respool.ResourcePoolConfig{
+			Name:      _testResPoolName,
+			Parent:    parentID,
+			Resources: s.getResources(),
+			Policy:    pb_respool.SchedulingPolicy_PriorityFIFO,
+		}, s.cfg)
+	s.NoError(err)
+	resPoolNode.SetNonSlackEntitlement(s.getEntitlement())
+	resPoolNode.SetDeadLetterThreshold(1)
+
+	gang := makeTaskGang(&resmgr.Task{
+		Name:     "job1-1",
+		Priority: 1,
+		JobId:    &peloton.JobID{Value: "job1"},
+		Id:       &peloton.TaskID{Value: "job1-1"},
+		Resource: &task.ResourceConfig{
+			CpuLimit:    500,
+			DiskLimitMb: 10,
+			MemLimitMb:  10,
+		},
+		Preemptible: true,
+	})
+	s.NoError(resPoolNode.EnqueueGang(gang))
+
+	dequeuedGangs, err := resPoolNode.DequeueGangs(1)
+	s.NoError(err)
+	s.Empty(dequeuedGangs)
+
+	deadLetters := resPoolNode.GetDeadLetteredGangs()
+	s.Len(deadLetters, 1)
+	s.Equal(gang, deadLetters[0].Gang)
+	s.Contains(deadLetters[0].Reason, parent.ID())
+}
+
 func (s *ResPoolSuite) TestEntitlement() {
 	resPoolNode := s.createTestResourcePool()
 	resPoolNode.UpdateResourceMetrics()
@@ -546,6 +858,100 @@ func (s *ResPoolSuite) TestAllocation() {
 	s.Equal(float64(0), resourceAlloc.GPU)
 }
 
+func (s *ResPoolSuite) TestGetSlack() {
+	resPoolNode := s.createTestResourcePool()
+	resPoolNode.SetEntitlement(&scalar.Resources{
+		CPU:    float64(100),
+		MEMORY: float64(1000),
+		DISK:   float64(100),
+		GPU:    float64(2),
+	})
+	s.Equal(&scalar.Resources{
+		CPU:    float64(100),
+		MEMORY: float64(1000),
+		DISK:   float64(100),
+		GPU:    float64(2),
+	}, resPoolNode.GetSlack())
+
+	allocation := scalar.NewAllocation()
+	allocation.Value[scalar.TotalAllocation] = &scalar.Resources{
+		CPU:    float64(40),
+		MEMORY: float64(1000),
+		DISK:   float64(10),
+		GPU:    float64(1),
+	}
+	s.NoError(resPoolNode.AddToAllocation(allocation))
+	s.Equal(&scalar.Resources{
+		CPU:    float64(60),
+		MEMORY: float64(0),
+		DISK:   float64(90),
+		GPU:    float64(1),
+	}, resPoolNode.GetSlack())
+
+	// the headroom is clamped at zero once the pool is over-allocated
+	s.NoError(resPoolNode.AddToAllocation(allocation))
+	s.Equal(&scalar.Resources{
+		CPU:    float64(20),
+		MEMORY: float64(0),
+		DISK:   float64(80),
+		GPU:    float64(0),
+	}, resPoolNode.GetSlack())
+}
+
+func (s *ResPoolSuite) TestMarkItDoneBatch() {
+	batchNode := s.createTestResourcePool()
+	sequenceNode := s.createTestResourcePool()
+
+	totalAlloc := scalar.NewAllocation()
+	var allocations []*scalar.Allocation
+	for _, t := range s.getTasks() {
+		alloc := scalar.GetTaskAllocation(t)
+		allocations = append(allocations, alloc)
+		totalAlloc = totalAlloc.Add(alloc)
+	}
+
+	// allocate the resources of all tasks plus some more, so that
+	// the allocation is not zero after marking all the tasks done.
+	extraAlloc := scalar.GetTaskAllocation(s.getTasks()[0])
+	for _, node := range []ResPool{batchNode, sequenceNode} {
+		s.NoError(node.AddToAllocation(totalAlloc))
+		s.NoError(node.AddToAllocation(extraAlloc))
+	}
+
+	s.NoError(batchNode.MarkItDoneBatch(allocations))
+	for _, alloc := range allocations {
+		s.NoError(sequenceNode.SubtractFromAllocation(alloc))
+	}
+
+	s.Equal(
+		sequenceNode.GetTotalAllocatedResources(),
+		batchNode.GetTotalAllocatedResources())
+	s.Equal(
+		extraAlloc.GetByType(scalar.TotalAllocation),
+		batchNode.GetTotalAllocatedResources())
+	s.Equal(
+		sequenceNode.GetNonSlackAllocatedResources(),
+		batchNode.GetNonSlackAllocatedResources())
+}
+
+func (s *ResPoolSuite) TestMarkItDoneBatchNegativeAllocation() {
+	resPoolNode := s.createTestResourcePool()
+
+	var allocations []*scalar.Allocation
+	for _, t := range s.getTasks() {
+		allocations = append(allocations, scalar.GetTaskAllocation(t))
+	}
+
+	// only the first task is allocated
+	s.NoError(resPoolNode.AddToAllocation(allocations[0]))
+
+	s.Error(resPoolNode.MarkItDoneBatch(allocations))
+	// allocation should not be changed on failure
+	s.Equal(
+		allocations[0].GetByType(scalar.TotalAllocation),
+		resPoolNode.GetTotalAllocatedResources())
+}
+
 func (s *ResPoolSuite) TestCalculateAllocation() {
 	rootID := peloton.ResourcePoolID{Value: "root"}
 	respool1ID := peloton.ResourcePoolID{Value: "respool1"}
@@ -1132,6 +1538,56 @@ func (s *ResPoolSuite) TestAggregatedChildrenReservations() {
 	s.Equal(map[string]float64{}, ar)
 }
 
+func (s *ResPoolSuite) TestGetChildrenInfo() {
+	poolConfigRoot := &pb_respool.ResourcePoolConfig{
+		Name:      "root",
+		Parent:    nil,
+		Resources: s.getResources(),
+		Policy:    pb_respool.SchedulingPolicy_PriorityFIFO,
+	}
+	resPoolRoot, err := NewRespool(tally.NoopScope, _rootResPoolID.Value,
+		nil, poolConfigRoot, s.cfg)
+	s.NoError(err)
+
+	// a leaf pool has no children
+	s.Empty(resPoolRoot.GetChildrenInfo())
+
+	children := list.New()
+	var expected []*ChildInfo
+	for i, policy := range []pb_respool.SchedulingPolicy{
+		pb_respool.SchedulingPolicy_PriorityFIFO,
+		pb_respool.SchedulingPolicy_UNKNOWN,
+		pb_respool.SchedulingPolicy_PriorityFIFO,
+	} {
+		id := fmt.Sprintf("respool%d", i)
+		poolConfig := &pb_respool.ResourcePoolConfig{
+			Name:      fmt.Sprintf("pool%d", i),
+			Parent:    &_rootResPoolID,
+			Resources: s.getResources(),
+			Policy:    pb_respool.SchedulingPolicy_PriorityFIFO,
+		}
+		resPoolNode, err := NewRespool(tally.NoopScope, id, resPoolRoot,
+			poolConfig, s.cfg)
+		s.NoError(err)
+
+		// the policy in the config is reported even if no queue
+		// could be created for it
+		poolConfig.Policy = policy
+		resPoolNode.SetResourcePoolConfig(poolConfig)
+
+		children.PushBack(resPoolNode)
+		expected = append(expected, &ChildInfo{
+			ID:     id,
+			Name:   poolConfig.Name,
+			Policy: policy,
+		})
+	}
+	resPoolRoot.SetChildren(children)
+
+	s.Equal(expected, resPoolRoot.GetChildrenInfo())
+	s.Equal(3, resPoolRoot.Children().Len())
+}
+
 func (s *ResPoolSuite) TestResPoolPeekGangs() {
 
 	for _, qt := range []QueueType{
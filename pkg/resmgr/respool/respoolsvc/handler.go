@@ -318,7 +318,7 @@ func (h *ServiceHandler) DeleteResourcePool(
 	}
 
 	// Deleting the respool from In memory tree.
-	if err := h.resPoolTree.Delete(resPoolID); err != nil {
+	if err := h.resPoolTree.Delete(resPoolID, false); err != nil {
 		h.metrics.DeleteResourcePoolFail.Inc(1)
 		// Logging and returning error as this is the API Failed
 		// We need to log the context in resmgr
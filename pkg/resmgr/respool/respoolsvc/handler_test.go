@@ -893,7 +893,7 @@ func (s *resPoolHandlerTestSuite) deleteResourcePool(
 	respool.EXPECT().IsLeaf().Return(true)
 	respool.EXPECT().GetTotalAllocatedResources().Return(scalar.ZeroResource)
 	respool.EXPECT().GetDemand().Return(scalar.ZeroResource)
-	resTree.EXPECT().Delete(gomock.Any()).Return(nil)
+	resTree.EXPECT().Delete(gomock.Any(), false).Return(nil)
 	s.mockResPoolOps.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
 }
 
@@ -944,7 +944,7 @@ func (s *resPoolHandlerTestSuite) deleteResourcePoolDeleteError(
 	respool.EXPECT().IsLeaf().Return(true)
 	respool.EXPECT().GetTotalAllocatedResources().Return(scalar.ZeroResource)
 	respool.EXPECT().GetDemand().Return(scalar.ZeroResource)
-	resTree.EXPECT().Delete(gomock.Any()).Return(assert.AnError)
+	resTree.EXPECT().Delete(gomock.Any(), false).Return(assert.AnError)
 }
 
 func (s *resPoolHandlerTestSuite) deleteResourcePoolOpsError(
@@ -959,7 +959,7 @@ func (s *resPoolHandlerTestSuite) deleteResourcePoolOpsError(
 	respool.EXPECT().IsLeaf().Return(true)
 	respool.EXPECT().GetTotalAllocatedResources().Return(scalar.ZeroResource)
 	respool.EXPECT().GetDemand().Return(scalar.ZeroResource)
-	resTree.EXPECT().Delete(gomock.Any()).Return(nil)
+	resTree.EXPECT().Delete(gomock.Any(), false).Return(nil)
 	s.mockResPoolOps.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(assert.AnError)
 }
 
@@ -18,6 +18,7 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -25,7 +26,9 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 
 	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/util"
 	rc "github.com/uber/peloton/pkg/resmgr/common"
+	"github.com/uber/peloton/pkg/resmgr/scalar"
 	"github.com/uber/peloton/pkg/storage"
 	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 
@@ -34,6 +37,14 @@ import (
 	"github.com/uber-go/tally"
 )
 
+// _maxDeleteBlockers is the maximum number of jobs listed in the error
+// returned when deleting a resource pool which still has active jobs.
+const _maxDeleteBlockers = 10
+
+// _deleteJobsPageSize is the number of jobs of a resource pool read per
+// page when looking for the active jobs blocking its deletion.
+const _deleteJobsPageSize = 100
+
 // Tree defines the interface for a Resource Pool Tree
 type Tree interface {
 	// Start initializes the respool tree by loading all resource pools
@@ -63,8 +74,33 @@ type Tree interface {
 	// can be used instead, without breaking circular imports.
 	UpdatedChannel() <-chan struct{}
 
-	// Delete deletes the resource pool from the tree
-	Delete(ID *peloton.ResourcePoolID) error
+	// Delete deletes the resource pool from the tree. A resource pool
+	// which still has child pools or active jobs is only deleted, along
+	// with all its descendants, if force is set.
+	Delete(ID *peloton.ResourcePoolID, force bool) error
+
+	// GetResourcePoolUsagesByOwner returns the usage of all the resource
+	// pools owned by the given owner
+	GetResourcePoolUsagesByOwner(owner string) ([]*ResourcePoolUsage, error)
+
+	// SetDeadLetterThreshold sets the dead letter threshold of all the
+	// resource pools in the tree, including the ones added later.
+	SetDeadLetterThreshold(threshold int)
+}
+
+// ResourcePoolUsage is the config of a resource pool along with its live
+// usage in the tree
+type ResourcePoolUsage struct {
+	// ID of the resource pool
+	ID string
+	// Config of the resource pool, as stored in DB
+	Config *respool.ResourcePoolConfig
+	// Total resources allocated to the resource pool
+	Allocation *scalar.Resources
+	// Entitlement of the resource pool
+	Entitlement *scalar.Resources
+	// Demand of the resource pool
+	Demand *scalar.Resources
 }
 
 // tree implements the Tree interface
@@ -73,6 +109,10 @@ type tree struct {
 
 	preemptionConfig rc.PreemptionConfig
 
+	// number of failed admission attempts after which a gang exceeding
+	// the limit of its resource pool is dead-lettered, disabled if zero.
+	deadLetterThreshold int
+
 	respoolOps ormobjects.ResPoolOps // resource pool related operations
 	metrics    *Metrics              // Metrics object for reporting
 	root       ResPool
@@ -140,6 +180,18 @@ func (t *tree) UpdatedChannel() <-chan struct{} {
 	return t.updatedChan
 }
 
+// SetDeadLetterThreshold sets the dead letter threshold of all the resource
+// pools in the tree, and of the resource pools added later.
+func (t *tree) SetDeadLetterThreshold(threshold int) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.deadLetterThreshold = threshold
+	for _, pool := range t.resPools {
+		pool.SetDeadLetterThreshold(threshold)
+	}
+}
+
 // initTree will initialize all the resource pools from Storage
 func (t *tree) initTree(
 	resPoolConfigs map[string]*respool.ResourcePoolConfig) (ResPool, error) {
@@ -185,6 +237,7 @@ func (t *tree) buildTree(
 
 	t.resPools[ID] = node
 	node.SetParent(parent)
+	node.SetDeadLetterThreshold(t.deadLetterThreshold)
 	childConfigs := t.getChildResPoolConfigs(ID, resPoolConfigs)
 	var childResourcePools = list.New()
 	// TODO: We need to detect cycle here.
@@ -312,6 +365,8 @@ func (t *tree) Upsert(ID *peloton.ResourcePoolID, resPoolConfig *respool.Resourc
 				ID.Value)
 		}
 
+		resourcePool.SetDeadLetterThreshold(t.deadLetterThreshold)
+
 		// link parent to child resource pool
 		children := parent.Children()
 		children.PushBack(resourcePool)
@@ -354,7 +409,18 @@ func (t *tree) walkTree(root ResPool, nodes []string) (ResPool, error) {
 	return nil, errors.Errorf("resource pool (%s) not found", nodes)
 }
 
-func (t *tree) Delete(respoolID *peloton.ResourcePoolID) error {
+func (t *tree) Delete(respoolID *peloton.ResourcePoolID, force bool) error {
+	// Look up the jobs before taking the lock as it reads from DB.
+	var jobIDs []peloton.JobID
+	if !force {
+		var err error
+		jobIDs, err = t.getActiveJobIDs(respoolID)
+		if err != nil {
+			return errors.Wrapf(err,
+				"failed to get jobs of resource pool %s", respoolID.GetValue())
+		}
+	}
+
 	t.Lock()
 	defer t.Unlock()
 
@@ -363,6 +429,17 @@ func (t *tree) Delete(respoolID *peloton.ResourcePoolID) error {
 	if err != nil {
 		return err
 	}
+	// A resource pool being drained can only be deleted once drained.
+	if resPool.IsDraining() && !resPool.IsDrained() {
+		return fmt.Errorf("resource pool %s is not drained yet",
+			respoolID.GetValue())
+	}
+	// Deleting a resource pool with child pools or jobs would orphan them.
+	if !force {
+		if err := deleteBlockersError(resPool, jobIDs); err != nil {
+			return err
+		}
+	}
 	// Get the parent.
 	parent := resPool.Parent()
 
@@ -381,13 +458,145 @@ func (t *tree) Delete(respoolID *peloton.ResourcePoolID) error {
 	// Updating the parent's children.
 	parent.SetChildren(newChildren)
 
-	// Delete all children from the internal list.
+	// Delete all descendants from the internal list.
+	t.deleteDescendants(resPool)
+	// delete the node itself
+	delete(t.resPools, respoolID.Value)
+
+	return nil
+}
+
+// getActiveJobIDs returns up to _maxDeleteBlockers IDs of the jobs in the
+// resource pool which are not in a terminal state. Jobs without a runtime
+// are not active.
+func (t *tree) getActiveJobIDs(
+	respoolID *peloton.ResourcePoolID,
+) ([]peloton.JobID, error) {
+	ctx := context.Background()
+
+	var active []peloton.JobID
+	var after string
+	for len(active) < _maxDeleteBlockers {
+		jobIDs, err := t.jobStore.GetJobIDsByRespoolID(
+			ctx,
+			respoolID,
+			after,
+			_deleteJobsPageSize,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(jobIDs) == 0 {
+			break
+		}
+
+		ids := make([]*peloton.JobID, 0, len(jobIDs))
+		for i := range jobIDs {
+			ids = append(ids, &jobIDs[i])
+		}
+		runtimes, err := t.jobStore.GetJobRuntimes(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range jobIDs {
+			runtime, ok := runtimes[id.GetValue()]
+			if !ok || util.IsPelotonJobStateTerminal(runtime.GetState()) {
+				continue
+			}
+			active = append(active, id)
+			if len(active) == _maxDeleteBlockers {
+				break
+			}
+		}
+
+		if len(jobIDs) < _deleteJobsPageSize {
+			break
+		}
+		after = jobIDs[len(jobIDs)-1].GetValue()
+	}
+	return active, nil
+}
+
+// deleteBlockersError returns an error listing the child pools and jobs
+// which block the deletion of the resource pool, or nil if there are none.
+func deleteBlockersError(resPool ResPool, jobIDs []peloton.JobID) error {
+	var blockers []string
+
+	var childIDs []string
+	for e := resPool.Children().Front(); e != nil; e = e.Next() {
+		child, _ := e.Value.(ResPool)
+		childIDs = append(childIDs, child.ID())
+	}
+	if len(childIDs) > 0 {
+		sort.Strings(childIDs)
+		blockers = append(blockers,
+			fmt.Sprintf("child pools [%s]", strings.Join(childIDs, ", ")))
+	}
+
+	if len(jobIDs) > 0 {
+		ids := make([]string, 0, len(jobIDs))
+		for _, id := range jobIDs {
+			ids = append(ids, id.GetValue())
+		}
+		blockers = append(blockers,
+			fmt.Sprintf("active jobs [%s]", strings.Join(ids, ", ")))
+	}
+
+	if len(blockers) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resource pool %s can't be deleted as it has %s",
+		resPool.ID(), strings.Join(blockers, " and "))
+}
+
+// deleteDescendants removes all the descendants of the resource pool from
+// the internal list.
+func (t *tree) deleteDescendants(resPool ResPool) {
 	for e := resPool.Children().Front(); e != nil; e = e.Next() {
 		child, _ := e.Value.(ResPool)
+		t.deleteDescendants(child)
 		delete(t.resPools, child.ID())
 	}
-	// delete the node itself
-	delete(t.resPools, respoolID.Value)
+}
 
-	return nil
+// GetResourcePoolUsagesByOwner returns the usage of all the resource pools
+// owned by the given owner, sorted by ID. The configs are read from DB and
+// joined with the nodes of the tree; pools which are not loaded in the tree
+// have zero usage.
+func (t *tree) GetResourcePoolUsagesByOwner(
+	owner string,
+) ([]*ResourcePoolUsage, error) {
+	resPoolConfigs, err := t.respoolOps.GetAll(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get resource pool configs")
+	}
+
+	t.RLock()
+	defer t.RUnlock()
+
+	var usages []*ResourcePoolUsage
+	for id, config := range resPoolConfigs {
+		if config.GetOwningTeam() != owner {
+			continue
+		}
+
+		usage := &ResourcePoolUsage{
+			ID:          id,
+			Config:      config,
+			Allocation:  &scalar.Resources{},
+			Entitlement: &scalar.Resources{},
+			Demand:      &scalar.Resources{},
+		}
+		if node, ok := t.resPools[id]; ok {
+			usage.Allocation = node.GetTotalAllocatedResources()
+			usage.Entitlement = node.GetEntitlement()
+			usage.Demand = node.GetDemand()
+		}
+		usages = append(usages, usage)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].ID < usages[j].ID
+	})
+	return usages, nil
 }
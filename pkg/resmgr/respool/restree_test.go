@@ -458,16 +458,265 @@ func (s *resTreeTestSuite) TestDelete() {
 	s.Equal(10, resourceTree.GetAllNodes(false).Len())
 
 	// delete respool 11
-	resourceTree.Delete(&peloton.ResourcePoolID{Value: "respool11"})
+	s.NoError(resourceTree.Delete(
+		&peloton.ResourcePoolID{Value: "respool11"}, false))
 	s.Equal(9, resourceTree.GetAllNodes(false).Len())
 	s.Equal(4, resourceTree.GetAllNodes(true).Len())
 
-	// delete respool 1
-	resourceTree.Delete(&peloton.ResourcePoolID{Value: "respool1"})
+	// delete respool 1 once its last child is deleted
+	s.NoError(resourceTree.Delete(
+		&peloton.ResourcePoolID{Value: "respool12"}, false))
+	s.NoError(resourceTree.Delete(
+		&peloton.ResourcePoolID{Value: "respool1"}, false))
 	s.Equal(7, resourceTree.GetAllNodes(false).Len())
 	s.Equal(3, resourceTree.GetAllNodes(true).Len())
 }
 
+// TestDeleteForced tests that a forced deletion deletes the resource pool
+// along with all its descendants, without looking up its jobs.
+func (s *resTreeTestSuite) TestDeleteForced() {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	// the jobs aren't looked up when forced
+	resourceTree.jobStore = store_mocks.NewMockJobStore(mockCtrl)
+
+	s.NoError(resourceTree.Delete(
+		&peloton.ResourcePoolID{Value: "respool2"}, true))
+	s.Equal(5, resourceTree.GetAllNodes(false).Len())
+	for _, id := range []string{
+		"respool2", "respool21", "respool22", "respool23", "respool99"} {
+		_, err := resourceTree.Get(&peloton.ResourcePoolID{Value: id})
+		s.Error(err)
+	}
+}
+
+// TestDeleteWithChildren tests that a resource pool with child pools can't
+// be deleted.
+func (s *resTreeTestSuite) TestDeleteWithChildren() {
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool2"}
+	s.EqualError(resourceTree.Delete(respoolID, false),
+		"resource pool respool2 can't be deleted as it has "+
+			"child pools [respool21, respool22]")
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+}
+
+// TestDeleteWithJobs tests that a resource pool with active jobs can't be
+// deleted, while terminal jobs and jobs without a runtime don't block the
+// deletion.
+func (s *resTreeTestSuite) TestDeleteWithJobs() {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	mockJobStore := store_mocks.NewMockJobStore(mockCtrl)
+	resourceTree.jobStore = mockJobStore
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool3"}
+	jobIDs := []peloton.JobID{{Value: "job1"}, {Value: "job2"}, {Value: "job3"}}
+	mockJobStore.EXPECT().
+		GetJobIDsByRespoolID(
+			gomock.Any(), respoolID, "", uint32(_deleteJobsPageSize)).
+		Return(jobIDs, nil).
+		Times(2)
+	mockJobStore.EXPECT().
+		GetJobRuntimes(gomock.Any(), gomock.Len(3)).
+		Return(map[string]*job.RuntimeInfo{
+			"job1": {State: job.JobState_RUNNING},
+			"job2": {State: job.JobState_SUCCEEDED},
+		}, nil)
+	s.EqualError(resourceTree.Delete(respoolID, false),
+		"resource pool respool3 can't be deleted as it has active jobs [job1]")
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+
+	// once all its jobs are terminal the pool can be deleted
+	mockJobStore.EXPECT().
+		GetJobRuntimes(gomock.Any(), gomock.Len(3)).
+		Return(map[string]*job.RuntimeInfo{
+			"job1": {State: job.JobState_KILLED},
+			"job2": {State: job.JobState_SUCCEEDED},
+		}, nil)
+	s.NoError(resourceTree.Delete(respoolID, false))
+	s.Equal(9, resourceTree.GetAllNodes(false).Len())
+}
+
+// TestDeleteWithJobsPaged tests that the jobs of a resource pool are paged
+// through until an active job is found.
+func (s *resTreeTestSuite) TestDeleteWithJobsPaged() {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	mockJobStore := store_mocks.NewMockJobStore(mockCtrl)
+	resourceTree.jobStore = mockJobStore
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool3"}
+	var firstPage []peloton.JobID
+	terminal := make(map[string]*job.RuntimeInfo)
+	for i := 0; i < _deleteJobsPageSize; i++ {
+		id := fmt.Sprintf("job%03d", i)
+		firstPage = append(firstPage, peloton.JobID{Value: id})
+		terminal[id] = &job.RuntimeInfo{State: job.JobState_SUCCEEDED}
+	}
+	lastID := firstPage[len(firstPage)-1].GetValue()
+
+	gomock.InOrder(
+		mockJobStore.EXPECT().
+			GetJobIDsByRespoolID(
+				gomock.Any(), respoolID, "", uint32(_deleteJobsPageSize)).
+			Return(firstPage, nil),
+		mockJobStore.EXPECT().
+			GetJobRuntimes(gomock.Any(), gomock.Len(_deleteJobsPageSize)).
+			Return(terminal, nil),
+		mockJobStore.EXPECT().
+			GetJobIDsByRespoolID(
+				gomock.Any(), respoolID, lastID, uint32(_deleteJobsPageSize)).
+			Return([]peloton.JobID{{Value: "job999"}}, nil),
+		mockJobStore.EXPECT().
+			GetJobRuntimes(gomock.Any(), gomock.Len(1)).
+			Return(map[string]*job.RuntimeInfo{
+				"job999": {State: job.JobState_PENDING},
+			}, nil),
+	)
+	s.EqualError(resourceTree.Delete(respoolID, false),
+		"resource pool respool3 can't be deleted as it has active jobs [job999]")
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+}
+
+// TestDeleteWithChildrenAndJobs tests that all the blockers are listed in
+// the error.
+func (s *resTreeTestSuite) TestDeleteWithChildrenAndJobs() {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	mockJobStore := store_mocks.NewMockJobStore(mockCtrl)
+	resourceTree.jobStore = mockJobStore
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool1"}
+	mockJobStore.EXPECT().
+		GetJobIDsByRespoolID(gomock.Any(), respoolID, "", gomock.Any()).
+		Return([]peloton.JobID{{Value: "job1"}}, nil)
+	mockJobStore.EXPECT().
+		GetJobRuntimes(gomock.Any(), gomock.Any()).
+		Return(map[string]*job.RuntimeInfo{
+			"job1": {State: job.JobState_RUNNING},
+		}, nil)
+	s.EqualError(resourceTree.Delete(respoolID, false),
+		"resource pool respool1 can't be deleted as it has "+
+			"child pools [respool11, respool12] and active jobs [job1]")
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+}
+
+// TestDeleteJobStoreError tests that the resource pool isn't deleted if its
+// jobs can't be looked up.
+func (s *resTreeTestSuite) TestDeleteJobStoreError() {
+	mockCtrl := gomock.NewController(s.T())
+	defer mockCtrl.Finish()
+
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	mockJobStore := store_mocks.NewMockJobStore(mockCtrl)
+	resourceTree.jobStore = mockJobStore
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool3"}
+	mockJobStore.EXPECT().
+		GetJobIDsByRespoolID(gomock.Any(), respoolID, "", gomock.Any()).
+		Return(nil, errors.New("db error"))
+	s.Error(resourceTree.Delete(respoolID, false))
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+}
+
+func (s *resTreeTestSuite) TestDeleteDraining() {
+	resourceTree := s.getTree(s.withStore(s.getResPools(), nil))
+	resourceTree.Start()
+
+	respoolID := &peloton.ResourcePoolID{Value: "respool11"}
+	resPool, err := resourceTree.Get(respoolID)
+	s.NoError(err)
+
+	// a draining pool with allocation can't be deleted
+	resPool.SetTotalAllocatedResources(&scalar.Resources{CPU: 1})
+	resPool.Drain()
+	s.EqualError(resourceTree.Delete(respoolID, false),
+		"resource pool respool11 is not drained yet")
+	s.Equal(10, resourceTree.GetAllNodes(false).Len())
+
+	// once drained it can be deleted
+	resPool.SetTotalAllocatedResources(&scalar.Resources{})
+	s.True(resPool.IsDrained())
+	s.NoError(resourceTree.Delete(respoolID, false))
+	s.Equal(9, resourceTree.GetAllNodes(false).Len())
+}
+
+// TestGetResourcePoolUsagesByOwner tests that the usages of the pools of an
+// owner match the allocation, entitlement and demand of their nodes, and
+// that pools which are not loaded have zero usage.
+func (s *resTreeTestSuite) TestGetResourcePoolUsagesByOwner() {
+	resPools := s.getResPools()
+	for _, id := range []string{"respool11", "respool12", "respool2"} {
+		resPools[id].OwningTeam = "team1"
+	}
+	resPools["respool3"].OwningTeam = "team2"
+
+	resourceTree := s.getTree(s.withStore(resPools, nil))
+	s.NoError(resourceTree.Start())
+
+	respool11, err := resourceTree.Get(&peloton.ResourcePoolID{Value: "respool11"})
+	s.NoError(err)
+	respool11.SetTotalAllocatedResources(&scalar.Resources{CPU: 10, MEMORY: 100})
+	respool11.SetEntitlement(s.getEntitlement())
+	s.NoError(respool11.AddToDemand(&scalar.Resources{CPU: 20, MEMORY: 200}))
+
+	// respool12 is not loaded in the tree anymore
+	s.NoError(resourceTree.Delete(
+		&peloton.ResourcePoolID{Value: "respool12"}, false))
+
+	usages, err := resourceTree.GetResourcePoolUsagesByOwner("team1")
+	s.NoError(err)
+	s.Len(usages, 3)
+
+	s.Equal("respool11", usages[0].ID)
+	s.Equal(resPools["respool11"], usages[0].Config)
+	s.Equal(respool11.GetTotalAllocatedResources(), usages[0].Allocation)
+	s.Equal(respool11.GetEntitlement(), usages[0].Entitlement)
+	s.Equal(respool11.GetDemand(), usages[0].Demand)
+	s.Equal(&scalar.Resources{CPU: 20, MEMORY: 200}, usages[0].Demand)
+
+	s.Equal("respool12", usages[1].ID)
+	s.Equal(&scalar.Resources{}, usages[1].Allocation)
+	s.Equal(&scalar.Resources{}, usages[1].Entitlement)
+	s.Equal(&scalar.Resources{}, usages[1].Demand)
+
+	respool2, err := resourceTree.Get(&peloton.ResourcePoolID{Value: "respool2"})
+	s.NoError(err)
+	s.Equal("respool2", usages[2].ID)
+	s.Equal(respool2.GetTotalAllocatedResources(), usages[2].Allocation)
+	s.Equal(respool2.GetEntitlement(), usages[2].Entitlement)
+
+	usages, err = resourceTree.GetResourcePoolUsagesByOwner("team3")
+	s.NoError(err)
+	s.Empty(usages)
+
+	// an error reading the configs is returned
+	resourceTree = s.getTree(s.withStore(nil, errors.New("read failed")))
+	_, err = resourceTree.GetResourcePoolUsagesByOwner("team1")
+	s.Error(err)
+}
+
 func TestPelotonResPool(t *testing.T) {
 	suite.Run(t, new(resTreeTestSuite))
 }
@@ -544,14 +793,21 @@ func (s *resTreeTestSuite) withStore(
 	return mockResPoolOps
 }
 
-// Creates and returns the Tree with respool store
+// Creates and returns the Tree with respool store and a job store which
+// has no jobs
 func (s *resTreeTestSuite) getTree(respoolOps ormobjects.ResPoolOps) *tree {
+	mockJobStore := store_mocks.NewMockJobStore(s.mockCtrl)
+	mockJobStore.EXPECT().
+		GetJobIDsByRespoolID(gomock.Any(), gomock.Any(), "", gomock.Any()).
+		Return(nil, nil).
+		AnyTimes()
+
 	return &tree{
 		respoolOps:  respoolOps,
 		root:        nil,
 		metrics:     NewMetrics(tally.NoopScope),
 		resPools:    make(map[string]ResPool),
-		jobStore:    nil,
+		jobStore:    mockJobStore,
 		taskStore:   nil,
 		scope:       tally.NoopScope,
 		updatedChan: make(chan struct{}, 1),
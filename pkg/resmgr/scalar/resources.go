@@ -153,6 +153,10 @@ type Resources struct {
 	MEMORY float64
 	DISK   float64
 	GPU    float64
+
+	// Custom holds named resources other than the four fixed
+	// dimensions, keyed by resource name. A missing key is zero.
+	Custom map[string]float64
 }
 
 // GetCPU returns the CPU resource
@@ -187,7 +191,7 @@ func (r *Resources) Get(kind string) float64 {
 	case common.DISK:
 		return r.GetDisk()
 	}
-	return float64(0)
+	return r.Custom[kind]
 }
 
 // Set sets the kind of resource with the Value
@@ -201,6 +205,11 @@ func (r *Resources) Set(kind string, value float64) {
 		r.MEMORY = value
 	case common.DISK:
 		r.DISK = value
+	default:
+		if r.Custom == nil {
+			r.Custom = make(map[string]float64)
+		}
+		r.Custom[kind] = value
 	}
 }
 
@@ -211,7 +220,36 @@ func (r *Resources) Add(other *Resources) *Resources {
 		MEMORY: r.MEMORY + other.MEMORY,
 		DISK:   r.DISK + other.DISK,
 		GPU:    r.GPU + other.GPU,
+		Custom: addCustom(r.Custom, other.Custom),
+	}
+}
+
+// addCustom merges two custom resource maps element-wise, summing the
+// values of shared keys. It returns nil if both maps are empty.
+func addCustom(c1, c2 map[string]float64) map[string]float64 {
+	if len(c1) == 0 && len(c2) == 0 {
+		return nil
+	}
+	result := make(map[string]float64, len(c1)+len(c2))
+	for name, v := range c1 {
+		result[name] = v
+	}
+	for name, v := range c2 {
+		result[name] += v
+	}
+	return result
+}
+
+// customNames returns the union of resource names in both custom maps.
+func customNames(c1, c2 map[string]float64) map[string]struct{} {
+	names := make(map[string]struct{}, len(c1)+len(c2))
+	for name := range c1 {
+		names[name] = struct{}{}
+	}
+	for name := range c2 {
+		names[name] = struct{}{}
 	}
+	return names
 }
 
 func lessThanOrEqual(f1, f2 float64) bool {
@@ -228,7 +266,33 @@ func (r *Resources) LessThanOrEqual(other *Resources) bool {
 	return lessThanOrEqual(r.CPU, other.CPU) &&
 		lessThanOrEqual(r.MEMORY, other.MEMORY) &&
 		lessThanOrEqual(r.DISK, other.DISK) &&
-		lessThanOrEqual(r.GPU, other.GPU)
+		lessThanOrEqual(r.GPU, other.GPU) &&
+		r.customLessThanOrEqual(other)
+}
+
+func (r *Resources) customLessThanOrEqual(other *Resources) bool {
+	for name := range customNames(r.Custom, other.Custom) {
+		if !lessThanOrEqual(r.Custom[name], other.Custom[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains determines whether current Resources is large enough to
+// hold the other one, including its custom resources.
+func (r *Resources) Contains(other *Resources) bool {
+	return other.LessThanOrEqual(r)
+}
+
+// TrySubtract subtracts another scalar resources from current one if
+// current one contains it. It returns false without subtracting if any
+// resource, including a custom one, would go below zero.
+func (r *Resources) TrySubtract(other *Resources) (*Resources, bool) {
+	if !r.Contains(other) {
+		return nil, false
+	}
+	return r.Subtract(other), true
 }
 
 func equal(f1, f2 float64) bool {
@@ -241,7 +305,17 @@ func (r *Resources) Equal(other *Resources) bool {
 	return equal(r.CPU, other.CPU) &&
 		equal(r.MEMORY, other.MEMORY) &&
 		equal(r.DISK, other.DISK) &&
-		equal(r.GPU, other.GPU)
+		equal(r.GPU, other.GPU) &&
+		r.customEqual(other)
+}
+
+func (r *Resources) customEqual(other *Resources) bool {
+	for name := range customNames(r.Custom, other.Custom) {
+		if !equal(r.Custom[name], other.Custom[name]) {
+			return false
+		}
+	}
+	return true
 }
 
 // ConvertToResmgrResource converts task resource config to scalar.Resources
@@ -259,8 +333,14 @@ func GetGangResources(gang *resmgrsvc.Gang) *Resources {
 	if gang == nil {
 		return nil
 	}
+	return GetTasksResources(gang.GetTasks())
+}
+
+// GetTasksResources returns the sum of the resources of the tasks, for
+// example the tasks of a gang
+func GetTasksResources(tasks []*resmgr.Task) *Resources {
 	totalRes := &Resources{}
-	for _, task := range gang.GetTasks() {
+	for _, task := range tasks {
 		totalRes = totalRes.Add(
 			ConvertToResmgrResource(task.GetResource()))
 	}
@@ -282,6 +362,29 @@ func Min(r1, r2 *Resources) *Resources {
 	}
 }
 
+// FloorGPU returns a copy of the resources with GPU rounded down to a
+// whole number of GPUs. A GPU value within epsilon of the next whole
+// number is rounded up to it, since it is the result of floating point
+// arithmetic. All the other resources are left untouched.
+func (r *Resources) FloorGPU() *Resources {
+	result := r.Clone()
+	result.GPU = math.Floor(r.GetGPU() + util.ResourceEpsilon)
+	return result
+}
+
+// CeilGPU returns a copy of the resources with GPU rounded up to a
+// whole number of GPUs. A GPU value within epsilon of the previous whole
+// number is rounded down to it, since it is the result of floating point
+// arithmetic. All the other resources are left untouched.
+func (r *Resources) CeilGPU() *Resources {
+	result := r.Clone()
+	result.GPU = math.Ceil(r.GetGPU() - util.ResourceEpsilon)
+	if result.GPU < 0 {
+		result.GPU = 0
+	}
+	return result
+}
+
 // Subtract another scalar resources from current one and return a new copy of result.
 func (r *Resources) Subtract(other *Resources) *Resources {
 	var result Resources
@@ -336,6 +439,24 @@ func (r *Resources) Subtract(other *Resources) *Resources {
 			result.DISK = float64(0)
 		}
 	}
+
+	for name := range customNames(r.Custom, other.Custom) {
+		if result.Custom == nil {
+			result.Custom = make(map[string]float64)
+		}
+		if r.Custom[name] < other.Custom[name] {
+			log.WithFields(log.Fields{
+				"from_" + name:  r.Custom[name],
+				"value_" + name: other.Custom[name],
+			}).Debug("Subtracted Value is Greater")
+			result.Custom[name] = float64(0)
+			continue
+		}
+		result.Custom[name] = r.Custom[name] - other.Custom[name]
+		if result.Custom[name] < util.ResourceEpsilon {
+			result.Custom[name] = float64(0)
+		}
+	}
 	return &result
 }
 
@@ -348,6 +469,7 @@ func (r *Resources) Clone() *Resources {
 		DISK:   r.DISK,
 		MEMORY: r.MEMORY,
 		GPU:    r.GPU,
+		Custom: addCustom(r.Custom, nil),
 	}
 }
 
@@ -358,4 +480,5 @@ func (r *Resources) Copy(other *Resources) {
 	r.DISK = other.DISK
 	r.MEMORY = other.MEMORY
 	r.GPU = other.GPU
+	r.Custom = addCustom(other.Custom, nil)
 }
@@ -35,10 +35,10 @@ func TestAdd(t *testing.T) {
 	}
 
 	result := empty.Add(&empty)
-	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0}, result)
+	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0, nil}, result)
 
 	result = r1.Add(&Resources{})
-	assertEqual(t, &Resources{1.0, 0.0, 0.0, 0.0}, result)
+	assertEqual(t, &Resources{1.0, 0.0, 0.0, 0.0, nil}, result)
 
 	r2 := Resources{
 		CPU:    4.0,
@@ -47,7 +47,72 @@ func TestAdd(t *testing.T) {
 		GPU:    1.0,
 	}
 	result = r1.Add(&r2)
-	assertEqual(t, &Resources{5.0, 3.0, 2.0, 1.0}, result)
+	assertEqual(t, &Resources{5.0, 3.0, 2.0, 1.0, nil}, result)
+}
+
+func TestAddCustomResources(t *testing.T) {
+	r1 := Resources{
+		CPU: 1.0,
+		Custom: map[string]float64{
+			"fpga": 1.0,
+			"nic":  2.0,
+		},
+	}
+	r2 := Resources{
+		CPU: 2.0,
+		Custom: map[string]float64{
+			"nic": 3.0,
+			"ssd": 4.0,
+		},
+	}
+
+	result := r1.Add(&r2)
+	assert.InDelta(t, 3.0, result.GetCPU(), _zeroDelta)
+	assert.Equal(t, map[string]float64{
+		"fpga": 1.0,
+		"nic":  5.0,
+		"ssd":  4.0,
+	}, result.Custom)
+	assert.InDelta(t, 5.0, result.Get("nic"), _zeroDelta)
+
+	// inputs are left untouched
+	assert.Equal(t, map[string]float64{"fpga": 1.0, "nic": 2.0}, r1.Custom)
+	assert.Equal(t, map[string]float64{"nic": 3.0, "ssd": 4.0}, r2.Custom)
+
+	// adding resources without custom resources keeps the map nil
+	empty := Resources{}
+	assert.Nil(t, empty.Add(&Resources{CPU: 1.0}).Custom)
+}
+
+func TestTrySubtractCustomResources(t *testing.T) {
+	r1 := Resources{
+		CPU: 2.0,
+		Custom: map[string]float64{
+			"fpga": 2.0,
+			"nic":  1.0,
+		},
+	}
+
+	result, ok := r1.TrySubtract(&Resources{
+		CPU:    1.0,
+		Custom: map[string]float64{"fpga": 1.5},
+	})
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, result.GetCPU(), _zeroDelta)
+	assert.InDelta(t, 0.5, result.Get("fpga"), _zeroDelta)
+	assert.InDelta(t, 1.0, result.Get("nic"), _zeroDelta)
+
+	// underflow on a shared custom key is detected
+	underflow := &Resources{Custom: map[string]float64{"nic": 2.0}}
+	assert.False(t, r1.Contains(underflow))
+	_, ok = r1.TrySubtract(underflow)
+	assert.False(t, ok)
+
+	// underflow on a custom key missing from r1 is detected
+	missing := &Resources{Custom: map[string]float64{"ssd": 1.0}}
+	assert.False(t, r1.Contains(missing))
+	_, ok = r1.TrySubtract(missing)
+	assert.False(t, ok)
 }
 
 func assertEqual(t *testing.T, expected *Resources, result *Resources) {
@@ -71,7 +136,7 @@ func TestSubtract(t *testing.T) {
 
 	res := r1.Subtract(&empty)
 	assert.NotNil(t, res)
-	assertEqual(t, &Resources{1.0, 2.0, 3.0, 4.0}, res)
+	assertEqual(t, &Resources{1.0, 2.0, 3.0, 4.0, nil}, res)
 
 	r2 := Resources{
 		CPU:    2.0,
@@ -82,10 +147,10 @@ func TestSubtract(t *testing.T) {
 	res = r2.Subtract(&r1)
 
 	assert.NotNil(t, res)
-	assertEqual(t, &Resources{1.0, 3.0, 1.0, 3.0}, res)
+	assertEqual(t, &Resources{1.0, 3.0, 1.0, 3.0, nil}, res)
 
 	res = r1.Subtract(&r2)
-	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0}, res)
+	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0, nil}, res)
 }
 
 func TestSubtractLessThanEpsilon(t *testing.T) {
@@ -103,7 +168,7 @@ func TestSubtractLessThanEpsilon(t *testing.T) {
 	}
 	res := r2.Subtract(&r1)
 	assert.NotNil(t, res)
-	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0}, res)
+	assertEqual(t, &Resources{0.0, 0.0, 0.0, 0.0, nil}, res)
 }
 
 func TestLessThanOrEqual(t *testing.T) {
@@ -154,7 +219,7 @@ func TestConvertToResmgrResource(t *testing.T) {
 		MemLimitMb:  10.0,
 	}
 	res := ConvertToResmgrResource(taskConfig)
-	assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0}, res)
+	assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0, nil}, res)
 }
 
 func TestSet(t *testing.T) {
@@ -164,12 +229,12 @@ func TestSet(t *testing.T) {
 		DISK:   3.0,
 		GPU:    4.0,
 	}
-	assertEqual(t, &Resources{1.0, 2.0, 3.0, 4.0}, &r1)
+	assertEqual(t, &Resources{1.0, 2.0, 3.0, 4.0, nil}, &r1)
 	r1.Set(common.CPU, float64(2.0))
 	r1.Set(common.MEMORY, float64(3.0))
 	r1.Set(common.DISK, float64(4.0))
 	r1.Set(common.GPU, float64(5.0))
-	assertEqual(t, &Resources{2.0, 3.0, 4.0, 5.0}, &r1)
+	assertEqual(t, &Resources{2.0, 3.0, 4.0, 5.0, nil}, &r1)
 }
 
 func TestClone(t *testing.T) {
@@ -304,6 +369,46 @@ func TestMinResources(t *testing.T) {
 	assert.Equal(t, result.GPU, float64(4))
 }
 
+func TestRoundGPU(t *testing.T) {
+	tt := []struct {
+		gpu   float64
+		floor float64
+		ceil  float64
+	}{
+		{gpu: 0, floor: 0, ceil: 0},
+		{gpu: 2, floor: 2, ceil: 2},
+		{gpu: 1.5, floor: 1, ceil: 2},
+		// floating point error around a whole number
+		{gpu: 1.9999, floor: 2, ceil: 2},
+		{gpu: 2.0001, floor: 2, ceil: 2},
+		{gpu: 0.0001, floor: 0, ceil: 0},
+	}
+
+	for _, test := range tt {
+		r := &Resources{
+			CPU:    1.5,
+			MEMORY: 100.25,
+			DISK:   1000.75,
+			GPU:    test.gpu,
+		}
+
+		floor := r.FloorGPU()
+		assert.Equal(t, test.floor, floor.GPU, "floor of %v", test.gpu)
+		assert.Equal(t, 1.5, floor.CPU)
+		assert.Equal(t, 100.25, floor.MEMORY)
+		assert.Equal(t, 1000.75, floor.DISK)
+
+		ceil := r.CeilGPU()
+		assert.Equal(t, test.ceil, ceil.GPU, "ceil of %v", test.gpu)
+		assert.Equal(t, 1.5, ceil.CPU)
+		assert.Equal(t, 100.25, ceil.MEMORY)
+		assert.Equal(t, 1000.75, ceil.DISK)
+
+		// the original resources are not modified
+		assert.Equal(t, test.gpu, r.GPU)
+	}
+}
+
 func TestGetTaskAllocation(t *testing.T) {
 	taskConfig := &task.ResourceConfig{
 		CpuLimit:    4.0,
@@ -359,12 +464,12 @@ func TestGetTaskAllocation(t *testing.T) {
 
 		// total should always be equal to the taskConfig
 		res := alloc.GetByType(TotalAllocation)
-		assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0}, res)
+		assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0, nil}, res)
 
 		// these should be equal to the taskConfig
 		for _, allocType := range test.hasAlloc {
 			res := alloc.GetByType(allocType)
-			assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0}, res)
+			assertEqual(t, &Resources{4.0, 10.0, 5.0, 1.0, nil}, res)
 		}
 
 		// these should be equal to zero
@@ -390,10 +495,60 @@ func TestGetGangResources(t *testing.T) {
 			},
 		},
 	})
-	assertEqual(t, &Resources{1.0, 1.0, 1.0, 1.0}, res)
+	assertEqual(t, &Resources{1.0, 1.0, 1.0, 1.0, nil}, res)
 	assert.Equal(t, "CPU:1.00 MEM:1.00 DISK:1.00 GPU:1.00", res.String())
 }
 
+func TestGetTasksResources(t *testing.T) {
+	assertEqual(t, &Resources{}, GetTasksResources(nil))
+
+	tasks := []*resmgr.Task{
+		{
+			Resource: &task.ResourceConfig{
+				CpuLimit:    1,
+				DiskLimitMb: 10,
+				GpuLimit:    0,
+				MemLimitMb:  100,
+			},
+		},
+		{
+			Resource: &task.ResourceConfig{
+				CpuLimit:    2.5,
+				DiskLimitMb: 20,
+				GpuLimit:    1,
+				MemLimitMb:  200,
+			},
+		},
+		{
+			// a task without resources doesn't add to the total
+		},
+		{
+			Resource: &task.ResourceConfig{
+				CpuLimit:    0.5,
+				DiskLimitMb: 30,
+				GpuLimit:    2,
+				MemLimitMb:  300,
+			},
+		},
+	}
+
+	expected := &Resources{}
+	for _, task := range tasks {
+		expected = expected.Add(ConvertToResmgrResource(task.GetResource()))
+	}
+	res := GetTasksResources(tasks)
+	assertEqual(t, expected, res)
+	assertEqual(t, &Resources{
+		CPU:    4.0,
+		MEMORY: 600.0,
+		DISK:   60.0,
+		GPU:    3.0,
+	}, res)
+
+	// the resources of a gang are the resources of its tasks
+	assertEqual(t, res, GetGangResources(&resmgrsvc.Gang{Tasks: tasks}))
+}
+
 func TestGetGangAllocation(t *testing.T) {
 	res := GetGangAllocation(&resmgrsvc.Gang{
 		Tasks: []*resmgr.Task{
@@ -407,5 +562,5 @@ func TestGetGangAllocation(t *testing.T) {
 			},
 		},
 	})
-	assertEqual(t, &Resources{1.0, 1.0, 1.0, 1.0}, res.GetByType(TotalAllocation))
+	assertEqual(t, &Resources{1.0, 1.0, 1.0, 1.0, nil}, res.GetByType(TotalAllocation))
 }
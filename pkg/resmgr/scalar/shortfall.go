@@ -0,0 +1,105 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/uber/peloton/pkg/common"
+)
+
+// Shortfall is the shortfall of a single resource dimension.
+type Shortfall struct {
+	// Kind is the name of the resource dimension.
+	Kind string
+	// Required is the amount of resource needed.
+	Required float64
+	// Available is the amount of resource available.
+	Available float64
+	// Short is the amount of resource missing, zero if the dimension
+	// is satisfied.
+	Short float64
+}
+
+// ShortfallExplanation explains, per resource dimension, how much of
+// the required resources are missing from the available resources.
+type ShortfallExplanation []Shortfall
+
+// ExplainShortfall returns the shortfall of every resource dimension,
+// including the custom ones, for placing required on available.
+// Dimensions which are satisfied are reported with a zero shortfall.
+func ExplainShortfall(required, available *Resources) ShortfallExplanation {
+	kinds := []string{common.CPU, common.MEMORY, common.DISK, common.GPU}
+	var custom []string
+	for name := range customNames(required.Custom, available.Custom) {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	kinds = append(kinds, custom...)
+
+	explanation := make(ShortfallExplanation, 0, len(kinds))
+	for _, kind := range kinds {
+		s := Shortfall{
+			Kind:      kind,
+			Required:  required.Get(kind),
+			Available: available.Get(kind),
+		}
+		if !lessThanOrEqual(s.Required, s.Available) {
+			s.Short = s.Required - s.Available
+		}
+		explanation = append(explanation, s)
+	}
+	return explanation
+}
+
+// Satisfied returns true if none of the resource dimensions are short.
+func (e ShortfallExplanation) Satisfied() bool {
+	for _, s := range e {
+		if s.Short > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the shortfall of the kind of resource.
+func (e ShortfallExplanation) Get(kind string) float64 {
+	for _, s := range e {
+		if s.Kind == kind {
+			return s.Short
+		}
+	}
+	return 0
+}
+
+// String returns a single line explaining the dimensions which are short,
+// e.g. "cpu: needed 4.00, had 2.00, short by 2.00".
+func (e ShortfallExplanation) String() string {
+	var parts []string
+	for _, s := range e {
+		if s.Short == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf(
+			"%s: needed %.2f, had %.2f, short by %.2f",
+			s.Kind, s.Required, s.Available, s.Short))
+	}
+	if len(parts) == 0 {
+		return "no shortfall"
+	}
+	return strings.Join(parts, "; ")
+}
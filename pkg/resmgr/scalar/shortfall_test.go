@@ -0,0 +1,62 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scalar
+
+import (
+	"testing"
+
+	"github.com/uber/peloton/pkg/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainShortfall(t *testing.T) {
+	required := &Resources{
+		CPU:    4.0,
+		MEMORY: 1024.0,
+		DISK:   100.0,
+		GPU:    2.0,
+		Custom: map[string]float64{"fpga": 1.0},
+	}
+	available := &Resources{
+		CPU:    2.5,
+		MEMORY: 2048.0,
+		DISK:   100.0,
+		GPU:    0.5,
+	}
+
+	explanation := ExplainShortfall(required, available)
+	assert.False(t, explanation.Satisfied())
+	assert.Len(t, explanation, 5)
+	assert.InDelta(t, 1.5, explanation.Get(common.CPU), _zeroDelta)
+	assert.InDelta(t, 1.5, explanation.Get(common.GPU), _zeroDelta)
+	assert.InDelta(t, 1.0, explanation.Get("fpga"), _zeroDelta)
+	assert.Zero(t, explanation.Get(common.MEMORY))
+	assert.Zero(t, explanation.Get(common.DISK))
+	assert.Equal(t,
+		"cpu: needed 4.00, had 2.50, short by 1.50; "+
+			"gpu: needed 2.00, had 0.50, short by 1.50; "+
+			"fpga: needed 1.00, had 0.00, short by 1.00",
+		explanation.String())
+
+	// A difference within epsilon is not a shortfall.
+	explanation = ExplainShortfall(
+		&Resources{CPU: 1.0005, MEMORY: 10.0},
+		&Resources{CPU: 1.0, MEMORY: 20.0},
+	)
+	assert.True(t, explanation.Satisfied())
+	assert.Zero(t, explanation.Get(common.CPU))
+	assert.Equal(t, "no shortfall", explanation.String())
+}
@@ -14,7 +14,11 @@
 
 package cassandra
 
-import "github.com/uber/peloton/pkg/storage/cassandra/impl"
+import (
+	"time"
+
+	"github.com/uber/peloton/pkg/storage/cassandra/impl"
+)
 
 // Replica is the config for Cassandra replicas
 type Replica struct {
@@ -44,9 +48,32 @@ type Config struct {
 	Migrations    string              `yaml:"migrations"`
 	// MaxParallelBatches controls the maximum number of go routines run to create tasks
 	MaxParallelBatches int `yaml:"max_parallel_batches"`
+	// MaxBatchSize controls the maximum number of task runtimes written
+	// in a single batch when creating tasks
+	MaxBatchSize int `yaml:"max_batch_size"`
+	// MaxParallelJobReads controls the maximum number of jobs whose
+	// tasks are read concurrently when bulk loading tasks
+	MaxParallelJobReads int `yaml:"max_parallel_job_reads"`
 	// MaxUpdatesPerJob controls the maximum number of
 	// updates per job kept in the database
 	MaxUpdatesPerJob int `yaml:"max_updates_job"`
 	// Replication controls the replication config of the keyspace
 	Replication *Replication `yaml:"replication"`
+	// JobConfigCacheSize controls the maximum number of job configs
+	// cached in process. Caching is disabled if it is zero.
+	JobConfigCacheSize int `yaml:"job_config_cache_size"`
+	// JobStateHistorySize controls the maximum number of state transitions
+	// kept in the state history of a job. A default is used if it is zero.
+	JobStateHistorySize int `yaml:"job_state_history_size"`
+	// AllowRollback allows rolling back schema migrations, which drops
+	// data. It must only be set in test or staging environments.
+	AllowRollback bool `yaml:"allow_rollback"`
+	// MaxRetryAttempts controls the maximum number of attempts to execute
+	// a statement failing with a transient error. A default is used if it
+	// is zero.
+	MaxRetryAttempts int `yaml:"max_retry_attempts"`
+	// RetryBackoff controls the delay before the first retry of a
+	// statement, which doubles after every retry. A default is used if it
+	// is zero.
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
 }
@@ -0,0 +1,53 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestQuoteLuceneValue tests that values embedded in a lucene query are
+// escaped so that they can neither break nor extend the query.
+func TestQuoteLuceneValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "plain", expected: `"plain"`},
+		{value: `"quoted"`, expected: `"\"quoted\""`},
+		{value: `back\slash`, expected: `"back\\slash"`},
+		{value: `x"}, {type: "match", field:"owner", value:"*"}`,
+			expected: `"x\"}, {type: \"match\", field:\"owner\", value:\"*\"}"`},
+		{value: "it's", expected: `"it''s"`},
+		{value: "line\nbreak\x00", expected: `"line\nbreak\u0000"`},
+	}
+
+	for _, tt := range tests {
+		quoted := quoteLuceneValue(tt.value)
+		assert.Equal(t, tt.expected, quoted)
+
+		// Once unwrapped from the CQL string literal, the quoted value
+		// must be a single JSON string decoding back to the original.
+		var decoded string
+		err := json.Unmarshal(
+			[]byte(strings.Replace(quoted, "''", "'", -1)), &decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.value, decoded)
+	}
+}
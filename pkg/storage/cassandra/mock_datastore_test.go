@@ -18,6 +18,8 @@ package cassandra
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,8 +27,11 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
+	"github.com/uber/peloton/.gen/peloton/api/v0/volume"
 	"github.com/uber/peloton/.gen/peloton/private/models"
 	"github.com/uber/peloton/pkg/common"
+	"github.com/uber/peloton/pkg/common/backoff"
+	"github.com/uber/peloton/pkg/common/util"
 	"github.com/uber/peloton/pkg/storage"
 	datastore "github.com/uber/peloton/pkg/storage/cassandra/api"
 	datastoremocks "github.com/uber/peloton/pkg/storage/cassandra/api/mocks"
@@ -34,10 +39,13 @@ import (
 	objectmocks "github.com/uber/peloton/pkg/storage/objects/mocks"
 	qb "github.com/uber/peloton/pkg/storage/querybuilder"
 
+	"github.com/gocql/gocql"
 	"github.com/golang/mock/gomock"
 	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 const (
@@ -295,3 +303,477 @@ func (suite *MockDatastoreTestSuite) TestDataStoreFailureGetTaskConfigs() {
 	_, _, err := suite.store.GetTaskConfigs(ctx, suite.testJobID, []uint32{0}, 0)
 	suite.Error(err)
 }
+
+// TestCreateTasksConcurrencyLimit tests that CreateTasks does not write
+// more than MaxParallelBatches batches at the same time
+func (suite *MockDatastoreTestSuite) TestCreateTasksConcurrencyLimit() {
+	const (
+		instanceCount      = 5000
+		maxBatchSize       = 10
+		maxParallelBatches = 4
+	)
+
+	var result datastore.ResultSet
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf: &Config{
+			MaxBatchSize:       maxBatchSize,
+			MaxParallelBatches: maxParallelBatches,
+		},
+	}
+
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+	// pod event writes
+	mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+		Return(result, nil).Times(instanceCount)
+
+	var running, maxRunning int32
+	mockedDataStore.EXPECT().ExecuteBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			ctx context.Context, stmts []datastore.Statement) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			suite.True(len(stmts) <= maxBatchSize)
+			time.Sleep(time.Millisecond)
+			return nil
+		}).Times(instanceCount / maxBatchSize)
+
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < instanceCount; i++ {
+		runtimes[i] = &task.RuntimeInfo{
+			State:       task.TaskState_INITIALIZED,
+			MesosTaskId: util.CreateMesosTaskID(suite.testJobID, i, 1),
+		}
+	}
+
+	suite.NoError(store.CreateTasks(
+		context.Background(), suite.testJobID, runtimes, "owner"))
+	suite.True(maxRunning > 0)
+	suite.True(maxRunning <= maxParallelBatches)
+}
+
+// TestWriteTasksPartialFailure tests that CreateTasks and UpdateTasks
+// write the tasks of each job in their own batches, and return an error
+// naming the number of tasks not written when some of the batches fail
+func (suite *MockDatastoreTestSuite) TestWriteTasksPartialFailure() {
+	const maxBatchSize = 10
+	otherJobID := &peloton.JobID{Value: uuid.New()}
+
+	newTaskInfos := func(
+		jobID *peloton.JobID,
+		instanceCount uint32,
+	) []*task.TaskInfo {
+		var taskInfos []*task.TaskInfo
+		for i := uint32(0); i < instanceCount; i++ {
+			taskInfos = append(taskInfos, &task.TaskInfo{
+				JobId:      jobID,
+				InstanceId: i,
+				Runtime: &task.RuntimeInfo{
+					State: task.TaskState_RUNNING,
+				},
+			})
+		}
+		return taskInfos
+	}
+
+	tests := []struct {
+		name string
+		// write writes the tasks with the store
+		write func(store *Store) error
+		// batches is the number of batches expected to be written
+		batches int
+		// fail tells if the n-th batch written, of the given size, fails
+		fail        func(n int32, size int) bool
+		expectedErr string
+	}{
+		{
+			name: "create fails one batch",
+			write: func(store *Store) error {
+				runtimes := make(map[uint32]*task.RuntimeInfo)
+				for i := uint32(0); i < 50; i++ {
+					runtimes[i] = &task.RuntimeInfo{
+						State: task.TaskState_INITIALIZED,
+					}
+				}
+				return store.CreateTasks(
+					context.Background(), suite.testJobID, runtimes, "owner")
+			},
+			batches: 5,
+			fail: func(n int32, size int) bool {
+				return n == 1
+			},
+			expectedErr: "failed to create 10 of 50 tasks",
+		},
+		{
+			// the 12 tasks of each job are written in a batch of 10
+			// tasks and a batch of 2 tasks, which fail
+			name: "update batches tasks by job",
+			write: func(store *Store) error {
+				return store.UpdateTasks(
+					context.Background(),
+					append(
+						newTaskInfos(suite.testJobID, 12),
+						newTaskInfos(otherJobID, 12)...,
+					),
+				)
+			},
+			batches: 4,
+			fail: func(n int32, size int) bool {
+				return size == 2
+			},
+			expectedErr: "failed to update 4 of 24 tasks",
+		},
+	}
+
+	for _, tt := range tests {
+		var result datastore.ResultSet
+		mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+		store := &Store{
+			DataStore: mockedDataStore,
+			metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+			Conf: &Config{
+				MaxBatchSize: maxBatchSize,
+			},
+		}
+
+		mockedDataStore.EXPECT().NewQuery().
+			Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+		// the failed batches are reported over the failed pod events
+		mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+			Return(result, errors.New("my-error")).AnyTimes()
+
+		var batches int32
+		mockedDataStore.EXPECT().ExecuteBatch(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context, stmts []datastore.Statement) error {
+				suite.True(len(stmts) <= maxBatchSize, tt.name)
+				if tt.fail(atomic.AddInt32(&batches, 1), len(stmts)) {
+					return errors.New("my-error")
+				}
+				return nil
+			}).Times(tt.batches)
+
+		err := tt.write(store)
+		suite.Error(err, tt.name)
+		suite.True(yarpcerrors.IsInternal(err), tt.name)
+		suite.Contains(err.Error(), tt.expectedErr, tt.name)
+	}
+}
+
+// TestWriteTasksPodEventFailure tests that CreateTasks and UpdateTasks
+// return the error of logging the state change of a written task
+func (suite *MockDatastoreTestSuite) TestWriteTasksPodEventFailure() {
+	var result datastore.ResultSet
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf:      &Config{},
+	}
+
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+	mockedDataStore.EXPECT().ExecuteBatch(gomock.Any(), gomock.Any()).
+		Return(nil).Times(2)
+	mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+		Return(result, errors.New("my-error")).Times(2)
+
+	runtime := &task.RuntimeInfo{
+		State:       task.TaskState_RUNNING,
+		MesosTaskId: util.CreateMesosTaskID(suite.testJobID, 0, 1),
+	}
+
+	err := store.CreateTasks(
+		context.Background(),
+		suite.testJobID,
+		map[uint32]*task.RuntimeInfo{0: runtime},
+		"owner")
+	suite.EqualError(err, "my-error")
+
+	err = store.UpdateTasks(
+		context.Background(),
+		[]*task.TaskInfo{{
+			JobId:      suite.testJobID,
+			InstanceId: 0,
+			Runtime:    runtime,
+		}})
+	suite.EqualError(err, "my-error")
+}
+
+// TestGetTasksByIDsBatching tests that GetTasksByIDs reads the tasks of a
+// job with one query per batch of instances rather than one per instance
+func (suite *MockDatastoreTestSuite) TestGetTasksByIDsBatching() {
+	const (
+		instanceCount  = 250
+		otherJobTasks  = 10
+		expectedChunks = 4
+	)
+
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf:      &Config{},
+	}
+
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+	mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			ctx context.Context,
+			stmt datastore.Statement) (datastore.ResultSet, error) {
+			result := datastoremocks.NewMockResultSet(suite.ctrl)
+			result.EXPECT().All(gomock.Any()).
+				Return([]map[string]interface{}{}, nil)
+			result.EXPECT().Close().Return(nil)
+			return result, nil
+		}).Times(expectedChunks)
+
+	var taskIDs []string
+	for i := 0; i < instanceCount; i++ {
+		taskIDs = append(taskIDs, fmt.Sprintf(taskIDFmt, testJob, i))
+	}
+	otherJob := uuid.New()
+	for i := 0; i < otherJobTasks; i++ {
+		taskIDs = append(taskIDs, fmt.Sprintf(taskIDFmt, otherJob, i))
+	}
+
+	found, notFound, err := store.GetTasksByIDs(context.Background(), taskIDs)
+	suite.NoError(err)
+	suite.Empty(found)
+	suite.Equal(taskIDs, notFound)
+}
+
+// TestGetTasksForJobByTerminalityBatching tests that
+// GetTasksForJobByTerminality reads the runtimes of the matching instances
+// with one query per batch of instances rather than one per instance
+func (suite *MockDatastoreTestSuite) TestGetTasksForJobByTerminalityBatching() {
+	const (
+		instanceCount  = 150
+		expectedChunks = 2
+	)
+
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf:      &Config{},
+	}
+
+	var instances []map[string]interface{}
+	for i := 0; i < instanceCount; i++ {
+		instances = append(instances, map[string]interface{}{
+			"instance_id": i,
+		})
+	}
+
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+	viewResult := datastoremocks.NewMockResultSet(suite.ctrl)
+	viewResult.EXPECT().All(gomock.Any()).Return(instances, nil)
+	viewResult.EXPECT().Close().Return(nil)
+	gomock.InOrder(
+		mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+			Return(viewResult, nil),
+		mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(
+				ctx context.Context,
+				stmt datastore.Statement) (datastore.ResultSet, error) {
+				result := datastoremocks.NewMockResultSet(suite.ctrl)
+				result.EXPECT().All(gomock.Any()).
+					Return([]map[string]interface{}{}, nil)
+				result.EXPECT().Close().Return(nil)
+				return result, nil
+			}).Times(expectedChunks),
+	)
+
+	tasks, err := store.GetTasksForJobByTerminality(
+		context.Background(), suite.testJobID, false)
+	suite.NoError(err)
+	suite.Empty(tasks)
+}
+
+// TestGetTasksForJobsStopsOnError tests that GetTasksForJobs does not read
+// more jobs once a read has failed or the context is done
+func (suite *MockDatastoreTestSuite) TestGetTasksForJobsStopsOnError() {
+	var jobIDs []*peloton.JobID
+	for i := 0; i < 5; i++ {
+		jobIDs = append(jobIDs, &peloton.JobID{Value: uuid.New()})
+	}
+
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf: &Config{
+			MaxParallelJobReads: 1,
+		},
+	}
+
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+	// only the first job is read
+	mockedDataStore.EXPECT().Execute(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("my-error")).Times(1)
+
+	tasks, err := store.GetTasksForJobs(context.Background(), jobIDs)
+	suite.Error(err)
+	suite.Nil(tasks)
+
+	// no job is read with a cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tasks, err = store.GetTasksForJobs(ctx, jobIDs)
+	suite.True(yarpcerrors.IsCancelled(err))
+	suite.Nil(tasks)
+}
+
+// TestDataStoreDoneContext tests that reads and writes with a cancelled or
+// expired context fail without sending the query to the data store
+func (suite *MockDatastoreTestSuite) TestDataStoreDoneContext() {
+	// Execute and ExecuteBatch are not expected to be called
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore: mockedDataStore,
+		metrics:   storage.NewMetrics(testScope.SubScope("storage")),
+		Conf:      &Config{},
+	}
+	mockedDataStore.EXPECT().NewQuery().
+		Return(&datastoreimpl.QueryBuilder{}).AnyTimes()
+
+	volumeID := &peloton.VolumeID{Value: "test"}
+	pv := &volume.PersistentVolumeInfo{Id: volumeID}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := store.GetPersistentVolume(ctx, volumeID)
+	suite.True(yarpcerrors.IsCancelled(err))
+	err = store.UpdatePersistentVolume(ctx, pv)
+	suite.True(yarpcerrors.IsCancelled(err))
+	// batches are not sent either
+	err = store.CreateTasks(ctx, suite.testJobID,
+		map[uint32]*task.RuntimeInfo{
+			0: {State: task.TaskState_INITIALIZED},
+		}, "owner")
+	suite.Error(err)
+
+	ctx, cancel = context.WithDeadline(
+		context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = store.GetPersistentVolume(ctx, volumeID)
+	suite.True(yarpcerrors.IsDeadlineExceeded(err))
+	err = store.UpdatePersistentVolume(ctx, pv)
+	suite.True(yarpcerrors.IsDeadlineExceeded(err))
+}
+
+// TestApplyStatementRetry tests that applyStatement retries statements
+// failing with a transient error, and does not retry other errors
+func (suite *MockDatastoreTestSuite) TestApplyStatementRetry() {
+	var result datastore.ResultSet
+	scope := tally.NewTestScope("", map[string]string{})
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore:   mockedDataStore,
+		metrics:     storage.NewMetrics(scope.SubScope("storage")),
+		Conf:        &Config{},
+		retryPolicy: backoff.NewExponentialRetryPolicy(5, time.Millisecond),
+	}
+	stmt := (&datastoreimpl.QueryBuilder{}).Insert(volumeTable).
+		Columns("volume_id").
+		Values("test")
+
+	// fails twice with a transient error then succeeds
+	gomock.InOrder(
+		mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+			Return(result, &gocql.RequestErrWriteTimeout{}).
+			Times(2),
+		mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+			Return(result, nil),
+	)
+	suite.NoError(store.applyStatement(context.Background(), stmt, "test"))
+	suite.Equal(int64(2), scope.Snapshot().
+		Counters()["storage.storage_error.retry+"].Value())
+
+	// logical failures are not retried
+	mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+		Return(result, &gocql.RequestErrWriteFailure{})
+	err := store.applyStatement(context.Background(), stmt, "test")
+	suite.True(yarpcerrors.IsAborted(err))
+	suite.Equal(int64(2), scope.Snapshot().
+		Counters()["storage.storage_error.retry+"].Value())
+}
+
+// TestExecuteIterRetry tests that executeIter retries reads failing with a
+// transient error, timeouts included, and does not retry other errors
+func (suite *MockDatastoreTestSuite) TestExecuteIterRetry() {
+	scope := tally.NewTestScope("", map[string]string{})
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore:   mockedDataStore,
+		metrics:     storage.NewMetrics(scope.SubScope("storage")),
+		Conf:        &Config{},
+		retryPolicy: backoff.NewExponentialRetryPolicy(5, time.Millisecond),
+	}
+	stmt := (&datastoreimpl.QueryBuilder{}).Select("*").
+		From(taskRuntimeTable).
+		Where(qb.Eq{"job_id": testJob})
+
+	// times out twice then succeeds
+	result := datastoremocks.NewMockResultSet(suite.ctrl)
+	gomock.InOrder(
+		mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+			Return(nil, &gocql.RequestErrReadTimeout{}).
+			Times(2),
+		mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+			Return(result, nil),
+	)
+	iter, err := store.executeIter(context.Background(), stmt)
+	suite.NoError(err)
+	suite.Equal(result, iter)
+	suite.Equal(int64(2), scope.Snapshot().
+		Counters()["storage.storage_error.retry+"].Value())
+
+	// logical failures are not retried
+	mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+		Return(nil, &gocql.RequestErrReadFailure{})
+	_, err = store.executeIter(context.Background(), stmt)
+	suite.True(yarpcerrors.IsAborted(err))
+	suite.Equal(int64(2), scope.Snapshot().
+		Counters()["storage.storage_error.retry+"].Value())
+}
+
+// TestApplyStatementCASTimeout tests that a conditional statement which
+// timed out is not retried, as it may have been applied
+func (suite *MockDatastoreTestSuite) TestApplyStatementCASTimeout() {
+	var result datastore.ResultSet
+	scope := tally.NewTestScope("", map[string]string{})
+	mockedDataStore := datastoremocks.NewMockDataStore(suite.ctrl)
+	store := &Store{
+		DataStore:   mockedDataStore,
+		metrics:     storage.NewMetrics(scope.SubScope("storage")),
+		Conf:        &Config{},
+		retryPolicy: backoff.NewExponentialRetryPolicy(5, time.Millisecond),
+	}
+	stmt := (&datastoreimpl.QueryBuilder{}).Insert(volumeTable).
+		Columns("volume_id").
+		Values("test").
+		IfNotExist()
+
+	for _, writeType := range []string{"CAS", "SIMPLE"} {
+		mockedDataStore.EXPECT().Execute(gomock.Any(), stmt).
+			Return(result, &gocql.RequestErrWriteTimeout{WriteType: writeType})
+		err := store.applyStatement(context.Background(), stmt, "test")
+		suite.True(yarpcerrors.IsDeadlineExceeded(err))
+	}
+	suite.Equal(int64(0), scope.Snapshot().
+		Counters()["storage.storage_error.retry+"].Value())
+}
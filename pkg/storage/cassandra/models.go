@@ -15,6 +15,7 @@
 package cassandra
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -293,12 +294,30 @@ type JobRuntimeRecord struct {
 	State       string            `cql:"state"`
 	UpdateTime  time.Time         `cql:"update_time"`
 	RuntimeInfo []byte            `cql:"runtime_info"`
+	TaskStats   string            `cql:"task_stats"`
 }
 
-// GetJobRuntime returns the job.Runtime from a JobRecord table record
+// GetJobRuntime returns the job.Runtime from a JobRecord table record.
+// The state and task stats columns take precedence over the ones in the
+// runtime blob, as they may be updated independently of the blob.
 func (t *JobRuntimeRecord) GetJobRuntime() (*job.RuntimeInfo, error) {
 	runtime := &job.RuntimeInfo{}
-	return runtime, proto.Unmarshal(t.RuntimeInfo, runtime)
+	if err := proto.Unmarshal(t.RuntimeInfo, runtime); err != nil {
+		return nil, err
+	}
+
+	if state, ok := job.JobState_value[t.State]; ok {
+		runtime.State = job.JobState(state)
+	}
+
+	if len(t.TaskStats) != 0 {
+		taskStats := make(map[string]uint32)
+		if err := json.Unmarshal([]byte(t.TaskStats), &taskStats); err != nil {
+			return nil, err
+		}
+		runtime.TaskStats = taskStats
+	}
+	return runtime, nil
 }
 
 // PersistentVolumeRecord contains persistent volume info.
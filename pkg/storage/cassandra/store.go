@@ -26,11 +26,15 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/uber/peloton/.gen/peloton/api/v0/changelog"
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
 	pb_volume "github.com/uber/peloton/.gen/peloton/api/v0/volume"
@@ -69,6 +73,7 @@ const (
 	jobConfigTable         = "job_config"
 	jobRuntimeTable        = "job_runtime"
 	jobIndexTable          = "job_index"
+	jobsByUpdateTimeTable  = "job_index_by_update_time"
 	taskConfigV2Table      = "task_config_v2"
 	taskConfigTable        = "task_config"
 	taskRuntimeTable       = "task_runtime"
@@ -76,8 +81,16 @@ const (
 	updatesTable           = "update_info"
 	podWorkflowEventsTable = "pod_workflow_events"
 	frameworksTable        = "frameworks"
+	tasksByStateView       = "mv_task_by_state"
 	updatesByJobView       = "mv_updates_by_job"
+	jobsByRespoolView      = "mv_job_index_by_respool"
+	jobsByOwnerView        = "mv_job_index_by_owner"
+	jobMetadataTable       = "job_metadata"
+	jobsByMetadataView     = "mv_job_metadata_by_key_value"
 	volumeTable            = "persistent_volumes"
+	volumesByJobView       = "mv_volumes_by_job"
+	volumesByStateView     = "mv_volumes_by_state"
+	respoolsTable          = "respools"
 
 	// DB field names
 	creationTimeField   = "creation_time"
@@ -100,10 +113,56 @@ const (
 	jobQueryDefaultSpanInDays = 7
 	jobQueryJitter            = time.Second * 30
 
+	// _defaultMaxBatchSize is the default number of task runtimes
+	// written in a single batch by CreateTasks
+	_defaultMaxBatchSize = 20
+
+	// _defaultMaxParallelBatches is the default number of batches
+	// CreateTasks writes concurrently
+	_defaultMaxParallelBatches = 1000
+
+	// _defaultMaxParallelJobReads is the default number of jobs
+	// GetTasksForJobs reads concurrently
+	_defaultMaxParallelJobReads = 20
+
+	// _defaultTaskSummaryJobBatchSize is the number of jobs read by a
+	// single query in GetTaskStateSummaryForJobs
+	_defaultTaskSummaryJobBatchSize = 20
+
+	// _defaultJobRuntimeBatchSize is the number of jobs read by a single
+	// query in GetJobRuntimes
+	_defaultJobRuntimeBatchSize = 20
+
+	// _defaultGetAllJobsPageSize is the number of jobs read by a single
+	// page of the job index scan in GetAllJobs
+	_defaultGetAllJobsPageSize = 1000
+
+	// _defaultJobsByOwnerBatchSize is the number of job keys read by a
+	// single query of the job index view by owner in GetJobsByOwnerPaged
+	_defaultJobsByOwnerBatchSize = 1000
+
+	// _defaultTaskIDBatchSize is the number of instances of a job read by
+	// a single query in GetTasksByIDs and GetTasksForJobByTerminality
+	_defaultTaskIDBatchSize = 100
+
 	// _defaultPodEventsLimit is default number of pod events
 	// to read if not provided for jobID + instanceID
 	_defaultPodEventsLimit = 100
 
+	// _casWriteType and _counterWriteType are the types of the writes
+	// which are not retried when they time out, as they may have been
+	// applied already
+	_casWriteType     = "CAS"
+	_counterWriteType = "COUNTER"
+
+	// _defaultMaxRetryAttempts is the default number of attempts to
+	// execute a statement failing with a transient error
+	_defaultMaxRetryAttempts = 5
+
+	// _defaultRetryBackoff is the default delay before the first retry
+	// of a statement
+	_defaultRetryBackoff = 50 * time.Millisecond
+
 	// Default context timeout for the method to cleanup old
 	// job updates from the storage
 	_jobUpdatesCleanupTimeout = 120 * time.Second
@@ -158,12 +217,23 @@ func ToOrmConfig(c *Config) *ormcassandra.Config {
 			CQLVersion:         c.CassandraConn.CQLVersion,
 			MaxGoRoutines:      c.CassandraConn.MaxGoRoutines,
 		},
-		StoreName: c.StoreName,
+		StoreName:           c.StoreName,
+		JobConfigCacheSize:  c.JobConfigCacheSize,
+		JobStateHistorySize: c.JobStateHistorySize,
 	}
 }
 
 type luceneClauses []string
 
+// quoteLuceneValue quotes a value so that it can be embedded in a lucene
+// index query. The value is escaped as a JSON string, which handles quotes,
+// backslashes and control characters, and single quotes are doubled since
+// the whole lucene query is itself a CQL string literal.
+func quoteLuceneValue(value string) string {
+	buf, _ := json.Marshal(value)
+	return strings.Replace(string(buf), "'", "''", -1)
+}
+
 // AutoMigrate migrates the db schemas for cassandra
 func (c *Config) AutoMigrate() []error {
 	connString := c.MigrateString()
@@ -176,6 +246,32 @@ func (c *Config) AutoMigrate() []error {
 	return nil
 }
 
+// Rollback rolls back the last n db schema migrations for cassandra. It is
+// refused unless AllowRollback is set in the config, since the down
+// migrations drop tables and their data.
+func (c *Config) Rollback(n int) []error {
+	if !c.AllowRollback {
+		return []error{errors.New(
+			"rollback of schema migrations is not allowed by the config")}
+	}
+	if n <= 0 {
+		return []error{errors.Errorf(
+			"number of migrations to roll back %d is not valid", n)}
+	}
+
+	connString := c.MigrateString()
+	errs, ok := migrate.MigrateSync(connString, c.Migrations, -n)
+	if !ok || len(errs) > 0 {
+		if len(errs) == 0 {
+			errs = []error{errors.New("rollback of schema migrations failed")}
+		}
+		log.Errorf("Rollback failed with errors: %v", errs)
+		return errs
+	}
+	log.WithField("steps", n).Info("Rollback complete")
+	return nil
+}
+
 // MigrateString returns the db string required for database migration
 // The code assumes that the keyspace (indicated by StoreName) is already created
 func (c *Config) MigrateString() string {
@@ -199,6 +295,18 @@ func (c *Config) MigrateString() string {
 	return connStr
 }
 
+// TaskReadSource selects where the tasks of a job are read from.
+type TaskReadSource int
+
+const (
+	// TaskReadFromView reads the tasks using a materialized view, which is
+	// cheaper but eventually consistent with the task runtime table.
+	TaskReadFromView TaskReadSource = iota
+	// TaskReadFromBaseTable reads the tasks from the task runtime table by
+	// instance range, which reflects all the previous writes.
+	TaskReadFromBaseTable
+)
+
 // Store implements JobStore, TaskStore, UpdateStore, FrameworkInfoStore,
 // and PersistentVolumeStore using a cassandra backend
 // TODO: Break this up into different files (and or structs) that implement
@@ -240,15 +348,38 @@ func NewStore(config *Config, scope tally.Scope) (*Store, error) {
 
 		metrics:     storage.NewMetrics(scope.SubScope("storage")),
 		Conf:        config,
-		retryPolicy: backoff.NewRetryPolicy(5, 50*time.Millisecond),
+		retryPolicy: newRetryPolicy(config),
 	}, nil
 }
 
-func (s *Store) handleDataStoreError(err error, p backoff.Retrier) error {
+// newRetryPolicy returns the policy to retry statements failing with a
+// transient error, as configured by MaxRetryAttempts and RetryBackoff.
+func newRetryPolicy(config *Config) backoff.RetryPolicy {
+	maxAttempts := config.MaxRetryAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = _defaultMaxRetryAttempts
+	}
+	retryBackoff := config.RetryBackoff
+	if retryBackoff <= 0 {
+		retryBackoff = _defaultRetryBackoff
+	}
+	return backoff.NewExponentialRetryPolicy(maxAttempts, retryBackoff)
+}
+
+// handleDataStoreError converts a data store error to a yarpc error, and
+// returns nil if the statement should be retried. Timeouts are only retried
+// if retryTimeouts is set, as a statement which timed out may have been
+// applied and retrying conditional or non-idempotent statements would fail
+// or apply them twice.
+func (s *Store) handleDataStoreError(
+	err error,
+	p backoff.Retrier,
+	retryTimeouts bool,
+) error {
 	retry := false
 	newErr := err
 
-	switch err.(type) {
+	switch e := err.(type) {
 	// TBD handle errOverloaded and errBootstrapping after error types added in gocql
 	case *gocql.RequestErrReadFailure:
 		s.metrics.ErrorMetrics.ReadFailure.Inc(1)
@@ -261,10 +392,14 @@ func (s *Store) handleDataStoreError(err error, p backoff.Retrier) error {
 		return yarpcerrors.AlreadyExistsErrorf("already exists error during statement execution %v", err.Error())
 	case *gocql.RequestErrReadTimeout:
 		s.metrics.ErrorMetrics.ReadTimeout.Inc(1)
-		return yarpcerrors.DeadlineExceededErrorf("read timeout during statement execution: %v", err.Error())
+		retry = retryTimeouts
+		newErr = yarpcerrors.DeadlineExceededErrorf("read timeout during statement execution: %v", err.Error())
 	case *gocql.RequestErrWriteTimeout:
 		s.metrics.ErrorMetrics.WriteTimeout.Inc(1)
-		return yarpcerrors.DeadlineExceededErrorf("write timeout during statement execution: %v", err.Error())
+		retry = retryTimeouts &&
+			e.WriteType != _casWriteType &&
+			e.WriteType != _counterWriteType
+		newErr = yarpcerrors.DeadlineExceededErrorf("write timeout during statement execution: %v", err.Error())
 	case *gocql.RequestErrUnavailable:
 		s.metrics.ErrorMetrics.RequestUnavailable.Inc(1)
 		retry = true
@@ -274,7 +409,8 @@ func (s *Store) handleDataStoreError(err error, p backoff.Retrier) error {
 	switch err {
 	case gocql.ErrTooManyTimeouts:
 		s.metrics.ErrorMetrics.TooManyTimeouts.Inc(1)
-		return yarpcerrors.DeadlineExceededErrorf("too many timeouts during statement execution: %v", err.Error())
+		retry = retryTimeouts
+		newErr = yarpcerrors.DeadlineExceededErrorf("too many timeouts during statement execution: %v", err.Error())
 	case gocql.ErrUnavailable:
 		s.metrics.ErrorMetrics.ConnUnavailable.Inc(1)
 		retry = true
@@ -299,6 +435,7 @@ func (s *Store) handleDataStoreError(err error, p backoff.Retrier) error {
 
 	if retry {
 		if backoff.CheckRetry(p) {
+			s.metrics.ErrorMetrics.Retry.Inc(1)
 			return nil
 		}
 		return newErr
@@ -307,14 +444,35 @@ func (s *Store) handleDataStoreError(err error, p backoff.Retrier) error {
 	return newErr
 }
 
+// contextError returns an error if the context is already cancelled or
+// past its deadline, so that no query is sent to Cassandra for a request
+// which has been abandoned by the caller.
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return yarpcerrors.DeadlineExceededErrorf(
+			"context deadline exceeded before statement execution")
+	default:
+		return yarpcerrors.CancelledErrorf(
+			"context cancelled before statement execution")
+	}
+}
+
 func (s *Store) executeWrite(ctx context.Context, stmt api.Statement) (api.ResultSet, error) {
 	p := backoff.NewRetrier(s.retryPolicy)
 	for {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
 		result, err := s.DataStore.Execute(ctx, stmt)
 		if err == nil {
 			return result, err
 		}
-		err = s.handleDataStoreError(err, p)
+		// A conditional statement which timed out may have been applied,
+		// and retrying it would then report that it was not applied.
+		err = s.handleDataStoreError(err, p, !stmt.IsCAS())
 
 		if err != nil {
 			if !common.IsTransientError(err) {
@@ -325,11 +483,51 @@ func (s *Store) executeWrite(ctx context.Context, stmt api.Statement) (api.Resul
 	}
 }
 
+// executeBatch executes the statements as a single batch, unless the
+// context has already been cancelled.
+func (s *Store) executeBatch(ctx context.Context, stmts []api.Statement) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	return s.DataStore.ExecuteBatch(ctx, stmts)
+}
+
+// executeIter executes a read statement, retrying transient errors like
+// executeRead does, and returns its result set without reading it, so that
+// the caller can iterate over the rows with Next. The caller must close the
+// result set.
+func (s *Store) executeIter(
+	ctx context.Context,
+	stmt api.Statement) (api.ResultSet, error) {
+	p := backoff.NewRetrier(s.retryPolicy)
+	for {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
+		result, err := s.DataStore.Execute(ctx, stmt)
+		if err == nil {
+			return result, nil
+		}
+		// reads are idempotent, so timed out statements are retried too
+		err = s.handleDataStoreError(err, p, true)
+
+		if err != nil {
+			if !common.IsTransientError(err) {
+				s.metrics.ErrorMetrics.NotTransient.Inc(1)
+			}
+			return nil, err
+		}
+	}
+}
+
 func (s *Store) executeRead(
 	ctx context.Context,
 	stmt api.Statement) ([]map[string]interface{}, error) {
 	p := backoff.NewRetrier(s.retryPolicy)
 	for {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
 		result, err := s.DataStore.Execute(ctx, stmt)
 		if err == nil {
 			if result != nil {
@@ -342,7 +540,7 @@ func (s *Store) executeRead(
 			result.Close()
 			err = nErr
 		}
-		err = s.handleDataStoreError(err, p)
+		err = s.handleDataStoreError(err, p, true)
 
 		if err != nil {
 			if !common.IsTransientError(err) {
@@ -464,7 +662,7 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 
 	// Labels field must contain value of the specified labels
 	for _, label := range spec.GetLabels() {
-		clauses = append(clauses, fmt.Sprintf(`{type: "contains", field:"labels", values:%s}`, strconv.Quote(label.Value)))
+		clauses = append(clauses, fmt.Sprintf(`{type: "contains", field:"labels", values:%s}`, quoteLuceneValue(label.Value)))
 	}
 
 	// jobconfig field must contain all specified keywords
@@ -481,7 +679,7 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 				`{type: "wildcard", field:"config", value:%s},`+
 				`{type: "match", field:"config", value:%s}`+
 				`]`+
-				`}`, strconv.Quote(wildcardWord), strconv.Quote(word)))
+				`}`, quoteLuceneValue(wildcardWord), quoteLuceneValue(word)))
 	}
 
 	// Add support on query by job state
@@ -500,7 +698,7 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 			if util.IsPelotonJobStateTerminal(s) {
 				queryTerminalStates = true
 			}
-			values = values + strconv.Quote(s.String())
+			values = values + quoteLuceneValue(s.String())
 			if i < len(spec.JobStates)-1 {
 				values = values + ","
 			}
@@ -509,18 +707,18 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 	}
 
 	if respoolID != nil {
-		clauses = append(clauses, fmt.Sprintf(`{type: "contains", field:"respool_id", values:%s}`, strconv.Quote(respoolID.GetValue())))
+		clauses = append(clauses, fmt.Sprintf(`{type: "contains", field:"respool_id", values:%s}`, quoteLuceneValue(respoolID.GetValue())))
 	}
 
 	owner := spec.GetOwner()
 	if owner != "" {
-		clauses = append(clauses, fmt.Sprintf(`{type: "match", field:"owner", value:%s}`, strconv.Quote(owner)))
+		clauses = append(clauses, fmt.Sprintf(`{type: "match", field:"owner", value:%s}`, quoteLuceneValue(owner)))
 	}
 
 	name := spec.GetName()
 	if name != "" {
 		wildcardName := fmt.Sprintf("*%s*", name)
-		clauses = append(clauses, fmt.Sprintf(`{type: "wildcard", field:"name", value:%s}`, strconv.Quote(wildcardName)))
+		clauses = append(clauses, fmt.Sprintf(`{type: "wildcard", field:"name", value:%s}`, quoteLuceneValue(wildcardName)))
 	}
 
 	creationTimeRange := spec.GetCreationTimeRange()
@@ -592,7 +790,7 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 	where += ", sort:["
 	count := 0
 	for _, order := range orderBy {
-		where += fmt.Sprintf("{field: \"%s\"", order.Property.GetValue())
+		where += fmt.Sprintf("{field: %s", quoteLuceneValue(order.Property.GetValue()))
 		if order.Order == query.OrderBy_DESC {
 			where += ", reverse: true"
 		}
@@ -722,6 +920,695 @@ func (s *Store) QueryJobs(ctx context.Context, respoolID *peloton.ResourcePoolID
 	return results, summaryResults, total, nil
 }
 
+// GetJobsUpdatedSince returns up to limit job summaries whose job_index
+// entry was updated after the given update time and job ID, ordered by
+// update time and then by job ID. The last two return values are the
+// update time and job ID of the last returned job, which callers pass back
+// as since and after to fetch the next page; they are empty if there are
+// no more jobs. Callers pass an empty after to get all the jobs updated
+// after since, and jobs sharing the update time of the last job of a page
+// are returned by the next page. The updates are indexed for
+// ormobjects.JobIndexByUpdateTimeRetention, and since must be within it.
+func (s *Store) GetJobsUpdatedSince(
+	ctx context.Context,
+	since time.Time,
+	after string,
+	limit uint32,
+) ([]*job.JobSummary, time.Time, string, error) {
+	if limit == 0 {
+		limit = _defaultQueryLimit
+	}
+
+	now := time.Now()
+	if since.Before(now.Add(-ormobjects.JobIndexByUpdateTimeRetention)) {
+		s.metrics.JobMetrics.JobGetUpdatedSinceFail.Inc(1)
+		return nil, time.Time{}, "", yarpcerrors.InvalidArgumentErrorf(
+			"job updates are indexed for %v, cannot get jobs updated since %v",
+			ormobjects.JobIndexByUpdateTimeRetention, since)
+	}
+
+	// Read the index a day at a time from the day of the cursor, skipping
+	// the rows of the jobs which were updated again or deleted since,
+	// until limit jobs are found.
+	var summaries []*job.JobSummary
+	lastDay := ormobjects.JobIndexUpdateDay(now)
+	for day := ormobjects.JobIndexUpdateDay(since); day <= lastDay; day++ {
+		for uint32(len(summaries)) < limit {
+			count := limit - uint32(len(summaries))
+			rows, err := s.getJobIndexUpdates(ctx, day, since, after, count)
+			if err != nil {
+				log.WithError(err).
+					WithField("since", since).
+					Info("failed to fetch jobs updated since")
+				s.metrics.JobMetrics.JobGetUpdatedSinceFail.Inc(1)
+				return nil, time.Time{}, "", err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			updated, err := s.getUpdatedJobSummaries(ctx, rows)
+			if err != nil {
+				s.metrics.JobMetrics.JobGetUpdatedSinceFail.Inc(1)
+				return nil, time.Time{}, "", err
+			}
+			summaries = append(summaries, updated...)
+			since, after = updateCursor(rows[len(rows)-1])
+
+			if uint32(len(rows)) < count {
+				break
+			}
+		}
+		if uint32(len(summaries)) == limit {
+			break
+		}
+	}
+
+	s.metrics.JobMetrics.JobGetUpdatedSince.Inc(1)
+	if len(summaries) == 0 {
+		return nil, time.Time{}, "", nil
+	}
+	return summaries, since, after, nil
+}
+
+// getJobIndexUpdates returns up to limit rows of a day of the job index by
+// update time table, which are ordered after the given update time and job
+// ID. An empty after returns the rows updated at the given time as well.
+func (s *Store) getJobIndexUpdates(
+	ctx context.Context,
+	day uint64,
+	since time.Time,
+	after string,
+	limit uint32,
+) ([]map[string]interface{}, error) {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("update_time", "job_id").
+		From(jobsByUpdateTimeTable).
+		Where(qb.Eq{"update_day": day})
+	if after == "" {
+		stmt = stmt.Where("update_time >= ?", since)
+	} else {
+		stmt = stmt.Where("(update_time, job_id) > (?, ?)", since, after)
+	}
+	stmt = stmt.Limit(uint64(limit))
+
+	return s.executeRead(ctx, stmt)
+}
+
+// getUpdatedJobSummaries returns the summaries of the jobs of the rows of
+// the job index by update time table, in the order of the rows. The jobs
+// which were deleted, or updated again after the row was written, are
+// skipped as they are returned with their latest row.
+func (s *Store) getUpdatedJobSummaries(
+	ctx context.Context,
+	rows []map[string]interface{},
+) ([]*job.JobSummary, error) {
+	jobIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		_, id := updateCursor(row)
+		jobIDs = append(jobIDs, id)
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select(
+		"job_id",
+		"name",
+		"owner",
+		"job_type",
+		"respool_id",
+		"instance_count",
+		"labels",
+		"runtime_info",
+		"update_time").
+		From(jobIndexTable).
+		Where(qb.Eq{"job_id": jobIDs})
+
+	results, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_ids", jobIDs).
+			Info("failed to fetch updated jobs")
+		return nil, err
+	}
+
+	resultsByID := make(map[string]map[string]interface{}, len(results))
+	for _, result := range results {
+		_, id := updateCursor(result)
+		resultsByID[id] = result
+	}
+
+	var updated []map[string]interface{}
+	for _, row := range rows {
+		updateTime, id := updateCursor(row)
+		result, ok := resultsByID[id]
+		if !ok {
+			continue
+		}
+		if jobUpdateTime, _ := updateCursor(result); !jobUpdateTime.Equal(updateTime) {
+			continue
+		}
+		updated = append(updated, result)
+	}
+
+	return s.getJobSummaryFromResultMap(ctx, updated)
+}
+
+// updateCursor returns the update time and job ID of a row of the job
+// index or of the job index by update time table.
+func updateCursor(result map[string]interface{}) (time.Time, string) {
+	updateTime, _ := result["update_time"].(time.Time)
+	id, _ := result["job_id"].(qb.UUID)
+	return updateTime, id.String()
+}
+
+// GetJobIDsByRespoolID returns up to limit IDs of the jobs in the resource
+// pool, ordered by job ID, without reading their configs. The page starts
+// after the given job ID, callers pass the last returned job ID back as
+// after to fetch the next page, and an empty after to start from the
+// first job.
+func (s *Store) GetJobIDsByRespoolID(
+	ctx context.Context,
+	respoolID *peloton.ResourcePoolID,
+	after string,
+	limit uint32,
+) ([]peloton.JobID, error) {
+	if limit == 0 {
+		limit = _defaultQueryLimit
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("job_id").
+		From(jobsByRespoolView).
+		Where(qb.Eq{"respool_id": respoolID.GetValue()})
+	if after != "" {
+		stmt = stmt.Where("job_id > ?", after)
+	}
+	stmt = stmt.Limit(uint64(limit))
+
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", respoolID.GetValue()).
+			Info("failed to fetch job ids by respool id")
+		s.metrics.JobMetrics.JobGetByRespoolIDFail.Inc(1)
+		return nil, err
+	}
+
+	jobIDs := make([]peloton.JobID, 0, len(allResults))
+	for _, value := range allResults {
+		id, ok := value["job_id"].(qb.UUID)
+		if !ok {
+			s.metrics.JobMetrics.JobGetByRespoolIDFail.Inc(1)
+			return nil, yarpcerrors.InternalErrorf(
+				"invalid job_id %v", value["job_id"])
+		}
+		jobIDs = append(jobIDs, peloton.JobID{Value: id.String()})
+	}
+
+	s.metrics.JobMetrics.JobGetByRespoolID.Inc(1)
+	return jobIDs, nil
+}
+
+// GetAllJobs returns the configs of all the jobs, keyed by job ID. It scans
+// the job index page by page instead of reading the whole table at once.
+// The configs do not include the per instance configs, which are not stored
+// in the job index.
+func (s *Store) GetAllJobs(
+	ctx context.Context,
+) (map[string]*job.JobConfig, error) {
+	jobs := make(map[string]*job.JobConfig)
+	var pagingState []byte
+	for {
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("job_id", "config").
+			From(jobIndexTable).
+			PageSize(_defaultGetAllJobsPageSize).
+			PagingState(pagingState)
+
+		result, err := s.DataStore.Execute(ctx, stmt)
+		if err != nil {
+			log.WithError(err).Error("failed to scan the job index")
+			s.metrics.JobMetrics.JobGetAllFail.Inc(1)
+			return nil, err
+		}
+		allResults, err := result.All(ctx)
+		pagingState = result.PagingState()
+		result.Close()
+		if err != nil {
+			log.WithError(err).Error("failed to read a page of the job index")
+			s.metrics.JobMetrics.JobGetAllFail.Inc(1)
+			return nil, err
+		}
+
+		for _, value := range allResults {
+			id, ok := value["job_id"].(qb.UUID)
+			if !ok {
+				s.metrics.JobMetrics.JobGetAllFail.Inc(1)
+				return nil, yarpcerrors.InternalErrorf(
+					"invalid job_id %v", value["job_id"])
+			}
+			configBuffer, _ := value["config"].(string)
+			if configBuffer == "" {
+				// the config of a job being created may not be indexed yet
+				continue
+			}
+			config := &job.JobConfig{}
+			if err := json.Unmarshal([]byte(configBuffer), config); err != nil {
+				log.WithError(err).
+					WithField("job_id", id.String()).
+					Error("failed to unmarshal job config from the job index")
+				s.metrics.JobMetrics.JobGetAllFail.Inc(1)
+				return nil, err
+			}
+			jobs[id.String()] = config
+		}
+
+		if len(pagingState) == 0 {
+			break
+		}
+	}
+
+	s.metrics.JobMetrics.JobGetAll.Inc(1)
+	return jobs, nil
+}
+
+// GetJobsByOwnerPaged returns the configs of up to limit jobs owned by the
+// owner after skipping the first offset jobs, along with the total number of
+// jobs of the owner. Jobs are ordered by job ID, so jobs created while
+// paging may shift the pages which are not read yet.
+func (s *Store) GetJobsByOwnerPaged(
+	ctx context.Context,
+	owner string,
+	offset uint32,
+	limit uint32,
+) ([]*job.JobConfig, uint32, error) {
+	if limit == 0 {
+		limit = _defaultQueryLimit
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	countStmt := queryBuilder.Select("count(*)").
+		From(jobsByOwnerView).
+		Where(qb.Eq{"owner": owner})
+	countResults, err := s.executeRead(ctx, countStmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("owner", owner).
+			Info("failed to count jobs by owner")
+		s.metrics.JobMetrics.JobGetByOwnerFail.Inc(1)
+		return nil, 0, err
+	}
+	var total uint32
+	if len(countResults) > 0 {
+		count, _ := countResults[0]["count"].(int64)
+		total = uint32(count)
+	}
+
+	if offset > total {
+		offset = total
+	}
+	if limit > total-offset {
+		limit = total - offset
+	}
+	end := offset + limit
+
+	// The view does not support OFFSET, so walk the keys of the owner
+	// partition in bounded chunks, resuming after the last key read, until
+	// the keys of the page are read.
+	var jobIDs []string
+	lastID := ""
+	for uint32(len(jobIDs)) < end {
+		batchSize := end - uint32(len(jobIDs))
+		if batchSize > _defaultJobsByOwnerBatchSize {
+			batchSize = _defaultJobsByOwnerBatchSize
+		}
+
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("job_id").
+			From(jobsByOwnerView).
+			Where(qb.Eq{"owner": owner})
+		if lastID != "" {
+			stmt = stmt.Where("job_id > ?", lastID)
+		}
+		stmt = stmt.Limit(uint64(batchSize))
+
+		allResults, err := s.executeRead(ctx, stmt)
+		if err != nil {
+			log.WithError(err).
+				WithField("owner", owner).
+				Info("failed to fetch job ids by owner")
+			s.metrics.JobMetrics.JobGetByOwnerFail.Inc(1)
+			return nil, 0, err
+		}
+
+		for _, value := range allResults {
+			id, ok := value["job_id"].(qb.UUID)
+			if !ok {
+				s.metrics.JobMetrics.JobGetByOwnerFail.Inc(1)
+				return nil, 0, yarpcerrors.InternalErrorf(
+					"invalid job_id %v", value["job_id"])
+			}
+			lastID = id.String()
+			jobIDs = append(jobIDs, lastID)
+		}
+		if uint32(len(allResults)) < batchSize {
+			break
+		}
+	}
+
+	// Jobs may have been deleted since they were counted.
+	if end > uint32(len(jobIDs)) {
+		end = uint32(len(jobIDs))
+	}
+	if offset > end {
+		offset = end
+	}
+
+	configs := make([]*job.JobConfig, 0, end-offset)
+	for _, id := range jobIDs[offset:end] {
+		jobConfig, _, err := s.jobConfigOps.GetCurrentVersion(
+			ctx, &peloton.JobID{Value: id})
+		if err != nil {
+			log.WithError(err).
+				WithField("owner", owner).
+				WithField("job_id", id).
+				Error("failed to get job config by owner")
+			s.metrics.JobMetrics.JobGetByOwnerFail.Inc(1)
+			return nil, 0, err
+		}
+		configs = append(configs, jobConfig)
+	}
+
+	s.metrics.JobMetrics.JobGetByOwner.Inc(1)
+	return configs, total, nil
+}
+
+// MoveJobToRespool moves a job to a different resource pool. The job config
+// is written as a new version with the new resource pool, and the job
+// runtime is updated to point to it. Then the respool_id and config columns
+// of the job_index row are updated, which moves the job in the job index
+// view by respool as well. The writes are applied only if the job config,
+// the job runtime and the job index were not updated since they were read,
+// so concurrent moves or updates of the same job do not interleave. A move
+// which failed half way can be completed by moving the job again.
+func (s *Store) MoveJobToRespool(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	respoolID *peloton.ResourcePoolID) error {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("respool_id").
+		From(jobIndexTable).
+		Where(qb.Eq{"job_id": jobID.GetValue()})
+
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+	if len(allResults) == 0 {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return yarpcerrors.NotFoundErrorf(
+			"job %s not found", jobID.GetValue())
+	}
+	oldRespoolID, _ := allResults[0]["respool_id"].(string)
+
+	runtime, err := s.jobRuntimeOps.Get(ctx, jobID)
+	if err != nil {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+	result, err := s.jobConfigOps.GetResult(
+		ctx, jobID, runtime.GetConfigurationVersion())
+	if err != nil {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+	if result == nil {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return yarpcerrors.NotFoundErrorf(
+			"config version %d of job %s not found",
+			runtime.GetConfigurationVersion(), jobID.GetValue())
+	}
+
+	config := result.JobConfig
+	if config.GetRespoolID().GetValue() != respoolID.GetValue() {
+		config, err = s.moveJobConfigToRespool(
+			ctx, jobID, runtime, result, respoolID)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID.GetValue()).
+				WithField("respool_id", respoolID.GetValue()).
+				Info("failed to write job config with new respool")
+			s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+			return err
+		}
+	}
+
+	if oldRespoolID == respoolID.GetValue() {
+		return nil
+	}
+
+	// Do not save the instance config with the job
+	// configuration in the job_index table.
+	indexConfig := proto.Clone(config).(*job.JobConfig)
+	indexConfig.InstanceConfig = nil
+	configBuffer, err := json.Marshal(indexConfig)
+	if err != nil {
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+
+	updateTime := time.Now().UTC()
+	stmt = queryBuilder.Update(jobIndexTable).
+		Set("respool_id", respoolID.GetValue()).
+		Set("config", configBuffer).
+		Set("update_time", updateTime).
+		Where(qb.Eq{"job_id": jobID.GetValue()}).
+		IfOnly(qb.Eq{"respool_id": oldRespoolID})
+
+	casResult, err := s.executeWrite(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			WithField("respool_id", respoolID.GetValue()).
+			Info("failed to move job to respool")
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+	if casResult != nil {
+		defer casResult.Close()
+		if !casResult.Applied() {
+			s.metrics.ErrorMetrics.CASNotApplied.Inc(1)
+			s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+			return yarpcerrors.FailedPreconditionErrorf(
+				"job %s was moved out of respool %s concurrently",
+				jobID.GetValue(), oldRespoolID)
+		}
+	}
+
+	stmt = queryBuilder.Insert(jobsByUpdateTimeTable).
+		Columns("update_day", "update_time", "job_id").
+		Values(
+			ormobjects.JobIndexUpdateDay(updateTime),
+			updateTime,
+			jobID.GetValue())
+
+	if _, err := s.executeWrite(ctx, stmt); err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			Info("failed to index update time of moved job")
+		s.metrics.JobMetrics.JobUpdateFail.Inc(1)
+		return err
+	}
+
+	s.metrics.JobMetrics.JobUpdate.Inc(1)
+	return nil
+}
+
+// moveJobConfigToRespool writes the next version of the job config read
+// in result with the given resource pool, and updates the job runtime to
+// the new version. Returns the new job config.
+func (s *Store) moveJobConfigToRespool(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	runtime *job.RuntimeInfo,
+	result *ormobjects.JobConfigOpsResult,
+	respoolID *peloton.ResourcePoolID,
+) (*job.JobConfig, error) {
+	now := uint64(time.Now().UnixNano())
+	version := runtime.GetConfigurationVersion() + 1
+
+	config := proto.Clone(result.JobConfig).(*job.JobConfig)
+	config.RespoolID = respoolID
+	config.ChangeLog = &peloton.ChangeLog{
+		Version:   version,
+		CreatedAt: result.JobConfig.GetChangeLog().GetCreatedAt(),
+		UpdatedAt: now,
+	}
+
+	var spec *stateless.JobSpec
+	if result.JobSpec != nil {
+		spec = proto.Clone(result.JobSpec).(*stateless.JobSpec)
+		spec.RespoolId = &v1alphapeloton.ResourcePoolID{
+			Value: respoolID.GetValue(),
+		}
+	}
+
+	if err := s.jobConfigOps.Update(
+		ctx,
+		jobID,
+		config,
+		result.ConfigAddOn,
+		spec,
+		runtime.GetConfigurationVersion(),
+	); err != nil {
+		return nil, err
+	}
+
+	newRuntime := proto.Clone(runtime).(*job.RuntimeInfo)
+	newRuntime.ConfigurationVersion = version
+	newRuntime.Revision = &peloton.ChangeLog{
+		Version:   runtime.GetRevision().GetVersion() + 1,
+		CreatedAt: runtime.GetRevision().GetCreatedAt(),
+		UpdatedAt: now,
+	}
+	if err := s.jobRuntimeOps.Update(
+		ctx,
+		jobID,
+		newRuntime,
+		runtime.GetRevision().GetVersion(),
+	); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// SetJobMetadata replaces the operational metadata of a job, such as the
+// owning team or the cost center, with the given key-value pairs. The
+// metadata is stored apart from the job config, so changing it does not
+// create a new config version. An empty metadata removes all the keys.
+func (s *Store) SetJobMetadata(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	metadata map[string]string,
+) error {
+	for key := range metadata {
+		if key == "" {
+			s.metrics.JobMetrics.JobSetMetadataFail.Inc(1)
+			return yarpcerrors.InvalidArgumentErrorf(
+				"empty metadata key for job %s", jobID.GetValue())
+		}
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("key").
+		From(jobMetadataTable).
+		Where(qb.Eq{"job_id": jobID.GetValue()})
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			Info("failed to read job metadata")
+		s.metrics.JobMetrics.JobSetMetadataFail.Inc(1)
+		return err
+	}
+
+	// Keys are deleted individually rather than by deleting the whole
+	// partition, whose tombstone would shadow the inserts in the same batch.
+	var stmts []api.Statement
+	for _, value := range allResults {
+		key, ok := value["key"].(string)
+		if !ok {
+			s.metrics.JobMetrics.JobSetMetadataFail.Inc(1)
+			return yarpcerrors.InternalErrorf(
+				"invalid metadata key %v", value["key"])
+		}
+		if _, ok := metadata[key]; ok {
+			continue
+		}
+		stmts = append(stmts, queryBuilder.Delete(jobMetadataTable).
+			Where(qb.Eq{"job_id": jobID.GetValue(), "key": key}))
+	}
+	for key, value := range metadata {
+		stmts = append(stmts, queryBuilder.Insert(jobMetadataTable).
+			Columns("job_id", "key", "value").
+			Values(jobID.GetValue(), key, value))
+	}
+	if len(stmts) == 0 {
+		s.metrics.JobMetrics.JobSetMetadata.Inc(1)
+		return nil
+	}
+
+	if err := s.executeBatch(ctx, stmts); err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			Info("failed to write job metadata")
+		s.metrics.JobMetrics.JobSetMetadataFail.Inc(1)
+		return err
+	}
+
+	s.metrics.JobMetrics.JobSetMetadata.Inc(1)
+	return nil
+}
+
+// QueryJobsByMetadata returns the IDs of the jobs whose metadata matches
+// all the key-value pairs of the selector, ordered by job ID.
+func (s *Store) QueryJobsByMetadata(
+	ctx context.Context,
+	selector map[string]string,
+) ([]peloton.JobID, error) {
+	if len(selector) == 0 {
+		s.metrics.JobMetrics.JobQueryByMetadataFail.Inc(1)
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"empty job metadata selector")
+	}
+
+	// number of selector pairs matched by each job
+	matches := make(map[string]int)
+	for key, value := range selector {
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("job_id").
+			From(jobsByMetadataView).
+			Where(qb.Eq{"key": key, "value": value})
+		allResults, err := s.executeRead(ctx, stmt)
+		if err != nil {
+			log.WithError(err).
+				WithField("key", key).
+				WithField("value", value).
+				Info("failed to fetch job ids by metadata")
+			s.metrics.JobMetrics.JobQueryByMetadataFail.Inc(1)
+			return nil, err
+		}
+
+		for _, result := range allResults {
+			id, ok := result["job_id"].(qb.UUID)
+			if !ok {
+				s.metrics.JobMetrics.JobQueryByMetadataFail.Inc(1)
+				return nil, yarpcerrors.InternalErrorf(
+					"invalid job_id %v", result["job_id"])
+			}
+			matches[id.String()]++
+		}
+	}
+
+	var ids []string
+	for id, count := range matches {
+		if count == len(selector) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	jobIDs := make([]peloton.JobID, 0, len(ids))
+	for _, id := range ids {
+		jobIDs = append(jobIDs, peloton.JobID{Value: id})
+	}
+
+	s.metrics.JobMetrics.JobQueryByMetadata.Inc(1)
+	return jobIDs, nil
+}
+
 // CreateTaskRuntime creates a task runtime for a peloton job
 func (s *Store) CreateTaskRuntime(
 	ctx context.Context,
@@ -730,7 +1617,7 @@ func (s *Store) CreateTaskRuntime(
 	runtime *task.RuntimeInfo,
 	owner string,
 	jobType job.JobType) error {
-	runtimeBuffer, err := proto.Marshal(runtime)
+	stmt, err := s.taskRuntimeInsertStmt(jobID, instanceID, runtime)
 	if err != nil {
 		log.WithField("job_id", jobID.GetValue()).
 			WithField("instance_id", instanceID).
@@ -740,27 +1627,6 @@ func (s *Store) CreateTaskRuntime(
 		return err
 	}
 
-	queryBuilder := s.DataStore.NewQuery()
-	stmt := queryBuilder.Insert(taskRuntimeTable).
-		Columns(
-			"job_id",
-			"instance_id",
-			"version",
-			"update_time",
-			"state",
-			"runtime_info").
-		Values(
-			jobID.GetValue(),
-			instanceID,
-			runtime.GetRevision().GetVersion(),
-			time.Now().UTC(),
-			runtime.GetState().String(),
-			runtimeBuffer)
-
-	// IfNotExist() will cause Writing task runtimes to Cassandra concurrently
-	// failed with Operation timed out issue when batch size is small, e.g. 1.
-	// For now, we have to drop the IfNotExist()
-
 	taskID := fmt.Sprintf(taskIDFmt, jobID, instanceID)
 	if err := s.applyStatement(ctx, stmt, taskID); err != nil {
 		s.metrics.TaskMetrics.TaskCreateFail.Inc(1)
@@ -776,6 +1642,258 @@ func (s *Store) CreateTaskRuntime(
 	return nil
 }
 
+// CreateTasks creates the task runtimes of a peloton job in batches of
+// at most Conf.MaxBatchSize tasks. Each batch is written by its own go
+// routine, and at most Conf.MaxParallelBatches of them run concurrently
+// so that creating a large job does not flood the cluster with writes.
+// If any batch fails, an Internal error naming how many of the tasks were
+// not created is returned. Otherwise the first error logging the state
+// change of a created task is returned.
+func (s *Store) CreateTasks(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	runtimes map[uint32]*task.RuntimeInfo,
+	owner string) error {
+	writes := make([]taskRuntimeWrite, 0, len(runtimes))
+	for instanceID, runtime := range runtimes {
+		writes = append(writes, taskRuntimeWrite{
+			jobID:      jobID,
+			instanceID: instanceID,
+			runtime:    runtime,
+		})
+	}
+
+	timeStart := time.Now()
+	tasksNotCreated, err := s.writeTaskRuntimes(
+		ctx,
+		writes,
+		s.taskRuntimeInsertStmt,
+		s.metrics.TaskMetrics.TaskCreate,
+		s.metrics.TaskMetrics.TaskCreateFail,
+	)
+	if tasksNotCreated != 0 {
+		return yarpcerrors.InternalErrorf(
+			"failed to create %d of %d tasks for job %v in %v",
+			tasksNotCreated,
+			len(writes),
+			jobID.GetValue(),
+			time.Since(timeStart))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithField("job_id", jobID.GetValue()).
+		WithField("tasks", len(writes)).
+		WithField("duration_s", time.Since(timeStart).Seconds()).
+		Debug("Created task runtimes")
+	return nil
+}
+
+// taskRuntimeWrite is the runtime of a task written by writeTaskRuntimes.
+type taskRuntimeWrite struct {
+	jobID      *peloton.JobID
+	instanceID uint32
+	runtime    *task.RuntimeInfo
+}
+
+// writeTaskRuntimes writes the task runtimes in batches of at most
+// Conf.MaxBatchSize tasks of the same job, with the statements built by
+// buildStmt. Each batch is written by its own go routine, and at most
+// Conf.MaxParallelBatches of them run concurrently. The state change of
+// each written task is logged as a pod event. It returns the number of
+// tasks which were not written, and the first error logging a pod event.
+func (s *Store) writeTaskRuntimes(
+	ctx context.Context,
+	writes []taskRuntimeWrite,
+	buildStmt func(
+		jobID *peloton.JobID,
+		instanceID uint32,
+		runtime *task.RuntimeInfo) (api.Statement, error),
+	successCounter tally.Counter,
+	failCounter tally.Counter,
+) (uint32, error) {
+	maxBatchSize := s.Conf.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = _defaultMaxBatchSize
+	}
+	maxParallelBatches := s.Conf.MaxParallelBatches
+	if maxParallelBatches <= 0 {
+		maxParallelBatches = _defaultMaxParallelBatches
+	}
+
+	// sort the writes by job and instance, so that each batch only holds
+	// tasks of the same job, which share the partition of the job
+	sort.Slice(writes, func(i, j int) bool {
+		if writes[i].jobID.GetValue() != writes[j].jobID.GetValue() {
+			return writes[i].jobID.GetValue() < writes[j].jobID.GetValue()
+		}
+		return writes[i].instanceID < writes[j].instanceID
+	})
+
+	var batches [][]taskRuntimeWrite
+	for start := 0; start < len(writes); {
+		end := start + 1
+		for end < len(writes) &&
+			end-start < maxBatchSize &&
+			writes[end].jobID.GetValue() == writes[start].jobID.GetValue() {
+			end++
+		}
+		batches = append(batches, writes[start:end])
+		start = end
+	}
+
+	tasksNotWritten := uint32(0)
+
+	var lock sync.Mutex
+	var podEventErr error
+
+	// sem bounds the number of batches being written at any time
+	sem := make(chan struct{}, maxParallelBatches)
+	wg := new(sync.WaitGroup)
+	for _, batch := range batches {
+		batch := batch
+		jobID := batch[0].jobID
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			var stmts []api.Statement
+			for _, w := range batch {
+				stmt, err := buildStmt(w.jobID, w.instanceID, w.runtime)
+				if err != nil {
+					log.WithField("job_id", jobID.GetValue()).
+						WithField("instance_id", w.instanceID).
+						WithError(err).
+						Error("Failed to build task runtime statement")
+					atomic.AddUint32(&tasksNotWritten, uint32(len(batch)))
+					failCounter.Inc(int64(len(batch)))
+					return
+				}
+				stmts = append(stmts, stmt)
+			}
+
+			if err := s.executeBatch(ctx, stmts); err != nil {
+				log.WithField("job_id", jobID.GetValue()).
+					WithField("batch_size", len(batch)).
+					WithError(err).
+					Error("Failed to write task runtime batch")
+				atomic.AddUint32(&tasksNotWritten, uint32(len(batch)))
+				failCounter.Inc(int64(len(batch)))
+				return
+			}
+			successCounter.Inc(int64(len(batch)))
+
+			for _, w := range batch {
+				if err := s.addPodEvent(
+					ctx, w.jobID, w.instanceID, w.runtime); err != nil {
+					log.WithField("job_id", jobID.GetValue()).
+						WithField("instance_id", w.instanceID).
+						WithError(err).
+						Error("Unable to log task state changes")
+					lock.Lock()
+					if podEventErr == nil {
+						podEventErr = err
+					}
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return tasksNotWritten, podEventErr
+}
+
+// CreateMissingTasks is like CreateTasks, but skips the tasks which already
+// exist. CreateTasks writes without IfNotExist since conditional updates
+// cannot span partitions in a batch, so re-running it, for example during
+// recovery, would overwrite the runtime of live tasks. CreateMissingTasks
+// first reads the instance IDs already stored for the job and creates only
+// the missing ones.
+func (s *Store) CreateMissingTasks(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	runtimes map[uint32]*task.RuntimeInfo,
+	owner string) error {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("instance_id").
+		From(taskRuntimeTable).
+		Where(qb.Eq{"job_id": jobID.GetValue()})
+
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithField("job_id", jobID.GetValue()).
+			WithError(err).
+			Error("Failed to read existing tasks")
+		s.metrics.TaskMetrics.TaskCreateFail.Inc(int64(len(runtimes)))
+		return err
+	}
+
+	existing := make(map[uint32]bool)
+	for _, value := range allResults {
+		instanceID, ok := value["instance_id"].(int)
+		if !ok {
+			s.metrics.TaskMetrics.TaskCreateFail.Inc(int64(len(runtimes)))
+			return yarpcerrors.InternalErrorf(
+				"invalid instance_id %v", value["instance_id"])
+		}
+		existing[uint32(instanceID)] = true
+	}
+
+	missing := make(map[uint32]*task.RuntimeInfo)
+	for instanceID, runtime := range runtimes {
+		if !existing[instanceID] {
+			missing[instanceID] = runtime
+		}
+	}
+
+	log.WithField("job_id", jobID.GetValue()).
+		WithField("tasks", len(runtimes)).
+		WithField("existing_tasks", len(runtimes)-len(missing)).
+		Debug("Skipping existing task runtimes")
+	return s.CreateTasks(ctx, jobID, missing, owner)
+}
+
+// taskRuntimeInsertStmt builds the statement which writes the runtime of
+// a task into the task_runtime table.
+func (s *Store) taskRuntimeInsertStmt(
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo) (api.Statement, error) {
+	runtimeBuffer, err := proto.Marshal(runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Insert(taskRuntimeTable).
+		Columns(
+			"job_id",
+			"instance_id",
+			"version",
+			"update_time",
+			"state",
+			"runtime_info").
+		Values(
+			jobID.GetValue(),
+			instanceID,
+			runtime.GetRevision().GetVersion(),
+			time.Now().UTC(),
+			runtime.GetState().String(),
+			runtimeBuffer)
+
+	// IfNotExist() will cause Writing task runtimes to Cassandra concurrently
+	// failed with Operation timed out issue when batch size is small, e.g. 1.
+	// For now, we have to drop the IfNotExist()
+	return stmt, nil
+}
+
 // addPodEvent upserts single pod state change for a Job -> Instance -> Run.
 // Task state events are sorted by reverse chronological run_id and time of event.
 func (s *Store) addPodEvent(
@@ -999,6 +2117,72 @@ func (s *Store) DeletePodEvents(
 	return nil
 }
 
+// CompactPodEvents deletes all but the most recent keepLast pod events for
+// provided JobID and InstanceID, bounding the state change history kept for
+// long running tasks. Pod events are ordered by run_id and then update_time,
+// so the retained events are the latest ones across all the runs.
+func (s *Store) CompactPodEvents(
+	ctx context.Context,
+	jobID string,
+	instanceID uint32,
+	keepLast uint32,
+) error {
+	maxBatchSize := s.Conf.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = _defaultMaxBatchSize
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("run_id", "update_time").
+		From(podEventsTable).
+		Where(qb.Eq{"job_id": jobID, "instance_id": instanceID})
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		s.metrics.TaskMetrics.PodEventsCompactFail.Inc(1)
+		return err
+	}
+	if len(allResults) <= int(keepLast) {
+		s.metrics.TaskMetrics.PodEventsCompact.Inc(1)
+		return nil
+	}
+
+	// events are read in descending order, so the ones to delete
+	// are at the end of the result set
+	var stmts []api.Statement
+	for _, value := range allResults[keepLast:] {
+		stmts = append(stmts, queryBuilder.
+			Delete(podEventsTable).
+			Where(qb.Eq{
+				"job_id":      jobID,
+				"instance_id": instanceID,
+				"run_id":      value["run_id"],
+				"update_time": value["update_time"],
+			}))
+	}
+
+	for start := 0; start < len(stmts); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(stmts) {
+			end = len(stmts)
+		}
+		if err := s.executeBatch(ctx, stmts[start:end]); err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				WithField("instance_id", instanceID).
+				Error("Failed to compact pod events")
+			s.metrics.TaskMetrics.PodEventsCompactFail.Inc(1)
+			return err
+		}
+	}
+
+	log.WithField("job_id", jobID).
+		WithField("instance_id", instanceID).
+		WithField("deleted", len(stmts)).
+		Debug("Compacted pod events")
+	s.metrics.TaskMetrics.PodEventsCompact.Inc(1)
+	return nil
+}
+
 // GetTasksForJobResultSet returns the result set that can be used to iterate each task in a job
 // Caller need to call result.Close()
 func (s *Store) GetTasksForJobResultSet(ctx context.Context, id *peloton.JobID) ([]map[string]interface{}, error) {
@@ -1016,19 +2200,50 @@ func (s *Store) GetTasksForJobResultSet(ctx context.Context, id *peloton.JobID)
 
 // GetTasksForJob returns all the task runtimes (no configuration) in a map of tasks.TaskInfo for a peloton job
 func (s *Store) GetTasksForJob(ctx context.Context, id *peloton.JobID) (map[uint32]*task.TaskInfo, error) {
-	allResults, err := s.GetTasksForJobResultSet(ctx, id)
+	resultMap := make(map[uint32]*task.TaskInfo)
+	err := s.ForEachTaskForJob(ctx, id, func(taskInfo *task.TaskInfo) error {
+		resultMap[taskInfo.InstanceId] = taskInfo
+		return nil
+	})
 	if err != nil {
 		log.WithField("job_id", id.GetValue()).
 			WithError(err).
 			Error("Fail to GetTasksForJob")
-		s.metrics.TaskMetrics.TaskGetForJobFail.Inc(1)
 		return nil, err
 	}
-	resultMap := make(map[uint32]*task.TaskInfo)
+	return resultMap, nil
+}
+
+// ForEachTaskForJob calls fn with the task runtime (no configuration) of
+// each task of a peloton job. The task runtimes are read from the database
+// row by row, so that memory use does not grow with the size of the job.
+// Iteration stops at the first error returned by fn, which is returned.
+func (s *Store) ForEachTaskForJob(
+	ctx context.Context,
+	id *peloton.JobID,
+	fn func(*task.TaskInfo) error) error {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("*").From(taskRuntimeTable).
+		Where(qb.Eq{"job_id": id.GetValue()})
+	result, err := s.executeIter(ctx, stmt)
+	if err != nil {
+		s.metrics.TaskMetrics.TaskGetForJobFail.Inc(1)
+		return err
+	}
+	defer result.Close()
+
+	for {
+		value, err := result.Next(ctx)
+		if err != nil {
+			s.metrics.TaskMetrics.TaskGetForJobFail.Inc(1)
+			return err
+		}
+		if len(value) == 0 {
+			return nil
+		}
 
-	for _, value := range allResults {
 		var record TaskRuntimeRecord
-		err := FillObject(value, &record, reflect.TypeOf(record))
+		err = FillObject(value, &record, reflect.TypeOf(record))
 		if err != nil {
 			log.WithField("value", value).
 				WithError(err).
@@ -1045,16 +2260,15 @@ func (s *Store) GetTasksForJob(ctx context.Context, id *peloton.JobID) (map[uint
 			continue
 		}
 
-		taskInfo := &task.TaskInfo{
+		s.metrics.TaskMetrics.TaskGetForJob.Inc(1)
+		if err := fn(&task.TaskInfo{
 			Runtime:    runtime,
 			InstanceId: uint32(record.InstanceID),
 			JobId:      id,
+		}); err != nil {
+			return err
 		}
-
-		s.metrics.TaskMetrics.TaskGetForJob.Inc(1)
-		resultMap[taskInfo.InstanceId] = taskInfo
 	}
-	return resultMap, nil
 }
 
 // GetTaskConfigs returns the task configs for a list of instance IDs,
@@ -1221,14 +2435,202 @@ func (s *Store) getTaskInfoFromRuntimeRecord(ctx context.Context, id *peloton.Jo
 	}, nil
 }
 
+// GetTaskStateSummaryForJobs returns the number of tasks in each state
+// for every given job. The result is keyed by job ID and then by task
+// state. Jobs are queried in batches of _defaultTaskSummaryJobBatchSize
+// so a dashboard covering many jobs needs only a few reads.
+func (s *Store) GetTaskStateSummaryForJobs(
+	ctx context.Context,
+	ids []*peloton.JobID) (map[string]map[string]uint32, error) {
+	summary := make(map[string]map[string]uint32)
+	for _, id := range ids {
+		summary[id.GetValue()] = make(map[string]uint32)
+	}
+
+	for start := 0; start < len(ids); start += _defaultTaskSummaryJobBatchSize {
+		end := start + _defaultTaskSummaryJobBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var jobIDs []string
+		for _, id := range ids[start:end] {
+			jobIDs = append(jobIDs, id.GetValue())
+		}
+
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("job_id", "state").From(taskRuntimeTable).
+			Where(qb.Eq{"job_id": jobIDs})
+		allResults, err := s.executeRead(ctx, stmt)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_ids", jobIDs).
+				Error("Failed to GetTaskStateSummaryForJobs")
+			s.metrics.TaskMetrics.TaskSummaryForJobFail.Inc(1)
+			return nil, err
+		}
+
+		for _, value := range allResults {
+			var record TaskRuntimeRecord
+			err := FillObject(value, &record, reflect.TypeOf(record))
+			if err != nil {
+				log.WithError(err).
+					WithField("job_ids", jobIDs).
+					WithField("value", value).
+					Error("GetTaskStateSummaryForJobs failed to Fill into TaskRecord")
+				s.metrics.TaskMetrics.TaskSummaryForJobFail.Inc(1)
+				return nil, err
+			}
+			jobID := record.JobID.String()
+			if _, ok := summary[jobID]; !ok {
+				summary[jobID] = make(map[string]uint32)
+			}
+			summary[jobID][record.State]++
+		}
+	}
+
+	s.metrics.TaskMetrics.TaskSummaryForJob.Inc(1)
+	return summary, nil
+}
+
+// GetJobRuntimes returns the runtimes of the given jobs, keyed by job ID.
+// Jobs which are not found are left out of the result. Jobs are queried in
+// batches of _defaultJobRuntimeBatchSize.
+func (s *Store) GetJobRuntimes(
+	ctx context.Context,
+	ids []*peloton.JobID) (map[string]*job.RuntimeInfo, error) {
+	runtimes := make(map[string]*job.RuntimeInfo, len(ids))
+
+	for start := 0; start < len(ids); start += _defaultJobRuntimeBatchSize {
+		end := start + _defaultJobRuntimeBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var jobIDs []string
+		for _, id := range ids[start:end] {
+			jobIDs = append(jobIDs, id.GetValue())
+		}
+
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("*").From(jobRuntimeTable).
+			Where(qb.Eq{"job_id": jobIDs})
+		allResults, err := s.executeRead(ctx, stmt)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_ids", jobIDs).
+				Error("Failed to GetJobRuntimes")
+			s.metrics.JobMetrics.JobGetRuntimeFail.Inc(1)
+			return nil, err
+		}
+
+		for _, value := range allResults {
+			var record JobRuntimeRecord
+			err := FillObject(value, &record, reflect.TypeOf(record))
+			if err != nil {
+				log.WithError(err).
+					WithField("job_ids", jobIDs).
+					WithField("value", value).
+					Error("GetJobRuntimes failed to Fill into JobRuntimeRecord")
+				s.metrics.JobMetrics.JobGetRuntimeFail.Inc(1)
+				return nil, err
+			}
+			runtime, err := record.GetJobRuntime()
+			if err != nil {
+				log.WithError(err).
+					WithField("job_id", record.JobID.String()).
+					Error("GetJobRuntimes failed to parse job runtime")
+				s.metrics.JobMetrics.JobGetRuntimeFail.Inc(1)
+				return nil, err
+			}
+			runtimes[record.JobID.String()] = runtime
+		}
+	}
+
+	s.metrics.JobMetrics.JobGetRuntime.Inc(1)
+	return runtimes, nil
+}
+
+// GetTasksForJobs returns all the task runtimes (no configuration) for
+// the given jobs, keyed by job ID and then instance ID. Jobs are read
+// concurrently, bounded by Conf.MaxParallelJobReads. No more jobs are read
+// once a read fails or the context is done.
+func (s *Store) GetTasksForJobs(
+	ctx context.Context,
+	ids []*peloton.JobID) (map[string]map[uint32]*task.TaskInfo, error) {
+	maxParallelJobReads := s.Conf.MaxParallelJobReads
+	if maxParallelJobReads <= 0 {
+		maxParallelJobReads = _defaultMaxParallelJobReads
+	}
+
+	// readCtx is cancelled on the first failed read, to abandon the reads
+	// which are still in flight
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lock sync.Mutex
+	var readErr error
+	result := make(map[string]map[uint32]*task.TaskInfo, len(ids))
+
+	// sem bounds the number of jobs being read at any time
+	sem := make(chan struct{}, maxParallelJobReads)
+	wg := new(sync.WaitGroup)
+	for _, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-readCtx.Done():
+		}
+		// a read may have failed while waiting for a slot
+		if readCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(id *peloton.JobID) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			tasks, err := s.GetTasksForJob(readCtx, id)
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if readErr == nil {
+					readErr = err
+					cancel()
+				}
+				return
+			}
+			result[id.GetValue()] = tasks
+		}(id)
+	}
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetTasksForJobAndStates returns the tasks for a peloton job which are in
-// one of the specified states.
-// result map key is TaskID, value is TaskHost
+// one of the specified states, read with a single query of the task runtime
+// table. Each task is returned once, keyed by instance ID.
+// It returns an InvalidArgument error if no state is specified.
 func (s *Store) GetTasksForJobAndStates(
 	ctx context.Context,
 	id *peloton.JobID,
 	states []task.TaskState) (map[uint32]*task.TaskInfo, error) {
 	jobID := id.GetValue()
+	if len(states) == 0 {
+		s.metrics.TaskMetrics.TaskGetForJobAndStatesFail.Inc(1)
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"no task states specified for job %s", jobID)
+	}
+
 	queryBuilder := s.DataStore.NewQuery()
 	taskStates := make(map[string]bool)
 	for _, state := range states {
@@ -1257,28 +2659,169 @@ func (s *Store) GetTasksForJobAndStates(
 			s.metrics.TaskMetrics.TaskGetForJobAndStatesFail.Inc(1)
 			return nil, err
 		}
-		for i := 0; i < len(taskStates); i++ {
-			if _, ok := taskStates[record.State]; !ok {
-				continue
+		if !taskStates[record.State] {
+			continue
+		}
+		resultMap[uint32(record.InstanceID)], err = s.getTask(ctx,
+			id.GetValue(), uint32(record.InstanceID))
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				WithField("instance_id", record.InstanceID).
+				WithField("value", value).
+				Error("Failed to get taskInfo from task")
+			s.metrics.TaskMetrics.TaskGetForJobAndStatesFail.Inc(1)
+			return nil, err
+		}
+	}
+	s.metrics.TaskMetrics.TaskGetForJobAndStates.Inc(1)
+	return resultMap, nil
+}
+
+// GetTasksForJobByTerminality returns the tasks of a peloton job which are
+// in a terminal state if terminal is true, and the tasks which are in a
+// non-terminal state otherwise. With TaskReadFromView, the states are
+// filtered by the database using the tasks by state materialized view,
+// which may miss very recent writes. With TaskReadFromBaseTable, all the
+// tasks of the job are read from the task runtime table and filtered in
+// memory, which reflects all the previous writes at a higher cost.
+func (s *Store) GetTasksForJobByTerminality(
+	ctx context.Context,
+	id *peloton.JobID,
+	terminal bool,
+	source TaskReadSource) (map[uint32]*task.TaskInfo, error) {
+	jobID := id.GetValue()
+
+	if source == TaskReadFromBaseTable {
+		tasks, err := s.GetTasksForJobByRange(ctx, id, nil)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				WithField("terminal", terminal).
+				Error("Failed to GetTasksForJobByTerminality from base table")
+			s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
+			return nil, err
+		}
+		for instanceID, taskInfo := range tasks {
+			if util.IsPelotonStateTerminal(
+				taskInfo.GetRuntime().GetState()) != terminal {
+				delete(tasks, instanceID)
 			}
-			resultMap[uint32(record.InstanceID)], err = s.getTask(ctx,
-				id.GetValue(), uint32(record.InstanceID))
+		}
+		s.metrics.TaskMetrics.TaskGetForJobByTerminality.Inc(1)
+		return tasks, nil
+	}
+
+	var states []string
+	for value, name := range task.TaskState_name {
+		if util.IsPelotonStateTerminal(task.TaskState(value)) == terminal {
+			states = append(states, name)
+		}
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Select("instance_id").From(tasksByStateView).
+		Where(qb.Eq{"job_id": jobID, "state": states})
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID).
+			WithField("terminal", terminal).
+			Error("Failed to GetTasksForJobByTerminality")
+		s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
+		return nil, err
+	}
+
+	var instanceIDs []uint32
+	for _, value := range allResults {
+		var record TaskRuntimeRecord
+		err := FillObject(value, &record, reflect.TypeOf(record))
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				WithField("value", value).
+				Error("GetTasksForJobByTerminality failed to Fill into TaskRecord")
+			s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
+			return nil, err
+		}
+		instanceIDs = append(instanceIDs, uint32(record.InstanceID))
+	}
+
+	// the view only holds the state, so the runtimes of the matching
+	// instances are read in batches of _defaultTaskIDBatchSize
+	resultMap := make(map[uint32]*task.TaskInfo)
+	for start := 0; start < len(instanceIDs); start += _defaultTaskIDBatchSize {
+		end := start + _defaultTaskIDBatchSize
+		if end > len(instanceIDs) {
+			end = len(instanceIDs)
+		}
+
+		queryBuilder := s.DataStore.NewQuery()
+		stmt := queryBuilder.Select("*").From(taskRuntimeTable).
+			Where(qb.Eq{
+				"job_id":      jobID,
+				"instance_id": instanceIDs[start:end],
+			})
+		allResults, err := s.executeRead(ctx, stmt)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				Error("Failed to GetTasksForJobByTerminality")
+			s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
+			return nil, err
+		}
+
+		for _, value := range allResults {
+			var record TaskRuntimeRecord
+			err := FillObject(value, &record, reflect.TypeOf(record))
 			if err != nil {
 				log.WithError(err).
 					WithField("job_id", jobID).
-					WithField("instance_id", record.InstanceID).
 					WithField("value", value).
-					Error("Failed to get taskInfo from task")
-				s.metrics.TaskMetrics.TaskGetForJobAndStatesFail.Inc(1)
+					Error("GetTasksForJobByTerminality failed to Fill into TaskRecord")
+				s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
+				return nil, err
+			}
+
+			taskInfo, err := s.getTaskInfoFromRuntimeRecord(ctx, id, &record)
+			if err != nil {
+				s.metrics.TaskMetrics.TaskGetForJobByTerminalityFail.Inc(1)
 				return nil, err
 			}
-			s.metrics.TaskMetrics.TaskGetForJobAndStates.Inc(1)
+			resultMap[uint32(record.InstanceID)] = taskInfo
 		}
 	}
-	s.metrics.TaskMetrics.TaskGetForJobAndStates.Inc(1)
+	s.metrics.TaskMetrics.TaskGetForJobByTerminality.Inc(1)
 	return resultMap, nil
 }
 
+// GetTasksForJobByConfigVersion returns the tasks of a peloton job whose
+// runtime is on the given config version, which lets an update count the
+// instances already moved to its config. As with GetTasksForJob, only the
+// runtime of the tasks is read.
+func (s *Store) GetTasksForJobByConfigVersion(
+	ctx context.Context,
+	id *peloton.JobID,
+	version uint64) (map[uint32]*task.TaskInfo, error) {
+	tasks, err := s.GetTasksForJob(ctx, id)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_id", id.GetValue()).
+			WithField("config_version", version).
+			Error("Failed to GetTasksForJobByConfigVersion")
+		s.metrics.TaskMetrics.TaskGetForJobByConfigVersionFail.Inc(1)
+		return nil, err
+	}
+
+	for instanceID, taskInfo := range tasks {
+		if taskInfo.GetRuntime().GetConfigVersion() != version {
+			delete(tasks, instanceID)
+		}
+	}
+	s.metrics.TaskMetrics.TaskGetForJobByConfigVersion.Inc(1)
+	return tasks, nil
+}
+
 func specContains(specifier []string, item string) bool {
 	if len(specifier) == 0 {
 		return true
@@ -1392,7 +2935,8 @@ func (s *Store) GetTaskRuntimesForJobByRange(ctx context.Context,
 }
 
 // GetTasksForJobByRange returns the TaskInfo for batch jobs by
-// instance ID range.
+// instance ID range. The range is half-open: it includes the instance
+// instanceRange.From and excludes the instance instanceRange.To.
 func (s *Store) GetTasksForJobByRange(ctx context.Context,
 	id *peloton.JobID, instanceRange *task.InstanceRange) (map[uint32]*task.TaskInfo, error) {
 	jobID := id.GetValue()
@@ -1529,24 +3073,130 @@ func (s *Store) UpdateTaskRuntime(
 	instanceID uint32,
 	runtime *task.RuntimeInfo,
 	jobType job.JobType) error {
+	stmt, err := s.taskRuntimeUpdateStmt(jobID, instanceID, runtime)
+	if err != nil {
+		s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
+		return err
+	}
+
+	if err := s.applyStatement(ctx, stmt, fmt.Sprintf(taskIDFmt, jobID.GetValue(), instanceID)); err != nil {
+		s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
+		return err
+	}
+
+	s.metrics.TaskMetrics.TaskUpdate.Inc(1)
+	s.addPodEvent(ctx, jobID, instanceID, runtime)
+
+	return nil
+}
+
+// UpdateTasks updates the runtimes of a list of tasks in batches of at
+// most Conf.MaxBatchSize tasks of the same job, like CreateTasks does for
+// new tasks. If any batch fails, an Internal error naming how many of the
+// tasks were not updated is returned, the other batches are still written.
+// Otherwise the first error logging the state change of an updated task is
+// returned.
+func (s *Store) UpdateTasks(
+	ctx context.Context,
+	taskInfos []*task.TaskInfo) error {
+	writes := make([]taskRuntimeWrite, 0, len(taskInfos))
+	for _, taskInfo := range taskInfos {
+		writes = append(writes, taskRuntimeWrite{
+			jobID:      taskInfo.GetJobId(),
+			instanceID: taskInfo.GetInstanceId(),
+			runtime:    taskInfo.GetRuntime(),
+		})
+	}
+
+	timeStart := time.Now()
+	tasksNotUpdated, err := s.writeTaskRuntimes(
+		ctx,
+		writes,
+		s.taskRuntimeUpdateStmt,
+		s.metrics.TaskMetrics.TaskUpdate,
+		s.metrics.TaskMetrics.TaskUpdateFail,
+	)
+	if tasksNotUpdated != 0 {
+		return yarpcerrors.InternalErrorf(
+			"failed to update %d of %d tasks in %v",
+			tasksNotUpdated,
+			len(writes),
+			time.Since(timeStart))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithField("tasks", len(writes)).
+		WithField("duration_s", time.Since(timeStart).Seconds()).
+		Debug("Updated task runtimes")
+	return nil
+}
+
+// taskRuntimeUpdateStmt returns the statement updating the runtime of a
+// task.
+func (s *Store) taskRuntimeUpdateStmt(
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo) (api.Statement, error) {
+	return s.taskRuntimeUpdateBuilder(jobID, instanceID, runtime)
+}
+
+// taskRuntimeUpdateBuilder builds the update of the runtime of a task, to
+// which conditions can be added.
+func (s *Store) taskRuntimeUpdateBuilder(
+	jobID *peloton.JobID,
+	instanceID uint32,
+	runtime *task.RuntimeInfo) (qb.UpdateBuilder, error) {
 	runtimeBuffer, err := proto.Marshal(runtime)
 	if err != nil {
-		s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
-		return err
+		return qb.UpdateBuilder{}, err
 	}
 
 	queryBuilder := s.DataStore.NewQuery()
-	stmt := queryBuilder.Update(taskRuntimeTable).
-		Set("version", runtime.Revision.Version).
+	return queryBuilder.Update(taskRuntimeTable).
+		Set("version", runtime.GetRevision().GetVersion()).
 		Set("update_time", time.Now().UTC()).
 		Set("state", runtime.GetState().String()).
 		Set("runtime_info", runtimeBuffer).
-		Where(qb.Eq{"job_id": jobID.GetValue(), "instance_id": instanceID})
+		Where(qb.Eq{"job_id": jobID.GetValue(), "instance_id": instanceID}), nil
+}
 
-	if err := s.applyStatement(ctx, stmt, fmt.Sprintf(taskIDFmt, jobID.GetValue(), instanceID)); err != nil {
+// UpdateTaskRuntimeCAS updates the runtime of a given task only if the
+// state stored for the task is still expectedState. A stale update, for
+// example an out-of-order status update, is rejected with a failed
+// precondition error instead of regressing the recorded task state.
+func (s *Store) UpdateTaskRuntimeCAS(
+	ctx context.Context,
+	taskInfo *task.TaskInfo,
+	expectedState task.TaskState) error {
+	jobID := taskInfo.GetJobId()
+	instanceID := taskInfo.GetInstanceId()
+	runtime := taskInfo.GetRuntime()
+	taskID := fmt.Sprintf(taskIDFmt, jobID.GetValue(), instanceID)
+
+	update, err := s.taskRuntimeUpdateBuilder(jobID, instanceID, runtime)
+	if err != nil {
+		s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
+		return err
+	}
+	stmt := update.IfOnly(qb.Eq{"state": expectedState.String()})
+
+	result, err := s.executeWrite(ctx, stmt)
+	if err != nil {
 		s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
 		return err
 	}
+	if result != nil {
+		defer result.Close()
+		if !result.Applied() {
+			s.metrics.ErrorMetrics.CASNotApplied.Inc(1)
+			s.metrics.TaskMetrics.TaskUpdateFail.Inc(1)
+			return yarpcerrors.FailedPreconditionErrorf(
+				"task %s is not in state %s, rejecting update to %s",
+				taskID, expectedState.String(), runtime.GetState().String())
+		}
+	}
 
 	s.metrics.TaskMetrics.TaskUpdate.Inc(1)
 	s.addPodEvent(ctx, jobID, instanceID, runtime)
@@ -1588,6 +3238,33 @@ func (s *Store) DeleteTaskRuntime(
 	return nil
 }
 
+// DeleteTask deletes a task along with its pod events, for example to
+// remove an orphaned task after the instance count of a job is shrunk.
+// Unlike DeleteTaskRuntime, it returns a not-found error if the task
+// does not exist.
+func (s *Store) DeleteTask(
+	ctx context.Context,
+	id *peloton.JobID,
+	instanceID uint32) error {
+	if _, err := s.getTaskRuntimeRecord(
+		ctx, id.GetValue(), instanceID); err != nil {
+		if !yarpcerrors.IsNotFound(err) {
+			s.metrics.TaskMetrics.TaskDeleteFail.Inc(1)
+		}
+		return err
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.Delete(podEventsTable).
+		Where(qb.Eq{"job_id": id.GetValue(), "instance_id": instanceID})
+	if err := s.applyStatement(ctx, stmt, id.GetValue()); err != nil {
+		s.metrics.TaskMetrics.TaskDeleteFail.Inc(1)
+		return err
+	}
+
+	return s.DeleteTaskRuntime(ctx, id, instanceID)
+}
+
 // 1) Pod Events table has partition key job_id + instance_id,
 // so pod events need to be deleted per instance.
 // 2) Fetch instance count from job config, and delete pod events
@@ -1647,7 +3324,8 @@ func (s *Store) deletePodEventsOnDeleteJob(
 	return nil
 }
 
-// DeleteJob deletes a job and associated tasks, by job id.
+// DeleteJob deletes a job and associated tasks, by job id. Deleting a job
+// which does not exist is not an error.
 // TODO: This implementation is not perfect, as if it's getting an transient
 // error, the job or some tasks may not be fully deleted.
 func (s *Store) DeleteJob(
@@ -1669,6 +3347,13 @@ func (s *Store) DeleteJob(
 		return err
 	}
 
+	// task configs written before task_config_v2 are partitioned by job
+	stmt = queryBuilder.Delete(taskConfigTable).Where(qb.Eq{"job_id": jobID})
+	if err := s.applyStatement(ctx, stmt, jobID); err != nil {
+		s.metrics.JobMetrics.JobDeleteFail.Inc(1)
+		return err
+	}
+
 	// Delete all updates for the job
 	updateIDs, err := s.GetUpdatesForJob(ctx, jobID)
 	if err != nil {
@@ -1688,8 +3373,15 @@ func (s *Store) DeleteJob(
 		return err
 	}
 
-	stmt = queryBuilder.Delete(jobRuntimeTable).Where(qb.Eq{"job_id": jobID})
-	err = s.applyStatement(ctx, stmt, jobID)
+	stmt = queryBuilder.Delete(jobMetadataTable).Where(qb.Eq{"job_id": jobID})
+	if err := s.applyStatement(ctx, stmt, jobID); err != nil {
+		s.metrics.JobMetrics.JobDeleteFail.Inc(1)
+		return err
+	}
+
+	// the job runtime is deleted along with the state history, the
+	// kill reason and the events of the job
+	err = s.jobRuntimeOps.Delete(ctx, &peloton.JobID{Value: jobID})
 	if err != nil {
 		s.metrics.JobMetrics.JobDeleteFail.Inc(1)
 	} else {
@@ -1750,6 +3442,82 @@ func (s *Store) GetTaskByID(ctx context.Context, taskID string) (*task.TaskInfo,
 	return s.getTask(ctx, jobID, uint32(instanceID))
 }
 
+// GetTasksByIDs returns the tasks for the given task IDs, keyed by task
+// ID, along with the IDs of the tasks which do not exist. Instances of
+// the same job are read in batches of _defaultTaskIDBatchSize.
+func (s *Store) GetTasksByIDs(
+	ctx context.Context,
+	taskIDs []string) (map[string]*task.TaskInfo, []string, error) {
+	// map of job ID -> instance IDs to read
+	instances := make(map[string][]uint32)
+	for _, taskID := range taskIDs {
+		jobID, instanceID, err := util.ParseTaskID(taskID)
+		if err != nil {
+			log.WithError(err).
+				WithField("task_id", taskID).
+				Error("Invalid task id")
+			s.metrics.TaskMetrics.TaskGetByIDsFail.Inc(1)
+			return nil, nil, err
+		}
+		instances[jobID] = append(instances[jobID], instanceID)
+	}
+
+	found := make(map[string]*task.TaskInfo)
+	for jobID, instanceIDs := range instances {
+		for start := 0; start < len(instanceIDs); start += _defaultTaskIDBatchSize {
+			end := start + _defaultTaskIDBatchSize
+			if end > len(instanceIDs) {
+				end = len(instanceIDs)
+			}
+
+			queryBuilder := s.DataStore.NewQuery()
+			stmt := queryBuilder.Select("*").From(taskRuntimeTable).
+				Where(qb.Eq{
+					"job_id":      jobID,
+					"instance_id": instanceIDs[start:end],
+				})
+			allResults, err := s.executeRead(ctx, stmt)
+			if err != nil {
+				log.WithError(err).
+					WithField("job_id", jobID).
+					Error("Failed to GetTasksByIDs")
+				s.metrics.TaskMetrics.TaskGetByIDsFail.Inc(1)
+				return nil, nil, err
+			}
+
+			for _, value := range allResults {
+				var record TaskRuntimeRecord
+				err := FillObject(value, &record, reflect.TypeOf(record))
+				if err != nil {
+					log.WithError(err).
+						WithField("job_id", jobID).
+						Error("GetTasksByIDs failed to Fill into TaskRecord")
+					s.metrics.TaskMetrics.TaskGetByIDsFail.Inc(1)
+					return nil, nil, err
+				}
+
+				taskInfo, err := s.getTaskInfoFromRuntimeRecord(
+					ctx, &peloton.JobID{Value: jobID}, &record)
+				if err != nil {
+					s.metrics.TaskMetrics.TaskGetByIDsFail.Inc(1)
+					return nil, nil, err
+				}
+				found[fmt.Sprintf(taskIDFmt, jobID, record.InstanceID)] = taskInfo
+			}
+		}
+	}
+
+	var notFound []string
+	for _, taskID := range taskIDs {
+		if _, ok := found[taskID]; !ok {
+			notFound = append(notFound, taskID)
+		}
+	}
+
+	s.metrics.TaskMetrics.TaskGetByIDs.Inc(1)
+	return found, notFound, nil
+}
+
 func (s *Store) getTask(ctx context.Context, jobID string, instanceID uint32) (*task.TaskInfo, error) {
 	record, err := s.getTaskRuntimeRecord(ctx, jobID, instanceID)
 	if err != nil {
@@ -1796,12 +3564,12 @@ func (s *Store) getTaskRuntimeRecord(ctx context.Context, jobID string, instance
 	return nil, yarpcerrors.NotFoundErrorf("task:%s not found", taskID)
 }
 
-//SetMesosStreamID stores the mesos framework id for a framework name
+// SetMesosStreamID stores the mesos framework id for a framework name
 func (s *Store) SetMesosStreamID(ctx context.Context, frameworkName string, mesosStreamID string) error {
 	return s.updateFrameworkTable(ctx, map[string]interface{}{"framework_name": frameworkName, "mesos_stream_id": mesosStreamID})
 }
 
-//SetMesosFrameworkID stores the mesos framework id for a framework name
+// SetMesosFrameworkID stores the mesos framework id for a framework name
 func (s *Store) SetMesosFrameworkID(ctx context.Context, frameworkName string, frameworkID string) error {
 	return s.updateFrameworkTable(ctx, map[string]interface{}{"framework_name": frameworkName, "framework_id": frameworkID})
 }
@@ -1833,7 +3601,7 @@ func (s *Store) updateFrameworkTable(ctx context.Context, content map[string]int
 	return nil
 }
 
-//GetMesosStreamID reads the mesos stream id for a framework name
+// GetMesosStreamID reads the mesos stream id for a framework name
 func (s *Store) GetMesosStreamID(ctx context.Context, frameworkName string) (string, error) {
 	frameworkInfoRecord, err := s.getFrameworkInfo(ctx, frameworkName)
 	if err != nil {
@@ -1845,7 +3613,7 @@ func (s *Store) GetMesosStreamID(ctx context.Context, frameworkName string) (str
 	return frameworkInfoRecord.MesosStreamID, nil
 }
 
-//GetFrameworkID reads the framework id for a framework name
+// GetFrameworkID reads the framework id for a framework name
 func (s *Store) GetFrameworkID(ctx context.Context, frameworkName string) (string, error) {
 	frameworkInfoRecord, err := s.getFrameworkInfo(ctx, frameworkName)
 	if err != nil {
@@ -2009,7 +3777,9 @@ func Less(orderByList []*query.OrderBy, t1 *task.TaskInfo, t2 *task.TaskInfo) bo
 	return t1.GetInstanceId() < t2.GetInstanceId()
 }
 
-// QueryTasks returns the tasks filtered on states(spec.TaskStates) in the given offset..offset+limit range.
+// QueryTasks returns the tasks filtered on states(spec.TaskStates) in the
+// given half-open [offset, offset+limit) range of the sorted tasks, so that
+// paging with consecutive offsets returns every task exactly once.
 func (s *Store) QueryTasks(
 	ctx context.Context,
 	jobID *peloton.JobID,
@@ -2070,7 +3840,18 @@ func (s *Store) QueryTasks(
 
 // CreatePersistentVolume creates a persistent volume entry.
 func (s *Store) CreatePersistentVolume(ctx context.Context, volume *pb_volume.PersistentVolumeInfo) error {
+	if err := s.createPersistentVolume(ctx, volume); err != nil {
+		s.metrics.VolumeMetrics.VolumeCreateFail.Inc(1)
+		return err
+	}
+
+	s.metrics.VolumeMetrics.VolumeCreate.Inc(1)
+	return nil
+}
 
+// createPersistentVolume inserts the persistent volume entry if it does not
+// exist yet, without updating the volume metrics.
+func (s *Store) createPersistentVolume(ctx context.Context, volume *pb_volume.PersistentVolumeInfo) error {
 	queryBuilder := s.DataStore.NewQuery()
 	stmt := queryBuilder.Insert(volumeTable).
 		Columns("volume_id", "state", "goal_state", "job_id", "instance_id", "hostname", "size_mb", "container_path", "creation_time", "update_time").
@@ -2087,8 +3868,33 @@ func (s *Store) CreatePersistentVolume(ctx context.Context, volume *pb_volume.Pe
 			time.Now().UTC()).
 		IfNotExist()
 
-	err := s.applyStatement(ctx, stmt, volume.GetId().GetValue())
-	if err != nil {
+	return s.applyStatement(ctx, stmt, volume.GetId().GetValue())
+}
+
+// CreatePersistentVolumes creates the persistent volume entries of several
+// volumes, such as the volumes of all the instances of a stateful job. The
+// inserts are conditional on different partitions, which Cassandra can't
+// apply in a single batch, so the volumes are created one at a time. It
+// stops at the first volume failing to be created, keeping the volumes
+// created before it.
+func (s *Store) CreatePersistentVolumes(ctx context.Context, volumes []*pb_volume.PersistentVolumeInfo) error {
+	for _, volume := range volumes {
+		if err := s.CreatePersistentVolume(ctx, volume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertPersistentVolume creates a persistent volume entry, treating an
+// existing entry for the same volume as success if it describes the same
+// volume, so that recovery can re-create volume records idempotently. The
+// state and goal state of an existing entry are left untouched, they are
+// advanced by UpdatePersistentVolume. An existing entry with a different
+// job, instance, host, size or container path is a conflict and an
+// AlreadyExists error is returned.
+func (s *Store) UpsertPersistentVolume(ctx context.Context, volume *pb_volume.PersistentVolumeInfo) error {
+	if err := s.upsertPersistentVolume(ctx, volume); err != nil {
 		s.metrics.VolumeMetrics.VolumeCreateFail.Inc(1)
 		return err
 	}
@@ -2097,6 +3903,37 @@ func (s *Store) CreatePersistentVolume(ctx context.Context, volume *pb_volume.Pe
 	return nil
 }
 
+// upsertPersistentVolume creates the persistent volume entry, or checks that
+// the existing entry describes the same volume, without updating the volume
+// create metrics.
+func (s *Store) upsertPersistentVolume(ctx context.Context, volume *pb_volume.PersistentVolumeInfo) error {
+	err := s.createPersistentVolume(ctx, volume)
+	if err == nil || !yarpcerrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	existing, err := s.GetPersistentVolume(ctx, volume.GetId())
+	if err != nil {
+		return err
+	}
+
+	if existing.GetJobId().GetValue() != volume.GetJobId().GetValue() ||
+		existing.GetInstanceId() != volume.GetInstanceId() ||
+		existing.GetHostname() != volume.GetHostname() ||
+		existing.GetSizeMB() != volume.GetSizeMB() ||
+		existing.GetContainerPath() != volume.GetContainerPath() {
+		log.WithFields(log.Fields{
+			"volume_id": volume.GetId().GetValue(),
+			"existing":  existing,
+			"volume":    volume,
+		}).Error("persistent volume exists with conflicting attributes")
+		return yarpcerrors.AlreadyExistsErrorf(
+			"volume %s exists with conflicting attributes",
+			volume.GetId().GetValue())
+	}
+	return nil
+}
+
 // UpdatePersistentVolume updates persistent volume info.
 func (s *Store) UpdatePersistentVolume(ctx context.Context, volumeInfo *pb_volume.PersistentVolumeInfo) error {
 
@@ -2146,29 +3983,333 @@ func (s *Store) GetPersistentVolume(ctx context.Context, volumeID *peloton.Volum
 			return nil, err
 		}
 		s.metrics.VolumeMetrics.VolumeGet.Inc(1)
-		return &pb_volume.PersistentVolumeInfo{
-			Id: &peloton.VolumeID{
-				Value: record.VolumeID,
-			},
-			State: pb_volume.VolumeState(
-				pb_volume.VolumeState_value[record.State]),
-			GoalState: pb_volume.VolumeState(
-				pb_volume.VolumeState_value[record.GoalState]),
-			JobId: &peloton.JobID{
-				Value: record.JobID,
-			},
-			InstanceId:    uint32(record.InstanceID),
-			Hostname:      record.Hostname,
-			SizeMB:        uint32(record.SizeMB),
-			ContainerPath: record.ContainerPath,
-			CreateTime:    record.CreateTime.String(),
-			UpdateTime:    record.UpdateTime.String(),
-		}, nil
+		return newPersistentVolumeInfo(&record), nil
 	}
 	s.metrics.VolumeMetrics.VolumeGetFail.Inc(1)
 	return nil, &storage.VolumeNotFoundError{VolumeID: volumeID}
 }
 
+// GetPersistentVolumesForJob gets the persistent volumes of a job, ordered
+// by volume ID. It returns an empty list if the job has no volumes.
+func (s *Store) GetPersistentVolumesForJob(ctx context.Context, jobID *peloton.JobID) ([]*pb_volume.PersistentVolumeInfo, error) {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.
+		Select("*").
+		From(volumesByJobView).
+		Where(qb.Eq{"job_id": jobID.GetValue()})
+	volumes, err := s.readVolumes(
+		ctx,
+		stmt,
+		s.metrics.VolumeMetrics.VolumeGetForJob,
+		s.metrics.VolumeMetrics.VolumeGetForJobFail,
+	)
+	if err != nil {
+		log.WithError(err).
+			WithField("job_id", jobID.GetValue()).
+			Error("Fail to GetPersistentVolumesForJob by jobID.")
+		return nil, err
+	}
+	return volumes, nil
+}
+
+// GetVolumesByState gets the persistent volumes in the given state, ordered
+// by volume ID, such as the volumes whose state differs from their goal
+// state for the volume reconciler. The volumes are read from a
+// materialized view, which is updated asynchronously, so a volume whose
+// state has just been updated may still be returned for its previous state
+// or not be returned yet for its new state.
+func (s *Store) GetVolumesByState(ctx context.Context, state pb_volume.VolumeState) ([]*pb_volume.PersistentVolumeInfo, error) {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.
+		Select("*").
+		From(volumesByStateView).
+		Where(qb.Eq{"state": state.String()})
+	volumes, err := s.readVolumes(
+		ctx,
+		stmt,
+		s.metrics.VolumeMetrics.VolumeGetByState,
+		s.metrics.VolumeMetrics.VolumeGetByStateFail,
+	)
+	if err != nil {
+		log.WithError(err).
+			WithField("state", state.String()).
+			Error("Fail to GetVolumesByState by state.")
+		return nil, err
+	}
+	return volumes, nil
+}
+
+// readVolumes reads the persistent volumes selected by the statement, and
+// increments the success or the fail counter with the outcome.
+func (s *Store) readVolumes(
+	ctx context.Context,
+	stmt api.Statement,
+	successCounter tally.Counter,
+	failCounter tally.Counter,
+) ([]*pb_volume.PersistentVolumeInfo, error) {
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		failCounter.Inc(1)
+		return nil, err
+	}
+
+	volumes := make([]*pb_volume.PersistentVolumeInfo, 0, len(allResults))
+	for _, value := range allResults {
+		var record PersistentVolumeRecord
+		err := FillObject(value, &record, reflect.TypeOf(record))
+		if err != nil {
+			log.WithError(err).
+				WithField("raw_volume_value", value).
+				Error("Failed to Fill into PersistentVolumeRecord.")
+			failCounter.Inc(1)
+			return nil, err
+		}
+		volumes = append(volumes, newPersistentVolumeInfo(&record))
+	}
+	successCounter.Inc(1)
+	return volumes, nil
+}
+
+// newPersistentVolumeInfo converts a persistent volume record read from
+// DB to a PersistentVolumeInfo.
+func newPersistentVolumeInfo(
+	record *PersistentVolumeRecord,
+) *pb_volume.PersistentVolumeInfo {
+	return &pb_volume.PersistentVolumeInfo{
+		Id: &peloton.VolumeID{
+			Value: record.VolumeID,
+		},
+		State: pb_volume.VolumeState(
+			pb_volume.VolumeState_value[record.State]),
+		GoalState: pb_volume.VolumeState(
+			pb_volume.VolumeState_value[record.GoalState]),
+		JobId: &peloton.JobID{
+			Value: record.JobID,
+		},
+		InstanceId:    uint32(record.InstanceID),
+		Hostname:      record.Hostname,
+		SizeMB:        uint32(record.SizeMB),
+		ContainerPath: record.ContainerPath,
+		CreateTime:    record.CreateTime.Format(time.RFC3339Nano),
+		UpdateTime:    record.UpdateTime.Format(time.RFC3339Nano),
+	}
+}
+
+// GetResourcePool returns the resource pool with the given ID.
+func (s *Store) GetResourcePool(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+) (*respool.ResourcePoolInfo, error) {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.
+		Select("respool_id", "owner", "respool_config",
+			"creation_time", "update_time").
+		From(respoolsTable).
+		Where(qb.Eq{"respool_id": id.GetValue()})
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", id.GetValue()).
+			Error("Fail to GetResourcePool by respoolID.")
+		s.metrics.ResourcePoolMetrics.ResourcePoolGetFail.Inc(1)
+		return nil, err
+	}
+
+	for _, value := range allResults {
+		info, err := newResourcePoolInfo(value)
+		if err != nil {
+			s.metrics.ResourcePoolMetrics.ResourcePoolGetFail.Inc(1)
+			return nil, err
+		}
+		s.metrics.ResourcePoolMetrics.ResourcePoolGet.Inc(1)
+		return info, nil
+	}
+	s.metrics.ResourcePoolMetrics.ResourcePoolGetFail.Inc(1)
+	return nil, &storage.ResourcePoolNotFoundError{ResourcePoolID: id}
+}
+
+// GetResourcePools returns up to limit resource pools, ordered by the token
+// of their resource pool ID, which is the order of the rows of the respools
+// table. The page starts after the pool with the given ID, callers pass the
+// last returned pool ID back as after to fetch the next page, and an empty
+// after to start from the first pool.
+func (s *Store) GetResourcePools(
+	ctx context.Context,
+	after string,
+	limit uint32,
+) ([]*respool.ResourcePoolInfo, error) {
+	if limit == 0 {
+		limit = _defaultQueryLimit
+	}
+
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.
+		Select("respool_id", "owner", "respool_config",
+			"creation_time", "update_time").
+		From(respoolsTable)
+	if after != "" {
+		stmt = stmt.Where("token(respool_id) > token(?)", after)
+	}
+	stmt = stmt.Limit(uint64(limit))
+
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("after", after).
+			Error("Fail to GetResourcePools.")
+		s.metrics.ResourcePoolMetrics.ResourcePoolGetFail.Inc(1)
+		return nil, err
+	}
+
+	page := make([]*respool.ResourcePoolInfo, 0, len(allResults))
+	for _, value := range allResults {
+		info, err := newResourcePoolInfo(value)
+		if err != nil {
+			s.metrics.ResourcePoolMetrics.ResourcePoolGetFail.Inc(1)
+			return nil, err
+		}
+		page = append(page, info)
+	}
+
+	s.metrics.ResourcePoolMetrics.ResourcePoolGet.Inc(1)
+	return page, nil
+}
+
+// newResourcePoolInfo converts a row of the respools table to a resource
+// pool info.
+func newResourcePoolInfo(
+	value map[string]interface{},
+) (*respool.ResourcePoolInfo, error) {
+	var record ResourcePoolRecord
+	err := FillObject(value, &record, reflect.TypeOf(record))
+	if err != nil {
+		log.WithError(err).
+			WithField("raw_respool_value", value).
+			Error("Failed to Fill into ResourcePoolRecord.")
+		return nil, err
+	}
+	config, err := record.GetResourcePoolConfig()
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", record.RespoolID).
+			Error("Failed to unmarshal resource pool config.")
+		return nil, err
+	}
+	return &respool.ResourcePoolInfo{
+		Id: &peloton.ResourcePoolID{
+			Value: record.RespoolID,
+		},
+		Parent: config.GetParent(),
+		Config: config,
+	}, nil
+}
+
+// UpdateResourcePool replaces the config of an existing resource pool. The
+// change log version of the given config must match the version of the
+// stored config, so that concurrent updates cannot overwrite each other.
+// The stored config gets the next version and keeps its creation time, while
+// its update time is bumped. The pool is read first so that updating a
+// missing pool fails instead of creating a pool without an owner or a
+// creation time.
+func (s *Store) UpdateResourcePool(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	config *respool.ResourcePoolConfig,
+) error {
+	queryBuilder := s.DataStore.NewQuery()
+	stmt := queryBuilder.
+		Select("respool_id", "owner", "respool_config",
+			"creation_time", "update_time").
+		From(respoolsTable).
+		Where(qb.Eq{"respool_id": id.GetValue()})
+	allResults, err := s.executeRead(ctx, stmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", id.GetValue()).
+			Error("Fail to read resource pool for update.")
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return err
+	}
+	if len(allResults) == 0 {
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return &storage.ResourcePoolNotFoundError{ResourcePoolID: id}
+	}
+
+	var record ResourcePoolRecord
+	if err := FillObject(
+		allResults[0], &record, reflect.TypeOf(record)); err != nil {
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return err
+	}
+	current, err := record.GetResourcePoolConfig()
+	if err != nil {
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return err
+	}
+
+	version := current.GetChangeLog().GetVersion()
+	if config.GetChangeLog().GetVersion() != version {
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return yarpcerrors.FailedPreconditionErrorf(
+			"resource pool %s is at version %d, rejecting update of version %d",
+			id.GetValue(), version, config.GetChangeLog().GetVersion())
+	}
+
+	// Pools written without a change log only have the creation time column.
+	createdAt := current.GetChangeLog().GetCreatedAt()
+	if createdAt == 0 {
+		createdAt = record.CreationTime.UnixNano()
+	}
+	now := time.Now().UTC()
+	newConfig := proto.Clone(config).(*respool.ResourcePoolConfig)
+	newConfig.ChangeLog = &changelog.ChangeLog{
+		Version:   version + 1,
+		CreatedAt: createdAt,
+		UpdatedAt: now.UnixNano(),
+		UpdatedBy: config.GetChangeLog().GetUpdatedBy(),
+	}
+
+	configBuffer, err := json.Marshal(newConfig)
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", id.GetValue()).
+			Error("Failed to marshal resource pool config.")
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return err
+	}
+
+	// Only apply the update if the config has not been changed since it
+	// was read.
+	updateStmt := queryBuilder.
+		Update(respoolsTable).
+		Set("respool_config", string(configBuffer)).
+		Set("update_time", now).
+		Where(qb.Eq{"respool_id": id.GetValue()}).
+		IfOnly(qb.Eq{"respool_config": record.RespoolConfig})
+
+	result, err := s.executeWrite(ctx, updateStmt)
+	if err != nil {
+		log.WithError(err).
+			WithField("respool_id", id.GetValue()).
+			Error("Failed to update resource pool config.")
+		s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+		return err
+	}
+	if result != nil {
+		defer result.Close()
+		if !result.Applied() {
+			s.metrics.ErrorMetrics.CASNotApplied.Inc(1)
+			s.metrics.ResourcePoolMetrics.ResourcePoolUpdateFail.Inc(1)
+			return yarpcerrors.FailedPreconditionErrorf(
+				"resource pool %s was updated concurrently from version %d",
+				id.GetValue(), version)
+		}
+	}
+
+	s.metrics.ResourcePoolMetrics.ResourcePoolUpdate.Inc(1)
+	return nil
+}
+
 // CreateUpdate creates a new update entry in DB.
 // If it already exists, the create will return an error.
 func (s *Store) CreateUpdate(
@@ -2838,6 +4979,37 @@ func (s *Store) GetUpdatesForJob(
 	return updateIDs, nil
 }
 
+// ListUpdatesForJob returns the job updates, including their configuration
+// and status, created for a given job sorted by creation time with the most
+// recent update first.
+func (s *Store) ListUpdatesForJob(
+	ctx context.Context,
+	jobID string,
+) ([]*models.UpdateModel, error) {
+	updateIDs, err := s.GetUpdatesForJob(ctx, jobID)
+	if err != nil {
+		s.metrics.UpdateMetrics.UpdateListForJobFail.Inc(1)
+		return nil, err
+	}
+
+	updates := make([]*models.UpdateModel, 0, len(updateIDs))
+	for _, updateID := range updateIDs {
+		updateModel, err := s.GetUpdate(ctx, updateID)
+		if err != nil {
+			log.WithError(err).
+				WithField("job_id", jobID).
+				WithField("update_id", updateID.GetValue()).
+				Info("failed to get update for the job")
+			s.metrics.UpdateMetrics.UpdateListForJobFail.Inc(1)
+			return nil, err
+		}
+		updates = append(updates, updateModel)
+	}
+
+	s.metrics.UpdateMetrics.UpdateListForJob.Inc(1)
+	return updates, nil
+}
+
 func parseTime(v string) time.Time {
 	r, err := time.Parse(time.RFC3339Nano, v)
 	if err != nil {
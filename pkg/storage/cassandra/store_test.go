@@ -20,7 +20,10 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -29,6 +32,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/query"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
 	"github.com/uber/peloton/.gen/peloton/api/v0/volume"
@@ -37,10 +41,12 @@ import (
 	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/common/backoff"
 	"github.com/uber/peloton/pkg/common/taskconfig"
+	"github.com/uber/peloton/pkg/common/util"
 	"github.com/uber/peloton/pkg/storage"
 	ormobjects "github.com/uber/peloton/pkg/storage/objects"
 	qb "github.com/uber/peloton/pkg/storage/querybuilder"
 
+	"github.com/gemnasium/migrate/migrate"
 	"github.com/gocql/gocql"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
@@ -67,6 +73,7 @@ var store *Store
 var testScope = tally.NewTestScope("", map[string]string{})
 var jobConfigOps ormobjects.JobConfigOps
 var jobRuntimeOps ormobjects.JobRuntimeOps
+var resPoolOps ormobjects.ResPoolOps
 
 // This test vector borrowed from gzip test suite to simulate a checksum
 // error during ucompressing data
@@ -102,6 +109,7 @@ func init() {
 
 	jobConfigOps = ormobjects.NewJobConfigOps(ormStore)
 	jobRuntimeOps = ormobjects.NewJobRuntimeOps(ormStore)
+	resPoolOps = ormobjects.NewResPoolOps(ormStore)
 }
 
 func TestCassandraStore(t *testing.T) {
@@ -156,7 +164,7 @@ func (suite *CassandraStoreTestSuite) createJob(
 	initialJobRuntime.TaskStats[task.TaskState_INITIALIZED.String()] = jobConfig.InstanceCount
 
 	// Create the initial job runtime record
-	err := jobRuntimeOps.Upsert(ctx, id, &initialJobRuntime)
+	err := jobRuntimeOps.Upsert(ctx, id, &initialJobRuntime, job.JobState_UNKNOWN)
 	if err != nil {
 		return err
 	}
@@ -217,6 +225,7 @@ func updateJobIndex(
 		return nil
 	}
 
+	updateTime := time.Now()
 	queryBuilder := store.DataStore.NewQuery()
 	stmt := queryBuilder.Update(jobIndexTable).
 		Where(qb.Eq{"job_id": id.GetValue()})
@@ -238,7 +247,7 @@ func updateJobIndex(
 			Set("state", runtime.GetState().String()).
 			Set("creation_time", parseTime(runtime.GetCreationTime())).
 			Set("completion_time", completeTime).
-			Set("update_time", time.Now())
+			Set("update_time", updateTime)
 	}
 
 	if config != nil {
@@ -270,6 +279,16 @@ func updateJobIndex(
 	if err != nil {
 		return err
 	}
+
+	if runtime != nil {
+		stmt := queryBuilder.Insert(jobsByUpdateTimeTable).
+			Columns("update_day", "update_time", "job_id").
+			Values(
+				ormobjects.JobIndexUpdateDay(updateTime),
+				updateTime,
+				id.GetValue())
+		return store.applyStatement(ctx, stmt, id.GetValue())
+	}
 	return nil
 }
 
@@ -330,6 +349,35 @@ func (suite *CassandraStoreTestSuite) TestMigrateString() {
 	suite.Equal(connStr, expectedStr)
 }
 
+// TestRollback tests that rolling back schema migrations is refused unless
+// allowed by the config, and that it decreases the schema version
+func (suite *CassandraStoreTestSuite) TestRollback() {
+	conf := GenerateTestCassandraConfig()
+	suite.Len(conf.AutoMigrate(), 0)
+	version, err := migrate.Version(conf.MigrateString(), conf.Migrations)
+	suite.NoError(err)
+
+	// rollback is refused by default
+	suite.Len(conf.Rollback(1), 1)
+	current, err := migrate.Version(conf.MigrateString(), conf.Migrations)
+	suite.NoError(err)
+	suite.Equal(version, current)
+
+	conf.AllowRollback = true
+	suite.Len(conf.Rollback(0), 1)
+
+	suite.Len(conf.Rollback(1), 0)
+	current, err = migrate.Version(conf.MigrateString(), conf.Migrations)
+	suite.NoError(err)
+	suite.True(current < version)
+
+	// migrate up again for the rest of the tests
+	suite.Len(conf.AutoMigrate(), 0)
+	current, err = migrate.Version(conf.MigrateString(), conf.Migrations)
+	suite.NoError(err)
+	suite.Equal(version, current)
+}
+
 // TestGetMaxJobConfigVersion tests get latest job version from job_config
 func (suite *CassandraStoreTestSuite) TestGetMaxJobConfigVersion() {
 	var jobStore storage.JobStore
@@ -411,7 +459,7 @@ func (suite *CassandraStoreTestSuite) TestQueryJobPaging() {
 		suite.NoError(err)
 
 		runtime.State = job.JobState(i + 1)
-		err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime)
+		err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime, job.JobState_UNKNOWN)
 		suite.NoError(err)
 	}
 
@@ -531,7 +579,7 @@ func (suite *CassandraStoreTestSuite) TestJobQueryStaleLuceneIndex() {
 	// set job creation time to two days ago
 	creationTime := time.Now().AddDate(0, 0, -5).UTC().Format(time.RFC3339Nano)
 	runtime.CreationTime = creationTime
-	err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime)
+	err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime, job.JobState_UNKNOWN)
 	suite.NoError(err)
 	err = updateJobIndex(context.Background(), &jobID, nil, runtime)
 	suite.NoError(err)
@@ -548,7 +596,7 @@ func (suite *CassandraStoreTestSuite) TestJobQueryStaleLuceneIndex() {
 
 	// Set runtime state to succeeded and update the job_index
 	runtime.State = job.JobState_SUCCEEDED
-	err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime)
+	err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime, job.JobState_UNKNOWN)
 	suite.NoError(err)
 	err = updateJobIndex(context.Background(), &jobID, nil, runtime)
 	suite.NoError(err)
@@ -570,6 +618,377 @@ func (suite *CassandraStoreTestSuite) TestJobQueryStaleLuceneIndex() {
 	suite.Equal(job.JobState_SUCCEEDED, newSummary[0].GetRuntime().GetState())
 }
 
+func (suite *CassandraStoreTestSuite) TestGetJobsUpdatedSince() {
+	ctx := context.Background()
+	jobTypes := []job.JobType{
+		job.JobType_BATCH,
+		job.JobType_SERVICE,
+		job.JobType_BATCH,
+		job.JobType_SERVICE,
+	}
+
+	var jobIDs []*peloton.JobID
+	var cutoff time.Time
+	for i, jobType := range jobTypes {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := &job.JobConfig{
+			Name:          fmt.Sprintf("UpdatedSince-%d", i),
+			OwningTeam:    "owner",
+			Type:          jobType,
+			InstanceCount: 1,
+		}
+		suite.NoError(suite.createJob(
+			ctx, jobID, jobConfig, &models.ConfigAddOn{}, "uber"))
+		jobIDs = append(jobIDs, jobID)
+
+		// update times are stored with millisecond precision
+		time.Sleep(5 * time.Millisecond)
+		if i == 0 {
+			cutoff = time.Now()
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// only jobs updated after the cutoff are returned, oldest first
+	summaries, last, lastID, err := store.GetJobsUpdatedSince(
+		ctx, cutoff, "", 100)
+	suite.NoError(err)
+	var ids []string
+	for _, summary := range summaries {
+		ids = append(ids, summary.GetId().GetValue())
+	}
+	suite.Equal([]string{
+		jobIDs[1].GetValue(),
+		jobIDs[2].GetValue(),
+		jobIDs[3].GetValue(),
+	}, ids)
+	suite.True(last.After(cutoff))
+	suite.Equal(jobIDs[3].GetValue(), lastID)
+
+	// page through the same jobs one at a time
+	since, after := cutoff, ""
+	for _, jobID := range jobIDs[1:] {
+		summaries, since, after, err = store.GetJobsUpdatedSince(
+			ctx, since, after, 1)
+		suite.NoError(err)
+		suite.Len(summaries, 1)
+		suite.Equal(jobID.GetValue(), summaries[0].GetId().GetValue())
+	}
+
+	summaries, since, after, err = store.GetJobsUpdatedSince(
+		ctx, since, after, 1)
+	suite.NoError(err)
+	suite.Empty(summaries)
+	suite.True(since.IsZero())
+	suite.Empty(after)
+
+	// a job updated again is returned at its latest update time only
+	time.Sleep(5 * time.Millisecond)
+	runtime, err := jobRuntimeOps.Get(ctx, jobIDs[1])
+	suite.NoError(err)
+	suite.NoError(updateJobIndex(ctx, jobIDs[1], nil, runtime))
+	summaries, _, lastID, err = store.GetJobsUpdatedSince(
+		ctx, cutoff, "", 100)
+	suite.NoError(err)
+	ids = nil
+	for _, summary := range summaries {
+		ids = append(ids, summary.GetId().GetValue())
+	}
+	suite.Equal([]string{
+		jobIDs[2].GetValue(),
+		jobIDs[3].GetValue(),
+		jobIDs[1].GetValue(),
+	}, ids)
+	suite.Equal(jobIDs[1].GetValue(), lastID)
+
+	// updates older than the retention of the index are not returned
+	_, _, _, err = store.GetJobsUpdatedSince(
+		ctx, time.Time{}, "", 100)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+func (suite *CassandraStoreTestSuite) TestGetJobIDsByRespoolID() {
+	ctx := context.Background()
+	respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+
+	expected := map[string]bool{}
+	for i := 0; i < 25; i++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := &job.JobConfig{
+			Name:          fmt.Sprintf("RespoolJob-%d", i),
+			OwningTeam:    "owner",
+			Type:          job.JobType_BATCH,
+			InstanceCount: 1,
+			RespoolID:     respoolID,
+		}
+		suite.NoError(suite.createJob(
+			ctx, jobID, jobConfig, &models.ConfigAddOn{}, "uber"))
+		expected[jobID.GetValue()] = true
+	}
+
+	// a job in another resource pool is not returned
+	otherConfig := &job.JobConfig{
+		Name:          "OtherRespoolJob",
+		OwningTeam:    "owner",
+		Type:          job.JobType_BATCH,
+		InstanceCount: 1,
+		RespoolID:     &peloton.ResourcePoolID{Value: uuid.New()},
+	}
+	suite.NoError(suite.createJob(
+		ctx, &peloton.JobID{Value: uuid.New()}, otherConfig,
+		&models.ConfigAddOn{}, "uber"))
+
+	jobIDs, err := store.GetJobIDsByRespoolID(ctx, respoolID, "", 100)
+	suite.NoError(err)
+	suite.Len(jobIDs, len(expected))
+	for _, jobID := range jobIDs {
+		suite.True(expected[jobID.GetValue()])
+	}
+
+	// page through the same jobs, ten at a time
+	found := map[string]bool{}
+	after := ""
+	for {
+		jobIDs, err = store.GetJobIDsByRespoolID(ctx, respoolID, after, 10)
+		suite.NoError(err)
+		if len(jobIDs) == 0 {
+			break
+		}
+		suite.True(len(jobIDs) <= 10)
+		for _, jobID := range jobIDs {
+			suite.False(found[jobID.GetValue()])
+			found[jobID.GetValue()] = true
+		}
+		after = jobIDs[len(jobIDs)-1].GetValue()
+	}
+	suite.Equal(expected, found)
+}
+
+func (suite *CassandraStoreTestSuite) TestGetAllJobs() {
+	ctx := context.Background()
+
+	expected := map[string]*job.JobConfig{}
+	for i := 0; i < 15; i++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := &job.JobConfig{
+			Name:          fmt.Sprintf("AllJobs-%d", i),
+			OwningTeam:    fmt.Sprintf("all_jobs_owner_%d", i%3),
+			Type:          job.JobType_BATCH,
+			InstanceCount: 1,
+		}
+		suite.NoError(suite.createJob(
+			ctx, jobID, jobConfig, &models.ConfigAddOn{}, "uber"))
+		expected[jobID.GetValue()] = jobConfig
+	}
+
+	jobs, err := store.GetAllJobs(ctx)
+	suite.NoError(err)
+	// other tests create jobs as well, so only check the ones created here
+	for id, config := range expected {
+		suite.Contains(jobs, id)
+		suite.Equal(config.GetName(), jobs[id].GetName())
+		suite.Equal(config.GetOwningTeam(), jobs[id].GetOwningTeam())
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestGetJobsByOwnerPaged() {
+	ctx := context.Background()
+	owner := uuid.New()
+
+	var expected []string
+	for i := 0; i < 25; i++ {
+		jobConfig := &job.JobConfig{
+			Name:          fmt.Sprintf("OwnerJob-%d", i),
+			OwningTeam:    owner,
+			Type:          job.JobType_BATCH,
+			InstanceCount: 1,
+		}
+		suite.NoError(suite.createJob(
+			ctx, &peloton.JobID{Value: uuid.New()}, jobConfig,
+			&models.ConfigAddOn{}, "uber"))
+		expected = append(expected, jobConfig.GetName())
+	}
+
+	// a job of another owner is not returned
+	otherConfig := &job.JobConfig{
+		Name:          "OtherOwnerJob",
+		OwningTeam:    uuid.New(),
+		Type:          job.JobType_BATCH,
+		InstanceCount: 1,
+	}
+	suite.NoError(suite.createJob(
+		ctx, &peloton.JobID{Value: uuid.New()}, otherConfig,
+		&models.ConfigAddOn{}, "uber"))
+
+	// page through the jobs ten at a time, no job should be returned
+	// twice or skipped
+	found := map[string]bool{}
+	for offset := uint32(0); ; offset += 10 {
+		configs, total, err := store.GetJobsByOwnerPaged(ctx, owner, offset, 10)
+		suite.NoError(err)
+		suite.Equal(uint32(len(expected)), total)
+		if len(configs) == 0 {
+			break
+		}
+		suite.True(len(configs) <= 10)
+		for _, config := range configs {
+			suite.Equal(owner, config.GetOwningTeam())
+			suite.False(found[config.GetName()])
+			found[config.GetName()] = true
+		}
+	}
+	suite.Len(found, len(expected))
+	for _, name := range expected {
+		suite.True(found[name])
+	}
+
+	// an offset past the last job returns an empty page
+	configs, total, err := store.GetJobsByOwnerPaged(ctx, owner, 100, 10)
+	suite.NoError(err)
+	suite.Empty(configs)
+	suite.Equal(uint32(len(expected)), total)
+
+	// a limit overflowing past the offset returns the remaining jobs
+	configs, total, err = store.GetJobsByOwnerPaged(
+		ctx, owner, 20, math.MaxUint32)
+	suite.NoError(err)
+	suite.Len(configs, 5)
+	suite.Equal(uint32(len(expected)), total)
+
+	configs, total, err = store.GetJobsByOwnerPaged(ctx, uuid.New(), 0, 10)
+	suite.NoError(err)
+	suite.Empty(configs)
+	suite.Equal(uint32(0), total)
+}
+
+func (suite *CassandraStoreTestSuite) TestMoveJobToRespool() {
+	ctx := context.Background()
+	oldRespoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+	newRespoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := &job.JobConfig{
+		Name:          "MoveRespoolJob",
+		OwningTeam:    "owner",
+		Type:          job.JobType_BATCH,
+		InstanceCount: 1,
+		RespoolID:     oldRespoolID,
+	}
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "uber"))
+
+	jobIDs, err := store.GetJobIDsByRespoolID(ctx, oldRespoolID, "", 10)
+	suite.NoError(err)
+	suite.Equal([]peloton.JobID{*jobID}, jobIDs)
+
+	suite.NoError(store.MoveJobToRespool(ctx, jobID, newRespoolID))
+
+	jobIDs, err = store.GetJobIDsByRespoolID(ctx, oldRespoolID, "", 10)
+	suite.NoError(err)
+	suite.Empty(jobIDs)
+	jobIDs, err = store.GetJobIDsByRespoolID(ctx, newRespoolID, "", 10)
+	suite.NoError(err)
+	suite.Equal([]peloton.JobID{*jobID}, jobIDs)
+
+	// the job config is moved as a new version
+	config, _, err := jobConfigOps.GetCurrentVersion(ctx, jobID)
+	suite.NoError(err)
+	suite.Equal(newRespoolID.GetValue(), config.GetRespoolID().GetValue())
+	suite.Equal(uint64(2), config.GetChangeLog().GetVersion())
+	summary, err := store.getJobSummaryFromIndex(ctx, jobID)
+	suite.NoError(err)
+	suite.Equal(newRespoolID.GetValue(), summary.GetRespoolID().GetValue())
+
+	// moving the job to the pool it is already in is a no-op
+	suite.NoError(store.MoveJobToRespool(ctx, jobID, newRespoolID))
+	jobIDs, err = store.GetJobIDsByRespoolID(ctx, newRespoolID, "", 10)
+	suite.NoError(err)
+	suite.Equal([]peloton.JobID{*jobID}, jobIDs)
+	runtime, err := jobRuntimeOps.Get(ctx, jobID)
+	suite.NoError(err)
+	suite.Equal(uint64(2), runtime.GetConfigurationVersion())
+
+	err = store.MoveJobToRespool(
+		ctx, &peloton.JobID{Value: uuid.New()}, newRespoolID)
+	suite.True(yarpcerrors.IsNotFound(err))
+}
+
+func (suite *CassandraStoreTestSuite) TestJobMetadata() {
+	ctx := context.Background()
+
+	jobIDs := []*peloton.JobID{
+		{Value: uuid.New()},
+		{Value: uuid.New()},
+		{Value: uuid.New()},
+	}
+	suite.NoError(store.SetJobMetadata(ctx, jobIDs[0], map[string]string{
+		"team":        "compute",
+		"cost_center": "cc1",
+	}))
+	suite.NoError(store.SetJobMetadata(ctx, jobIDs[1], map[string]string{
+		"team":        "compute",
+		"cost_center": "cc2",
+	}))
+	suite.NoError(store.SetJobMetadata(ctx, jobIDs[2], map[string]string{
+		"team": "storage",
+	}))
+
+	sorted := func(ids ...*peloton.JobID) []peloton.JobID {
+		var result []peloton.JobID
+		for _, id := range ids {
+			result = append(result, *id)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].GetValue() < result[j].GetValue()
+		})
+		return result
+	}
+
+	// single selector
+	ids, err := store.QueryJobsByMetadata(ctx, map[string]string{
+		"team": "compute",
+	})
+	suite.NoError(err)
+	suite.Equal(sorted(jobIDs[0], jobIDs[1]), ids)
+
+	// multiple selectors
+	ids, err = store.QueryJobsByMetadata(ctx, map[string]string{
+		"team":        "compute",
+		"cost_center": "cc2",
+	})
+	suite.NoError(err)
+	suite.Equal(sorted(jobIDs[1]), ids)
+
+	// no job matches all the selectors
+	ids, err = store.QueryJobsByMetadata(ctx, map[string]string{
+		"team":        "storage",
+		"cost_center": "cc1",
+	})
+	suite.NoError(err)
+	suite.Empty(ids)
+
+	// replacing the metadata removes the keys not set anymore
+	suite.NoError(store.SetJobMetadata(ctx, jobIDs[0], map[string]string{
+		"team": "storage",
+	}))
+	ids, err = store.QueryJobsByMetadata(ctx, map[string]string{
+		"cost_center": "cc1",
+	})
+	suite.NoError(err)
+	suite.Empty(ids)
+	ids, err = store.QueryJobsByMetadata(ctx, map[string]string{
+		"team": "storage",
+	})
+	suite.NoError(err)
+	suite.Equal(sorted(jobIDs[0], jobIDs[2]), ids)
+
+	// empty keys and selectors are rejected
+	suite.True(yarpcerrors.IsInvalidArgument(store.SetJobMetadata(
+		ctx, jobIDs[0], map[string]string{"": "value"})))
+	_, err = store.QueryJobsByMetadata(ctx, nil)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
 func (suite *CassandraStoreTestSuite) TestGetJobSummaryByTimeRange() {
 	var jobStore storage.JobStore
 	jobStore = store
@@ -935,7 +1354,7 @@ func (suite *CassandraStoreTestSuite) TestQueryJob() {
 		suite.NoError(err)
 
 		runtime.State = job.JobState(i + 1)
-		err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime)
+		err = jobRuntimeOps.Upsert(context.Background(), &jobID, runtime, job.JobState_UNKNOWN)
 		suite.NoError(err)
 
 		err = updateJobIndex(context.Background(), &jobID, nil, runtime)
@@ -1194,7 +1613,7 @@ func (suite *CassandraStoreTestSuite) TestQueryJob() {
 		runtime, err := jobRuntimeOps.Get(context.Background(), jobIDs[i])
 		suite.NoError(err)
 		runtime.State = job.JobState(i)
-		jobRuntimeOps.Upsert(context.Background(), jobIDs[i], runtime)
+		jobRuntimeOps.Upsert(context.Background(), jobIDs[i], runtime, job.JobState_UNKNOWN)
 	}
 
 	suite.refreshLuceneIndex()
@@ -1222,6 +1641,58 @@ func (suite *CassandraStoreTestSuite) TestQueryJob() {
 	}
 }
 
+// TestQueryJobEscapedLabels tests that label values with characters that are
+// special to the lucene query are matched literally.
+func (suite *CassandraStoreTestSuite) TestQueryJobEscapedLabels() {
+	vals := []string{
+		`quote"value`,
+		`back\slash\value`,
+		`{brace}value`,
+		`it's a value`,
+	}
+
+	var jobIDs []*peloton.JobID
+	for i, val := range vals {
+		jobID := peloton.JobID{Value: uuid.New()}
+		jobIDs = append(jobIDs, &jobID)
+		jobConfig := job.JobConfig{
+			Name:       fmt.Sprintf("TestQueryJobEscapedLabels_%d", i),
+			OwningTeam: "owner",
+			Type:       job.JobType_BATCH,
+			Labels: []*peloton.Label{
+				{Key: "escapedKey", Value: val},
+			},
+		}
+		err := suite.createJob(context.Background(), &jobID, &jobConfig,
+			&models.ConfigAddOn{}, "uber")
+		suite.NoError(err)
+	}
+	suite.refreshLuceneIndex()
+
+	// Each label value should match only its own job
+	for i, val := range vals {
+		spec := &job.QuerySpec{
+			Labels: []*peloton.Label{{Key: "escapedKey", Value: val}},
+		}
+		result, _ := suite.queryJobs(spec, 1, 1)
+		suite.Equal(jobIDs[i].GetValue(), result[0].GetId().GetValue())
+	}
+
+	// A value trying to inject another clause should not match anything
+	spec := &job.QuerySpec{
+		Labels: []*peloton.Label{{
+			Key:   "escapedKey",
+			Value: `x"}, {type: "wildcard", field:"name", value:"*`,
+		}},
+	}
+	_, _ = suite.queryJobs(spec, 0, 0)
+
+	for _, jobID := range jobIDs {
+		suite.NoError(store.DeleteJob(context.Background(), jobID.GetValue()))
+		suite.NoError(deleteJobIndex(context.Background(), jobID))
+	}
+}
+
 func (suite *CassandraStoreTestSuite) TestFrameworkInfo() {
 	var frameworkStore storage.FrameworkInfoStore
 	frameworkStore = store
@@ -1550,46 +2021,680 @@ func (suite *CassandraStoreTestSuite) TestTaskConfigsForLegacyJobs() {
 	suite.Equal(len(taskConfigs), 5)
 	suite.NoError(err)
 
-	for i := 0; i < numTasks; i++ {
-		// make sure backfill to task_config_v2 works.
-		taskConfig, _, err := store.taskConfigV2Ops.GetTaskConfig(
-			context.Background(), &jobID, uint32(i), 0)
+	for i := 0; i < numTasks; i++ {
+		// make sure backfill to task_config_v2 works.
+		taskConfig, _, err := store.taskConfigV2Ops.GetTaskConfig(
+			context.Background(), &jobID, uint32(i), 0)
+		suite.NoError(err)
+		expectedCfg, ok := instanceConfig[uint32(i)]
+		suite.True(ok)
+		suite.Equal(*taskConfig, *expectedCfg)
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestGetTaskStateSummary() {
+	var taskStore storage.TaskStore
+	taskStore = store
+	var jobID = peloton.JobID{Value: uuid.New()}
+	configAddOn := &models.ConfigAddOn{}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = uint32(2 * len(task.TaskState_name))
+	err := suite.createJob(context.Background(), &jobID, jobConfig, configAddOn, "user1")
+	suite.Nil(err)
+
+	for i := uint32(0); i < uint32(2*len(task.TaskState_name)); i++ {
+		taskInfo := createTaskInfo(jobConfig, &jobID, i)
+		err := taskStore.CreateTaskRuntime(
+			context.Background(),
+			&jobID,
+			i,
+			taskInfo.Runtime,
+			"user1",
+			jobConfig.GetType())
+		suite.Nil(err)
+		taskInfo.Runtime.State = task.TaskState(i / 2)
+		err = taskStore.UpdateTaskRuntime(
+			context.Background(),
+			&jobID,
+			i,
+			taskInfo.Runtime,
+			jobConfig.GetType())
+		suite.Nil(err)
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestGetTaskStateSummaryForJobs() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	// each job has a different mix of running and succeeded tasks
+	jobStates := [][]task.TaskState{
+		{task.TaskState_RUNNING, task.TaskState_RUNNING},
+		{task.TaskState_RUNNING, task.TaskState_SUCCEEDED, task.TaskState_SUCCEEDED},
+		{task.TaskState_FAILED},
+	}
+
+	var jobIDs []*peloton.JobID
+	expected := make(map[string]map[string]uint32)
+	for _, states := range jobStates {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := buildJobConfig()
+		jobConfig.InstanceCount = uint32(len(states))
+		suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+		expected[jobID.GetValue()] = make(map[string]uint32)
+		for i, state := range states {
+			taskInfo := createTaskInfo(jobConfig, jobID, uint32(i))
+			taskInfo.Runtime.State = state
+			suite.NoError(store.CreateTaskRuntime(
+				ctx,
+				jobID,
+				uint32(i),
+				taskInfo.Runtime,
+				"user1",
+				jobConfig.GetType()))
+			expected[jobID.GetValue()][state.String()]++
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	// a job without tasks has an empty summary
+	emptyJobID := &peloton.JobID{Value: uuid.New()}
+	jobIDs = append(jobIDs, emptyJobID)
+	expected[emptyJobID.GetValue()] = map[string]uint32{}
+
+	summary, err := store.GetTaskStateSummaryForJobs(ctx, jobIDs)
+	suite.NoError(err)
+	suite.Equal(expected, summary)
+}
+
+// TestGetJobRuntimes tests reading the runtimes of several jobs, across
+// more than one batch, in a single call
+func (suite *CassandraStoreTestSuite) TestGetJobRuntimes() {
+	ctx := context.Background()
+	states := []job.JobState{
+		job.JobState_INITIALIZED,
+		job.JobState_RUNNING,
+		job.JobState_SUCCEEDED,
+		job.JobState_FAILED,
+	}
+
+	var jobIDs []*peloton.JobID
+	expected := make(map[string]*job.RuntimeInfo)
+	for i := 0; i < _defaultJobRuntimeBatchSize+2; i++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		runtime := &job.RuntimeInfo{
+			State:     states[i%len(states)],
+			GoalState: job.JobState_SUCCEEDED,
+			TaskStats: map[string]uint32{
+				task.TaskState_RUNNING.String(): uint32(i),
+			},
+			Revision: &peloton.ChangeLog{Version: uint64(i + 1)},
+		}
+		suite.NoError(jobRuntimeOps.Upsert(ctx, jobID, runtime, job.JobState_UNKNOWN))
+		jobIDs = append(jobIDs, jobID)
+		expected[jobID.GetValue()] = runtime
+	}
+
+	// a job which does not exist is left out of the result
+	jobIDs = append(jobIDs, &peloton.JobID{Value: uuid.New()})
+
+	runtimes, err := store.GetJobRuntimes(ctx, jobIDs)
+	suite.NoError(err)
+	suite.Equal(len(expected), len(runtimes))
+	for id, runtime := range expected {
+		suite.Equal(runtime.GetState(), runtimes[id].GetState())
+		suite.Equal(runtime.GetTaskStats(), runtimes[id].GetTaskStats())
+		suite.Equal(
+			runtime.GetRevision().GetVersion(),
+			runtimes[id].GetRevision().GetVersion())
+	}
+
+	for _, id := range jobIDs[:len(expected)] {
+		suite.NoError(jobRuntimeOps.Delete(ctx, id))
+	}
+}
+
+// TestGetJobRuntimesSubset tests that only the jobs which have a runtime
+// are returned when reading the runtimes of several jobs
+func (suite *CassandraStoreTestSuite) TestGetJobRuntimesSubset() {
+	ctx := context.Background()
+
+	var jobIDs []*peloton.JobID
+	for i := 0; i < 5; i++ {
+		jobIDs = append(jobIDs, &peloton.JobID{Value: uuid.New()})
+	}
+
+	// only every other job has a runtime
+	var withRuntime []*peloton.JobID
+	for i := 0; i < len(jobIDs); i += 2 {
+		suite.NoError(jobRuntimeOps.Upsert(ctx, jobIDs[i], &job.RuntimeInfo{
+			State:     job.JobState_RUNNING,
+			GoalState: job.JobState_SUCCEEDED,
+		}, job.JobState_UNKNOWN))
+		withRuntime = append(withRuntime, jobIDs[i])
+	}
+
+	runtimes, err := store.GetJobRuntimes(ctx, jobIDs)
+	suite.NoError(err)
+	suite.Len(runtimes, len(withRuntime))
+	for _, id := range withRuntime {
+		suite.Equal(job.JobState_RUNNING, runtimes[id.GetValue()].GetState())
+		suite.NoError(jobRuntimeOps.Delete(ctx, id))
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestGetTasksForJobs() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	// read fewer jobs concurrently than are requested
+	maxParallelJobReads := store.Conf.MaxParallelJobReads
+	store.Conf.MaxParallelJobReads = 2
+	defer func() {
+		store.Conf.MaxParallelJobReads = maxParallelJobReads
+	}()
+
+	var jobIDs []*peloton.JobID
+	for j := 0; j < 5; j++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := buildJobConfig()
+		jobConfig.InstanceCount = uint32(j + 1)
+		suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+		runtimes := make(map[uint32]*task.RuntimeInfo)
+		for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+			runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+		}
+		suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	tasks, err := store.GetTasksForJobs(ctx, jobIDs)
+	suite.NoError(err)
+	suite.Len(tasks, len(jobIDs))
+	for j, jobID := range jobIDs {
+		jobTasks := tasks[jobID.GetValue()]
+		suite.Len(jobTasks, j+1)
+		for i := uint32(0); i < uint32(j+1); i++ {
+			suite.Equal(i, jobTasks[i].GetInstanceId())
+			suite.Equal(jobID.GetValue(), jobTasks[i].GetJobId().GetValue())
+			suite.Equal(
+				task.TaskState_INITIALIZED,
+				jobTasks[i].GetRuntime().GetState())
+		}
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestForEachTaskForJob() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 10
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+	}
+	suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+
+	// the callback is invoked once per instance
+	calls := make(map[uint32]int)
+	suite.NoError(store.ForEachTaskForJob(ctx, jobID,
+		func(taskInfo *task.TaskInfo) error {
+			suite.Equal(jobID.GetValue(), taskInfo.GetJobId().GetValue())
+			suite.Equal(
+				task.TaskState_INITIALIZED,
+				taskInfo.GetRuntime().GetState())
+			calls[taskInfo.GetInstanceId()]++
+			return nil
+		}))
+	suite.Len(calls, int(jobConfig.InstanceCount))
+	for instanceID, count := range calls {
+		suite.Equal(1, count, "instance %d", instanceID)
+	}
+
+	// returning an error aborts the iteration
+	errStop := errors.New("stop")
+	var count int
+	err := store.ForEachTaskForJob(ctx, jobID,
+		func(*task.TaskInfo) error {
+			count++
+			if count == 3 {
+				return errStop
+			}
+			return nil
+		})
+	suite.Equal(errStop, err)
+	suite.Equal(3, count)
+}
+
+func (suite *CassandraStoreTestSuite) TestDeleteJob() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 3
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+	}
+	suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+	suite.NoError(jobRuntimeOps.SetKillReason(
+		ctx, jobID, ormobjects.JobKillReasonUserRequest))
+
+	suite.NoError(store.DeleteJob(ctx, jobID.GetValue()))
+	suite.NoError(deleteJobIndex(ctx, jobID))
+
+	_, _, err := jobConfigOps.GetCurrentVersion(ctx, jobID)
+	suite.True(yarpcerrors.IsNotFound(err))
+	_, err = jobRuntimeOps.Get(ctx, jobID)
+	suite.True(yarpcerrors.IsNotFound(err))
+	killReason, err := jobRuntimeOps.GetKillReason(ctx, jobID)
+	suite.NoError(err)
+	suite.Nil(killReason)
+
+	tasks, err := store.GetTasksForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Empty(tasks)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		_, err = store.GetTaskForJob(ctx, jobID.GetValue(), i)
+		suite.Error(err)
+	}
+
+	// deleting the job again is a no-op
+	suite.NoError(store.DeleteJob(ctx, jobID.GetValue()))
+}
+
+func (suite *CassandraStoreTestSuite) TestUpdateTasks() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	maxBatchSize := store.Conf.MaxBatchSize
+	store.Conf.MaxBatchSize = 2
+	defer func() {
+		store.Conf.MaxBatchSize = maxBatchSize
+	}()
+
+	var jobIDs []*peloton.JobID
+	for j := 0; j < 2; j++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := buildJobConfig()
+		jobConfig.InstanceCount = 5
+		suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+		runtimes := make(map[uint32]*task.RuntimeInfo)
+		for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+			runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+		}
+		suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	// update some of the instances of both jobs
+	updated := map[string][]uint32{
+		jobIDs[0].GetValue(): {0, 2, 3},
+		jobIDs[1].GetValue(): {1, 4},
+	}
+	var taskInfos []*task.TaskInfo
+	for _, jobID := range jobIDs {
+		for _, instanceID := range updated[jobID.GetValue()] {
+			runtime, err := store.GetTaskRuntime(ctx, jobID, instanceID)
+			suite.NoError(err)
+			runtime.State = task.TaskState_RUNNING
+			runtime.Host = fmt.Sprintf("host-%d", instanceID)
+			taskInfos = append(taskInfos, &task.TaskInfo{
+				JobId:      jobID,
+				InstanceId: instanceID,
+				Runtime:    runtime,
+			})
+		}
+	}
+	suite.NoError(store.UpdateTasks(ctx, taskInfos))
+
+	for _, jobID := range jobIDs {
+		tasks, err := store.GetTasksForJob(ctx, jobID)
+		suite.NoError(err)
+		suite.Len(tasks, 5)
+		isUpdated := make(map[uint32]bool)
+		for _, instanceID := range updated[jobID.GetValue()] {
+			isUpdated[instanceID] = true
+		}
+		for instanceID, taskInfo := range tasks {
+			if isUpdated[instanceID] {
+				suite.Equal(
+					task.TaskState_RUNNING, taskInfo.GetRuntime().GetState())
+				suite.Equal(
+					fmt.Sprintf("host-%d", instanceID),
+					taskInfo.GetRuntime().GetHost())
+			} else {
+				suite.Equal(
+					task.TaskState_INITIALIZED,
+					taskInfo.GetRuntime().GetState())
+				suite.Empty(taskInfo.GetRuntime().GetHost())
+			}
+		}
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestCreateMissingTasks() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 5
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	// create the first 3 tasks and move them to running
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < 3; i++ {
+		runtime := createTaskInfo(jobConfig, jobID, i).GetRuntime()
+		runtime.State = task.TaskState_RUNNING
+		runtimes[i] = runtime
+	}
+	suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+
+	// create all the tasks again, skipping the existing ones
+	runtimes = make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+	}
+	suite.NoError(store.CreateMissingTasks(ctx, jobID, runtimes, "user1"))
+
+	tasks, err := store.GetTasksForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Len(tasks, int(jobConfig.InstanceCount))
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		expectedState := task.TaskState_INITIALIZED
+		if i < 3 {
+			expectedState = task.TaskState_RUNNING
+		}
+		suite.Equal(expectedState, tasks[i].GetRuntime().GetState())
+	}
+
+	// nothing is written if all the tasks exist
+	suite.NoError(store.CreateMissingTasks(ctx, jobID, runtimes, "user1"))
+	tasks, err = store.GetTasksForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Equal(task.TaskState_RUNNING, tasks[0].GetRuntime().GetState())
+}
+
+func (suite *CassandraStoreTestSuite) TestGetTasksByIDs() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	var taskIDs []string
+	var expectedNotFound []string
+	for j := 0; j < 2; j++ {
+		jobID := &peloton.JobID{Value: uuid.New()}
+		jobConfig := buildJobConfig()
+		jobConfig.InstanceCount = 3
+		suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+		runtimes := make(map[uint32]*task.RuntimeInfo)
+		for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+			runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+			taskIDs = append(taskIDs, fmt.Sprintf("%s-%d", jobID.GetValue(), i))
+		}
+		suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+
+		// an instance beyond the instance count does not exist
+		missingTaskID := fmt.Sprintf("%s-%d", jobID.GetValue(), jobConfig.InstanceCount)
+		taskIDs = append(taskIDs, missingTaskID)
+		expectedNotFound = append(expectedNotFound, missingTaskID)
+	}
+
+	// a task of a job which does not exist
+	missingTaskID := fmt.Sprintf("%s-%d", uuid.New(), 0)
+	taskIDs = append(taskIDs, missingTaskID)
+	expectedNotFound = append(expectedNotFound, missingTaskID)
+
+	found, notFound, err := store.GetTasksByIDs(ctx, taskIDs)
+	suite.NoError(err)
+	suite.Equal(expectedNotFound, notFound)
+	suite.Len(found, len(taskIDs)-len(expectedNotFound))
+	for taskID, taskInfo := range found {
+		suite.Equal(taskID, fmt.Sprintf("%s-%d",
+			taskInfo.GetJobId().GetValue(), taskInfo.GetInstanceId()))
+		suite.NotNil(taskInfo.GetRuntime())
+		suite.NotNil(taskInfo.GetConfig())
+	}
+
+	// an invalid task ID fails the whole call
+	_, _, err = store.GetTasksByIDs(ctx, []string{"invalid"})
+	suite.Error(err)
+}
+
+func (suite *CassandraStoreTestSuite) TestDeleteTask() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 3
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		runtimes[i] = createTaskInfo(jobConfig, jobID, i).GetRuntime()
+	}
+	suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+
+	podEvents, err := store.GetPodEvents(ctx, jobID.GetValue(), 1)
+	suite.NoError(err)
+	suite.NotEmpty(podEvents)
+
+	suite.NoError(store.DeleteTask(ctx, jobID, 1))
+
+	// the task and its pod events are gone
+	_, err = store.GetTaskByID(ctx, fmt.Sprintf("%s-%d", jobID.GetValue(), 1))
+	suite.True(yarpcerrors.IsNotFound(err))
+	podEvents, err = store.GetPodEvents(ctx, jobID.GetValue(), 1)
+	suite.NoError(err)
+	suite.Empty(podEvents)
+
+	// the sibling tasks remain
+	tasks, err := store.GetTasksForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Len(tasks, 2)
+	suite.Contains(tasks, uint32(0))
+	suite.Contains(tasks, uint32(2))
+	podEvents, err = store.GetPodEvents(ctx, jobID.GetValue(), 0)
+	suite.NoError(err)
+	suite.NotEmpty(podEvents)
+
+	// deleting a task which does not exist fails
+	suite.True(yarpcerrors.IsNotFound(store.DeleteTask(ctx, jobID, 1)))
+}
+
+func (suite *CassandraStoreTestSuite) TestGetTasksForJobByTerminality() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	states := []task.TaskState{
+		task.TaskState_RUNNING,
+		task.TaskState_SUCCEEDED,
+		task.TaskState_PENDING,
+		task.TaskState_FAILED,
+		task.TaskState_KILLED,
+	}
+
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = uint32(len(states))
+	suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+	expectedActive := make(map[uint32]task.TaskState)
+	expectedTerminal := make(map[uint32]task.TaskState)
+	for i, state := range states {
+		taskInfo := createTaskInfo(jobConfig, jobID, uint32(i))
+		taskInfo.Runtime.State = state
+		suite.NoError(store.CreateTaskRuntime(
+			ctx,
+			jobID,
+			uint32(i),
+			taskInfo.Runtime,
+			"user1",
+			jobConfig.GetType()))
+		if util.IsPelotonStateTerminal(state) {
+			expectedTerminal[uint32(i)] = state
+		} else {
+			expectedActive[uint32(i)] = state
+		}
+	}
+
+	for terminal, expected := range map[bool]map[uint32]task.TaskState{
+		true:  expectedTerminal,
+		false: expectedActive,
+	} {
+		for _, source := range []TaskReadSource{
+			TaskReadFromView,
+			TaskReadFromBaseTable,
+		} {
+			tasks, err := store.GetTasksForJobByTerminality(
+				ctx, jobID, terminal, source)
+			suite.NoError(err)
+			suite.Len(tasks, len(expected))
+			for instanceID, state := range expected {
+				suite.Equal(state, tasks[instanceID].GetRuntime().GetState())
+			}
+		}
+	}
+}
+
+// TestGetTasksForJobAndStates tests reading the tasks of a job which are in
+// any of several states with a single call.
+func (suite *CassandraStoreTestSuite) TestGetTasksForJobAndStates() {
+	ctx := context.Background()
+	configAddOn := &models.ConfigAddOn{}
+
+	states := []task.TaskState{
+		task.TaskState_RUNNING,
+		task.TaskState_LAUNCHED,
+		task.TaskState_RUNNING,
+		task.TaskState_SUCCEEDED,
+	}
+
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = uint32(len(states))
+	suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+	for i, state := range states {
+		taskInfo := createTaskInfo(jobConfig, jobID, uint32(i))
+		taskInfo.Runtime.State = state
+		suite.NoError(store.CreateTaskRuntime(
+			ctx,
+			jobID,
+			uint32(i),
+			taskInfo.Runtime,
+			"user1",
+			jobConfig.GetType()))
+	}
+
+	// a duplicated state does not return a task twice
+	tasks, err := store.GetTasksForJobAndStates(ctx, jobID, []task.TaskState{
+		task.TaskState_RUNNING,
+		task.TaskState_LAUNCHED,
+		task.TaskState_RUNNING,
+	})
+	suite.NoError(err)
+	suite.Len(tasks, 3)
+	for _, instanceID := range []uint32{0, 1, 2} {
+		suite.Equal(states[instanceID],
+			tasks[instanceID].GetRuntime().GetState())
+	}
+
+	tasks, err = store.GetTasksForJobAndStates(
+		ctx, jobID, []task.TaskState{task.TaskState_FAILED})
+	suite.NoError(err)
+	suite.Empty(tasks)
+
+	_, err = store.GetTasksForJobAndStates(ctx, jobID, nil)
+	suite.True(yarpcerrors.IsInvalidArgument(err))
+}
+
+// TestGetTasksForJobByTerminalityReadYourWrites tests that reading from the
+// base table reflects a task update immediately after it is written.
+func (suite *CassandraStoreTestSuite) TestGetTasksForJobByConfigVersion() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 6
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	// the first two instances have been moved to the new config
+	runtimes := make(map[uint32]*task.RuntimeInfo)
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		runtime := createTaskInfo(jobConfig, jobID, i).GetRuntime()
+		if i < 2 {
+			runtime.ConfigVersion = 2
+		}
+		runtimes[i] = runtime
+	}
+	suite.NoError(store.CreateTasks(ctx, jobID, runtimes, "user1"))
+
+	for version, expected := range map[uint64][]uint32{
+		1: {2, 3, 4, 5},
+		2: {0, 1},
+		3: {},
+	} {
+		tasks, err := store.GetTasksForJobByConfigVersion(ctx, jobID, version)
 		suite.NoError(err)
-		expectedCfg, ok := instanceConfig[uint32(i)]
-		suite.True(ok)
-		suite.Equal(*taskConfig, *expectedCfg)
+		suite.Len(tasks, len(expected))
+		for _, instanceID := range expected {
+			suite.Equal(
+				version,
+				tasks[instanceID].GetRuntime().GetConfigVersion())
+		}
 	}
 }
 
-func (suite *CassandraStoreTestSuite) TestGetTaskStateSummary() {
-	var taskStore storage.TaskStore
-	taskStore = store
-	var jobID = peloton.JobID{Value: uuid.New()}
+func (suite *CassandraStoreTestSuite) TestGetTasksForJobByTerminalityReadYourWrites() {
+	ctx := context.Background()
 	configAddOn := &models.ConfigAddOn{}
+
+	jobID := &peloton.JobID{Value: uuid.New()}
 	jobConfig := buildJobConfig()
-	jobConfig.InstanceCount = uint32(2 * len(task.TaskState_name))
-	err := suite.createJob(context.Background(), &jobID, jobConfig, configAddOn, "user1")
-	suite.Nil(err)
+	jobConfig.InstanceCount = 2
+	suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
 
-	for i := uint32(0); i < uint32(2*len(task.TaskState_name)); i++ {
-		taskInfo := createTaskInfo(jobConfig, &jobID, i)
-		err := taskStore.CreateTaskRuntime(
-			context.Background(),
-			&jobID,
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		taskInfo := createTaskInfo(jobConfig, jobID, i)
+		taskInfo.Runtime.State = task.TaskState_RUNNING
+		suite.NoError(store.CreateTaskRuntime(
+			ctx,
+			jobID,
 			i,
 			taskInfo.Runtime,
 			"user1",
-			jobConfig.GetType())
-		suite.Nil(err)
-		taskInfo.Runtime.State = task.TaskState(i / 2)
-		err = taskStore.UpdateTaskRuntime(
-			context.Background(),
-			&jobID,
-			i,
-			taskInfo.Runtime,
-			jobConfig.GetType())
-		suite.Nil(err)
+			jobConfig.GetType()))
 	}
+
+	// move the first task to a terminal state and read it back right away
+	runtime, err := store.GetTaskRuntime(ctx, jobID, 0)
+	suite.NoError(err)
+	runtime.State = task.TaskState_SUCCEEDED
+	suite.NoError(store.UpdateTaskRuntime(
+		ctx, jobID, 0, runtime, jobConfig.GetType()))
+
+	terminal, err := store.GetTasksForJobByTerminality(
+		ctx, jobID, true, TaskReadFromBaseTable)
+	suite.NoError(err)
+	suite.Len(terminal, 1)
+	suite.Equal(task.TaskState_SUCCEEDED,
+		terminal[0].GetRuntime().GetState())
+	suite.NotNil(terminal[0].GetConfig())
+
+	active, err := store.GetTasksForJobByTerminality(
+		ctx, jobID, false, TaskReadFromBaseTable)
+	suite.NoError(err)
+	suite.Len(active, 1)
+	suite.Equal(task.TaskState_RUNNING, active[1].GetRuntime().GetState())
 }
 
 func (suite *CassandraStoreTestSuite) TestGetTaskByRange() {
@@ -1754,6 +2859,11 @@ func (suite *CassandraStoreTestSuite) TestPersistentVolumeInfo() {
 	suite.Equal(rpv.Hostname, "host")
 	suite.Equal(rpv.SizeMB, uint32(10))
 	suite.Equal(rpv.ContainerPath, "testpath")
+	createTime, err := time.Parse(time.RFC3339Nano, rpv.GetCreateTime())
+	suite.NoError(err)
+	updateTime, err := time.Parse(time.RFC3339Nano, rpv.GetUpdateTime())
+	suite.NoError(err)
+	suite.False(updateTime.Before(createTime))
 
 	// Verify get non-existent volume returns error.
 	volumeID2 := &peloton.VolumeID{
@@ -1779,6 +2889,378 @@ func (suite *CassandraStoreTestSuite) TestPersistentVolumeInfo() {
 	suite.Equal(rpv.ContainerPath, "testpath")
 }
 
+// TestUpsertPersistentVolume tests re-creating persistent volumes in
+// upsert mode
+func (suite *CassandraStoreTestSuite) TestUpsertPersistentVolume() {
+	var volumeStore storage.PersistentVolumeStore
+	volumeStore = store
+	ctx := context.Background()
+	newVolume := func(id string) *volume.PersistentVolumeInfo {
+		return &volume.PersistentVolumeInfo{
+			Id: &peloton.VolumeID{
+				Value: id,
+			},
+			State:     volume.VolumeState_INITIALIZED,
+			GoalState: volume.VolumeState_CREATED,
+			JobId: &peloton.JobID{
+				Value: "upsert-job",
+			},
+			Hostname:      "host",
+			InstanceId:    uint32(1),
+			SizeMB:        uint32(10),
+			ContainerPath: "testpath",
+		}
+	}
+
+	// a brand-new volume is created
+	pv := newVolume("upsert-volume1")
+	suite.NoError(volumeStore.UpsertPersistentVolume(ctx, pv))
+	rpv, err := volumeStore.GetPersistentVolume(ctx, pv.GetId())
+	suite.NoError(err)
+	suite.Equal("upsert-job", rpv.GetJobId().GetValue())
+	suite.Equal(volume.VolumeState_INITIALIZED, rpv.GetState())
+
+	// plain create still fails for an existing volume
+	err = volumeStore.CreatePersistentVolume(ctx, newVolume("upsert-volume1"))
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+
+	// re-creating an identical volume succeeds and leaves the stored
+	// state untouched
+	rpv.State = volume.VolumeState_CREATED
+	suite.NoError(volumeStore.UpdatePersistentVolume(ctx, rpv))
+	suite.NoError(volumeStore.UpsertPersistentVolume(
+		ctx, newVolume("upsert-volume1")))
+	rpv, err = volumeStore.GetPersistentVolume(ctx, pv.GetId())
+	suite.NoError(err)
+	suite.Equal(volume.VolumeState_CREATED, rpv.GetState())
+
+	// re-creating a conflicting volume fails
+	conflicting := newVolume("upsert-volume1")
+	conflicting.Hostname = "otherhost"
+	err = volumeStore.UpsertPersistentVolume(ctx, conflicting)
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+	conflicting = newVolume("upsert-volume1")
+	conflicting.SizeMB = uint32(20)
+	err = volumeStore.UpsertPersistentVolume(ctx, conflicting)
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+
+	rpv, err = volumeStore.GetPersistentVolume(ctx, pv.GetId())
+	suite.NoError(err)
+	suite.Equal("host", rpv.GetHostname())
+	suite.Equal(uint32(10), rpv.GetSizeMB())
+}
+
+// TestGetPersistentVolumesForJob tests creating several persistent volumes
+// for a job and reading them back
+func (suite *CassandraStoreTestSuite) TestGetPersistentVolumesForJob() {
+	var volumeStore storage.PersistentVolumeStore
+	volumeStore = store
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+
+	// a job without volumes has an empty list of volumes
+	volumes, err := volumeStore.GetPersistentVolumesForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Empty(volumes)
+
+	var pvs []*volume.PersistentVolumeInfo
+	for i := uint32(0); i < 3; i++ {
+		pvs = append(pvs, &volume.PersistentVolumeInfo{
+			Id: &peloton.VolumeID{
+				Value: fmt.Sprintf("%s-volume%d", jobID.GetValue(), i),
+			},
+			State:         volume.VolumeState_INITIALIZED,
+			GoalState:     volume.VolumeState_CREATED,
+			JobId:         jobID,
+			Hostname:      fmt.Sprintf("host%d", i),
+			InstanceId:    i,
+			SizeMB:        uint32(10),
+			ContainerPath: "testpath",
+		})
+	}
+	suite.NoError(volumeStore.CreatePersistentVolumes(ctx, pvs))
+
+	// a volume of another job is not returned
+	otherJobVolume := &volume.PersistentVolumeInfo{
+		Id:        &peloton.VolumeID{Value: uuid.New()},
+		State:     volume.VolumeState_INITIALIZED,
+		GoalState: volume.VolumeState_CREATED,
+		JobId:     &peloton.JobID{Value: uuid.New()},
+	}
+	suite.NoError(volumeStore.CreatePersistentVolume(ctx, otherJobVolume))
+
+	volumes, err = volumeStore.GetPersistentVolumesForJob(ctx, jobID)
+	suite.NoError(err)
+	suite.Len(volumes, 3)
+	for i, pv := range volumes {
+		suite.Equal(pvs[i].GetId().GetValue(), pv.GetId().GetValue())
+		suite.Equal(jobID.GetValue(), pv.GetJobId().GetValue())
+		suite.Equal(uint32(i), pv.GetInstanceId())
+		suite.Equal(pvs[i].GetHostname(), pv.GetHostname())
+		suite.Equal(volume.VolumeState_INITIALIZED, pv.GetState())
+		suite.Equal(volume.VolumeState_CREATED, pv.GetGoalState())
+		suite.Equal(uint32(10), pv.GetSizeMB())
+		suite.Equal("testpath", pv.GetContainerPath())
+	}
+
+	// creating volumes which exist already fails
+	err = volumeStore.CreatePersistentVolumes(ctx, pvs[:1])
+	suite.True(yarpcerrors.IsAlreadyExists(err))
+}
+
+// TestGetVolumesByState tests reading back the persistent volumes in a
+// given state
+func (suite *CassandraStoreTestSuite) TestGetVolumesByState() {
+	var volumeStore storage.PersistentVolumeStore
+	volumeStore = store
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+
+	states := []volume.VolumeState{
+		volume.VolumeState_INITIALIZED,
+		volume.VolumeState_CREATED,
+		volume.VolumeState_CREATED,
+	}
+	var pvs []*volume.PersistentVolumeInfo
+	for i, state := range states {
+		pvs = append(pvs, &volume.PersistentVolumeInfo{
+			Id:         &peloton.VolumeID{Value: uuid.New()},
+			State:      state,
+			GoalState:  volume.VolumeState_CREATED,
+			JobId:      jobID,
+			InstanceId: uint32(i),
+		})
+	}
+	suite.NoError(volumeStore.CreatePersistentVolumes(ctx, pvs))
+
+	// other tests create volumes too, so only the volumes of the job are
+	// checked
+	volumesOfJob := func(state volume.VolumeState) map[string]uint32 {
+		volumes, err := volumeStore.GetVolumesByState(ctx, state)
+		suite.NoError(err)
+		instances := make(map[string]uint32)
+		for _, pv := range volumes {
+			suite.Equal(state, pv.GetState())
+			if pv.GetJobId().GetValue() == jobID.GetValue() {
+				instances[pv.GetId().GetValue()] = pv.GetInstanceId()
+			}
+		}
+		return instances
+	}
+
+	suite.Equal(map[string]uint32{
+		pvs[0].GetId().GetValue(): 0,
+	}, volumesOfJob(volume.VolumeState_INITIALIZED))
+	suite.Equal(map[string]uint32{
+		pvs[1].GetId().GetValue(): 1,
+		pvs[2].GetId().GetValue(): 2,
+	}, volumesOfJob(volume.VolumeState_CREATED))
+	suite.Empty(volumesOfJob(volume.VolumeState_DELETED))
+
+	// a volume moves to the view of its new state once updated, and the
+	// view is updated asynchronously, so it is polled until it moves
+	pvs[0].State = volume.VolumeState_CREATED
+	suite.NoError(volumeStore.UpdatePersistentVolume(ctx, pvs[0]))
+	moved := false
+	for i := 0; i < 50 && !moved; i++ {
+		moved = len(volumesOfJob(volume.VolumeState_INITIALIZED)) == 0 &&
+			len(volumesOfJob(volume.VolumeState_CREATED)) == 3
+		if !moved {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	suite.True(moved)
+}
+
+// TestGetResourcePool tests reading back a resource pool by its ID
+func (suite *CassandraStoreTestSuite) TestGetResourcePool() {
+	var respoolStore storage.ResourcePoolStore
+	respoolStore = store
+	ctx := context.Background()
+
+	respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+	config := &respool.ResourcePoolConfig{
+		Name:       respoolID.GetValue(),
+		OwningTeam: "team",
+		Parent:     &peloton.ResourcePoolID{Value: common.RootResPoolID},
+		Resources: []*respool.ResourceConfig{
+			{
+				Kind:        common.CPU,
+				Reservation: 10,
+				Limit:       20,
+				Share:       1,
+			},
+			{
+				Kind:        common.MEMORY,
+				Reservation: 100,
+				Limit:       200,
+				Share:       1,
+			},
+		},
+	}
+	suite.NoError(resPoolOps.Create(ctx, respoolID, config, "team"))
+
+	info, err := respoolStore.GetResourcePool(ctx, respoolID)
+	suite.NoError(err)
+	suite.Equal(respoolID.GetValue(), info.GetId().GetValue())
+	suite.Equal(common.RootResPoolID, info.GetParent().GetValue())
+	suite.Equal("team", info.GetConfig().GetOwningTeam())
+	suite.Len(info.GetConfig().GetResources(), 2)
+	for i, resource := range config.GetResources() {
+		suite.Equal(resource.GetKind(), info.GetConfig().GetResources()[i].GetKind())
+		suite.Equal(resource.GetLimit(), info.GetConfig().GetResources()[i].GetLimit())
+	}
+
+	// Verify get non-existent resource pool returns not found error.
+	_, err = respoolStore.GetResourcePool(
+		ctx, &peloton.ResourcePoolID{Value: uuid.New()})
+	suite.Error(err)
+	_, ok := err.(*storage.ResourcePoolNotFoundError)
+	suite.True(ok)
+
+	suite.NoError(resPoolOps.Delete(ctx, respoolID))
+}
+
+// TestGetResourcePools tests that paging through the resource pools returns
+// complete, ordered and non-overlapping pages
+func (suite *CassandraStoreTestSuite) TestGetResourcePools() {
+	ctx := context.Background()
+
+	created := make(map[string]bool)
+	for i := 0; i < 7; i++ {
+		respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+		config := &respool.ResourcePoolConfig{
+			Name:       respoolID.GetValue(),
+			OwningTeam: "team",
+			Parent:     &peloton.ResourcePoolID{Value: common.RootResPoolID},
+		}
+		suite.NoError(resPoolOps.Create(ctx, respoolID, config, "team"))
+		created[respoolID.GetValue()] = true
+	}
+
+	all, err := store.GetResourcePools(ctx, "", math.MaxUint32)
+	suite.NoError(err)
+	suite.True(len(all) >= len(created))
+
+	tt := []struct {
+		limit uint32
+	}{
+		{limit: 1},
+		{limit: 3},
+		{limit: uint32(len(all))},
+		{limit: uint32(len(all) + 1)},
+	}
+	for _, test := range tt {
+		var paged []string
+		after := ""
+		for {
+			page, err := store.GetResourcePools(ctx, after, test.limit)
+			suite.NoError(err)
+			suite.True(len(page) <= int(test.limit))
+			if len(page) == 0 {
+				break
+			}
+			for _, info := range page {
+				paged = append(paged, info.GetId().GetValue())
+			}
+			after = paged[len(paged)-1]
+		}
+
+		// the pages follow the order of the full fetch, without overlap
+		suite.Len(paged, len(all), "limit %d", test.limit)
+		for i, id := range paged {
+			suite.Equal(all[i].GetId().GetValue(), id, "limit %d", test.limit)
+		}
+		for id := range created {
+			suite.Contains(paged, id)
+		}
+	}
+
+	page, err := store.GetResourcePools(
+		ctx, all[len(all)-1].GetId().GetValue(), 1)
+	suite.NoError(err)
+	suite.Empty(page)
+
+	for id := range created {
+		suite.NoError(resPoolOps.Delete(ctx, &peloton.ResourcePoolID{Value: id}))
+	}
+}
+
+// TestUpdateResourcePool tests updating the config of a resource pool
+func (suite *CassandraStoreTestSuite) TestUpdateResourcePool() {
+	var respoolStore storage.ResourcePoolStore
+	respoolStore = store
+	ctx := context.Background()
+
+	respoolID := &peloton.ResourcePoolID{Value: uuid.New()}
+	config := &respool.ResourcePoolConfig{
+		Name:       respoolID.GetValue(),
+		OwningTeam: "team",
+		Parent:     &peloton.ResourcePoolID{Value: common.RootResPoolID},
+		Resources: []*respool.ResourceConfig{
+			{
+				Kind:        common.CPU,
+				Reservation: 10,
+				Limit:       20,
+				Share:       1,
+			},
+		},
+	}
+	suite.NoError(resPoolOps.Create(ctx, respoolID, config, "team"))
+
+	created, err := resPoolOps.GetResult(ctx, respoolID.GetValue())
+	suite.NoError(err)
+
+	config.Resources[0].Limit = 40
+	suite.NoError(respoolStore.UpdateResourcePool(ctx, respoolID, config))
+
+	info, err := respoolStore.GetResourcePool(ctx, respoolID)
+	suite.NoError(err)
+	suite.Equal(float64(40), info.GetConfig().GetResources()[0].GetLimit())
+	suite.Equal(float64(10), info.GetConfig().GetResources()[0].GetReservation())
+	suite.Equal("team", info.GetConfig().GetOwningTeam())
+	suite.Equal(int64(1), info.GetConfig().GetChangeLog().GetVersion())
+	createdAt := info.GetConfig().GetChangeLog().GetCreatedAt()
+	suite.Equal(created.CreationTime.UnixNano(), createdAt)
+	suite.True(info.GetConfig().GetChangeLog().GetUpdatedAt() >= createdAt)
+
+	// Update again with the version which was read.
+	versioned := info.GetConfig()
+	versioned.Resources[0].Limit = 50
+	suite.NoError(respoolStore.UpdateResourcePool(ctx, respoolID, versioned))
+
+	info, err = respoolStore.GetResourcePool(ctx, respoolID)
+	suite.NoError(err)
+	suite.Equal(float64(50), info.GetConfig().GetResources()[0].GetLimit())
+	suite.Equal(int64(2), info.GetConfig().GetChangeLog().GetVersion())
+	suite.Equal(createdAt, info.GetConfig().GetChangeLog().GetCreatedAt())
+
+	// An update based on a stale version is rejected.
+	config.Resources[0].Limit = 60
+	err = respoolStore.UpdateResourcePool(ctx, respoolID, config)
+	suite.True(yarpcerrors.IsFailedPrecondition(err))
+
+	info, err = respoolStore.GetResourcePool(ctx, respoolID)
+	suite.NoError(err)
+	suite.Equal(float64(50), info.GetConfig().GetResources()[0].GetLimit())
+	suite.Equal(int64(2), info.GetConfig().GetChangeLog().GetVersion())
+
+	// The creation time column is left untouched by updates.
+	updated, err := resPoolOps.GetResult(ctx, respoolID.GetValue())
+	suite.NoError(err)
+	suite.True(created.CreationTime.Equal(updated.CreationTime))
+
+	// Verify updating a non-existent resource pool does not create it.
+	missingID := &peloton.ResourcePoolID{Value: uuid.New()}
+	err = respoolStore.UpdateResourcePool(ctx, missingID, config)
+	_, ok := err.(*storage.ResourcePoolNotFoundError)
+	suite.True(ok)
+	_, err = respoolStore.GetResourcePool(ctx, missingID)
+	suite.Error(err)
+
+	suite.NoError(resPoolOps.Delete(ctx, respoolID))
+}
+
 // TestUpdate tests all job update related APIs by writing and reading
 // from actual Cassandra instance. Since the state needs to be
 // created in Cassandra and DB calls are not mocked, one test will be used
@@ -2218,6 +3700,54 @@ func (suite *CassandraStoreTestSuite) TestUpdate() {
 
 // TestWriteUpdateProgressChangUpdateTimeOnly tests the case the WriteUpdateProgress
 // only changes updateTime without touching other fields.
+func (suite *CassandraStoreTestSuite) TestListUpdatesForJob() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+
+	// a job without updates has an empty list
+	updates, err := store.ListUpdatesForJob(ctx, jobID.GetValue())
+	suite.NoError(err)
+	suite.Empty(updates)
+
+	states := []update.State{
+		update.State_SUCCEEDED,
+		update.State_ROLLING_FORWARD,
+	}
+	var updateIDs []*peloton.UpdateID
+	for i, state := range states {
+		updateID := &peloton.UpdateID{Value: uuid.New()}
+		suite.NoError(store.CreateUpdate(ctx, &models.UpdateModel{
+			UpdateID:             updateID,
+			JobID:                jobID,
+			UpdateConfig:         &update.UpdateConfig{BatchSize: uint32(i + 1)},
+			JobConfigVersion:     uint64(i + 2),
+			PrevJobConfigVersion: uint64(i + 1),
+			State:                state,
+			InstancesTotal:       10,
+			Type:                 models.WorkflowType_UPDATE,
+			CreationTime:         time.Now().Format(time.RFC3339Nano),
+			UpdateTime:           time.Now().Format(time.RFC3339Nano),
+		}))
+		updateIDs = append(updateIDs, updateID)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	updates, err = store.ListUpdatesForJob(ctx, jobID.GetValue())
+	suite.NoError(err)
+	suite.Len(updates, len(states))
+
+	// the most recent update is listed first
+	for i, updateModel := range updates {
+		j := len(states) - 1 - i
+		suite.Equal(updateIDs[j].GetValue(), updateModel.GetUpdateID().GetValue())
+		suite.Equal(jobID.GetValue(), updateModel.GetJobID().GetValue())
+		suite.Equal(uint32(j+1), updateModel.GetUpdateConfig().GetBatchSize())
+		suite.Equal(uint64(j+2), updateModel.GetJobConfigVersion())
+		suite.Equal(states[j], updateModel.GetState())
+		suite.Equal(uint32(10), updateModel.GetInstancesTotal())
+	}
+}
+
 func (suite *CassandraStoreTestSuite) TestWriteUpdateProgressChangUpdateTimeOnly() {
 	// the job identifier
 	jobID := &peloton.JobID{
@@ -2389,7 +3919,7 @@ func (suite *CassandraStoreTestSuite) TestModifyUpdate() {
 		},
 		ConfigurationVersion: jobConfig.GetChangeLog().GetVersion(),
 	}
-	err := jobRuntimeOps.Upsert(context.Background(), jobID, &initialJobRuntime)
+	err := jobRuntimeOps.Upsert(context.Background(), jobID, &initialJobRuntime, job.JobState_UNKNOWN)
 	// create a new update
 	suite.NoError(store.CreateUpdate(
 		context.Background(),
@@ -2533,6 +4063,47 @@ func (suite *CassandraStoreTestSuite) TestGetTaskRuntime() {
 	suite.Equal(info.Runtime, runtime)
 }
 
+func (suite *CassandraStoreTestSuite) TestUpdateTaskRuntimeCAS() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	taskInfo := createTaskInfo(jobConfig, jobID, 0)
+	suite.NoError(store.CreateTaskRuntime(
+		ctx, jobID, 0, taskInfo.GetRuntime(), "", job.JobType_BATCH))
+
+	// in-order update is applied
+	taskInfo.Runtime.State = task.TaskState_RUNNING
+	taskInfo.Runtime.Revision.Version = 2
+	suite.NoError(store.UpdateTaskRuntimeCAS(
+		ctx, taskInfo, task.TaskState_INITIALIZED))
+
+	runtime, err := store.GetTaskRuntime(ctx, jobID, 0)
+	suite.NoError(err)
+	suite.Equal(task.TaskState_RUNNING, runtime.GetState())
+
+	// out-of-order update still expecting the initialized state is rejected
+	stale := createTaskInfo(jobConfig, jobID, 0)
+	stale.Runtime.State = task.TaskState_LAUNCHED
+	err = store.UpdateTaskRuntimeCAS(ctx, stale, task.TaskState_INITIALIZED)
+	suite.Error(err)
+	suite.True(yarpcerrors.IsFailedPrecondition(err))
+
+	runtime, err = store.GetTaskRuntime(ctx, jobID, 0)
+	suite.NoError(err)
+	suite.Equal(task.TaskState_RUNNING, runtime.GetState())
+	suite.Equal(uint64(2), runtime.GetRevision().GetVersion())
+
+	// next in-order update is applied
+	taskInfo.Runtime.State = task.TaskState_SUCCEEDED
+	taskInfo.Runtime.Revision.Version = 3
+	suite.NoError(store.UpdateTaskRuntimeCAS(
+		ctx, taskInfo, task.TaskState_RUNNING))
+
+	runtime, err = store.GetTaskRuntime(ctx, jobID, 0)
+	suite.NoError(err)
+	suite.Equal(task.TaskState_SUCCEEDED, runtime.GetState())
+}
+
 func (suite *CassandraStoreTestSuite) TestTaskQueryFilter() {
 	var taskStore storage.TaskStore
 	taskStore = store
@@ -2630,6 +4201,206 @@ func (suite *CassandraStoreTestSuite) TestTaskQueryFilter() {
 
 }
 
+// TestQueryTasksPaging tests that QueryTasks returns exactly limit tasks
+// from the offset, including at the boundary of the instance count, and that
+// paging covers every instance exactly once
+func (suite *CassandraStoreTestSuite) TestQueryTasksPaging() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	configAddOn := &models.ConfigAddOn{}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 5
+	suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		taskInfo := createTaskInfo(jobConfig, jobID, i)
+		suite.NoError(store.CreateTaskRuntime(
+			ctx, jobID, i, taskInfo.Runtime, "user1", jobConfig.GetType()))
+	}
+
+	tt := []struct {
+		offset    uint32
+		limit     uint32
+		instances []uint32
+	}{
+		{offset: 0, limit: 1, instances: []uint32{0}},
+		{offset: 0, limit: 4, instances: []uint32{0, 1, 2, 3}},
+		{offset: 0, limit: 5, instances: []uint32{0, 1, 2, 3, 4}},
+		{offset: 0, limit: 6, instances: []uint32{0, 1, 2, 3, 4}},
+		{offset: 3, limit: 2, instances: []uint32{3, 4}},
+		{offset: 4, limit: 1, instances: []uint32{4}},
+		{offset: 4, limit: 2, instances: []uint32{4}},
+		{offset: 5, limit: 1, instances: nil},
+		{offset: 6, limit: 1, instances: nil},
+	}
+	for _, test := range tt {
+		tasks, total, err := store.QueryTasks(ctx, jobID, &task.QuerySpec{
+			Pagination: &query.PaginationSpec{
+				Offset: test.offset,
+				Limit:  test.limit,
+			},
+		})
+		suite.NoError(err)
+		suite.Equal(jobConfig.InstanceCount, total)
+
+		var instances []uint32
+		for _, t := range tasks {
+			instances = append(instances, t.GetInstanceId())
+		}
+		suite.Equal(test.instances, instances,
+			"offset %d limit %d", test.offset, test.limit)
+	}
+
+	// paging covers every instance exactly once
+	for _, limit := range []uint32{1, 2, 3} {
+		var instances []uint32
+		for offset := uint32(0); offset < jobConfig.InstanceCount; offset += limit {
+			tasks, _, err := store.QueryTasks(ctx, jobID, &task.QuerySpec{
+				Pagination: &query.PaginationSpec{
+					Offset: offset,
+					Limit:  limit,
+				},
+			})
+			suite.NoError(err)
+			for _, t := range tasks {
+				instances = append(instances, t.GetInstanceId())
+			}
+		}
+		suite.Equal([]uint32{0, 1, 2, 3, 4}, instances, "limit %d", limit)
+	}
+}
+
+// TestQueryTasksFilteredTotal tests that the total returned by QueryTasks
+// with filters is the number of matching tasks, not the page length
+func (suite *CassandraStoreTestSuite) TestQueryTasksFilteredTotal() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 9
+	suite.NoError(suite.createJob(
+		ctx, jobID, jobConfig, &models.ConfigAddOn{}, "user1"))
+
+	// every third instance is running
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		taskInfo := createTaskInfo(jobConfig, jobID, i)
+		if i%3 == 0 {
+			taskInfo.Runtime.State = task.TaskState_RUNNING
+		}
+		suite.NoError(store.CreateTaskRuntime(
+			ctx, jobID, i, taskInfo.Runtime, "user1", jobConfig.GetType()))
+	}
+
+	for _, limit := range []uint32{1, 2, 5} {
+		for state, expected := range map[task.TaskState]uint32{
+			task.TaskState_RUNNING:     3,
+			task.TaskState_INITIALIZED: 6,
+			task.TaskState_FAILED:      0,
+		} {
+			tasks, total, err := store.QueryTasks(ctx, jobID, &task.QuerySpec{
+				TaskStates: []task.TaskState{state},
+				Pagination: &query.PaginationSpec{Limit: limit},
+			})
+			suite.NoError(err)
+			suite.Equal(expected, total, "state %s limit %d", state, limit)
+			if expected < limit {
+				suite.Len(tasks, int(expected))
+			} else {
+				suite.Len(tasks, int(limit))
+			}
+		}
+	}
+}
+
+func (suite *CassandraStoreTestSuite) TestQueryTasksOrderBy() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	configAddOn := &models.ConfigAddOn{}
+	jobConfig := buildJobConfig()
+	jobConfig.InstanceCount = 4
+	suite.NoError(suite.createJob(ctx, jobID, jobConfig, configAddOn, "user1"))
+
+	states := []task.TaskState{
+		task.TaskState_RUNNING,
+		task.TaskState_PENDING,
+		task.TaskState_FAILED,
+		task.TaskState_LAUNCHED,
+	}
+	hosts := []string{"host-c", "host-a", "host-b", "host-a"}
+	for i := uint32(0); i < jobConfig.InstanceCount; i++ {
+		taskInfo := createTaskInfo(jobConfig, jobID, i)
+		taskInfo.Runtime.State = states[i]
+		taskInfo.Runtime.Host = hosts[i]
+		suite.NoError(store.CreateTaskRuntime(
+			ctx, jobID, i, taskInfo.Runtime, "user1", jobConfig.GetType()))
+	}
+
+	orderBy := func(
+		order query.OrderBy_Order,
+		properties ...string) []*query.OrderBy {
+		var result []*query.OrderBy
+		for _, property := range properties {
+			result = append(result, &query.OrderBy{
+				Order:    order,
+				Property: &query.PropertyPath{Value: property},
+			})
+		}
+		return result
+	}
+
+	tt := []struct {
+		msg       string
+		orderBy   []*query.OrderBy
+		instances []uint32
+	}{
+		{
+			msg:       "instance ascending",
+			orderBy:   orderBy(query.OrderBy_ASC, "instanceId"),
+			instances: []uint32{0, 1, 2, 3},
+		},
+		{
+			msg:       "instance descending",
+			orderBy:   orderBy(query.OrderBy_DESC, "instanceId"),
+			instances: []uint32{3, 2, 1, 0},
+		},
+		{
+			msg:       "state ascending",
+			orderBy:   orderBy(query.OrderBy_ASC, "state"),
+			instances: []uint32{1, 0, 2, 3},
+		},
+		{
+			msg:       "state descending",
+			orderBy:   orderBy(query.OrderBy_DESC, "state"),
+			instances: []uint32{3, 2, 0, 1},
+		},
+		{
+			msg:       "host ascending then instance",
+			orderBy:   orderBy(query.OrderBy_ASC, "host", "instanceId"),
+			instances: []uint32{1, 3, 2, 0},
+		},
+		{
+			msg:       "host descending then instance",
+			orderBy:   orderBy(query.OrderBy_DESC, "host", "instanceId"),
+			instances: []uint32{0, 2, 3, 1},
+		},
+	}
+
+	for _, test := range tt {
+		tasks, total, err := store.QueryTasks(ctx, jobID, &task.QuerySpec{
+			Pagination: &query.PaginationSpec{
+				OrderBy: test.orderBy,
+			},
+		})
+		suite.NoError(err, test.msg)
+		suite.Equal(jobConfig.InstanceCount, total, test.msg)
+
+		var instances []uint32
+		for _, taskInfo := range tasks {
+			instances = append(instances, taskInfo.GetInstanceId())
+		}
+		suite.Equal(test.instances, instances, test.msg)
+	}
+}
+
 func (suite *CassandraStoreTestSuite) TestQueryTasks() {
 	var taskStore storage.TaskStore
 	taskStore = store
@@ -3011,6 +4782,60 @@ func (suite *CassandraStoreTestSuite) TestPodEvents() {
 	}
 }
 
+func (suite *CassandraStoreTestSuite) TestCompactPodEvents() {
+	ctx := context.Background()
+	jobID := &peloton.JobID{Value: uuid.New()}
+	mesosTaskID := fmt.Sprintf("%s-0-1", jobID.GetValue())
+	states := []task.TaskState{
+		task.TaskState_INITIALIZED,
+		task.TaskState_PENDING,
+		task.TaskState_LAUNCHED,
+		task.TaskState_STARTING,
+		task.TaskState_RUNNING,
+		task.TaskState_FAILED,
+		task.TaskState_INITIALIZED,
+		task.TaskState_RUNNING,
+	}
+
+	for i, state := range states {
+		runtime := &task.RuntimeInfo{
+			State:     state,
+			GoalState: task.TaskState_RUNNING,
+			Message:   fmt.Sprintf("event-%d", i),
+			MesosTaskId: &mesos.TaskID{
+				Value: &mesosTaskID,
+			},
+		}
+		suite.NoError(store.addPodEvent(ctx, jobID, 0, runtime))
+		time.Sleep(time.Millisecond)
+	}
+
+	podEvents, err := store.GetPodEvents(ctx, jobID.GetValue(), 0)
+	suite.NoError(err)
+	suite.Len(podEvents, len(states))
+
+	// compacting to more events than present is a no-op
+	suite.NoError(store.CompactPodEvents(
+		ctx, jobID.GetValue(), 0, uint32(len(states)+1)))
+	podEvents, err = store.GetPodEvents(ctx, jobID.GetValue(), 0)
+	suite.NoError(err)
+	suite.Len(podEvents, len(states))
+
+	keepLast := 3
+	suite.NoError(store.CompactPodEvents(
+		ctx, jobID.GetValue(), 0, uint32(keepLast)))
+	podEvents, err = store.GetPodEvents(ctx, jobID.GetValue(), 0)
+	suite.NoError(err)
+	suite.Len(podEvents, keepLast)
+
+	// the most recent events are kept, latest first
+	for i, podEvent := range podEvents {
+		suite.Equal(
+			fmt.Sprintf("event-%d", len(states)-1-i),
+			podEvent.GetMessage())
+	}
+}
+
 func (suite *CassandraStoreTestSuite) TestGetPodEvent() {
 	dummyJobID := &peloton.JobID{Value: "dummy id"}
 	_, err := store.GetPodEvents(
@@ -3245,12 +5070,26 @@ func (suite *CassandraStoreTestSuite) TestHandleDataStoreError() {
 		gocql.RequestErrReadFailure{},
 		gocql.RequestErrWriteFailure{},
 		gocql.RequestErrAlreadyExists{},
-		gocql.RequestErrReadTimeout{},
-		gocql.RequestErrWriteTimeout{},
-		gocql.ErrTooManyTimeouts,
+		&gocql.RequestErrWriteTimeout{WriteType: "CAS"},
+		&gocql.RequestErrWriteTimeout{WriteType: "COUNTER"},
 	}
 	for _, nErr := range nonRetryableErrs {
-		suite.Error(store.handleDataStoreError(nErr, backoff.NewRetrier(policy)))
+		suite.Error(store.handleDataStoreError(
+			nErr, backoff.NewRetrier(policy), true))
+	}
+
+	timeoutErrs := []error{
+		&gocql.RequestErrReadTimeout{},
+		&gocql.RequestErrWriteTimeout{WriteType: "SIMPLE"},
+		gocql.ErrTooManyTimeouts,
+	}
+	for _, nErr := range timeoutErrs {
+		// timeouts are only retried if allowed
+		suite.NoError(store.handleDataStoreError(
+			nErr, backoff.NewRetrier(policy), true))
+		err := store.handleDataStoreError(
+			nErr, backoff.NewRetrier(policy), false)
+		suite.True(yarpcerrors.IsDeadlineExceeded(err))
 	}
 
 	retryableErrs := []error{
@@ -3261,9 +5100,9 @@ func (suite *CassandraStoreTestSuite) TestHandleDataStoreError() {
 		gocql.ErrNoStreams,
 	}
 	for _, nErr := range retryableErrs {
-		suite.NoError(store.handleDataStoreError(nErr, backoff.NewRetrier(policy)))
+		suite.NoError(store.handleDataStoreError(
+			nErr, backoff.NewRetrier(policy), false))
 	}
-
 }
 
 func (suite *CassandraStoreTestSuite) TestCreateTaskRuntimeForServiceJob() {
@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/uber/peloton/pkg/storage/cassandra/api"
 	"github.com/uber/peloton/pkg/storage/objects/base"
 	"github.com/uber/peloton/pkg/storage/orm"
 
@@ -96,6 +97,22 @@ func NewCassandraConnector(
 // ensure that implementation (cassandraConnector) satisfies the interface
 var _ orm.Connector = (*cassandraConnector)(nil)
 
+// newQuery creates a query run with the context, using the consistency
+// of the query overrides of the context if there is one, like the legacy
+// datastore does.
+func (c *cassandraConnector) newQuery(
+	ctx context.Context,
+	stmt string,
+	values ...interface{},
+) *gocql.Query {
+	q := c.Session.Query(stmt, values...).WithContext(ctx)
+	overrides, ok := ctx.Value(api.QueryOverridesKey).(*api.QueryOverrides)
+	if ok && overrides != nil && overrides.Consistency != nil {
+		q.Consistency(overrides.Consistency.Value)
+	}
+	return q
+}
+
 // getGocqlErrorTag gets a error tag for metrics based on gocql error
 // We cannot just use err.Error() as a tag because it contains invalid
 // characters like = : etc. which will be rejected by M3
@@ -260,7 +277,7 @@ func (c *cassandraConnector) CreateIfNotExists(
 	e *base.Definition,
 	row []base.Column,
 ) error {
-	return c.create(ctx, e, row, useCasWrite)
+	return c.create(ctx, e, row, useCasWrite, 0)
 }
 
 // Create creates a new row in DB.
@@ -269,7 +286,17 @@ func (c *cassandraConnector) Create(
 	e *base.Definition,
 	row []base.Column,
 ) error {
-	return c.create(ctx, e, row, !useCasWrite)
+	return c.create(ctx, e, row, !useCasWrite, 0)
+}
+
+// CreateWithTTL creates a new row in DB, which expires after ttl.
+func (c *cassandraConnector) CreateWithTTL(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+	ttl time.Duration,
+) error {
+	return c.create(ctx, e, row, !useCasWrite, ttl)
 }
 
 func (c *cassandraConnector) create(
@@ -277,6 +304,7 @@ func (c *cassandraConnector) create(
 	e *base.Definition,
 	row []base.Column,
 	casWrite bool,
+	ttl time.Duration,
 ) error {
 	// split row into a list of names and values to compose query stmt using
 	// names and use values in the session query call, so the order needs to be
@@ -284,11 +312,12 @@ func (c *cassandraConnector) create(
 	colNames, colValues := splitColumnNameValue(row)
 
 	// Prepare insert statement
-	stmt, err := InsertStmt(
+	stmt, args, err := InsertStmt(
 		Table(e.Name),
 		Columns(colNames),
 		Values(colValues),
 		IfNotExist(casWrite),
+		TTL(int(ttl.Seconds())),
 	)
 	if err != nil {
 		return err
@@ -299,7 +328,7 @@ func (c *cassandraConnector) create(
 		operation = cas
 	}
 
-	q := c.Session.Query(stmt, colValues...).WithContext(ctx)
+	q := c.newQuery(ctx, stmt, args...)
 
 	if casWrite {
 		applied, err := q.MapScanCAS(map[string]interface{}{})
@@ -325,12 +354,16 @@ func (c *cassandraConnector) create(
 // buildSelectQuery builds a select query using base object and key columns.
 // If limit is non-zero, it will be enforced in the select query.
 // If limit is 0, the select query will fetch all rows that match.
+// Additional options, like the ordering of the rows, are added to the query,
+// and the values of their conditions are bound after the key values.
 func (c *cassandraConnector) buildSelectQuery(
 	ctx context.Context,
 	e *base.Definition,
 	keyCols []base.Column,
 	colNamesToRead []string,
 	limit int,
+	optValues []interface{},
+	opts ...OptFunc,
 ) (*gocql.Query, error) {
 
 	// split keyCols into a list of names and values to compose query stmt using
@@ -339,17 +372,17 @@ func (c *cassandraConnector) buildSelectQuery(
 	keyColNames, keyColValues := splitColumnNameValue(keyCols)
 
 	// Prepare select statement
-	stmt, err := SelectStmt(
+	stmt, err := SelectStmt(append([]OptFunc{
 		Table(e.Name),
 		Columns(colNamesToRead),
 		Conditions(keyColNames),
 		Limit(limit),
-	)
+	}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.Session.Query(stmt, keyColValues...).WithContext(ctx), nil
+	return c.newQuery(ctx, stmt, append(keyColValues, optValues...)...), nil
 }
 
 // Get fetches a record from DB using primary keys
@@ -371,7 +404,8 @@ func (c *cassandraConnector) Get(
 		e,
 		keyCols,
 		colNamesToRead,
-		_defaultQueryLimit)
+		_defaultQueryLimit,
+		nil)
 	if err != nil {
 		sendCounters(c.executeFailScope, e.Name, get, err)
 		return nil, err
@@ -409,6 +443,51 @@ func (c *cassandraConnector) GetAll(
 	ctx context.Context,
 	e *base.Definition,
 	keyCols []base.Column,
+) ([]map[string]interface{}, error) {
+	return c.getAll(ctx, e, keyCols, _ignoredQueryLimit, nil)
+}
+
+// GetAllOrdered fetches at most limit rows from DB using partition keys,
+// sorted by the clustering column orderBy
+func (c *cassandraConnector) GetAllOrdered(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+	orderBy string,
+	asc bool,
+	limit int,
+) ([]map[string]interface{}, error) {
+	return c.getAll(ctx, e, keyCols, limit, nil, OrderBy(orderBy, asc))
+}
+
+// GetAllInTimeRange fetches the rows from DB using partition keys, whose
+// timeuuid clustering column is in the [from, to) time range, sorted by
+// ascending order of the column
+func (c *cassandraConnector) GetAllInTimeRange(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+	column string,
+	from time.Time,
+	to time.Time,
+) ([]map[string]interface{}, error) {
+	return c.getAll(
+		ctx,
+		e,
+		keyCols,
+		_ignoredQueryLimit,
+		[]interface{}{from, to},
+		TimeRange(column),
+		OrderBy(column, true))
+}
+
+func (c *cassandraConnector) getAll(
+	ctx context.Context,
+	e *base.Definition,
+	keyCols []base.Column,
+	limit int,
+	optValues []interface{},
+	opts ...OptFunc,
 ) ([]map[string]interface{}, error) {
 	var result []map[string]interface{}
 	colNamesToRead := e.GetColumnsToRead()
@@ -417,7 +496,9 @@ func (c *cassandraConnector) GetAll(
 		e,
 		keyCols,
 		colNamesToRead,
-		_ignoredQueryLimit)
+		limit,
+		optValues,
+		opts...)
 	if err != nil {
 		sendCounters(c.executeFailScope, e.Name, getAll, err)
 		return nil, err
@@ -474,7 +555,8 @@ func (c *cassandraConnector) GetAllIter(
 		e,
 		keyCols,
 		colNamesToRead,
-		_ignoredQueryLimit)
+		_ignoredQueryLimit,
+		nil)
 	if err != nil {
 		return nil, err
 	}
@@ -513,7 +595,7 @@ func (c *cassandraConnector) Delete(
 		return err
 	}
 
-	q := c.Session.Query(stmt, keyColValues...).WithContext(ctx)
+	q := c.newQuery(ctx, stmt, keyColValues...)
 
 	if err := q.Exec(); err != nil {
 		sendCounters(c.executeFailScope, e.Name, del, err)
@@ -532,6 +614,28 @@ func (c *cassandraConnector) Update(
 	row []base.Column,
 	keyCols []base.Column,
 ) error {
+	return c.update(ctx, e, row, keyCols, nil)
+}
+
+// UpdateIf updates an existing row in DB if its columns have the values of
+// ifCols. Uses CAS write.
+func (c *cassandraConnector) UpdateIf(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+	keyCols []base.Column,
+	ifCols []base.Column,
+) error {
+	return c.update(ctx, e, row, keyCols, ifCols)
+}
+
+func (c *cassandraConnector) update(
+	ctx context.Context,
+	e *base.Definition,
+	row []base.Column,
+	keyCols []base.Column,
+	ifCols []base.Column,
+) error {
 
 	// split keyCols into a list of names and values to compose query stmt using
 	// names and use values in the session query call, so the order needs to be
@@ -543,11 +647,15 @@ func (c *cassandraConnector) Update(
 	// maintained.
 	colNames, colValues := splitColumnNameValue(row)
 
+	// the values of the IF conditions are bound last
+	ifColNames, ifColValues := splitColumnNameValue(ifCols)
+
 	// Prepare update statement
 	stmt, err := UpdateStmt(
 		Table(e.Name),
 		Updates(colNames),
 		Conditions(keyColNames),
+		IfOnly(ifColNames),
 	)
 
 	if err != nil {
@@ -556,17 +664,33 @@ func (c *cassandraConnector) Update(
 
 	// list of values to be supplied in the query
 	updateVals := append(colValues, keyColValues...)
+	updateVals = append(updateVals, ifColValues...)
+
+	operation := update
+	if len(ifCols) > 0 {
+		operation = cas
+	}
 
-	q := c.Session.Query(
-		stmt, updateVals...).WithContext(ctx)
+	q := c.newQuery(ctx, stmt, updateVals...)
 
-	if err := q.Exec(); err != nil {
-		sendCounters(c.executeFailScope, e.Name, update, err)
-		return err
+	if len(ifCols) > 0 {
+		applied, err := q.MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			sendCounters(c.executeFailScope, e.Name, operation, err)
+			return err
+		}
+		if !applied {
+			return yarpcerrors.AbortedErrorf("item was updated concurrently")
+		}
+	} else {
+		if err := q.Exec(); err != nil {
+			sendCounters(c.executeFailScope, e.Name, operation, err)
+			return err
+		}
 	}
 
-	sendLatency(c.scope, e.Name, update, time.Duration(q.Latency()))
-	sendCounters(c.executeSuccessScope, e.Name, update, nil)
+	sendLatency(c.scope, e.Name, operation, time.Duration(q.Latency()))
+	sendCounters(c.executeSuccessScope, e.Name, operation, nil)
 	return nil
 }
 
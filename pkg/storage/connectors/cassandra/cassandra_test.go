@@ -21,8 +21,10 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/uber/peloton/pkg/storage/cassandra/api"
 	"github.com/uber/peloton/pkg/storage/objects/base"
 
+	"github.com/gocql/gocql"
 	log "github.com/sirupsen/logrus"
 	"github.com/uber-go/tally"
 	"go.uber.org/yarpc/yarpcerrors"
@@ -485,6 +487,46 @@ func (suite *CassandraConnSuite) TestCreateIfNotExists() {
 	suite.True(yarpcerrors.IsAlreadyExists(err))
 }
 
+// TestUpdateIf tests that a CAS update is applied only if its conditions
+// hold
+func (suite *CassandraConnSuite) TestUpdateIf() {
+	// Definition stores schema information about an Object
+	obj := &base.Definition{
+		Name: testTableName1,
+		Key: &base.PrimaryKey{
+			PartitionKeys: []string{"id"},
+		},
+		// Column name to data type mapping of the object
+		ColumnToType: map[string]reflect.Type{
+			"id":   reflect.TypeOf(1),
+			"data": reflect.TypeOf("data"),
+			"name": reflect.TypeOf("name"),
+		},
+	}
+	err := connector.Create(context.Background(), obj, testRow)
+	suite.NoError(err)
+
+	testUpdateRow := []base.Column{{Name: "name", Value: "test-cas"}}
+
+	// the condition does not hold, so the row is not updated
+	err = connector.UpdateIf(context.Background(), obj, testUpdateRow, keyRow,
+		[]base.Column{{Name: "data", Value: "not-the-data"}})
+	suite.True(yarpcerrors.IsAborted(err))
+
+	row, err := connector.Get(context.Background(), obj, keyRow)
+	suite.NoError(err)
+	suite.NotEqual("test-cas", row["name"])
+
+	// the condition holds, so the row is updated
+	err = connector.UpdateIf(context.Background(), obj, testUpdateRow, keyRow,
+		[]base.Column{{Name: "data", Value: row["data"]}})
+	suite.NoError(err)
+
+	row, err = connector.Get(context.Background(), obj, keyRow)
+	suite.NoError(err)
+	suite.Equal("test-cas", row["name"])
+}
+
 // TestCreateDBFailures tests failures executing DB query
 func (suite *CassandraConnSuite) TestDBFailures() {
 	// Definition stores schema information about an Object
@@ -570,3 +612,46 @@ func (suite *CassandraConnSuite) TestBuildResultRowUnknownPtrType() {
 	suite.IsType(&value, row[0])
 	suite.Nil(row[1])
 }
+
+// TestNewClusterConsistency tests that the configured consistency is used
+// by the session, defaulting to LOCAL_QUORUM
+func (suite *CassandraConnSuite) TestNewClusterConsistency() {
+	cluster := newCluster(&CassandraConn{})
+	suite.Equal(gocql.LocalQuorum, cluster.Consistency)
+
+	cluster = newCluster(&CassandraConn{Consistency: "QUORUM"})
+	suite.Equal(gocql.Quorum, cluster.Consistency)
+}
+
+// TestQueryOverridesConsistency tests that the consistency of the query
+// overrides of the context overrides the consistency of the session
+func (suite *CassandraConnSuite) TestQueryOverridesConsistency() {
+	obj := &base.Definition{
+		Name: testTableName1,
+		Key: &base.PrimaryKey{
+			PartitionKeys: []string{"id"},
+		},
+		// Column name to data type mapping of the object
+		ColumnToType: map[string]reflect.Type{
+			"id":   reflect.TypeOf(1),
+			"data": reflect.TypeOf("data"),
+			"name": reflect.TypeOf("name"),
+		},
+	}
+
+	// the test keyspace has a single replica, so a write requiring three
+	// replicas can only fail if the override is applied
+	ctx := api.ContextWithQueryOverrides(context.Background(), &api.QueryOverrides{
+		Consistency: &api.ConsistencyOverride{Value: gocql.Three},
+	})
+	suite.Error(connector.Create(ctx, obj, testRow))
+
+	ctx = api.ContextWithQueryOverrides(context.Background(), &api.QueryOverrides{
+		Consistency: &api.ConsistencyOverride{Value: gocql.One},
+	})
+	suite.NoError(connector.Create(ctx, obj, testRow))
+	row, err := connector.Get(ctx, obj, keyRow)
+	suite.NoError(err)
+	suite.Len(row, 3)
+	suite.NoError(connector.Delete(ctx, obj, keyRow))
+}
@@ -46,4 +46,10 @@ type Config struct {
 	CassandraConn *CassandraConn `yaml:"connection"`
 	StoreName     string         `yaml:"store_name"`
 	Migrations    string         `yaml:"migrations"`
+	// JobConfigCacheSize controls the maximum number of job configs
+	// cached in process. Caching is disabled if it is zero.
+	JobConfigCacheSize int `yaml:"job_config_cache_size"`
+	// JobStateHistorySize controls the maximum number of state transitions
+	// kept in the state history of a job. A default is used if it is zero.
+	JobStateHistorySize int `yaml:"job_state_history_size"`
 }
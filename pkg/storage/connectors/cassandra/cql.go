@@ -37,15 +37,33 @@ const (
 	ifNotExist = "IfNotExist"
 	// limit is used to indicate the query limit for number of rows.
 	limit = "Limit"
+	// in is used to indicate an IN condition in the query
+	in = "In"
+	// orderBy is used to indicate the clustering order of the query rows
+	orderBy = "OrderBy"
+	// ttl is used to indicate the time to live in seconds of inserted rows
+	ttl = "TTL"
+	// ifOnly is used to indicate the conditions of a CAS update query
+	ifOnly = "IfOnly"
+	// timeRange is used to indicate a time range condition in the query
+	timeRange = "TimeRange"
 
 	// insertTemplate is used to construct an insert query
 	insertTemplate = `INSERT INTO {{.Table}} ({{ColumnFunc .Columns ", "}})` +
-		` VALUES ({{QuestionMark .Values ", "}}){{ExistsFunc .IfNotExist}};`
+		` VALUES ({{QuestionMark .Values ", "}}){{ExistsFunc .IfNotExist}}` +
+		`{{TTLFunc .TTL}};`
 
 	// selectTemplate is used to construct a select query
 	selectTemplate = `SELECT {{ColumnFunc .Columns ", "}} FROM {{.Table}}` +
 		`{{WhereFunc .Conditions}}{{ConditionsFunc .Conditions " AND "}}` +
-		`{{LimitFunc .Limit}};`
+		`{{InFunc .Conditions .In}}` +
+		`{{TimeRangeFunc .Conditions .In .TimeRange}}` +
+		`{{OrderByFunc .OrderBy}}{{LimitFunc .Limit}};`
+
+	// countTemplate is used to construct a count query
+	countTemplate = `SELECT count(*) FROM {{.Table}}` +
+		`{{WhereFunc .Conditions}}{{ConditionsFunc .Conditions " AND "}}` +
+		`{{InFunc .Conditions .In}};`
 
 	// deleteTemplate is used to construct a delete query
 	deleteTemplate = `DELETE FROM {{.Table}} WHERE ` +
@@ -53,7 +71,8 @@ const (
 
 	// updateTemplate is used to construct update query
 	updateTemplate = `UPDATE {{.Table}} SET {{ConditionsFunc .Updates ", "}}` +
-		`{{WhereFunc .Conditions}}{{ConditionsFunc .Conditions " AND "}};`
+		`{{WhereFunc .Conditions}}{{ConditionsFunc .Conditions " AND "}}` +
+		`{{IfFunc .IfOnly}};`
 )
 
 var (
@@ -65,6 +84,11 @@ var (
 		"WhereFunc":      whereFunc,
 		"ExistsFunc":     existsFunc,
 		"LimitFunc":      limitFunc,
+		"InFunc":         inFunc,
+		"OrderByFunc":    orderByFunc,
+		"TTLFunc":        ttlFunc,
+		"IfFunc":         ifFunc,
+		"TimeRangeFunc":  timeRangeFunc,
 	}
 
 	// insert CQL query template implementation
@@ -73,6 +97,9 @@ var (
 	// select CQL query template implementation
 	selectTmpl = template.Must(
 		template.New("select").Funcs(funcMap).Parse(selectTemplate))
+	// count CQL query template implementation
+	countTmpl = template.Must(
+		template.New("count").Funcs(funcMap).Parse(countTemplate))
 	// delete CQL query template implementation
 	deleteTmpl = template.Must(
 		template.New("delete").Funcs(funcMap).Parse(deleteTemplate))
@@ -94,11 +121,17 @@ func questionMarkFunc(qs []interface{}, sep string) string {
 func conditionsFunc(conds []string, sep string) string {
 	cstrs := make([]string, len(conds))
 	for i, cond := range conds {
-		cstrs[i] = fmt.Sprintf("%s=?", cond)
+		cstrs[i] = fmt.Sprintf("%s=?", quoteIdentifier(cond))
 	}
 	return strings.Join(cstrs, sep)
 }
 
+// quoteIdentifier quotes a column name so that reserved words and mixed
+// case names can be used as identifiers
+func quoteIdentifier(name string) string {
+	return strconv.Quote(name)
+}
+
 // whereFunc adds where clause to the select query
 func whereFunc(conds []string) string {
 	if len(conds) > 0 {
@@ -115,6 +148,14 @@ func existsFunc(exists bool) string {
 	return ""
 }
 
+// ifFunc adds an IF clause with =? conditions to the update query
+func ifFunc(conds []string) string {
+	if len(conds) > 0 {
+		return " IF " + conditionsFunc(conds, " AND ")
+	}
+	return ""
+}
+
 // limitFunc adds a LIMIT clause to the select query.
 func limitFunc(num int) string {
 	if num > 0 {
@@ -123,6 +164,76 @@ func limitFunc(num int) string {
 	return ""
 }
 
+// ttlFunc adds a USING TTL clause to the insert query. The TTL is bound
+// after the inserted values.
+func ttlFunc(seconds int) string {
+	if seconds > 0 {
+		return " USING TTL ?"
+	}
+	return ""
+}
+
+// inClause is an IN condition on a column with a number of values
+type inClause struct {
+	column string
+	count  int
+}
+
+// inFunc adds an IN (?, ...) condition to the select query, after the =?
+// conditions if there are any
+func inFunc(conds []string, clause *inClause) string {
+	if clause == nil {
+		return ""
+	}
+	prefix := " WHERE "
+	if len(conds) > 0 {
+		prefix = " AND "
+	}
+	return fmt.Sprintf("%s%s IN (%s)", prefix, quoteIdentifier(clause.column),
+		questionMarkFunc(make([]interface{}, clause.count), ", "))
+}
+
+// timeRangeClause is a [from, to) time range condition on a timeuuid column
+type timeRangeClause struct {
+	column string
+}
+
+// timeRangeFunc adds a >= minTimeuuid(?) AND < minTimeuuid(?) condition to
+// the select query, after the =? and IN conditions if there are any
+func timeRangeFunc(
+	conds []string,
+	in *inClause,
+	clause *timeRangeClause,
+) string {
+	if clause == nil {
+		return ""
+	}
+	prefix := " WHERE "
+	if len(conds) > 0 || in != nil {
+		prefix = " AND "
+	}
+	column := quoteIdentifier(clause.column)
+	return fmt.Sprintf("%s%s>=minTimeuuid(?) AND %s<minTimeuuid(?)",
+		prefix, column, column)
+}
+
+// orderByClause is the order of the rows by a clustering column
+type orderByClause struct {
+	column string
+	asc    bool
+}
+
+// orderByFunc adds an ORDER BY clause to the select query
+func orderByFunc(clause *orderByClause) string {
+	if clause == nil {
+		return ""
+	}
+	if clause.asc {
+		return fmt.Sprintf(" ORDER BY %s ASC", quoteIdentifier(clause.column))
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC", quoteIdentifier(clause.column))
+}
+
 // Option to compose a cql statement
 type Option map[string]interface{}
 
@@ -132,7 +243,7 @@ type OptFunc func(Option)
 // Table sets the `table` to the cql statement
 func Table(v string) OptFunc {
 	return func(opt Option) {
-		opt[table] = strconv.Quote(v)
+		opt[table] = quoteIdentifier(v)
 	}
 }
 
@@ -141,7 +252,7 @@ func Columns(v []string) OptFunc {
 	return func(opt Option) {
 		quoCs := make([]string, len(v))
 		for i, c := range v {
-			quoCs[i] = strconv.Quote(c)
+			quoCs[i] = quoteIdentifier(c)
 		}
 		opt[columns] = quoCs
 	}
@@ -182,15 +293,72 @@ func Limit(v interface{}) OptFunc {
 	}
 }
 
-// InsertStmt creates insert statement
-func InsertStmt(opts ...OptFunc) (string, error) {
+// InClause sets a `column IN (?, ...)` condition with count values to the
+// `where` clause of the select cql statement, along with the equality
+// conditions set by Conditions
+func InClause(column string, count int) OptFunc {
+	return func(opt Option) {
+		opt[in] = &inClause{column: column, count: count}
+	}
+}
+
+// TTL sets the `using ttl` clause to the insert cql statement, so that the
+// inserted row expires after the given number of seconds. The TTL is not
+// set if seconds is zero.
+func TTL(seconds int) OptFunc {
+	return func(opt Option) {
+		opt[ttl] = seconds
+	}
+}
+
+// TimeRange sets a `column >= minTimeuuid(?) AND column < minTimeuuid(?)`
+// condition to the `where` clause of the select cql statement, so that only
+// the rows with a timeuuid column in a [from, to) time range are selected.
+// The bounds are bound after the values of the other conditions.
+func TimeRange(column string) OptFunc {
+	return func(opt Option) {
+		opt[timeRange] = &timeRangeClause{column: column}
+	}
+}
+
+// IfOnly sets the `if` clause to the update cql statement, so that the
+// row is only updated if the given columns have the values bound after
+// the updated values and the key values
+func IfOnly(v []string) OptFunc {
+	return func(opt Option) {
+		opt[ifOnly] = v
+	}
+}
+
+// OrderBy sets the `order by` clause to the select cql statement, in
+// ascending order of the clustering column if asc is set and in descending
+// order otherwise
+func OrderBy(column string, asc bool) OptFunc {
+	return func(opt Option) {
+		opt[orderBy] = &orderByClause{column: column, asc: asc}
+	}
+}
+
+// InsertStmt creates insert statement along with the values to bind to
+// it, which are the inserted values followed by the TTL if it is set
+func InsertStmt(opts ...OptFunc) (string, []interface{}, error) {
 	var bb bytes.Buffer
-	option := Option{}
+	option := Option{
+		ifNotExist: false,
+		ttl:        0,
+	}
 	for _, opt := range opts {
 		opt(option)
 	}
-	err := insertTmpl.Execute(&bb, option)
-	return bb.String(), err
+	if err := insertTmpl.Execute(&bb, option); err != nil {
+		return "", nil, err
+	}
+
+	args, _ := option[values].([]interface{})
+	if seconds, _ := option[ttl].(int); seconds > 0 {
+		args = append(append([]interface{}{}, args...), seconds)
+	}
+	return bb.String(), args, nil
 }
 
 // SelectStmt creates select statement
@@ -206,6 +374,17 @@ func SelectStmt(opts ...OptFunc) (string, error) {
 	return bb.String(), err
 }
 
+// CountStmt creates a statement counting the rows matching the conditions
+func CountStmt(opts ...OptFunc) (string, error) {
+	var bb bytes.Buffer
+	option := Option{}
+	for _, opt := range opts {
+		opt(option)
+	}
+	err := countTmpl.Execute(&bb, option)
+	return bb.String(), err
+}
+
 // DeleteStmt creates delete statement
 func DeleteStmt(opts ...OptFunc) (string, error) {
 	var bb bytes.Buffer
@@ -15,6 +15,7 @@
 package cassandra
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -57,7 +58,7 @@ func (suite *CassandraConnSuite) TestInsertStmt() {
 		},
 	}
 	for _, d := range data {
-		stmt, err := InsertStmt(
+		stmt, args, err := InsertStmt(
 			Table(d.table),
 			Columns(d.columns),
 			Values(d.values),
@@ -65,6 +66,54 @@ func (suite *CassandraConnSuite) TestInsertStmt() {
 		)
 		suite.NoError(err)
 		suite.Equal(stmt, d.stmt)
+		suite.Equal(d.values, args)
+	}
+}
+
+// TestInsertStmtTTL tests constructing insert CQL query with a TTL and its
+// bind values
+func (suite *CassandraConnSuite) TestInsertStmtTTL() {
+	data := []struct {
+		opts []OptFunc
+		stmt string
+		args []interface{}
+	}{
+		{
+			stmt: "INSERT INTO \"table1\" (\"c1\", \"c2\") VALUES (?, ?);",
+			args: []interface{}{"val1", 2},
+		},
+		{
+			opts: []OptFunc{TTL(0)},
+			stmt: "INSERT INTO \"table1\" (\"c1\", \"c2\") VALUES (?, ?);",
+			args: []interface{}{"val1", 2},
+		},
+		{
+			opts: []OptFunc{TTL(3600)},
+			stmt: "INSERT INTO \"table1\" (\"c1\", \"c2\") VALUES (?, ?)" +
+				" USING TTL ?;",
+			args: []interface{}{"val1", 2, 3600},
+		},
+		{
+			opts: []OptFunc{IfNotExist(true), TTL(60)},
+			stmt: "INSERT INTO \"table1\" (\"c1\", \"c2\") VALUES (?, ?)" +
+				" IF NOT EXISTS USING TTL ?;",
+			args: []interface{}{"val1", 2, 60},
+		},
+	}
+	for _, d := range data {
+		values := []interface{}{"val1", 2}
+		opts := append([]OptFunc{
+			Table("table1"),
+			Columns([]string{"c1", "c2"}),
+			Values(values),
+		}, d.opts...)
+		stmt, args, err := InsertStmt(opts...)
+		suite.NoError(err)
+		suite.Equal(d.stmt, stmt)
+		suite.Equal(d.args, args)
+		suite.Equal(strings.Count(stmt, "?"), len(args))
+		// the inserted values are left untouched
+		suite.Equal([]interface{}{"val1", 2}, values)
 	}
 }
 
@@ -82,14 +131,15 @@ func (suite *CassandraConnSuite) TestSelectStmt() {
 			table:   "table1",
 			cols:    []string{"c1", "c2"},
 			keyCols: []string{"c3", "c4"},
-			stmt:    "SELECT \"c1\", \"c2\" FROM \"table1\" WHERE c3=? AND c4=?;",
+			stmt: "SELECT \"c1\", \"c2\" FROM \"table1\" " +
+				"WHERE \"c3\"=? AND \"c4\"=?;",
 		},
 		{
 			table:   "table2",
 			cols:    []string{"c1", "c2", "c3"},
 			keyCols: []string{"c4"},
 			stmt: "SELECT \"c1\", \"c2\", \"c3\" FROM" +
-				" \"table2\" WHERE c4=?;",
+				" \"table2\" WHERE \"c4\"=?;",
 		},
 		{
 			table:   "table3",
@@ -117,6 +167,138 @@ func (suite *CassandraConnSuite) TestSelectStmt() {
 	}
 }
 
+// TestSelectStmtInClause tests constructing select CQL query with an IN
+// condition
+func (suite *CassandraConnSuite) TestSelectStmtInClause() {
+	data := []struct {
+		keyCols []string
+		count   int
+		stmt    string
+	}{
+		{
+			count: 1,
+			stmt:  "SELECT \"c1\" FROM \"table1\" WHERE \"c2\" IN (?);",
+		},
+		{
+			count: 3,
+			stmt:  "SELECT \"c1\" FROM \"table1\" WHERE \"c2\" IN (?, ?, ?);",
+		},
+		{
+			count: 0,
+			stmt:  "SELECT \"c1\" FROM \"table1\" WHERE \"c2\" IN ();",
+		},
+		{
+			keyCols: []string{"c3"},
+			count:   3,
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c3\"=? AND " +
+				"\"c2\" IN (?, ?, ?);",
+		},
+	}
+	for _, d := range data {
+		stmt, err := SelectStmt(
+			Table("table1"),
+			Columns([]string{"c1"}),
+			Conditions(d.keyCols),
+			InClause("c2", d.count),
+		)
+		suite.NoError(err)
+		suite.Equal(d.stmt, stmt)
+		suite.Equal(len(d.keyCols)+d.count, strings.Count(stmt, "?"))
+	}
+}
+
+// TestSelectStmtOrderByLimit tests constructing select CQL query with an
+// ORDER BY, a LIMIT and a time range clause
+func (suite *CassandraConnSuite) TestSelectStmtOrderByLimit() {
+	data := []struct {
+		opts []OptFunc
+		stmt string
+	}{
+		{
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=?;",
+		},
+		{
+			opts: []OptFunc{Limit(10)},
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=? LIMIT 10;",
+		},
+		{
+			opts: []OptFunc{OrderBy("c3", true)},
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=? " +
+				"ORDER BY \"c3\" ASC;",
+		},
+		{
+			opts: []OptFunc{OrderBy("c3", false), Limit(10)},
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=? " +
+				"ORDER BY \"c3\" DESC LIMIT 10;",
+		},
+		{
+			opts: []OptFunc{InClause("c4", 2), OrderBy("c3", false)},
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=? " +
+				"AND \"c4\" IN (?, ?) ORDER BY \"c3\" DESC;",
+		},
+		{
+			opts: []OptFunc{TimeRange("c3"), OrderBy("c3", true)},
+			stmt: "SELECT \"c1\" FROM \"table1\" WHERE \"c2\"=? " +
+				"AND \"c3\">=minTimeuuid(?) AND \"c3\"<minTimeuuid(?) " +
+				"ORDER BY \"c3\" ASC;",
+		},
+	}
+	for _, d := range data {
+		opts := append([]OptFunc{
+			Table("table1"),
+			Columns([]string{"c1"}),
+			Conditions([]string{"c2"}),
+		}, d.opts...)
+		stmt, err := SelectStmt(opts...)
+		suite.NoError(err)
+		suite.Equal(d.stmt, stmt)
+	}
+}
+
+// TestCountStmt tests constructing count CQL query
+func (suite *CassandraConnSuite) TestCountStmt() {
+	data := []struct {
+		table   string
+		keyCols []string
+		stmt    string
+	}{
+		{
+			table:   "table1",
+			keyCols: []string{"c1", "c2"},
+			stmt: "SELECT count(*) FROM \"table1\" " +
+				"WHERE \"c1\"=? AND \"c2\"=?;",
+		},
+		{
+			table:   "table2",
+			keyCols: []string{},
+			stmt:    "SELECT count(*) FROM \"table2\";",
+		},
+	}
+	for _, d := range data {
+		stmt, err := CountStmt(
+			Table(d.table),
+			Conditions(d.keyCols),
+		)
+		suite.NoError(err)
+		suite.Equal(d.stmt, stmt)
+	}
+
+	// count without conditions
+	stmt, err := CountStmt(Table("table3"))
+	suite.NoError(err)
+	suite.Equal("SELECT count(*) FROM \"table3\";", stmt)
+
+	// count with an IN condition
+	stmt, err = CountStmt(
+		Table("table1"),
+		Conditions([]string{"c1"}),
+		InClause("c2", 2),
+	)
+	suite.NoError(err)
+	suite.Equal("SELECT count(*) FROM \"table1\" "+
+		"WHERE \"c1\"=? AND \"c2\" IN (?, ?);", stmt)
+}
+
 // TestDeleteStmt tests constructing delete CQL query
 func (suite *CassandraConnSuite) TestDeleteStmt() {
 
@@ -132,14 +314,14 @@ func (suite *CassandraConnSuite) TestDeleteStmt() {
 			cols:    []string{"c1", "c2"},
 			keyCols: []string{"c3", "c4"},
 			values:  []interface{}{"val1", "val2"},
-			stmt:    "DELETE FROM \"table1\" WHERE c3=? AND c4=?;",
+			stmt:    "DELETE FROM \"table1\" WHERE \"c3\"=? AND \"c4\"=?;",
 		},
 		{
 			table:   "table2",
 			cols:    []string{"c1", "c2"},
 			keyCols: []string{"c4"},
 			values:  []interface{}{"val1", "val2"},
-			stmt:    "DELETE FROM \"table2\" WHERE c4=?;",
+			stmt:    "DELETE FROM \"table2\" WHERE \"c4\"=?;",
 		},
 	}
 	for _, d := range data {
@@ -175,15 +357,16 @@ func (suite *CassandraConnSuite) TestUpdateStmt() {
 			cols:    []string{"c1", "c2"},
 			keyCols: []string{"c3", "c4"},
 			values:  []interface{}{1, 2},
-			stmt:    "UPDATE \"table1\" SET c1=?, c2=? WHERE c3=? AND c4=?;",
+			stmt: "UPDATE \"table1\" SET \"c1\"=?, \"c2\"=? " +
+				"WHERE \"c3\"=? AND \"c4\"=?;",
 		},
 
 		{
 			table:   "table2",
 			cols:    []string{"c1", "c2", "c3"},
 			keyCols: []string{"c4"},
-			stmt: "UPDATE \"table2\" SET c1=?, c2=?, c3=? " +
-				"WHERE c4=?;",
+			stmt: "UPDATE \"table2\" SET \"c1\"=?, \"c2\"=?, \"c3\"=? " +
+				"WHERE \"c4\"=?;",
 		},
 	}
 	for _, d := range data {
@@ -205,4 +388,59 @@ func (suite *CassandraConnSuite) TestUpdateStmt() {
 		suite.NoError(err)
 		suite.Equal(stmt, d.stmt)
 	}
+
+	// CAS update
+	stmt, err := UpdateStmt(
+		Table("table1"),
+		Updates([]string{"c1"}),
+		Conditions([]string{"c2"}),
+		IfOnly([]string{"c3", "c4"}),
+	)
+	suite.NoError(err)
+	suite.Equal("UPDATE \"table1\" SET \"c1\"=? WHERE \"c2\"=? "+
+		"IF \"c3\"=? AND \"c4\"=?;", stmt)
+}
+
+// TestQuotedIdentifiers tests that reserved words and mixed case column
+// names are quoted in all the statements
+func (suite *CassandraConnSuite) TestQuotedIdentifiers() {
+	cols := []string{"state", "Owner"}
+
+	stmt, _, err := InsertStmt(
+		Table("table1"),
+		Columns(cols),
+		Values([]interface{}{"val1", "val2"}),
+	)
+	suite.NoError(err)
+	suite.Equal("INSERT INTO \"table1\" (\"state\", \"Owner\") "+
+		"VALUES (?, ?);", stmt)
+
+	stmt, err = SelectStmt(
+		Table("table1"),
+		Columns(cols),
+		Conditions(cols),
+		InClause("Key", 2),
+		OrderBy("Order", true),
+	)
+	suite.NoError(err)
+	suite.Equal("SELECT \"state\", \"Owner\" FROM \"table1\" "+
+		"WHERE \"state\"=? AND \"Owner\"=? AND \"Key\" IN (?, ?) "+
+		"ORDER BY \"Order\" ASC;", stmt)
+
+	stmt, err = DeleteStmt(
+		Table("table1"),
+		Conditions(cols),
+	)
+	suite.NoError(err)
+	suite.Equal("DELETE FROM \"table1\" "+
+		"WHERE \"state\"=? AND \"Owner\"=?;", stmt)
+
+	stmt, err = UpdateStmt(
+		Table("table1"),
+		Updates(cols),
+		Conditions([]string{"Key"}),
+	)
+	suite.NoError(err)
+	suite.Equal("UPDATE \"table1\" SET \"state\"=?, \"Owner\"=? "+
+		"WHERE \"Key\"=?;", stmt)
 }
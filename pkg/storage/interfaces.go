@@ -20,6 +20,7 @@ import (
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
 	"github.com/uber/peloton/.gen/peloton/api/v0/task"
 	"github.com/uber/peloton/.gen/peloton/api/v0/update"
 	"github.com/uber/peloton/.gen/peloton/api/v0/volume"
@@ -37,6 +38,16 @@ func (e *VolumeNotFoundError) Error() string {
 	return fmt.Sprintf("volume %v is not found", e.VolumeID.GetValue())
 }
 
+// ResourcePoolNotFoundError indicates that resource pool is not found
+type ResourcePoolNotFoundError struct {
+	ResourcePoolID *peloton.ResourcePoolID
+}
+
+func (e *ResourcePoolNotFoundError) Error() string {
+	return fmt.Sprintf("resource pool %v is not found",
+		e.ResourcePoolID.GetValue())
+}
+
 // Store is is a generic store interface which is
 // a collection of different store interfaces
 type Store interface {
@@ -45,6 +56,7 @@ type Store interface {
 	UpdateStore
 	FrameworkInfoStore
 	PersistentVolumeStore
+	ResourcePoolStore
 }
 
 // JobStore is the interface to store job states
@@ -56,6 +68,12 @@ type JobStore interface {
 	DeleteJob(ctx context.Context, jobID string) error
 	// GetMaxJobConfigVersion returns the maximum version of configs of a given job
 	GetMaxJobConfigVersion(ctx context.Context, jobID string) (uint64, error)
+	// GetJobRuntimes returns the runtimes of the given jobs keyed by job ID,
+	// leaving out the jobs which have no runtime
+	GetJobRuntimes(ctx context.Context, ids []*peloton.JobID) (map[string]*job.RuntimeInfo, error)
+	// GetJobIDsByRespoolID returns up to limit IDs of the jobs in the
+	// resource pool, starting after the given job ID
+	GetJobIDsByRespoolID(ctx context.Context, respoolID *peloton.ResourcePoolID, after string, limit uint32) ([]peloton.JobID, error)
 }
 
 // TaskStore is the interface to store task states
@@ -78,12 +96,26 @@ type TaskStore interface {
 		instanceID uint32,
 		runtime *task.RuntimeInfo,
 		jobType job.JobType) error
+	// UpdateTasks updates the runtimes of a list of tasks in batches
+	UpdateTasks(ctx context.Context, taskInfos []*task.TaskInfo) error
+	// UpdateTaskRuntimeCAS updates the runtime of a given task only if
+	// its stored state is expectedState
+	UpdateTaskRuntimeCAS(
+		ctx context.Context,
+		taskInfo *task.TaskInfo,
+		expectedState task.TaskState) error
 
 	// GetTasksForJob gets the task info for all tasks in a job
 	GetTasksForJob(ctx context.Context, id *peloton.JobID) (map[uint32]*task.TaskInfo, error)
+	// ForEachTaskForJob calls fn with the task info of each task in a job,
+	// without reading all of them in memory, until fn returns an error
+	ForEachTaskForJob(ctx context.Context, id *peloton.JobID, fn func(*task.TaskInfo) error) error
 	// GetTasksForJobAndStates gets the task info for all
-	// tasks in a given job and in a given state
+	// tasks in a given job and in any of the given states
 	GetTasksForJobAndStates(ctx context.Context, id *peloton.JobID, states []task.TaskState) (map[uint32]*task.TaskInfo, error)
+	// GetTasksForJobByConfigVersion gets the task info for all tasks in a
+	// job whose runtime is on the given config version
+	GetTasksForJobByConfigVersion(ctx context.Context, id *peloton.JobID, version uint64) (map[uint32]*task.TaskInfo, error)
 	// GetTaskRuntimesForJobByRange gets the task runtime for all
 	// tasks in a job with instanceID in the given range
 	GetTaskRuntimesForJobByRange(ctx context.Context, id *peloton.JobID, instanceRange *task.InstanceRange) (map[uint32]*task.RuntimeInfo, error)
@@ -103,6 +135,8 @@ type TaskStore interface {
 	DeleteTaskRuntime(ctx context.Context, id *peloton.JobID, instanceID uint32) error
 	// DeletePodEvents deletes the pod events for provided JobID, InstanceID and RunID in the range [fromRunID-toRunID)
 	DeletePodEvents(ctx context.Context, jobID string, instanceID uint32, fromRunID uint64, toRunID uint64) error
+	// CompactPodEvents deletes all but the most recent keepLast pod events for provided JobID and InstanceID
+	CompactPodEvents(ctx context.Context, jobID string, instanceID uint32, keepLast uint32) error
 	// GetPodEvents returns pod events for a Job + Instance + PodID (optional), events are sorted descending timestamp order
 	GetPodEvents(ctx context.Context, jobID string, instanceID uint32, podID ...string) ([]*pod.PodEvent, error)
 }
@@ -155,6 +189,11 @@ type UpdateStore interface {
 	// GetUpdatesForJob returns the list of job updates created for a given job
 	GetUpdatesForJob(ctx context.Context, jobID string) ([]*peloton.UpdateID, error)
 
+	// ListUpdatesForJob returns the job updates, including their
+	// configuration and status, created for a given job sorted by
+	// creation time with the most recent update first
+	ListUpdatesForJob(ctx context.Context, jobID string) ([]*models.UpdateModel, error)
+
 	// AddWorkflowEvent adds a workflow event for an update and instance
 	// to track the progress
 	AddWorkflowEvent(
@@ -186,6 +225,36 @@ type FrameworkInfoStore interface {
 // PersistentVolumeStore is the interface to store all the persistent volume info
 type PersistentVolumeStore interface {
 	CreatePersistentVolume(ctx context.Context, volumeInfo *volume.PersistentVolumeInfo) error
+	// CreatePersistentVolumes creates the persistent volume entries of
+	// several volumes, stopping at the first volume failing to be created.
+	CreatePersistentVolumes(ctx context.Context, volumes []*volume.PersistentVolumeInfo) error
+	// UpsertPersistentVolume creates a persistent volume entry, succeeding
+	// without changes if an entry for the same volume already exists with
+	// the same job, instance, host, size and container path, and failing
+	// if the existing entry conflicts.
+	UpsertPersistentVolume(ctx context.Context, volumeInfo *volume.PersistentVolumeInfo) error
 	UpdatePersistentVolume(ctx context.Context, volumeInfo *volume.PersistentVolumeInfo) error
 	GetPersistentVolume(ctx context.Context, volumeID *peloton.VolumeID) (*volume.PersistentVolumeInfo, error)
+	// GetPersistentVolumesForJob gets the persistent volumes of a job, or an
+	// empty list if the job has no volumes.
+	GetPersistentVolumesForJob(ctx context.Context, jobID *peloton.JobID) ([]*volume.PersistentVolumeInfo, error)
+	// GetVolumesByState gets the persistent volumes in the given state. It
+	// reads from a materialized view, so it may briefly miss or return
+	// volumes whose state has just been updated.
+	GetVolumesByState(ctx context.Context, state volume.VolumeState) ([]*volume.PersistentVolumeInfo, error)
+}
+
+// ResourcePoolStore is the interface to store resource pools
+type ResourcePoolStore interface {
+	// GetResourcePool returns the resource pool with the given ID, or a
+	// ResourcePoolNotFoundError if it does not exist.
+	GetResourcePool(ctx context.Context, id *peloton.ResourcePoolID) (*respool.ResourcePoolInfo, error)
+	// GetResourcePools returns up to limit resource pools after the pool
+	// with the given ID, in a stable order. An empty after starts from the
+	// first pool.
+	GetResourcePools(ctx context.Context, after string, limit uint32) ([]*respool.ResourcePoolInfo, error)
+	// UpdateResourcePool replaces the config of an existing resource pool,
+	// or returns a ResourcePoolNotFoundError if it does not exist. The
+	// change log version of the config must match the stored version.
+	UpdateResourcePool(ctx context.Context, id *peloton.ResourcePoolID, config *respool.ResourcePoolConfig) error
 }
@@ -51,6 +51,18 @@ type JobMetrics struct {
 	JobGetByRespoolID     tally.Counter
 	JobGetByRespoolIDFail tally.Counter
 
+	JobGetByOwner     tally.Counter
+	JobGetByOwnerFail tally.Counter
+
+	JobSetMetadata     tally.Counter
+	JobSetMetadataFail tally.Counter
+
+	JobQueryByMetadata     tally.Counter
+	JobQueryByMetadataFail tally.Counter
+
+	JobGetUpdatedSince     tally.Counter
+	JobGetUpdatedSinceFail tally.Counter
+
 	JobUpdateRuntime     tally.Counter
 	JobUpdateRuntimeFail tally.Counter
 
@@ -90,10 +102,32 @@ type OrmJobMetrics struct {
 	// job_config
 	JobConfigCreate     tally.Counter
 	JobConfigCreateFail tally.Counter
+	JobConfigUpdate     tally.Counter
+	JobConfigUpdateFail tally.Counter
 	JobConfigGet        tally.Counter
 	JobConfigGetFail    tally.Counter
 	JobConfigDelete     tally.Counter
 	JobConfigDeleteFail tally.Counter
+	JobConfigCacheHit   tally.Counter
+	JobConfigCacheMiss  tally.Counter
+
+	JobConfigGetBySchemaVersion     tally.Counter
+	JobConfigGetBySchemaVersionFail tally.Counter
+
+	JobConfigBackfillSchemaShard     tally.Counter
+	JobConfigBackfillSchemaShardFail tally.Counter
+
+	// job_state_history
+	JobStateHistoryAdd     tally.Counter
+	JobStateHistoryAddFail tally.Counter
+	JobStateHistoryGet     tally.Counter
+	JobStateHistoryGetFail tally.Counter
+
+	// job_kill_reason
+	JobKillReasonSet     tally.Counter
+	JobKillReasonSetFail tally.Counter
+	JobKillReasonGet     tally.Counter
+	JobKillReasonGetFail tally.Counter
 
 	// active_jobs.
 	ActiveJobsCreate         tally.Counter
@@ -105,14 +139,16 @@ type OrmJobMetrics struct {
 	ActiveJobsDeleteFail     tally.Counter
 
 	// secret_info
-	SecretInfoCreate     tally.Counter
-	SecretInfoCreateFail tally.Counter
-	SecretInfoGet        tally.Counter
-	SecretInfoGetFail    tally.Counter
-	SecretInfoUpdate     tally.Counter
-	SecretInfoUpdateFail tally.Counter
-	SecretInfoDelete     tally.Counter
-	SecretInfoDeleteFail tally.Counter
+	SecretInfoCreate        tally.Counter
+	SecretInfoCreateFail    tally.Counter
+	SecretInfoGet           tally.Counter
+	SecretInfoGetFail       tally.Counter
+	SecretInfoGetForJob     tally.Counter
+	SecretInfoGetForJobFail tally.Counter
+	SecretInfoUpdate        tally.Counter
+	SecretInfoUpdateFail    tally.Counter
+	SecretInfoDelete        tally.Counter
+	SecretInfoDeleteFail    tally.Counter
 }
 
 // OrmRespoolMetrics tracks counters for resource pools related tables accessed through ORM layer.
@@ -162,12 +198,21 @@ type TaskMetrics struct {
 	TaskGetForJobAndStates     tally.Counter
 	TaskGetForJobAndStatesFail tally.Counter
 
+	TaskGetForJobByTerminality     tally.Counter
+	TaskGetForJobByTerminalityFail tally.Counter
+
+	TaskGetForJobByConfigVersion     tally.Counter
+	TaskGetForJobByConfigVersionFail tally.Counter
+
 	TaskIDsGetForJobAndState     tally.Counter
 	TaskIDsGetForJobAndStateFail tally.Counter
 
 	TaskSummaryForJob     tally.Counter
 	TaskSummaryForJobFail tally.Counter
 
+	TaskGetByIDs     tally.Counter
+	TaskGetByIDsFail tally.Counter
+
 	TaskGetForJobRange     tally.Counter
 	TaskGetForJobRangeFail tally.Counter
 
@@ -197,6 +242,9 @@ type TaskMetrics struct {
 
 	PodEventsDeleteSucess tally.Counter
 	PodEventsDeleteFail   tally.Counter
+
+	PodEventsCompact     tally.Counter
+	PodEventsCompactFail tally.Counter
 }
 
 // UpdateMetrics is a struct for tracking job update related
@@ -217,6 +265,9 @@ type UpdateMetrics struct {
 	UpdateGetForJob     tally.Counter
 	UpdateGetForJobFail tally.Counter
 
+	UpdateListForJob     tally.Counter
+	UpdateListForJobFail tally.Counter
+
 	UpdateDeleteFail tally.Counter
 	UpdateDelete     tally.Counter
 
@@ -265,6 +316,12 @@ type VolumeMetrics struct {
 	VolumeGetFail    tally.Counter
 	VolumeDelete     tally.Counter
 	VolumeDeleteFail tally.Counter
+
+	VolumeGetForJob     tally.Counter
+	VolumeGetForJobFail tally.Counter
+
+	VolumeGetByState     tally.Counter
+	VolumeGetByStateFail tally.Counter
 }
 
 // ErrorMetrics is a struct for tracking all the storage error counters
@@ -283,6 +340,8 @@ type ErrorMetrics struct {
 	NoStreams          tally.Counter
 	NotTransient       tally.Counter
 	CASNotApplied      tally.Counter
+
+	Retry tally.Counter
 }
 
 // WorkflowMetrics is a struct for tracking all the workflow operations/events
@@ -355,6 +414,16 @@ type OrmJobUpdateEventsMetrics struct {
 	JobUpdateEventsDeleteFail tally.Counter
 }
 
+// OrmJobEventsMetrics tracks counter of job events related tables
+type OrmJobEventsMetrics struct {
+	JobEventsAdd        tally.Counter
+	JobEventsAddFail    tally.Counter
+	JobEventsGet        tally.Counter
+	JobEventsGetFail    tally.Counter
+	JobEventsDelete     tally.Counter
+	JobEventsDeleteFail tally.Counter
+}
+
 // Metrics is a struct for tracking all the general purpose counters that have relevance to the storage
 // layer, i.e. how many jobs and tasks were created/deleted in the storage layer
 type Metrics struct {
@@ -371,6 +440,7 @@ type Metrics struct {
 	OrmTaskMetrics            *OrmTaskMetrics
 	OrmHostInfoMetrics        *OrmHostInfoMetrics
 	OrmJobUpdateEventsMetrics *OrmJobUpdateEventsMetrics
+	OrmJobEventsMetrics       *OrmJobEventsMetrics
 }
 
 // NewMetrics returns a new Metrics struct, with all metrics initialized and rooted at the given tally.Scope
@@ -435,16 +505,24 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		JobQueryAll:  jobSuccessScope.Counter("query_all"),
 		JobQueryFail: jobFailScope.Counter("query"),
 
-		JobGetRuntime:         jobSuccessScope.Counter("get_runtime"),
-		JobGetRuntimeFail:     jobFailScope.Counter("get_runtime"),
-		JobGetAll:             jobSuccessScope.Counter("get_job_all"),
-		JobGetAllFail:         jobFailScope.Counter("get_job_all"),
-		JobGetByRespoolID:     jobSuccessScope.Counter("get_job_by_respool_id"),
-		JobGetByRespoolIDFail: jobFailScope.Counter("get_job_by_respool_id"),
-		JobUpdateRuntime:      jobSuccessScope.Counter("update_runtime"),
-		JobUpdateRuntimeFail:  jobFailScope.Counter("update_runtime"),
-		JobUpdateConfig:       jobSuccessScope.Counter("update_config"),
-		JobUpdateConfigFail:   jobFailScope.Counter("update_config"),
+		JobGetRuntime:          jobSuccessScope.Counter("get_runtime"),
+		JobGetRuntimeFail:      jobFailScope.Counter("get_runtime"),
+		JobGetAll:              jobSuccessScope.Counter("get_job_all"),
+		JobGetAllFail:          jobFailScope.Counter("get_job_all"),
+		JobGetByRespoolID:      jobSuccessScope.Counter("get_job_by_respool_id"),
+		JobGetByRespoolIDFail:  jobFailScope.Counter("get_job_by_respool_id"),
+		JobGetByOwner:          jobSuccessScope.Counter("get_job_by_owner"),
+		JobGetByOwnerFail:      jobFailScope.Counter("get_job_by_owner"),
+		JobSetMetadata:         jobSuccessScope.Counter("set_metadata"),
+		JobSetMetadataFail:     jobFailScope.Counter("set_metadata"),
+		JobQueryByMetadata:     jobSuccessScope.Counter("query_by_metadata"),
+		JobQueryByMetadataFail: jobFailScope.Counter("query_by_metadata"),
+		JobGetUpdatedSince:     jobSuccessScope.Counter("get_job_updated_since"),
+		JobGetUpdatedSinceFail: jobFailScope.Counter("get_job_updated_since"),
+		JobUpdateRuntime:       jobSuccessScope.Counter("update_runtime"),
+		JobUpdateRuntimeFail:   jobFailScope.Counter("update_runtime"),
+		JobUpdateConfig:        jobSuccessScope.Counter("update_config"),
+		JobUpdateConfigFail:    jobFailScope.Counter("update_config"),
 
 		JobNameToID:     jobSuccessScope.Counter("job_name_to_id"),
 		JobNameToIDFail: jobFailScope.Counter("job_name_to_id_fail"),
@@ -474,18 +552,24 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		TaskGetLogState:     taskSuccessScope.Counter("get_log_state"),
 		TaskGetLogStateFail: taskFailScope.Counter("get_log_state"),
 
-		TaskGetForJob:                  taskSuccessScope.Counter("get_for_job"),
-		TaskGetForJobFail:              taskFailScope.Counter("get_for_job"),
-		TaskGetForJobAndStates:         taskSuccessScope.Counter("get_for_job_and_states"),
-		TaskGetForJobAndStatesFail:     taskFailScope.Counter("get_for_job_and_states"),
-		TaskIDsGetForJobAndState:       taskSuccessScope.Counter("get_ids_for_job_and_state"),
-		TaskIDsGetForJobAndStateFail:   taskFailScope.Counter("get_ids_for_job_and_state"),
-		TaskSummaryForJob:              taskSuccessScope.Counter("summary_for_job"),
-		TaskSummaryForJobFail:          taskFailScope.Counter("summary_for_job"),
-		TaskGetForJobRange:             taskSuccessScope.Counter("get_for_job_range"),
-		TaskGetForJobRangeFail:         taskFailScope.Counter("get_for_job_range"),
-		TaskGetRuntimesForJobRange:     taskSuccessScope.Counter("get_runtimes_for_job_range"),
-		TaskGetRuntimesForJobRangeFail: taskFailScope.Counter("get_runtimes_for_job_range"),
+		TaskGetForJob:                    taskSuccessScope.Counter("get_for_job"),
+		TaskGetForJobFail:                taskFailScope.Counter("get_for_job"),
+		TaskGetForJobAndStates:           taskSuccessScope.Counter("get_for_job_and_states"),
+		TaskGetForJobAndStatesFail:       taskFailScope.Counter("get_for_job_and_states"),
+		TaskGetForJobByTerminality:       taskSuccessScope.Counter("get_for_job_by_terminality"),
+		TaskGetForJobByTerminalityFail:   taskFailScope.Counter("get_for_job_by_terminality"),
+		TaskGetForJobByConfigVersion:     taskSuccessScope.Counter("get_for_job_by_config_version"),
+		TaskGetForJobByConfigVersionFail: taskFailScope.Counter("get_for_job_by_config_version"),
+		TaskIDsGetForJobAndState:         taskSuccessScope.Counter("get_ids_for_job_and_state"),
+		TaskIDsGetForJobAndStateFail:     taskFailScope.Counter("get_ids_for_job_and_state"),
+		TaskSummaryForJob:                taskSuccessScope.Counter("summary_for_job"),
+		TaskSummaryForJobFail:            taskFailScope.Counter("summary_for_job"),
+		TaskGetByIDs:                     taskSuccessScope.Counter("get_by_ids"),
+		TaskGetByIDsFail:                 taskFailScope.Counter("get_by_ids"),
+		TaskGetForJobRange:               taskSuccessScope.Counter("get_for_job_range"),
+		TaskGetForJobRangeFail:           taskFailScope.Counter("get_for_job_range"),
+		TaskGetRuntimesForJobRange:       taskSuccessScope.Counter("get_runtimes_for_job_range"),
+		TaskGetRuntimesForJobRangeFail:   taskFailScope.Counter("get_runtimes_for_job_range"),
 
 		TaskGetRuntime:        taskSuccessScope.Counter("get_runtime"),
 		TaskGetRuntimeFail:    taskFailScope.Counter("get_runtime"),
@@ -506,6 +590,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		PodEventsGetFail:      taskFailScope.Counter("pod_events_get"),
 		PodEventsDeleteSucess: taskSuccessScope.Counter("pod_events_delete"),
 		PodEventsDeleteFail:   taskFailScope.Counter("pod_events_delete"),
+		PodEventsCompact:      taskSuccessScope.Counter("pod_events_compact"),
+		PodEventsCompactFail:  taskFailScope.Counter("pod_events_compact"),
 	}
 
 	updateMetrics := &UpdateMetrics{
@@ -524,6 +610,9 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		UpdateGetForJob:     updateSuccessScope.Counter("get_for_job"),
 		UpdateGetForJobFail: updateFailScope.Counter("get_for_job"),
 
+		UpdateListForJob:     updateSuccessScope.Counter("list_for_job"),
+		UpdateListForJobFail: updateFailScope.Counter("list_for_job"),
+
 		UpdateDelete:     updateSuccessScope.Counter("delete"),
 		UpdateDeleteFail: updateFailScope.Counter("delete"),
 
@@ -567,6 +656,12 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		VolumeUpdateFail: volumeFailScope.Counter("update"),
 		VolumeDelete:     volumeSuccessScope.Counter("delete"),
 		VolumeDeleteFail: volumeFailScope.Counter("delete"),
+
+		VolumeGetForJob:     volumeSuccessScope.Counter("get_for_job"),
+		VolumeGetForJobFail: volumeFailScope.Counter("get_for_job"),
+
+		VolumeGetByState:     volumeSuccessScope.Counter("get_by_state"),
+		VolumeGetByStateFail: volumeFailScope.Counter("get_by_state"),
 	}
 
 	errorMetrics := &ErrorMetrics{
@@ -584,6 +679,8 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		NoStreams:          storageErrorScope.Counter("no_streams"),
 		NotTransient:       storageErrorScope.Counter("not_transient"),
 		CASNotApplied:      storageErrorScope.Counter("cas_not_applied"),
+
+		Retry: storageErrorScope.Counter("retry"),
 	}
 
 	workflowMetrics := &WorkflowMetrics{
@@ -623,6 +720,18 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	jobConfigFailScope := jobConfigScope.Tagged(
 		map[string]string{"result": "fail"})
 
+	jobStateHistoryScope := ormScope.SubScope("job_state_history")
+	jobStateHistorySuccessScope := jobStateHistoryScope.Tagged(
+		map[string]string{"result": "success"})
+	jobStateHistoryFailScope := jobStateHistoryScope.Tagged(
+		map[string]string{"result": "fail"})
+
+	jobKillReasonScope := ormScope.SubScope("job_kill_reason")
+	jobKillReasonSuccessScope := jobKillReasonScope.Tagged(
+		map[string]string{"result": "success"})
+	jobKillReasonFailScope := jobKillReasonScope.Tagged(
+		map[string]string{"result": "fail"})
+
 	podEventsScope := ormScope.SubScope("pod_events")
 	podEventsSuccessScope := podEventsScope.Tagged(
 		map[string]string{"result": "success"})
@@ -659,6 +768,12 @@ func NewMetrics(scope tally.Scope) *Metrics {
 	jobUpdateEventsFailScope := jobUpdateEventsScope.Tagged(
 		map[string]string{"result": "fail"})
 
+	jobEventsScope := ormScope.SubScope("job_events")
+	jobEventsSuccessScope := jobEventsScope.Tagged(
+		map[string]string{"result": "success"})
+	jobEventsFailScope := jobEventsScope.Tagged(
+		map[string]string{"result": "fail"})
+
 	ormJobMetrics := &OrmJobMetrics{
 		JobIndexCreate:     jobIndexSuccessScope.Counter("create"),
 		JobIndexCreateFail: jobIndexFailScope.Counter("create"),
@@ -678,10 +793,30 @@ func NewMetrics(scope tally.Scope) *Metrics {
 
 		JobConfigCreate:     jobConfigSuccessScope.Counter("create"),
 		JobConfigCreateFail: jobConfigFailScope.Counter("create"),
+		JobConfigUpdate:     jobConfigSuccessScope.Counter("update"),
+		JobConfigUpdateFail: jobConfigFailScope.Counter("update"),
 		JobConfigGet:        jobConfigSuccessScope.Counter("get"),
 		JobConfigGetFail:    jobConfigFailScope.Counter("get"),
 		JobConfigDelete:     jobConfigSuccessScope.Counter("delete"),
 		JobConfigDeleteFail: jobConfigFailScope.Counter("delete"),
+		JobConfigCacheHit:   jobConfigSuccessScope.Counter("cache_hit"),
+		JobConfigCacheMiss:  jobConfigSuccessScope.Counter("cache_miss"),
+
+		JobConfigGetBySchemaVersion:     jobConfigSuccessScope.Counter("get_by_schema_version"),
+		JobConfigGetBySchemaVersionFail: jobConfigFailScope.Counter("get_by_schema_version"),
+
+		JobConfigBackfillSchemaShard:     jobConfigSuccessScope.Counter("backfill_schema_shard"),
+		JobConfigBackfillSchemaShardFail: jobConfigFailScope.Counter("backfill_schema_shard"),
+
+		JobStateHistoryAdd:     jobStateHistorySuccessScope.Counter("add"),
+		JobStateHistoryAddFail: jobStateHistoryFailScope.Counter("add"),
+		JobStateHistoryGet:     jobStateHistorySuccessScope.Counter("get"),
+		JobStateHistoryGetFail: jobStateHistoryFailScope.Counter("get"),
+
+		JobKillReasonSet:     jobKillReasonSuccessScope.Counter("set"),
+		JobKillReasonSetFail: jobKillReasonFailScope.Counter("set"),
+		JobKillReasonGet:     jobKillReasonSuccessScope.Counter("get"),
+		JobKillReasonGetFail: jobKillReasonFailScope.Counter("get"),
 
 		ActiveJobsCreate:         activeJobsSuccessScope.Counter("create"),
 		ActiveJobsCreateFail:     activeJobsFailScope.Counter("create"),
@@ -691,14 +826,16 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		ActiveJobsDelete:         activeJobsSuccessScope.Counter("delete"),
 		ActiveJobsDeleteFail:     activeJobsFailScope.Counter("delete"),
 
-		SecretInfoCreate:     secretInfoSuccessScope.Counter("create"),
-		SecretInfoCreateFail: secretInfoFailScope.Counter("create"),
-		SecretInfoGet:        secretInfoSuccessScope.Counter("get"),
-		SecretInfoGetFail:    secretInfoFailScope.Counter("get"),
-		SecretInfoUpdate:     secretInfoSuccessScope.Counter("update"),
-		SecretInfoUpdateFail: secretInfoFailScope.Counter("update"),
-		SecretInfoDelete:     secretInfoSuccessScope.Counter("delete"),
-		SecretInfoDeleteFail: secretInfoFailScope.Counter("delete"),
+		SecretInfoCreate:        secretInfoSuccessScope.Counter("create"),
+		SecretInfoCreateFail:    secretInfoFailScope.Counter("create"),
+		SecretInfoGet:           secretInfoSuccessScope.Counter("get"),
+		SecretInfoGetFail:       secretInfoFailScope.Counter("get"),
+		SecretInfoGetForJob:     secretInfoSuccessScope.Counter("get_for_job"),
+		SecretInfoGetForJobFail: secretInfoFailScope.Counter("get_for_job"),
+		SecretInfoUpdate:        secretInfoSuccessScope.Counter("update"),
+		SecretInfoUpdateFail:    secretInfoFailScope.Counter("update"),
+		SecretInfoDelete:        secretInfoSuccessScope.Counter("delete"),
+		SecretInfoDeleteFail:    secretInfoFailScope.Counter("delete"),
 	}
 
 	ormRespoolMetrics := &OrmRespoolMetrics{
@@ -761,6 +898,15 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		JobUpdateEventsDeleteFail: jobUpdateEventsFailScope.Counter("delete"),
 	}
 
+	ormJobEventsMetrics := &OrmJobEventsMetrics{
+		JobEventsAdd:        jobEventsSuccessScope.Counter("add"),
+		JobEventsAddFail:    jobEventsFailScope.Counter("add"),
+		JobEventsGet:        jobEventsSuccessScope.Counter("get"),
+		JobEventsGetFail:    jobEventsFailScope.Counter("get"),
+		JobEventsDelete:     jobEventsSuccessScope.Counter("delete"),
+		JobEventsDeleteFail: jobEventsFailScope.Counter("delete"),
+	}
+
 	metrics := &Metrics{
 		JobMetrics:                jobMetrics,
 		TaskMetrics:               taskMetrics,
@@ -775,6 +921,7 @@ func NewMetrics(scope tally.Scope) *Metrics {
 		OrmTaskMetrics:            ormTaskMetrics,
 		OrmJobUpdateEventsMetrics: ormJobUpdateEventsMetrics,
 		OrmHostInfoMetrics:        ormHostInfoMetrics,
+		OrmJobEventsMetrics:       ormJobEventsMetrics,
 	}
 
 	return metrics
@@ -18,7 +18,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"fmt"
 	"go.uber.org/yarpc/yarpcerrors"
+	"hash/fnv"
 	"io/ioutil"
 	"time"
 
@@ -37,8 +39,20 @@ import (
 // init adds a JobConfigObject instance to the global list of storage objects
 func init() {
 	Objs = append(Objs, &JobConfigObject{})
+	Objs = append(Objs, &JobConfigBySchemaShardObject{})
 }
 
+// JobConfigSchemaVersion is the schema version of the job configs written
+// by this version of Peloton. It must be bumped whenever the encoding of the
+// config changes, so that the job configs written under an older schema can
+// be found with GetJobConfigsBySchemaVersion and migrated.
+const JobConfigSchemaVersion uint32 = 1
+
+// JobConfigSchemaShards is the number of shards the job configs of a schema
+// version are spread over in the mv_job_config_by_schema_shard view, so
+// that they are not all in the same partition.
+const JobConfigSchemaShards uint32 = 16
+
 // JobConfigObject corresponds to a row in job_config table.
 type JobConfigObject struct {
 	// DB specific annotations
@@ -58,6 +72,24 @@ type JobConfigObject struct {
 	ApiVersion string `column:"name=api_version"`
 	// Creation time of the job
 	CreationTime time.Time `column:"name=creation_time"`
+	// Schema version of the config of the job
+	SchemaVersion uint32 `column:"name=schema_version"`
+	// Schema version and shard of the config of the job, see schemaShard
+	SchemaShard string `column:"name=schema_shard"`
+}
+
+// JobConfigBySchemaShardObject corresponds to a row in the
+// mv_job_config_by_schema_shard materialized view, which indexes the
+// job_config table by schema version and shard.
+type JobConfigBySchemaShardObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=mv_job_config_by_schema_shard, primaryKey=((schema_shard), job_id, version)"`
+	// Schema version and shard of the config of the job
+	SchemaShard string `column:"name=schema_shard"`
+	// JobID of the job
+	JobID string `column:"name=job_id"`
+	// Version of the config of the job
+	Version uint64 `column:"name=version"`
 }
 
 // transform will convert all the value from DB into the corresponding type
@@ -70,6 +102,29 @@ func (o *JobConfigObject) transform(row map[string]interface{}) {
 	o.Spec = row["spec"].([]byte)
 	o.ApiVersion = row["api_version"].(string)
 	o.CreationTime = row["creation_time"].(time.Time)
+	// job configs written before schema versions were recorded have none
+	if schemaVersion, ok := row["schema_version"].(uint32); ok {
+		o.SchemaVersion = schemaVersion
+	}
+	if schemaShard, ok := row["schema_shard"].(string); ok {
+		o.SchemaShard = schemaShard
+	}
+}
+
+// schemaShard returns the value of the schema_shard column of the job
+// configs of a job written under a schema version. It is the schema version
+// followed by the shard of the job, as the partition key of a view can only
+// have one column which is not in the primary key of the table.
+func schemaShard(schemaVersion uint32, id *peloton.JobID) string {
+	h := fnv.New32a()
+	h.Write([]byte(id.GetValue()))
+	return formatSchemaShard(schemaVersion, h.Sum32()%JobConfigSchemaShards)
+}
+
+// formatSchemaShard formats a schema version and a shard as the value of
+// the schema_shard column.
+func formatSchemaShard(schemaVersion uint32, shard uint32) string {
+	return fmt.Sprintf("%d-%d", schemaVersion, shard)
 }
 
 // JobConfigOpsResult contains the unmarshalled result of a job_config Get()
@@ -84,6 +139,9 @@ type JobConfigOpsResult struct {
 	JobSpec *stateless.JobSpec
 	// ApiVersion contains the API version string
 	ApiVersion string
+	// SchemaVersion is the schema version the config was written under,
+	// zero if it was written before schema versions were recorded
+	SchemaVersion uint32
 }
 
 // JobConfigOps provides methods for manipulating job_config table.
@@ -98,6 +156,17 @@ type JobConfigOps interface {
 		version uint64,
 	) error
 
+	// Update inserts a row for the next version of the job config, if the
+	// current version of the job config is expectedVersion.
+	Update(
+		ctx context.Context,
+		id *peloton.JobID,
+		config *job.JobConfig,
+		configAddOn *models.ConfigAddOn,
+		spec *stateless.JobSpec,
+		expectedVersion uint64,
+	) error
+
 	// GetCurrentVersion retrieves current version of job_config
 	GetCurrentVersion(
 		ctx context.Context,
@@ -125,6 +194,21 @@ type JobConfigOps interface {
 		id *peloton.JobID,
 	) (*JobConfigOpsResult, error)
 
+	// GetJobConfigsBySchemaVersion returns the versions of the job configs
+	// written under the given schema version in the given shard, keyed by
+	// job ID. The shards go from 0 to JobConfigSchemaShards-1.
+	GetJobConfigsBySchemaVersion(
+		ctx context.Context,
+		schemaVersion uint32,
+		shard uint32,
+	) (map[string][]uint64, error)
+
+	// BackfillSchemaShard sets the schema shard of the job configs of the
+	// job which have none, so that they can be found by schema version.
+	// Job configs without a schema version are recorded under schema
+	// version zero. Returns the number of job configs backfilled.
+	BackfillSchemaShard(ctx context.Context, id *peloton.JobID) (int, error)
+
 	// Delete removes an object from the table.
 	Delete(ctx context.Context, id *peloton.JobID, version uint64) error
 }
@@ -132,6 +216,11 @@ type JobConfigOps interface {
 // ensure that default implementation (jobConfigOps) satisfies the interface
 var _ JobConfigOps = (*jobConfigOps)(nil)
 
+// ErrConcurrentUpdate is returned by Update if the job config was updated
+// concurrently, and hence the version the update is based on is stale.
+var ErrConcurrentUpdate = yarpcerrors.AbortedErrorf(
+	"job config was updated concurrently")
+
 // compress a blob using gzip
 func compress(buffer []byte) ([]byte, error) {
 	var b bytes.Buffer
@@ -243,6 +332,8 @@ func newJobConfigObject(
 	obj.Config = configBuffer
 	obj.ConfigAddOn = addOnBuffer
 	obj.CreationTime = time.Now().UTC()
+	obj.SchemaVersion = JobConfigSchemaVersion
+	obj.SchemaShard = schemaShard(JobConfigSchemaVersion, id)
 	return obj, nil
 }
 
@@ -276,6 +367,10 @@ func (d *jobConfigOps) Create(
 		return errors.Wrap(err, "Failed to construct JobConfigObject")
 	}
 
+	// Invalidate the cached config even if the write fails, since it may
+	// still have been applied.
+	defer d.store.jobConfigCache.remove(id, version)
+
 	if err = d.store.oClient.CreateIfNotExists(ctx, obj); err != nil {
 		d.store.metrics.OrmJobMetrics.JobConfigCreateFail.Inc(1)
 		return err
@@ -285,6 +380,57 @@ func (d *jobConfigOps) Create(
 	return nil
 }
 
+// Update writes config as the next version of the job config, but only if
+// the ChangeLog version of the current job config is expectedVersion. The
+// version of config must be expectedVersion+1. The config versions are
+// written with a lightweight transaction, so if another writer already
+// wrote that version the update is not applied either.
+// Returns ErrConcurrentUpdate if the job config was updated concurrently.
+func (d *jobConfigOps) Update(
+	ctx context.Context,
+	id *peloton.JobID,
+	config *job.JobConfig,
+	configAddOn *models.ConfigAddOn,
+	spec *stateless.JobSpec,
+	expectedVersion uint64,
+) error {
+	version := config.GetChangeLog().GetVersion()
+	if version != expectedVersion+1 {
+		d.store.metrics.OrmJobMetrics.JobConfigUpdateFail.Inc(1)
+		return yarpcerrors.InvalidArgumentErrorf(
+			"job config version %d does not follow expected version %d",
+			version, expectedVersion)
+	}
+
+	current, _, err := d.GetCurrentVersion(ctx, id)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.JobConfigUpdateFail.Inc(1)
+		return err
+	}
+	if current.GetChangeLog().GetVersion() != expectedVersion {
+		d.store.metrics.OrmJobMetrics.JobConfigUpdateFail.Inc(1)
+		return ErrConcurrentUpdate
+	}
+
+	if err := d.Create(
+		ctx,
+		id,
+		config,
+		configAddOn,
+		spec,
+		version,
+	); err != nil {
+		d.store.metrics.OrmJobMetrics.JobConfigUpdateFail.Inc(1)
+		if yarpcerrors.IsAlreadyExists(err) {
+			return ErrConcurrentUpdate
+		}
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.JobConfigUpdate.Inc(1)
+	return nil
+}
+
 // GetCurrentVersion gets the latest version JobConfigObject from DB
 func (d *jobConfigOps) GetCurrentVersion(
 	ctx context.Context,
@@ -304,6 +450,10 @@ func (d *jobConfigOps) Get(
 	id *peloton.JobID,
 	version uint64,
 ) (*job.JobConfig, *models.ConfigAddOn, error) {
+	if result, ok := d.getFromCache(id, version); ok {
+		return result.JobConfig, result.ConfigAddOn, nil
+	}
+
 	obj := &JobConfigObject{
 		JobID:   id.GetValue(),
 		Version: version,
@@ -331,6 +481,19 @@ func (d *jobConfigOps) Get(
 		return nil, nil, errors.Wrap(err, "Failed to unmarshal configAddOn")
 	}
 
+	if d.store.jobConfigCache != nil {
+		// The spec is only unmarshalled to be cached along with the config.
+		if spec, err := obj.toSpec(); err == nil {
+			d.store.jobConfigCache.add(id, version, &JobConfigOpsResult{
+				JobConfig:     config,
+				ConfigAddOn:   configAddOn,
+				JobSpec:       spec,
+				ApiVersion:    obj.ApiVersion,
+				SchemaVersion: obj.SchemaVersion,
+			})
+		}
+	}
+
 	d.store.metrics.OrmJobMetrics.JobConfigGet.Inc(1)
 	return config, configAddOn, nil
 }
@@ -354,6 +517,10 @@ func (d *jobConfigOps) GetResult(
 	id *peloton.JobID,
 	version uint64,
 ) (*JobConfigOpsResult, error) {
+	if result, ok := d.getFromCache(id, version); ok {
+		return result, nil
+	}
+
 	obj := &JobConfigObject{
 		JobID:   id.GetValue(),
 		Version: version,
@@ -385,13 +552,109 @@ func (d *jobConfigOps) GetResult(
 		return nil, errors.Wrap(err, "Failed to unmarshal spec")
 	}
 
+	result := &JobConfigOpsResult{
+		JobConfig:     config,
+		ConfigAddOn:   configAddOn,
+		JobSpec:       spec,
+		ApiVersion:    obj.ApiVersion,
+		SchemaVersion: obj.SchemaVersion,
+	}
+	d.store.jobConfigCache.add(id, version, result)
+
 	d.store.metrics.OrmJobMetrics.JobConfigGet.Inc(1)
-	return &JobConfigOpsResult{
-		JobConfig:   config,
-		ConfigAddOn: configAddOn,
-		JobSpec:     spec,
-		ApiVersion:  obj.ApiVersion,
-	}, nil
+	return result, nil
+}
+
+// getFromCache returns the config of the job at the given version if it is
+// cached.
+func (d *jobConfigOps) getFromCache(
+	id *peloton.JobID,
+	version uint64,
+) (*JobConfigOpsResult, bool) {
+	if d.store.jobConfigCache == nil {
+		return nil, false
+	}
+
+	result, ok := d.store.jobConfigCache.get(id, version)
+	if !ok {
+		d.store.metrics.OrmJobMetrics.JobConfigCacheMiss.Inc(1)
+		return nil, false
+	}
+	d.store.metrics.OrmJobMetrics.JobConfigCacheHit.Inc(1)
+	d.store.metrics.OrmJobMetrics.JobConfigGet.Inc(1)
+	return result, true
+}
+
+// GetJobConfigsBySchemaVersion reads the job configs written under the
+// given schema version in the given shard from the
+// mv_job_config_by_schema_shard view. Job configs written before the schema
+// shard was recorded are only in the view once BackfillSchemaShard has run
+// for their job.
+func (d *jobConfigOps) GetJobConfigsBySchemaVersion(
+	ctx context.Context,
+	schemaVersion uint32,
+	shard uint32,
+) (map[string][]uint64, error) {
+	if shard >= JobConfigSchemaShards {
+		d.store.metrics.OrmJobMetrics.JobConfigGetBySchemaVersionFail.Inc(1)
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"schema shard %d is not below %d", shard, JobConfigSchemaShards)
+	}
+
+	rows, err := d.store.oClient.GetAll(ctx, &JobConfigBySchemaShardObject{
+		SchemaShard: formatSchemaShard(schemaVersion, shard),
+	})
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.JobConfigGetBySchemaVersionFail.Inc(1)
+		return nil, err
+	}
+
+	versions := make(map[string][]uint64)
+	for _, row := range rows {
+		jobID := row["job_id"].(string)
+		versions[jobID] = append(versions[jobID], row["version"].(uint64))
+	}
+	d.store.metrics.OrmJobMetrics.JobConfigGetBySchemaVersion.Inc(1)
+	return versions, nil
+}
+
+// BackfillSchemaShard sets the schema_shard column of the job configs of
+// the job which have none. It is meant to be run for every job by migration
+// tooling, so that the job configs written before the column was added can
+// be found with GetJobConfigsBySchemaVersion.
+func (d *jobConfigOps) BackfillSchemaShard(
+	ctx context.Context,
+	id *peloton.JobID,
+) (int, error) {
+	rows, err := d.store.oClient.GetAll(ctx, &JobConfigObject{
+		JobID: id.GetValue(),
+	})
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.JobConfigBackfillSchemaShardFail.Inc(1)
+		return 0, err
+	}
+
+	count := 0
+	for _, row := range rows {
+		obj := &JobConfigObject{}
+		obj.transform(row)
+		if obj.SchemaShard != "" {
+			continue
+		}
+
+		// a missing schema version is read as zero
+		obj.SchemaShard = schemaShard(obj.SchemaVersion, id)
+		err := d.store.oClient.Update(ctx, obj, "SchemaVersion", "SchemaShard")
+		d.store.jobConfigCache.remove(id, obj.Version)
+		if err != nil {
+			d.store.metrics.OrmJobMetrics.JobConfigBackfillSchemaShardFail.Inc(1)
+			return count, err
+		}
+		count++
+	}
+
+	d.store.metrics.OrmJobMetrics.JobConfigBackfillSchemaShard.Inc(1)
+	return count, nil
 }
 
 // Delete deletes a JobConfigObject from db
@@ -405,6 +668,9 @@ func (d *jobConfigOps) Delete(
 		Version: version,
 	}
 
+	// Invalidate the cached config even if the delete fails.
+	defer d.store.jobConfigCache.remove(id, version)
+
 	if err := d.store.oClient.Delete(ctx, obj); err != nil {
 		d.store.metrics.OrmJobMetrics.JobConfigDeleteFail.Inc(1)
 		return err
@@ -0,0 +1,118 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"sync"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+	"github.com/uber/peloton/.gen/peloton/api/v1alpha/job/stateless"
+	"github.com/uber/peloton/.gen/peloton/private/models"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+// jobConfigCacheKey identifies a version of the config of a job.
+type jobConfigCacheKey struct {
+	jobID   string
+	version uint64
+}
+
+// jobConfigCache is an in-process LRU cache of unmarshalled job configs,
+// so that repeated reads of a config version which has not changed avoid
+// the DB. A nil jobConfigCache caches nothing.
+type jobConfigCache struct {
+	sync.Mutex
+	lru *simplelru.LRU
+}
+
+// newJobConfigCache returns a jobConfigCache holding up to size configs.
+func newJobConfigCache(size int) (*jobConfigCache, error) {
+	lru, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &jobConfigCache{lru: lru}, nil
+}
+
+// get returns a copy of the cached config of the job at the given version.
+func (c *jobConfigCache) get(
+	id *peloton.JobID,
+	version uint64,
+) (*JobConfigOpsResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	value, ok := c.lru.Get(jobConfigCacheKey{
+		jobID:   id.GetValue(),
+		version: version,
+	})
+	if !ok {
+		return nil, false
+	}
+	return copyJobConfigOpsResult(value.(*JobConfigOpsResult)), true
+}
+
+// add caches a copy of the config of the job at the given version.
+func (c *jobConfigCache) add(
+	id *peloton.JobID,
+	version uint64,
+	result *JobConfigOpsResult,
+) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.lru.Add(jobConfigCacheKey{
+		jobID:   id.GetValue(),
+		version: version,
+	}, copyJobConfigOpsResult(result))
+}
+
+// remove invalidates the cached config of the job at the given version.
+func (c *jobConfigCache) remove(id *peloton.JobID, version uint64) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.lru.Remove(jobConfigCacheKey{
+		jobID:   id.GetValue(),
+		version: version,
+	})
+}
+
+// copyJobConfigOpsResult deep copies the result, so that callers modifying
+// a config they read do not modify the cached one.
+func copyJobConfigOpsResult(r *JobConfigOpsResult) *JobConfigOpsResult {
+	return &JobConfigOpsResult{
+		JobConfig:     proto.Clone(r.JobConfig).(*job.JobConfig),
+		ConfigAddOn:   proto.Clone(r.ConfigAddOn).(*models.ConfigAddOn),
+		JobSpec:       proto.Clone(r.JobSpec).(*stateless.JobSpec),
+		ApiVersion:    r.ApiVersion,
+		SchemaVersion: r.SchemaVersion,
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/uber/peloton/.gen/peloton/api/v1alpha/pod"
 	"github.com/uber/peloton/.gen/peloton/private/models"
 
+	"github.com/uber/peloton/pkg/storage/objects/base"
 	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
 
 	"github.com/golang/mock/gomock"
@@ -100,7 +101,7 @@ func (s *JobConfigObjectTestSuite) TestGetCurrentVersion() {
 		ConfigurationVersion: 1,
 	}
 
-	err := jobRuntimeOps.Upsert(ctx, s.jobID, runtime)
+	err := jobRuntimeOps.Upsert(ctx, s.jobID, runtime, job.JobState_UNKNOWN)
 	s.NoError(err)
 
 	err = jobConfigOps.Create(
@@ -124,6 +125,161 @@ func (s *JobConfigObjectTestSuite) TestGetCurrentVersion() {
 	s.True(proto.Equal(obj.JobSpec, s.spec))
 }
 
+// TestUpdateJobConfig tests updating the job config based on its current
+// version
+func (s *JobConfigObjectTestSuite) TestUpdateJobConfig() {
+	jobConfigOps := NewJobConfigOps(testStore)
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.config.ChangeLog = &peloton.ChangeLog{Version: 1}
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, &job.RuntimeInfo{
+		State:                job.JobState_INITIALIZED,
+		GoalState:            job.JobState_SUCCEEDED,
+		ConfigurationVersion: 1,
+	}, job.JobState_UNKNOWN))
+	s.NoError(jobConfigOps.Create(
+		ctx, s.jobID, s.config, s.configAddOn, s.spec, uint64(1)))
+
+	newConfig := proto.Clone(s.config).(*job.JobConfig)
+	newConfig.Name = "my-updated-test-job"
+	newConfig.ChangeLog = &peloton.ChangeLog{Version: 2}
+
+	// the version of the config must follow the expected version
+	err := jobConfigOps.Update(
+		ctx, s.jobID, newConfig, s.configAddOn, s.spec, uint64(2))
+	s.True(yarpcerrors.IsInvalidArgument(err))
+
+	// the update is based on a stale version
+	err = jobConfigOps.Update(
+		ctx, s.jobID, s.config, s.configAddOn, s.spec, uint64(0))
+	s.Equal(ErrConcurrentUpdate, err)
+
+	s.NoError(jobConfigOps.Update(
+		ctx, s.jobID, newConfig, s.configAddOn, s.spec, uint64(1)))
+	config, _, err := jobConfigOps.Get(ctx, s.jobID, uint64(2))
+	s.NoError(err)
+	s.True(proto.Equal(newConfig, config))
+
+	// another update based on the same version conflicts with the first
+	// one, even though the runtime still points at the old version
+	conflictingConfig := proto.Clone(newConfig).(*job.JobConfig)
+	conflictingConfig.Name = "my-conflicting-test-job"
+	err = jobConfigOps.Update(
+		ctx, s.jobID, conflictingConfig, s.configAddOn, s.spec, uint64(1))
+	s.Equal(ErrConcurrentUpdate, err)
+
+	config, _, err = jobConfigOps.Get(ctx, s.jobID, uint64(2))
+	s.NoError(err)
+	s.True(proto.Equal(newConfig, config))
+}
+
+// TestGetJobConfigsBySchemaVersion tests finding the job configs written
+// under a schema version
+func (s *JobConfigObjectTestSuite) TestGetJobConfigsBySchemaVersion() {
+	jobConfigOps := NewJobConfigOps(testStore)
+	ctx := context.Background()
+
+	// the current job config is written under the current schema version
+	s.NoError(jobConfigOps.Create(
+		ctx, s.jobID, s.config, s.configAddOn, s.spec, uint64(1)))
+	result, err := jobConfigOps.GetResult(ctx, s.jobID, uint64(1))
+	s.NoError(err)
+	s.Equal(JobConfigSchemaVersion, result.SchemaVersion)
+
+	// an older version of the job config was written under another schema
+	oldSchemaVersion := JobConfigSchemaVersion + 100
+	obj, err := newJobConfigObject(
+		s.jobID, uint64(0), s.config, s.configAddOn, s.spec)
+	s.NoError(err)
+	obj.SchemaVersion = oldSchemaVersion
+	obj.SchemaShard = schemaShard(oldSchemaVersion, s.jobID)
+	s.NoError(testStore.oClient.Create(ctx, obj))
+
+	result, err = jobConfigOps.GetResult(ctx, s.jobID, uint64(0))
+	s.NoError(err)
+	s.Equal(oldSchemaVersion, result.SchemaVersion)
+
+	shard := s.schemaShard()
+	versions, err := jobConfigOps.GetJobConfigsBySchemaVersion(
+		ctx, oldSchemaVersion, shard)
+	s.NoError(err)
+	s.Equal([]uint64{0}, versions[s.jobID.GetValue()])
+
+	versions, err = jobConfigOps.GetJobConfigsBySchemaVersion(
+		ctx, JobConfigSchemaVersion, shard)
+	s.NoError(err)
+	s.Equal([]uint64{1}, versions[s.jobID.GetValue()])
+
+	// the job is only in its own shard
+	versions, err = jobConfigOps.GetJobConfigsBySchemaVersion(
+		ctx, JobConfigSchemaVersion, (shard+1)%JobConfigSchemaShards)
+	s.NoError(err)
+	s.NotContains(versions, s.jobID.GetValue())
+
+	_, err = jobConfigOps.GetJobConfigsBySchemaVersion(
+		ctx, JobConfigSchemaVersion, JobConfigSchemaShards)
+	s.True(yarpcerrors.IsInvalidArgument(err))
+
+	s.NoError(jobConfigOps.Delete(ctx, s.jobID, uint64(0)))
+	s.NoError(jobConfigOps.Delete(ctx, s.jobID, uint64(1)))
+}
+
+// TestBackfillSchemaShard tests that the job configs written before the
+// schema shard was recorded can be found once it is backfilled
+func (s *JobConfigObjectTestSuite) TestBackfillSchemaShard() {
+	jobConfigOps := NewJobConfigOps(testStore)
+	ctx := context.Background()
+
+	// a job config written before schema versions were recorded
+	obj, err := newJobConfigObject(
+		s.jobID, uint64(1), s.config, s.configAddOn, s.spec)
+	s.NoError(err)
+	obj.SchemaVersion = 0
+	obj.SchemaShard = ""
+	s.NoError(testStore.oClient.Create(ctx, obj))
+	// and one written under the current schema version
+	s.NoError(jobConfigOps.Create(
+		ctx, s.jobID, s.config, s.configAddOn, s.spec, uint64(2)))
+
+	shard := s.schemaShard()
+	versions, err := jobConfigOps.GetJobConfigsBySchemaVersion(ctx, 0, shard)
+	s.NoError(err)
+	s.NotContains(versions, s.jobID.GetValue())
+
+	count, err := jobConfigOps.BackfillSchemaShard(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(1, count)
+
+	versions, err = jobConfigOps.GetJobConfigsBySchemaVersion(ctx, 0, shard)
+	s.NoError(err)
+	s.Equal([]uint64{1}, versions[s.jobID.GetValue()])
+	versions, err = jobConfigOps.GetJobConfigsBySchemaVersion(
+		ctx, JobConfigSchemaVersion, shard)
+	s.NoError(err)
+	s.Equal([]uint64{2}, versions[s.jobID.GetValue()])
+
+	// backfilling again is a no-op
+	count, err = jobConfigOps.BackfillSchemaShard(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(0, count)
+
+	s.NoError(jobConfigOps.Delete(ctx, s.jobID, uint64(1)))
+	s.NoError(jobConfigOps.Delete(ctx, s.jobID, uint64(2)))
+}
+
+// schemaShard returns the schema shard of the test job
+func (s *JobConfigObjectTestSuite) schemaShard() uint32 {
+	for shard := uint32(0); shard < JobConfigSchemaShards; shard++ {
+		if schemaShard(JobConfigSchemaVersion, s.jobID) ==
+			formatSchemaShard(JobConfigSchemaVersion, shard) {
+			return shard
+		}
+	}
+	s.Fail("no schema shard for job")
+	return 0
+}
+
 // TestCreateGetDeleteJobConfigFail tests failure cases due to ORM Client errors
 func (s *JobConfigObjectTestSuite) TestCreateGetDeleteJobConfigFail() {
 	ctrl := gomock.NewController(s.T())
@@ -174,6 +330,75 @@ func (s *JobConfigObjectTestSuite) TestCreateGetDeleteJobConfigFail() {
 	s.Equal("delete failed", err.Error())
 }
 
+// TestJobConfigCache tests that cached job configs are read without a DB
+// call, and that writes invalidate the cached configs.
+func (s *JobConfigObjectTestSuite) TestJobConfigCache() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	cache, err := newJobConfigCache(10)
+	s.NoError(err)
+	mockClient := ormmocks.NewMockClient(ctrl)
+	mockStore := &Store{
+		oClient:        mockClient,
+		metrics:        testStore.metrics,
+		jobConfigCache: cache,
+	}
+	configOps := NewJobConfigOps(mockStore)
+
+	// All the calls go through to the DB, and the expectations count the
+	// DB calls.
+	mockClient.EXPECT().CreateIfNotExists(gomock.Any(), gomock.Any()).
+		DoAndReturn(testStore.oClient.CreateIfNotExists).Times(2)
+	mockClient.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		DoAndReturn(testStore.oClient.Delete).Times(1)
+	mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			ctx context.Context,
+			e base.Object,
+			fieldsToRead ...string,
+		) (map[string]interface{}, error) {
+			return testStore.oClient.Get(ctx, e, fieldsToRead...)
+		}).Times(3)
+
+	ctx := context.Background()
+	version := uint64(1)
+	s.NoError(configOps.Create(
+		ctx, s.jobID, s.config, s.configAddOn, s.spec, version))
+
+	// The first read populates the cache, the next ones hit it.
+	config, _, err := configOps.Get(ctx, s.jobID, version)
+	s.NoError(err)
+	s.True(proto.Equal(config, s.config))
+
+	config.Name = "modified-by-caller"
+	config, configAddOn, err := configOps.Get(ctx, s.jobID, version)
+	s.NoError(err)
+	s.True(proto.Equal(config, s.config))
+	s.True(proto.Equal(configAddOn, s.configAddOn))
+
+	result, err := configOps.GetResult(ctx, s.jobID, version)
+	s.NoError(err)
+	s.True(proto.Equal(result.JobConfig, s.config))
+	s.True(proto.Equal(result.JobSpec, s.spec))
+
+	// Writing the config again invalidates the cached config.
+	s.NoError(NewJobConfigOps(testStore).Delete(ctx, s.jobID, version))
+	newConfig := proto.Clone(s.config).(*job.JobConfig)
+	newConfig.Name = "my-updated-test-job"
+	s.NoError(configOps.Create(
+		ctx, s.jobID, newConfig, s.configAddOn, s.spec, version))
+
+	config, _, err = configOps.Get(ctx, s.jobID, version)
+	s.NoError(err)
+	s.True(proto.Equal(config, newConfig))
+
+	// Deleting the config invalidates the cached config.
+	s.NoError(configOps.Delete(ctx, s.jobID, version))
+	_, _, err = configOps.Get(ctx, s.jobID, version)
+	s.True(yarpcerrors.IsNotFound(err))
+}
+
 func (s *JobConfigObjectTestSuite) buildConfig() {
 	s.jobID = &peloton.JobID{Value: uuid.New()}
 
@@ -0,0 +1,190 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gocql/gocql"
+)
+
+// init adds a JobEventsObject instance to the global list of storage objects
+func init() {
+	Objs = append(Objs, &JobEventsObject{})
+}
+
+// JobEventsObject corresponds to a row in job_events table.
+type JobEventsObject struct {
+	// base.Object DB specific annotations
+	base.Object `cassandra:"name=job_events, primaryKey=((job_id),create_time)"`
+	// JobID of the job (uuid)
+	JobID string `column:"name=job_id"`
+	// CreateTime is the time uuid of the event
+	CreateTime *base.OptionalString `column:"name=create_time"`
+	// Event is the lifecycle event, e.g. created or killed
+	Event string `column:"name=event"`
+	// Details is a free form description of the event
+	Details string `column:"name=details"`
+}
+
+// transform will convert all the value from DB into the corresponding type
+// in ORM object to be interpreted by base store client
+func (o *JobEventsObject) transform(row map[string]interface{}) {
+	o.JobID = row["job_id"].(string)
+	o.CreateTime = base.NewOptionalString(row["create_time"])
+	o.Event = row["event"].(string)
+	o.Details = row["details"].(string)
+}
+
+// JobEvent is a single entry in the audit trail of a job.
+type JobEvent struct {
+	// Event is the lifecycle event, e.g. created or killed
+	Event string
+	// Details is a free form description of the event
+	Details string
+	// Time at which the event happened
+	Time time.Time
+}
+
+// JobEventsOps provides methods for manipulating job_events table.
+type JobEventsOps interface {
+	// AddJobEvent records a lifecycle event of a job which happened
+	// at eventTime.
+	AddJobEvent(
+		ctx context.Context,
+		jobID *peloton.JobID,
+		event string,
+		details string,
+		eventTime time.Time,
+	) error
+
+	// GetJobEvents returns the events of a job which happened in
+	// [from, to), sorted by ascending order of time of event.
+	GetJobEvents(
+		ctx context.Context,
+		jobID *peloton.JobID,
+		from time.Time,
+		to time.Time,
+	) ([]*JobEvent, error)
+
+	// Delete deletes all the events of a job.
+	Delete(
+		ctx context.Context,
+		jobID *peloton.JobID,
+	) error
+}
+
+// ensure that default implementation (jobEventsOps) satisfies the interface
+var _ JobEventsOps = (*jobEventsOps)(nil)
+
+// jobEventsOps implements JobEventsOps using a particular Store
+type jobEventsOps struct {
+	store *Store
+}
+
+// NewJobEventsOps constructs a JobEventsOps object for provided Store.
+func NewJobEventsOps(s *Store) JobEventsOps {
+	return &jobEventsOps{store: s}
+}
+
+// AddJobEvent records a lifecycle event of a job which happened
+// at eventTime.
+func (d *jobEventsOps) AddJobEvent(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	event string,
+	details string,
+	eventTime time.Time,
+) error {
+	obj := &JobEventsObject{
+		JobID:      jobID.GetValue(),
+		CreateTime: base.NewOptionalString(gocql.UUIDFromTime(eventTime).String()),
+		Event:      event,
+		Details:    details,
+	}
+
+	if err := d.store.oClient.Create(ctx, obj); err != nil {
+		d.store.metrics.OrmJobEventsMetrics.JobEventsAddFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobEventsMetrics.JobEventsAdd.Inc(1)
+	return nil
+}
+
+// GetJobEvents returns the events of a job which happened in
+// [from, to), sorted by ascending order of time of event.
+func (d *jobEventsOps) GetJobEvents(
+	ctx context.Context,
+	jobID *peloton.JobID,
+	from time.Time,
+	to time.Time,
+) ([]*JobEvent, error) {
+	obj := &JobEventsObject{
+		JobID: jobID.GetValue(),
+	}
+
+	rows, err := d.store.oClient.GetAllInTimeRange(
+		ctx, obj, "create_time", from, to)
+	if err != nil {
+		d.store.metrics.OrmJobEventsMetrics.JobEventsGetFail.Inc(1)
+		return nil, err
+	}
+
+	var events []*JobEvent
+	for _, row := range rows {
+		jobEventsObjectValue := &JobEventsObject{}
+		jobEventsObjectValue.transform(row)
+		timeUUID, err := gocql.ParseUUID(jobEventsObjectValue.CreateTime.Value)
+		if err != nil {
+			d.store.metrics.OrmJobEventsMetrics.JobEventsGetFail.Inc(1)
+			return nil, err
+		}
+
+		events = append(events, &JobEvent{
+			Event:   jobEventsObjectValue.Event,
+			Details: jobEventsObjectValue.Details,
+			Time:    timeUUID.Time(),
+		})
+	}
+
+	d.store.metrics.OrmJobEventsMetrics.JobEventsGet.Inc(1)
+	return events, nil
+}
+
+// Delete deletes all the events of a job.
+func (d *jobEventsOps) Delete(
+	ctx context.Context,
+	jobID *peloton.JobID,
+) error {
+	// the create time is not set, so that all the events of the job
+	// are deleted
+	obj := &JobEventsObject{
+		JobID: jobID.GetValue(),
+	}
+
+	if err := d.store.oClient.Delete(ctx, obj); err != nil {
+		d.store.metrics.OrmJobEventsMetrics.JobEventsDeleteFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobEventsMetrics.JobEventsDelete.Inc(1)
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type JobEventsObjectTestSuite struct {
+	suite.Suite
+	jobID *peloton.JobID
+}
+
+func (s *JobEventsObjectTestSuite) SetupTest() {
+	setupTestStore()
+	s.jobID = &peloton.JobID{Value: uuid.New()}
+}
+
+func TestJobEventsObjectTestSuite(t *testing.T) {
+	suite.Run(t, new(JobEventsObjectTestSuite))
+}
+
+func (s *JobEventsObjectTestSuite) TestAddAndGetJobEvents() {
+	db := NewJobEventsOps(testStore)
+	ctx := context.Background()
+	now := time.Now()
+
+	// add the events out of order
+	s.NoError(db.AddJobEvent(
+		ctx, s.jobID, "update_finished", "update succeeded", now.Add(-1*time.Minute)))
+	s.NoError(db.AddJobEvent(
+		ctx, s.jobID, "created", "job created", now.Add(-3*time.Minute)))
+	s.NoError(db.AddJobEvent(
+		ctx, s.jobID, "update_started", "update started", now.Add(-2*time.Minute)))
+	s.NoError(db.AddJobEvent(
+		ctx, s.jobID, "killed", "killed by user", now))
+
+	events, err := db.GetJobEvents(
+		ctx, s.jobID, now.Add(-time.Hour), now.Add(time.Hour))
+	s.NoError(err)
+	s.Len(events, 4)
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Event)
+	}
+	s.Equal([]string{
+		"created", "update_started", "update_finished", "killed"}, names)
+	s.Equal("job created", events[0].Details)
+
+	// only events within the window are returned
+	events, err = db.GetJobEvents(
+		ctx, s.jobID, now.Add(-150*time.Second), now.Add(-30*time.Second))
+	s.NoError(err)
+	s.Len(events, 2)
+	s.Equal("update_started", events[0].Event)
+	s.Equal("update_finished", events[1].Event)
+
+	// the window bounds are pushed into the query, [from, to)
+	events, err = db.GetJobEvents(
+		ctx, s.jobID, now.Add(-2*time.Minute), now)
+	s.NoError(err)
+	s.Len(events, 2)
+	s.Equal("update_started", events[0].Event)
+	s.Equal("update_finished", events[1].Event)
+
+	// no events for an unknown job
+	events, err = db.GetJobEvents(
+		ctx,
+		&peloton.JobID{Value: uuid.New()},
+		now.Add(-time.Hour),
+		now.Add(time.Hour))
+	s.NoError(err)
+	s.Empty(events)
+
+	// all the events of the job are deleted
+	s.NoError(db.Delete(ctx, s.jobID))
+	events, err = db.GetJobEvents(
+		ctx, s.jobID, now.Add(-time.Hour), now.Add(time.Hour))
+	s.NoError(err)
+	s.Empty(events)
+}
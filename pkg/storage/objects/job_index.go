@@ -52,9 +52,15 @@ var (
 	}
 )
 
+// JobIndexByUpdateTimeRetention is the time for which the rows of the
+// job_index_by_update_time table are kept, it matches the default TTL of
+// the table.
+const JobIndexByUpdateTimeRetention = 30 * 24 * time.Hour
+
 // init adds a JobIndexObject instance to the global list of storage objects
 func init() {
 	Objs = append(Objs, &JobIndexObject{})
+	Objs = append(Objs, &JobIndexByUpdateTimeObject{})
 }
 
 // JobIndexObject corresponds to a row in job_index table.
@@ -98,6 +104,27 @@ type JobIndexObject struct {
 	SLA string `column:"name=sla"`
 }
 
+// JobIndexByUpdateTimeObject corresponds to a row in
+// job_index_by_update_time table, which indexes the jobs by the update
+// time of their job_index row.
+type JobIndexByUpdateTimeObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=job_index_by_update_time, primaryKey=((update_day), update_time, job_id)"`
+
+	// Day of the update time, in days since the epoch
+	UpdateDay uint64 `column:"name=update_day"`
+	// Time when job was updated
+	UpdateTime time.Time `column:"name=update_time"`
+	// JobID of the job
+	JobID string `column:"name=job_id"`
+}
+
+// JobIndexUpdateDay returns the partition of job_index_by_update_time
+// table for an update time, which is its day in days since the epoch.
+func JobIndexUpdateDay(updateTime time.Time) uint64 {
+	return uint64(updateTime.Unix() / int64((24 * time.Hour).Seconds()))
+}
+
 // transform will convert all the value from DB into the corresponding type
 // in ORM object to be interpreted by base store client
 func (o *JobIndexObject) transform(row map[string]interface{}) {
@@ -322,6 +349,13 @@ func (d *jobIndexOps) Create(
 		return err
 	}
 
+	if runtime != nil {
+		if err = d.indexUpdateTime(ctx, obj); err != nil {
+			d.store.metrics.OrmJobMetrics.JobIndexCreateFail.Inc(1)
+			return err
+		}
+	}
+
 	d.store.metrics.OrmJobMetrics.JobIndexCreate.Inc(1)
 	return nil
 }
@@ -430,10 +464,30 @@ func (d *jobIndexOps) Update(
 		return err
 	}
 
+	if runtime != nil {
+		if err = d.indexUpdateTime(ctx, obj); err != nil {
+			d.store.metrics.OrmJobMetrics.JobIndexUpdateFail.Inc(1)
+			return err
+		}
+	}
+
 	d.store.metrics.OrmJobMetrics.JobIndexUpdate.Inc(1)
 	return nil
 }
 
+// indexUpdateTime adds the update time of a JobIndexObject written to db
+// to job_index_by_update_time table.
+func (d *jobIndexOps) indexUpdateTime(
+	ctx context.Context,
+	obj *JobIndexObject,
+) error {
+	return d.store.oClient.Create(ctx, &JobIndexByUpdateTimeObject{
+		UpdateDay:  JobIndexUpdateDay(obj.UpdateTime),
+		UpdateTime: obj.UpdateTime,
+		JobID:      obj.JobID.String(),
+	})
+}
+
 // Delete deletes a JobIndexObject from db
 func (d *jobIndexOps) Delete(
 	ctx context.Context,
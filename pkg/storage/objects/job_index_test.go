@@ -393,6 +393,16 @@ func (s *JobIndexObjectTestSuite) TestJobIndexOpsClientFail() {
 	err = indexOps.Delete(ctx, jobID)
 	s.Error(err)
 	s.Equal("delete failed", err.Error())
+
+	// the update fails if its update time is not indexed
+	mockClient.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	mockClient.EXPECT().Create(gomock.Any(), gomock.Any()).
+		Return(errors.New("index failed"))
+
+	err = indexOps.Update(ctx, jobID, nil, s.runtime)
+	s.Error(err)
+	s.Equal("index failed", err.Error())
 }
 
 // TestToJobSummary tests converting JobIndexObject to JobSummary
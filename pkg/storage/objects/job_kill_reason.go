@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+)
+
+// JobKillReasonUserRequest is the kill reason used when a user asked to
+// kill the job.
+const JobKillReasonUserRequest = "user_request"
+
+// init adds a JobKillReasonObject instance to the global list of storage
+// objects
+func init() {
+	Objs = append(Objs, &JobKillReasonObject{})
+}
+
+// JobKillReasonObject corresponds to a row in job_kill_reason table.
+type JobKillReasonObject struct {
+	// base.Object DB specific annotations
+	base.Object `cassandra:"name=job_kill_reason, primaryKey=((job_id))"`
+	// JobID of the job (uuid)
+	JobID string `column:"name=job_id"`
+	// Reason the job was killed for
+	Reason string `column:"name=reason"`
+	// KillTime is the time the reason was recorded at
+	KillTime time.Time `column:"name=kill_time"`
+}
+
+// transform will convert all the value from DB into the corresponding type
+// in ORM object to be interpreted by base store client
+func (o *JobKillReasonObject) transform(row map[string]interface{}) {
+	o.JobID = row["job_id"].(string)
+	o.Reason = row["reason"].(string)
+	o.KillTime = row["kill_time"].(time.Time)
+}
+
+// JobKill records why and when a job was killed.
+type JobKill struct {
+	// Reason the job was killed for
+	Reason string
+	// Time the reason was recorded at
+	Time time.Time
+}
+
+// SetKillReason records the reason a job is being killed for, replacing
+// any reason recorded earlier.
+func (d *jobRuntimeOps) SetKillReason(
+	ctx context.Context,
+	id *peloton.JobID,
+	reason string,
+) error {
+	obj := &JobKillReasonObject{
+		JobID:    id.GetValue(),
+		Reason:   reason,
+		KillTime: time.Now().UTC(),
+	}
+	if err := d.store.oClient.Create(ctx, obj); err != nil {
+		d.store.metrics.OrmJobMetrics.JobKillReasonSetFail.Inc(1)
+		return err
+	}
+
+	d.store.metrics.OrmJobMetrics.JobKillReasonSet.Inc(1)
+	return nil
+}
+
+// GetKillReason returns the reason the job was last killed for, or nil if
+// no reason was recorded.
+func (d *jobRuntimeOps) GetKillReason(
+	ctx context.Context,
+	id *peloton.JobID,
+) (*JobKill, error) {
+	obj := &JobKillReasonObject{
+		JobID: id.GetValue(),
+	}
+	row, err := d.store.oClient.Get(ctx, obj)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.JobKillReasonGetFail.Inc(1)
+		return nil, err
+	}
+
+	d.store.metrics.OrmJobMetrics.JobKillReasonGet.Inc(1)
+	if len(row) == 0 {
+		return nil, nil
+	}
+	obj.transform(row)
+	return &JobKill{
+		Reason: obj.Reason,
+		Time:   obj.KillTime,
+	}, nil
+}
@@ -16,7 +16,7 @@ package objects
 
 import (
 	"context"
-	"go.uber.org/yarpc/yarpcerrors"
+	"encoding/json"
 	"time"
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/job"
@@ -24,8 +24,21 @@ import (
 
 	"github.com/uber/peloton/pkg/storage/objects/base"
 
+	"github.com/gocql/gocql"
 	"github.com/gogo/protobuf/proto"
 	"github.com/pkg/errors"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// stateColumn is the job_runtime column holding the job state
+	stateColumn = "state"
+	// revisionColumn is the job_runtime column holding the runtime version
+	revisionColumn = "revision"
+
+	// _maxJobRuntimeUpdateAttempts is the number of times a read-modify-write
+	// of the job runtime is attempted when it is updated concurrently
+	_maxJobRuntimeUpdateAttempts = 5
 )
 
 // init adds a JobRuntimeObject instance to the global list of storage objects
@@ -46,6 +59,13 @@ type JobRuntimeObject struct {
 	State string `column:"name=state"`
 	// Update time of the job
 	UpdateTime time.Time `column:"name=update_time"`
+	// Task stats of the job, serialized as json. It is kept outside of
+	// RuntimeInfo so that it can be adjusted without rewriting the blob.
+	TaskStats string `column:"name=task_stats"`
+	// Version of the job runtime. It is kept outside of RuntimeInfo so that
+	// the partial updates can bump it, and be applied only if it did not
+	// change since the runtime was read.
+	Revision uint64 `column:"name=revision"`
 }
 
 // transform will convert all the value from DB into the corresponding type
@@ -55,15 +75,23 @@ func (o *JobRuntimeObject) transform(row map[string]interface{}) {
 	o.RuntimeInfo = row["runtime_info"].([]byte)
 	o.State = row["state"].(string)
 	o.UpdateTime = row["update_time"].(time.Time)
+	if taskStats, ok := row["task_stats"].(string); ok {
+		o.TaskStats = taskStats
+	}
+	if revision, ok := row[revisionColumn].(uint64); ok {
+		o.Revision = revision
+	}
 }
 
 // JobRuntimeOps provides methods for manipulating job_config table.
 type JobRuntimeOps interface {
-	// Upsert inserts/updates a row in the table.
+	// Upsert inserts/updates a row in the table. previousState is the
+	// state of the job runtime being replaced, or UNKNOWN if there is none.
 	Upsert(
 		ctx context.Context,
 		id *peloton.JobID,
 		runtime *job.RuntimeInfo,
+		previousState job.JobState,
 	) error
 
 	// Get retrieves a row from the table.
@@ -72,11 +100,67 @@ type JobRuntimeOps interface {
 		id *peloton.JobID,
 	) (*job.RuntimeInfo, error)
 
+	// GetJobState retrieves only the state of the job runtime, without
+	// decoding the runtime blob.
+	GetJobState(
+		ctx context.Context,
+		id *peloton.JobID,
+	) (job.JobState, error)
+
 	// Delete removes an object from the table.
 	Delete(
 		ctx context.Context,
 		id *peloton.JobID,
 	) error
+
+	// Update writes the job runtime, provided the runtime version in the
+	// table matches expectedVersion.
+	Update(
+		ctx context.Context,
+		id *peloton.JobID,
+		runtime *job.RuntimeInfo,
+		expectedVersion uint64,
+	) error
+
+	// UpdateState updates only the state of the job runtime and bumps its
+	// version, provided the runtime version in the table matches
+	// expectedVersion.
+	UpdateState(
+		ctx context.Context,
+		id *peloton.JobID,
+		state job.JobState,
+		expectedVersion uint64,
+	) error
+
+	// AdjustTaskStats applies the per task state deltas to the task stats
+	// of the job runtime and bumps its version, leaving the rest of the
+	// runtime untouched.
+	AdjustTaskStats(
+		ctx context.Context,
+		id *peloton.JobID,
+		deltas map[string]int32,
+	) error
+
+	// GetJobStateHistory returns the most recent state transitions of
+	// the job, sorted by ascending order of time of transition.
+	GetJobStateHistory(
+		ctx context.Context,
+		id *peloton.JobID,
+	) ([]*JobStateTransition, error)
+
+	// SetKillReason records the reason the job is being killed for.
+	SetKillReason(
+		ctx context.Context,
+		id *peloton.JobID,
+		reason string,
+	) error
+
+	// GetKillReason returns the reason the job was last killed for, or nil
+	// if no reason was recorded.
+	GetKillReason(
+		ctx context.Context,
+		id *peloton.JobID,
+	) (*JobKill, error)
 }
 
 // ensure that default implementation (jobRuntimeOps) satisfies the interface
@@ -94,12 +178,53 @@ func newJobRuntimeObject(
 		return nil, errors.Wrap(err, "Failed to marshal jobRuntime")
 	}
 
+	if len(runtime.GetTaskStats()) != 0 {
+		taskStatsBuffer, err := json.Marshal(runtime.GetTaskStats())
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to marshal task stats")
+		}
+		obj.TaskStats = string(taskStatsBuffer)
+	}
+
 	obj.RuntimeInfo = runtimeBuffer
 	obj.State = runtime.GetState().String()
 	obj.UpdateTime = time.Now().UTC()
+	obj.Revision = runtime.GetRevision().GetVersion()
 	return obj, nil
 }
 
+// toRuntimeInfo converts a JobRuntimeObject to job runtime. The state, task
+// stats and revision columns take precedence over the ones in the runtime
+// blob, as they may have been updated independently of the blob.
+func (o *JobRuntimeObject) toRuntimeInfo() (*job.RuntimeInfo, error) {
+	runtime := &job.RuntimeInfo{}
+	if err := proto.Unmarshal(o.RuntimeInfo, runtime); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal job runtime")
+	}
+
+	if state, ok := job.JobState_value[o.State]; ok {
+		runtime.State = job.JobState(state)
+	}
+
+	if len(o.TaskStats) != 0 {
+		taskStats := make(map[string]uint32)
+		if err := json.Unmarshal([]byte(o.TaskStats), &taskStats); err != nil {
+			return nil, errors.Wrap(err, "Failed to unmarshal task stats")
+		}
+		runtime.TaskStats = taskStats
+	}
+
+	if o.Revision > runtime.GetRevision().GetVersion() {
+		runtime.Revision = &peloton.ChangeLog{
+			Version:   o.Revision,
+			CreatedAt: runtime.GetRevision().GetCreatedAt(),
+			UpdatedAt: uint64(o.UpdateTime.UnixNano()),
+		}
+	}
+
+	return runtime, nil
+}
+
 // jobRuntimeOps implements jobRuntimeOps using a particular Store
 type jobRuntimeOps struct {
 	store *Store
@@ -110,11 +235,14 @@ func NewJobRuntimeOps(s *Store) JobRuntimeOps {
 	return &jobRuntimeOps{store: s}
 }
 
-// Upsert creates/updates a JobRuntimeObject in db
+// Upsert creates/updates a JobRuntimeObject in db, and records the state
+// of the runtime in the state history of the job if it differs from
+// previousState.
 func (d *jobRuntimeOps) Upsert(
 	ctx context.Context,
 	id *peloton.JobID,
 	runtime *job.RuntimeInfo,
+	previousState job.JobState,
 ) error {
 
 	obj, err := newJobRuntimeObject(id, runtime)
@@ -126,6 +254,8 @@ func (d *jobRuntimeOps) Upsert(
 		return err
 	}
 
+	d.store.recordJobStateTransition(
+		ctx, id, previousState, runtime.GetState(), obj.UpdateTime)
 	return nil
 }
 
@@ -134,6 +264,18 @@ func (d *jobRuntimeOps) Get(
 	ctx context.Context,
 	id *peloton.JobID,
 ) (*job.RuntimeInfo, error) {
+	obj, err := d.getObject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return obj.toRuntimeInfo()
+}
+
+// getObject reads a JobRuntimeObject from db
+func (d *jobRuntimeOps) getObject(
+	ctx context.Context,
+	id *peloton.JobID,
+) (*JobRuntimeObject, error) {
 	obj := &JobRuntimeObject{
 		JobID: id.GetValue(),
 	}
@@ -147,15 +289,50 @@ func (d *jobRuntimeOps) Get(
 			"Job runtime not found %s", id.Value)
 	}
 	obj.transform(row)
-	runtime := &job.RuntimeInfo{}
-	if err := proto.Unmarshal(obj.RuntimeInfo, runtime); err != nil {
-		return nil, errors.Wrap(err, "Failed to unmarshal job runtime")
+	return obj, nil
+}
+
+// revisionCondition returns the condition for a partial update of the
+// JobRuntimeObject read from db to be applied only if it was not updated
+// since. Runtimes written before the revision column was added have no
+// revision, which is read as zero, as versions start at one.
+func revisionCondition(obj *JobRuntimeObject) []base.Column {
+	condition := base.Column{Name: revisionColumn}
+	if obj.Revision != 0 {
+		condition.Value = obj.Revision
 	}
+	return []base.Column{condition}
+}
 
-	return runtime, nil
+// GetJobState reads only the state column of a JobRuntimeObject from db
+func (d *jobRuntimeOps) GetJobState(
+	ctx context.Context,
+	id *peloton.JobID,
+) (job.JobState, error) {
+	obj := &JobRuntimeObject{
+		JobID: id.GetValue(),
+	}
+
+	row, err := d.store.oClient.Get(ctx, obj, stateColumn)
+	if err != nil {
+		return job.JobState_UNKNOWN, err
+	}
+	if len(row) == 0 {
+		return job.JobState_UNKNOWN, yarpcerrors.NotFoundErrorf(
+			"Job runtime not found %s", id.Value)
+	}
+
+	stateStr, _ := row[stateColumn].(string)
+	state, ok := job.JobState_value[stateStr]
+	if !ok {
+		return job.JobState_UNKNOWN, yarpcerrors.InternalErrorf(
+			"invalid state %q in job runtime %s", stateStr, id.Value)
+	}
+	return job.JobState(state), nil
 }
 
-// Delete deletes a JobRuntimeObject from db
+// Delete deletes a JobRuntimeObject from db, along with the state history,
+// the kill reason and the events of the job
 func (d *jobRuntimeOps) Delete(
 	ctx context.Context,
 	id *peloton.JobID,
@@ -167,5 +344,217 @@ func (d *jobRuntimeOps) Delete(
 		return err
 	}
 
+	// the update time is not set, so that the whole state history
+	// of the job is deleted
+	if err := d.store.oClient.Delete(ctx, &JobStateHistoryObject{
+		JobID: id.GetValue(),
+	}); err != nil {
+		return err
+	}
+
+	if err := d.store.oClient.Delete(ctx, &JobKillReasonObject{
+		JobID: id.GetValue(),
+	}); err != nil {
+		return err
+	}
+
+	return NewJobEventsOps(d.store).Delete(ctx, id)
+}
+
+// Update writes the job runtime to the JobRuntimeObject in db, if the
+// version of the job runtime matches expectedVersion. The version of
+// runtime must be expectedVersion+1.
+func (d *jobRuntimeOps) Update(
+	ctx context.Context,
+	id *peloton.JobID,
+	runtime *job.RuntimeInfo,
+	expectedVersion uint64,
+) error {
+	version := runtime.GetRevision().GetVersion()
+	if version != expectedVersion+1 {
+		return yarpcerrors.InvalidArgumentErrorf(
+			"job runtime version %d does not follow expected version %d",
+			version, expectedVersion)
+	}
+
+	current, err := d.getObject(ctx, id)
+	if err != nil {
+		return err
+	}
+	currentRuntime, err := current.toRuntimeInfo()
+	if err != nil {
+		return err
+	}
+	if currentRuntime.GetRevision().GetVersion() != expectedVersion {
+		return yarpcerrors.AbortedErrorf(
+			"job runtime version %d does not match expected version %d",
+			currentRuntime.GetRevision().GetVersion(),
+			expectedVersion)
+	}
+
+	obj, err := newJobRuntimeObject(id, runtime)
+	if err != nil {
+		return errors.Wrap(err, "Failed to construct JobRuntimeObject")
+	}
+	fieldsToUpdate := []string{
+		"RuntimeInfo", "State", "UpdateTime", "TaskStats", "Revision"}
+	if err := d.store.oClient.UpdateIf(
+		ctx,
+		obj,
+		revisionCondition(current),
+		fieldsToUpdate...,
+	); err != nil {
+		return err
+	}
+
+	d.store.recordJobStateTransition(
+		ctx, id, currentRuntime.GetState(), runtime.GetState(), obj.UpdateTime)
+	return nil
+}
+
+// UpdateState updates the state column of a JobRuntimeObject in db,
+// if the version of the job runtime matches expectedVersion, and bumps
+// the version. The runtime blob is not rewritten, so fields updated
+// concurrently (like task stats) are not clobbered.
+func (d *jobRuntimeOps) UpdateState(
+	ctx context.Context,
+	id *peloton.JobID,
+	state job.JobState,
+	expectedVersion uint64,
+) error {
+	current, err := d.getObject(ctx, id)
+	if err != nil {
+		return err
+	}
+	runtime, err := current.toRuntimeInfo()
+	if err != nil {
+		return err
+	}
+
+	if runtime.GetRevision().GetVersion() != expectedVersion {
+		return yarpcerrors.AbortedErrorf(
+			"job runtime version %d does not match expected version %d",
+			runtime.GetRevision().GetVersion(),
+			expectedVersion)
+	}
+
+	obj := &JobRuntimeObject{
+		JobID:      id.GetValue(),
+		State:      state.String(),
+		UpdateTime: time.Now().UTC(),
+		Revision:   expectedVersion + 1,
+	}
+	fieldsToUpdate := []string{"State", "UpdateTime", "Revision"}
+	if err := d.store.oClient.UpdateIf(
+		ctx,
+		obj,
+		revisionCondition(current),
+		fieldsToUpdate...,
+	); err != nil {
+		return err
+	}
+
+	d.store.recordJobStateTransition(
+		ctx, id, runtime.GetState(), state, obj.UpdateTime)
 	return nil
 }
+
+// AdjustTaskStats adds the deltas to the task stats column of a
+// JobRuntimeObject in db, and bumps the version of the job runtime.
+// It fails if any of the resulting counts would be negative. The task
+// stats are read and adjusted again if the job runtime was updated
+// concurrently.
+func (d *jobRuntimeOps) AdjustTaskStats(
+	ctx context.Context,
+	id *peloton.JobID,
+	deltas map[string]int32,
+) error {
+	var err error
+	for i := 0; i < _maxJobRuntimeUpdateAttempts; i++ {
+		err = d.adjustTaskStats(ctx, id, deltas)
+		if !yarpcerrors.IsAborted(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// adjustTaskStats adds the deltas to the task stats column of a
+// JobRuntimeObject in db, if the job runtime is not updated between the
+// read and the write. Returns an Aborted error otherwise.
+func (d *jobRuntimeOps) adjustTaskStats(
+	ctx context.Context,
+	id *peloton.JobID,
+	deltas map[string]int32,
+) error {
+	current, err := d.getObject(ctx, id)
+	if err != nil {
+		return err
+	}
+	runtime, err := current.toRuntimeInfo()
+	if err != nil {
+		return err
+	}
+
+	taskStats := make(map[string]uint32)
+	for state, count := range runtime.GetTaskStats() {
+		taskStats[state] = count
+	}
+
+	for state, delta := range deltas {
+		count := int64(taskStats[state]) + int64(delta)
+		if count < 0 {
+			return yarpcerrors.InvalidArgumentErrorf(
+				"task stats for state %s cannot be negative", state)
+		}
+		taskStats[state] = uint32(count)
+	}
+
+	taskStatsBuffer, err := json.Marshal(taskStats)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal task stats")
+	}
+
+	obj := &JobRuntimeObject{
+		JobID:      id.GetValue(),
+		TaskStats:  string(taskStatsBuffer),
+		UpdateTime: time.Now().UTC(),
+		Revision:   runtime.GetRevision().GetVersion() + 1,
+	}
+	fieldsToUpdate := []string{"TaskStats", "UpdateTime", "Revision"}
+	return d.store.oClient.UpdateIf(
+		ctx,
+		obj,
+		revisionCondition(current),
+		fieldsToUpdate...,
+	)
+}
+
+// GetJobStateHistory returns the most recent state transitions of the job,
+// sorted by ascending order of time of transition.
+func (d *jobRuntimeOps) GetJobStateHistory(
+	ctx context.Context,
+	id *peloton.JobID,
+) ([]*JobStateTransition, error) {
+	rows, err := d.store.getJobStateHistoryRows(ctx, id)
+	if err != nil {
+		d.store.metrics.OrmJobMetrics.JobStateHistoryGetFail.Inc(1)
+		return nil, err
+	}
+
+	history := make([]*JobStateTransition, 0, len(rows))
+	for _, row := range rows {
+		timeUUID, err := gocql.ParseUUID(row.UpdateTime.Value)
+		if err != nil {
+			d.store.metrics.OrmJobMetrics.JobStateHistoryGetFail.Inc(1)
+			return nil, err
+		}
+		history = append(history, &JobStateTransition{
+			State: job.JobState(job.JobState_value[row.State]),
+			Time:  timeUUID.Time(),
+		})
+	}
+
+	d.store.metrics.OrmJobMetrics.JobStateHistoryGet.Inc(1)
+	return history, nil
+}
@@ -17,6 +17,7 @@ package objects
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -51,7 +52,7 @@ func (s *JobRuntimeObjectTestSuite) TestUpsertGetDeleteJobRuntime() {
 	jobRuntimeOps := NewJobRuntimeOps(testStore)
 	ctx := context.Background()
 
-	err := jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime)
+	err := jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN)
 	s.NoError(err)
 
 	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
@@ -67,6 +68,311 @@ func (s *JobRuntimeObjectTestSuite) TestUpsertGetDeleteJobRuntime() {
 	s.True(yarpcerrors.IsNotFound(err))
 }
 
+// TestUpdateStateAndAdjustTaskStats tests that a state only update does
+// not disturb the task stats adjusted before it and vice versa, and that
+// both bump the runtime version
+func (s *JobRuntimeObjectTestSuite) TestUpdateStateAndAdjustTaskStats() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.runtime.TaskStats = map[string]uint32{"RUNNING": 2}
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	s.NoError(jobRuntimeOps.AdjustTaskStats(
+		ctx,
+		s.jobID,
+		map[string]int32{"RUNNING": -1, "SUCCEEDED": 1},
+	))
+	s.NoError(jobRuntimeOps.UpdateState(
+		ctx,
+		s.jobID,
+		job.JobState_RUNNING,
+		s.runtime.GetRevision().GetVersion()+1,
+	))
+
+	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(job.JobState_RUNNING, runtime.GetState())
+	s.Equal(
+		map[string]uint32{"RUNNING": 1, "SUCCEEDED": 1},
+		runtime.GetTaskStats())
+	s.Equal(s.runtime.GetGoalState(), runtime.GetGoalState())
+	s.Equal(
+		s.runtime.GetRevision().GetVersion()+2,
+		runtime.GetRevision().GetVersion())
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestConcurrentUpdateState tests that only one of concurrent state updates
+// expecting the same version is applied
+func (s *JobRuntimeObjectTestSuite) TestConcurrentUpdateState() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	states := []job.JobState{job.JobState_RUNNING, job.JobState_KILLING}
+	errs := make([]error, len(states))
+	var wg sync.WaitGroup
+	wg.Add(len(states))
+	for i, state := range states {
+		go func(i int, state job.JobState) {
+			defer wg.Done()
+			errs[i] = jobRuntimeOps.UpdateState(
+				ctx,
+				s.jobID,
+				state,
+				s.runtime.GetRevision().GetVersion(),
+			)
+		}(i, state)
+	}
+	wg.Wait()
+
+	var applied job.JobState
+	failed := 0
+	for i, err := range errs {
+		if err == nil {
+			applied = states[i]
+			continue
+		}
+		s.True(yarpcerrors.IsAborted(err))
+		failed++
+	}
+	s.Equal(1, failed)
+
+	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(applied, runtime.GetState())
+	s.Equal(
+		s.runtime.GetRevision().GetVersion()+1,
+		runtime.GetRevision().GetVersion())
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestConcurrentAdjustTaskStats tests that no adjustment is lost when
+// the task stats are adjusted concurrently
+func (s *JobRuntimeObjectTestSuite) TestConcurrentAdjustTaskStats() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	callers := _maxJobRuntimeUpdateAttempts - 1
+	s.runtime.TaskStats = map[string]uint32{"RUNNING": uint32(callers)}
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			s.NoError(jobRuntimeOps.AdjustTaskStats(
+				ctx,
+				s.jobID,
+				map[string]int32{"RUNNING": -1, "SUCCEEDED": 1},
+			))
+		}()
+	}
+	wg.Wait()
+
+	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(
+		map[string]uint32{"RUNNING": 0, "SUCCEEDED": uint32(callers)},
+		runtime.GetTaskStats())
+	s.Equal(
+		s.runtime.GetRevision().GetVersion()+uint64(callers),
+		runtime.GetRevision().GetVersion())
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestUpdateStateVersionMismatch tests that the state is not updated
+// when the expected version does not match
+func (s *JobRuntimeObjectTestSuite) TestUpdateStateVersionMismatch() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	err := jobRuntimeOps.UpdateState(
+		ctx,
+		s.jobID,
+		job.JobState_RUNNING,
+		s.runtime.GetRevision().GetVersion()+1,
+	)
+	s.Error(err)
+	s.True(yarpcerrors.IsAborted(err))
+
+	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(s.runtime.GetState(), runtime.GetState())
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestAdjustTaskStatsNegative tests that task stats cannot be
+// adjusted below zero
+func (s *JobRuntimeObjectTestSuite) TestAdjustTaskStatsNegative() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.runtime.TaskStats = map[string]uint32{"RUNNING": 1}
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	err := jobRuntimeOps.AdjustTaskStats(
+		ctx,
+		s.jobID,
+		map[string]int32{"RUNNING": -2},
+	)
+	s.Error(err)
+	s.True(yarpcerrors.IsInvalidArgument(err))
+
+	runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(map[string]uint32{"RUNNING": 1}, runtime.GetTaskStats())
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestGetJobState tests that GetJobState matches the state of the full
+// runtime across state transitions
+func (s *JobRuntimeObjectTestSuite) TestGetJobState() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	_, err := jobRuntimeOps.GetJobState(ctx, s.jobID)
+	s.Error(err)
+	s.True(yarpcerrors.IsNotFound(err))
+
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+	for _, state := range []job.JobState{
+		job.JobState_PENDING,
+		job.JobState_RUNNING,
+		job.JobState_SUCCEEDED,
+	} {
+		s.runtime.State = state
+		s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+		jobState, err := jobRuntimeOps.GetJobState(ctx, s.jobID)
+		s.NoError(err)
+		runtime, err := jobRuntimeOps.Get(ctx, s.jobID)
+		s.NoError(err)
+		s.Equal(runtime.GetState(), jobState)
+		s.Equal(state, jobState)
+	}
+
+	// a state only update is visible to GetJobState as well
+	s.NoError(jobRuntimeOps.UpdateState(
+		ctx,
+		s.jobID,
+		job.JobState_KILLED,
+		s.runtime.GetRevision().GetVersion(),
+	))
+	jobState, err := jobRuntimeOps.GetJobState(ctx, s.jobID)
+	s.NoError(err)
+	s.Equal(job.JobState_KILLED, jobState)
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+}
+
+// TestJobStateHistory tests that state transitions of the job are recorded
+// in order, only when the state changes, that the history is bounded and
+// that it is deleted with the job
+func (s *JobRuntimeObjectTestSuite) TestJobStateHistory() {
+	store := &Store{
+		oClient:             testStore.oClient,
+		metrics:             testStore.metrics,
+		jobStateHistorySize: 3,
+	}
+	jobRuntimeOps := NewJobRuntimeOps(store)
+	ctx := context.Background()
+
+	history, err := jobRuntimeOps.GetJobStateHistory(ctx, s.jobID)
+	s.NoError(err)
+	s.Empty(history)
+
+	s.NoError(jobRuntimeOps.Upsert(
+		ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+	// an update which does not change the state adds no transition
+	s.NoError(jobRuntimeOps.Upsert(
+		ctx, s.jobID, s.runtime, s.runtime.GetState()))
+
+	history, err = jobRuntimeOps.GetJobStateHistory(ctx, s.jobID)
+	s.NoError(err)
+	s.Len(history, 1)
+	s.Equal(job.JobState_INITIALIZED, history[0].State)
+
+	for _, state := range []job.JobState{
+		job.JobState_PENDING,
+		job.JobState_RUNNING,
+	} {
+		previousState := s.runtime.GetState()
+		s.runtime.State = state
+		s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, previousState))
+	}
+	s.NoError(jobRuntimeOps.UpdateState(
+		ctx,
+		s.jobID,
+		job.JobState_KILLED,
+		s.runtime.GetRevision().GetVersion(),
+	))
+
+	// only the most recent transitions are kept
+	history, err = jobRuntimeOps.GetJobStateHistory(ctx, s.jobID)
+	s.NoError(err)
+	s.Len(history, 3)
+	for i, state := range []job.JobState{
+		job.JobState_PENDING,
+		job.JobState_RUNNING,
+		job.JobState_KILLED,
+	} {
+		s.Equal(state, history[i].State)
+		if i > 0 {
+			s.False(history[i].Time.Before(history[i-1].Time))
+		}
+	}
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+	history, err = jobRuntimeOps.GetJobStateHistory(ctx, s.jobID)
+	s.NoError(err)
+	s.Empty(history)
+}
+
+// TestKillReason tests that the reason a job was killed for round trips,
+// is not clobbered by runtime updates and is deleted with the job
+func (s *JobRuntimeObjectTestSuite) TestKillReason() {
+	jobRuntimeOps := NewJobRuntimeOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+	kill, err := jobRuntimeOps.GetKillReason(ctx, s.jobID)
+	s.NoError(err)
+	s.Nil(kill)
+
+	for _, reason := range []string{
+		JobKillReasonUserRequest,
+		"test reason",
+	} {
+		before := time.Now().Add(-time.Second)
+		s.NoError(jobRuntimeOps.SetKillReason(ctx, s.jobID, reason))
+
+		s.runtime.State = job.JobState_KILLED
+		s.NoError(jobRuntimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN))
+
+		kill, err = jobRuntimeOps.GetKillReason(ctx, s.jobID)
+		s.NoError(err)
+		s.Equal(reason, kill.Reason)
+		s.True(kill.Time.After(before))
+	}
+
+	s.NoError(jobRuntimeOps.Delete(ctx, s.jobID))
+	kill, err = jobRuntimeOps.GetKillReason(ctx, s.jobID)
+	s.NoError(err)
+	s.Nil(kill)
+}
+
 // TestCreateGetDeleteJobRuntimeFail tests failure cases due to ORM Client errors
 func (s *JobRuntimeObjectTestSuite) TestCreateGetDeleteJobRuntimeFail() {
 	ctrl := gomock.NewController(s.T())
@@ -85,7 +391,7 @@ func (s *JobRuntimeObjectTestSuite) TestCreateGetDeleteJobRuntimeFail() {
 
 	ctx := context.Background()
 
-	err := runtimeOps.Upsert(ctx, s.jobID, s.runtime)
+	err := runtimeOps.Upsert(ctx, s.jobID, s.runtime, job.JobState_UNKNOWN)
 	s.Error(err)
 	s.Equal("create failed", err.Error())
 
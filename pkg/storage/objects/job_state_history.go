@@ -0,0 +1,150 @@
+// Copyright (c) 2019 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objects
+
+import (
+	"context"
+	"time"
+
+	"github.com/uber/peloton/.gen/peloton/api/v0/job"
+	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
+
+	"github.com/uber/peloton/pkg/storage/objects/base"
+
+	"github.com/gocql/gocql"
+	log "github.com/sirupsen/logrus"
+)
+
+// _defaultJobStateHistorySize is the number of state transitions kept in
+// the state history of a job if it is not configured.
+const _defaultJobStateHistorySize = 100
+
+// init adds a JobStateHistoryObject instance to the global list of storage
+// objects
+func init() {
+	Objs = append(Objs, &JobStateHistoryObject{})
+}
+
+// JobStateHistoryObject corresponds to a row in job_state_history table.
+type JobStateHistoryObject struct {
+	// base.Object DB specific annotations
+	base.Object `cassandra:"name=job_state_history, primaryKey=((job_id),update_time)"`
+	// JobID of the job (uuid)
+	JobID string `column:"name=job_id"`
+	// UpdateTime is the time uuid of the state transition
+	UpdateTime *base.OptionalString `column:"name=update_time"`
+	// State of the job after the transition
+	State string `column:"name=state"`
+}
+
+// transform will convert all the value from DB into the corresponding type
+// in ORM object to be interpreted by base store client
+func (o *JobStateHistoryObject) transform(row map[string]interface{}) {
+	o.JobID = row["job_id"].(string)
+	o.UpdateTime = base.NewOptionalString(row["update_time"])
+	o.State = row["state"].(string)
+}
+
+// JobStateTransition is a single entry in the state history of a job.
+type JobStateTransition struct {
+	// State of the job after the transition
+	State job.JobState
+	// Time at which the transition happened
+	Time time.Time
+}
+
+// getJobStateHistoryRows reads the state history rows of a job, sorted by
+// ascending order of time of transition.
+func (s *Store) getJobStateHistoryRows(
+	ctx context.Context,
+	id *peloton.JobID,
+) ([]*JobStateHistoryObject, error) {
+	rows, err := s.oClient.GetAll(ctx, &JobStateHistoryObject{
+		JobID: id.GetValue(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]*JobStateHistoryObject, 0, len(rows))
+	for _, row := range rows {
+		obj := &JobStateHistoryObject{}
+		obj.transform(row)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// addJobStateTransition appends a transition to state to the state history
+// of a job, and drops the oldest transitions beyond jobStateHistorySize.
+func (s *Store) addJobStateTransition(
+	ctx context.Context,
+	id *peloton.JobID,
+	state job.JobState,
+	updateTime time.Time,
+) error {
+	if err := s.oClient.Create(ctx, &JobStateHistoryObject{
+		JobID:      id.GetValue(),
+		UpdateTime: base.NewOptionalString(gocql.UUIDFromTime(updateTime).String()),
+		State:      state.String(),
+	}); err != nil {
+		s.metrics.OrmJobMetrics.JobStateHistoryAddFail.Inc(1)
+		return err
+	}
+
+	history, err := s.getJobStateHistoryRows(ctx, id)
+	if err != nil {
+		s.metrics.OrmJobMetrics.JobStateHistoryAddFail.Inc(1)
+		return err
+	}
+
+	maxSize := s.jobStateHistorySize
+	if maxSize <= 0 {
+		maxSize = _defaultJobStateHistorySize
+	}
+	for len(history) > maxSize {
+		old := history[0]
+		history = history[1:]
+		if err := s.oClient.Delete(ctx, old); err != nil {
+			s.metrics.OrmJobMetrics.JobStateHistoryAddFail.Inc(1)
+			return err
+		}
+	}
+
+	s.metrics.OrmJobMetrics.JobStateHistoryAdd.Inc(1)
+	return nil
+}
+
+// recordJobStateTransition records the transition of a job from
+// previousState to state in its state history, if the state changed.
+// A failure is logged but not returned, so that the history does not
+// fail the update of the job runtime.
+func (s *Store) recordJobStateTransition(
+	ctx context.Context,
+	id *peloton.JobID,
+	previousState job.JobState,
+	state job.JobState,
+	updateTime time.Time,
+) {
+	if previousState == state {
+		return
+	}
+	if err := s.addJobStateTransition(ctx, id, state, updateTime); err != nil {
+		log.WithError(err).
+			WithField("job_id", id.GetValue()).
+			WithField("state", state.String()).
+			Warn("failed to record job state transition")
+	}
+}
@@ -21,10 +21,12 @@ import (
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/storage/objects/base"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 var (
@@ -50,6 +52,19 @@ type ResPoolObject struct {
 	UpdateTime time.Time `column:"name=update_time"`
 }
 
+// ResPoolNameObject corresponds to a row in respool_names table, which
+// indexes the names of the resource pools under their parent.
+type ResPoolNameObject struct {
+	// base.Object DB specific annotations.
+	base.Object `cassandra:"name=respool_names, primaryKey=((parent_id), name)"`
+	// ParentID is the ID of the parent of the resource pool.
+	ParentID string `column:"name=parent_id"`
+	// Name of the resource pool.
+	Name string `column:"name=name"`
+	// RespoolID is the ID of the resource pool with the name.
+	RespoolID string `column:"name=respool_id"`
+}
+
 // transform will convert all the value from DB into the corresponding type
 // in ORM object to be interpreted by base store client
 func (o *ResPoolObject) transform(row map[string]interface{}) {
@@ -114,6 +129,7 @@ type resPoolOps struct {
 // init adds a ResPoolObject instance to the global list of storage objects.
 func init() {
 	Objs = append(Objs, &ResPoolObject{})
+	Objs = append(Objs, &ResPoolNameObject{})
 }
 
 // newResPoolObject creates a ResPoolObject.
@@ -157,6 +173,11 @@ func (r *resPoolOps) Create(
 	Config *respool.ResourcePoolConfig,
 	owner string,
 ) error {
+	if err := r.validateParent(ctx, id, Config); err != nil {
+		r.store.metrics.OrmRespoolMetrics.RespoolCreateFail.Inc(1)
+		return err
+	}
+
 	createTime := time.Now().UTC()
 	obj, err := newResPoolObject(id, Config, createTime, createTime, owner)
 	if err != nil {
@@ -164,7 +185,17 @@ func (r *resPoolOps) Create(
 		return errors.Wrap(err, "Failed to construct ResPoolObject")
 	}
 
+	claimed, err := r.claimName(ctx, id, Config)
+	if err != nil {
+		r.store.metrics.OrmRespoolMetrics.RespoolCreateFail.Inc(1)
+		return err
+	}
+
 	if err = r.store.oClient.CreateIfNotExists(ctx, obj); err != nil {
+		// a name claimed by an earlier attempt belongs to the existing pool
+		if claimed {
+			r.releaseName(ctx, id, Config)
+		}
 		r.store.metrics.OrmRespoolMetrics.RespoolCreateFail.Inc(1)
 		return err
 	}
@@ -173,6 +204,167 @@ func (r *resPoolOps) Create(
 	return nil
 }
 
+// validateParent walks up the parents of the resource pool being created
+// until the root, and returns an error if a parent does not exist or if
+// the pool would end up being its own ancestor.
+func (r *resPoolOps) validateParent(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	config *respool.ResourcePoolConfig,
+) error {
+	visited := map[string]bool{id.GetValue(): true}
+	parent := config.GetParent().GetValue()
+	for parent != "" && parent != common.RootResPoolID {
+		if visited[parent] {
+			return errors.Errorf(
+				"resource pool %s creates a cycle through parent %s",
+				id.GetValue(), parent)
+		}
+		visited[parent] = true
+
+		result, err := r.GetResult(ctx, parent)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get parent %s", parent)
+		}
+		if result == nil {
+			return errors.Errorf(
+				"parent %s of resource pool %s does not exist",
+				parent, id.GetValue())
+		}
+		parent = result.RespoolConfig.GetParent().GetValue()
+	}
+	return nil
+}
+
+// claimName indexes the name of the resource pool under its parent with a
+// lightweight transaction, and returns an error if a sibling with the same
+// name already exists, since the path of a resource pool is made of the
+// names of its ancestors and has to identify it. It returns true if the
+// name was claimed by this call, and false if the resource pool already
+// owned it.
+func (r *resPoolOps) claimName(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	config *respool.ResourcePoolConfig,
+) (bool, error) {
+	obj := &ResPoolNameObject{
+		ParentID:  parentID(config),
+		Name:      config.GetName(),
+		RespoolID: id.GetValue(),
+	}
+	err := r.store.oClient.CreateIfNotExists(ctx, obj)
+	if err == nil {
+		// the resource pools created before the names were indexed are
+		// not in the index, so they are checked in the respools table
+		if err := r.validateUnindexedSiblings(ctx, id, config); err != nil {
+			r.releaseName(ctx, id, config)
+			return false, err
+		}
+		return true, nil
+	}
+	if !yarpcerrors.IsAlreadyExists(err) {
+		return false, err
+	}
+
+	// the name may already be claimed by the same resource pool, when its
+	// creation is retried or when it is updated
+	siblingID, err := r.getNameOwner(ctx, obj.ParentID, obj.Name)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get sibling resource pool")
+	}
+	if siblingID == id.GetValue() {
+		return false, nil
+	}
+	return false, nameConflictError(id.GetValue(), siblingID, config)
+}
+
+// validateUnindexedSiblings returns an error if another resource pool with
+// the same name exists under the same parent in the respools table.
+func (r *resPoolOps) validateUnindexedSiblings(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	config *respool.ResourcePoolConfig,
+) error {
+	configs, err := r.GetAll(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get sibling resource pools")
+	}
+	for siblingID, sibling := range configs {
+		if siblingID != id.GetValue() &&
+			parentID(sibling) == parentID(config) &&
+			sibling.GetName() == config.GetName() {
+			return nameConflictError(id.GetValue(), siblingID, config)
+		}
+	}
+	return nil
+}
+
+// nameConflictError returns the error of a resource pool whose name is
+// already used by a sibling.
+func nameConflictError(
+	id string,
+	siblingID string,
+	config *respool.ResourcePoolConfig,
+) error {
+	return yarpcerrors.AlreadyExistsErrorf(
+		"resource pool %s conflicts with resource pool %s: "+
+			"name %s already exists under parent %s",
+		id, siblingID, config.GetName(), parentID(config))
+}
+
+// releaseName removes the name of the resource pool from the index of the
+// names under its parent, if it is claimed by the resource pool. Failures
+// are only logged, as a leaked name is released once its pool is deleted.
+func (r *resPoolOps) releaseName(
+	ctx context.Context,
+	id *peloton.ResourcePoolID,
+	config *respool.ResourcePoolConfig,
+) {
+	parent := parentID(config)
+	owner, err := r.getNameOwner(ctx, parent, config.GetName())
+	if err == nil && owner == id.GetValue() {
+		err = r.store.oClient.Delete(ctx, &ResPoolNameObject{
+			ParentID: parent,
+			Name:     config.GetName(),
+		})
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"respool_id": id.GetValue(),
+			"parent_id":  parent,
+			"name":       config.GetName(),
+		}).WithError(err).Warn("Failed to release resource pool name")
+	}
+}
+
+// getNameOwner returns the ID of the resource pool with the name under
+// the parent, or an empty ID if the name is not claimed.
+func (r *resPoolOps) getNameOwner(
+	ctx context.Context,
+	parent string,
+	name string,
+) (string, error) {
+	row, err := r.store.oClient.Get(ctx, &ResPoolNameObject{
+		ParentID: parent,
+		Name:     name,
+	})
+	if err != nil {
+		return "", err
+	}
+	owner, _ := row["respool_id"].(string)
+	return owner, nil
+}
+
+// parentID returns the ID of the parent of a resource pool, where no
+// parent means the root resource pool.
+func parentID(config *respool.ResourcePoolConfig) string {
+	parent := config.GetParent().GetValue()
+	if parent == "" {
+		return common.RootResPoolID
+	}
+	return parent
+}
+
 // GetAll gets all the resource pool configs from the table.
 func (r *resPoolOps) GetAll(ctx context.Context) (map[string]*respool.ResourcePoolConfig, error) {
 	resultObjs := map[string]*respool.ResourcePoolConfig{}
@@ -252,6 +444,11 @@ func (r *resPoolOps) Update(
 		return nil
 	}
 	obj.transform(row)
+	oldConfig, err := obj.toConfig()
+	if err != nil {
+		r.store.metrics.OrmRespoolMetrics.RespoolUpdateFail.Inc(1)
+		return errors.Wrap(err, "Failed to unmarshal config")
+	}
 	configBuffer, err := json.Marshal(config)
 	if err != nil {
 		return errors.Wrap(err, "Failed to marshal resourcePoolConfig")
@@ -259,6 +456,16 @@ func (r *resPoolOps) Update(
 	obj.RespoolConfig = string(configBuffer)
 	obj.UpdateTime = time.Now().UTC()
 
+	// the new name is claimed even if it did not change, so that the
+	// resource pools created before the names were indexed get indexed
+	renamed := parentID(oldConfig) != parentID(config) ||
+		oldConfig.GetName() != config.GetName()
+	claimed, err := r.claimName(ctx, id, config)
+	if err != nil {
+		r.store.metrics.OrmRespoolMetrics.RespoolUpdateFail.Inc(1)
+		return err
+	}
+
 	err = r.store.oClient.Update(ctx, obj, _respoolConfigFields...)
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -266,16 +473,29 @@ func (r *resPoolOps) Update(
 			"config":     config,
 		}).Error(err)
 
+		if claimed {
+			r.releaseName(ctx, id, config)
+		}
 		r.store.metrics.OrmRespoolMetrics.RespoolUpdateFail.Inc(1)
 		return err
 	}
 
+	if renamed {
+		r.releaseName(ctx, id, oldConfig)
+	}
+
 	r.store.metrics.OrmRespoolMetrics.RespoolUpdate.Inc(1)
 	return nil
 }
 
-// Delete removes the ResPoolObject from the db.
+// Delete removes the ResPoolObject from the db, and releases its name.
 func (r *resPoolOps) Delete(ctx context.Context, id *peloton.ResourcePoolID) error {
+	result, err := r.GetResult(ctx, id.GetValue())
+	if err != nil {
+		r.store.metrics.OrmRespoolMetrics.RespoolDeleteFail.Inc(1)
+		return err
+	}
+
 	resPoolObject := &ResPoolObject{
 		RespoolID: base.NewOptionalString(id.GetValue()),
 	}
@@ -283,6 +503,10 @@ func (r *resPoolOps) Delete(ctx context.Context, id *peloton.ResourcePoolID) err
 		r.store.metrics.OrmRespoolMetrics.RespoolDeleteFail.Inc(1)
 		return err
 	}
+
+	if result != nil {
+		r.releaseName(ctx, id, result.RespoolConfig)
+	}
 	r.store.metrics.OrmRespoolMetrics.RespoolDelete.Inc(1)
 	return nil
 }
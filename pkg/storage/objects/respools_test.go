@@ -26,8 +26,10 @@ import (
 
 	"github.com/uber/peloton/.gen/peloton/api/v0/peloton"
 	"github.com/uber/peloton/.gen/peloton/api/v0/respool"
+	"github.com/uber/peloton/pkg/common"
 	"github.com/uber/peloton/pkg/storage/objects/base"
 	ormmocks "github.com/uber/peloton/pkg/storage/orm/mocks"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 type ResPoolsObjectTestSuite struct {
@@ -54,16 +56,18 @@ func (s *ResPoolsObjectTestSuite) TestCreateGetAllResourcePools() {
 	ctx := context.Background()
 
 	// Create two resource pools.
+	secondConfig := *s.resPoolConfig
+	secondConfig.Name = "resource-pool-2"
 	err := testResPoolOps.Create(ctx, s.respoolId1, s.resPoolConfig, "peloton")
 	s.NoError(err)
-	err = testResPoolOps.Create(ctx, s.respoolId2, s.resPoolConfig, "peloton")
+	err = testResPoolOps.Create(ctx, s.respoolId2, &secondConfig, "peloton")
 	s.NoError(err)
 
 	// Check the created respool matches what's created in the db.
 	results, err := testResPoolOps.GetAll(ctx)
 	s.NoError(err)
 	s.Len(results, 2)
-	s.Equal(s.resPoolConfig, results[s.respoolId2.GetValue()])
+	s.Equal(&secondConfig, results[s.respoolId2.GetValue()])
 	s.Equal(s.resPoolConfig, results[s.respoolId1.GetValue()])
 
 	// clean up the created respool
@@ -73,6 +77,177 @@ func (s *ResPoolsObjectTestSuite) TestCreateGetAllResourcePools() {
 	s.NoError(err)
 }
 
+// TestCreateResourcePoolParentValidation tests that creating a resource
+// pool fails if its parent does not exist or if it creates a cycle.
+func (s *ResPoolsObjectTestSuite) TestCreateResourcePoolParentValidation() {
+	testResPoolOps := NewResPoolOps(testStore)
+	ctx := context.Background()
+
+	// A child of an existing resource pool can be created.
+	err := testResPoolOps.Create(ctx, s.respoolId1, s.resPoolConfig, "peloton")
+	s.NoError(err)
+	childConfig := &respool.ResourcePoolConfig{
+		Name:   "child",
+		Parent: s.respoolId1,
+	}
+	err = testResPoolOps.Create(ctx, s.respoolId2, childConfig, "peloton")
+	s.NoError(err)
+
+	// A child of a non-existent resource pool cannot be created.
+	orphanID := &peloton.ResourcePoolID{Value: uuid.New()}
+	orphanConfig := &respool.ResourcePoolConfig{
+		Name:   "orphan",
+		Parent: &peloton.ResourcePoolID{Value: uuid.New()},
+	}
+	err = testResPoolOps.Create(ctx, orphanID, orphanConfig, "peloton")
+	s.Error(err)
+	s.Contains(err.Error(), "does not exist")
+
+	// A resource pool cannot be its own parent.
+	selfConfig := &respool.ResourcePoolConfig{
+		Name:   "self",
+		Parent: orphanID,
+	}
+	err = testResPoolOps.Create(ctx, orphanID, selfConfig, "peloton")
+	s.Error(err)
+	s.Contains(err.Error(), "cycle")
+
+	// Re-parent respool1 under a pool which is yet to be created,
+	// so that creating it would close the loop respool1 -> respool2 ->
+	// cyclic -> respool1.
+	cyclicID := &peloton.ResourcePoolID{Value: uuid.New()}
+	updatedConfig := &respool.ResourcePoolConfig{
+		Name:   s.resPoolConfig.GetName(),
+		Parent: cyclicID,
+	}
+	err = testResPoolOps.Update(ctx, s.respoolId1, updatedConfig)
+	s.NoError(err)
+	cyclicConfig := &respool.ResourcePoolConfig{
+		Name:   "cyclic",
+		Parent: s.respoolId2,
+	}
+	err = testResPoolOps.Create(ctx, cyclicID, cyclicConfig, "peloton")
+	s.Error(err)
+	s.Contains(err.Error(), "cycle")
+
+	result, err := testResPoolOps.GetResult(ctx, cyclicID.GetValue())
+	s.NoError(err)
+	s.Nil(result)
+
+	// clean up the created respools
+	s.NoError(testResPoolOps.Delete(ctx, s.respoolId1))
+	s.NoError(testResPoolOps.Delete(ctx, s.respoolId2))
+}
+
+// TestCreateResourcePoolSiblingNames tests that creating a resource pool
+// fails if a sibling with the same name exists under the same parent.
+func (s *ResPoolsObjectTestSuite) TestCreateResourcePoolSiblingNames() {
+	testResPoolOps := NewResPoolOps(testStore)
+	ctx := context.Background()
+
+	s.NoError(testResPoolOps.Create(ctx, s.respoolId1, s.resPoolConfig, "peloton"))
+
+	// Siblings with distinct names can be created.
+	childID1 := &peloton.ResourcePoolID{Value: uuid.New()}
+	childID2 := &peloton.ResourcePoolID{Value: uuid.New()}
+	s.NoError(testResPoolOps.Create(ctx, childID1, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	}, "peloton"))
+	s.NoError(testResPoolOps.Create(ctx, childID2, &respool.ResourcePoolConfig{
+		Name:   "child2",
+		Parent: s.respoolId1,
+	}, "peloton"))
+
+	// A sibling with a colliding name is rejected.
+	collidingID := &peloton.ResourcePoolID{Value: uuid.New()}
+	err := testResPoolOps.Create(ctx, collidingID, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	}, "peloton")
+	s.Error(err)
+	s.True(yarpcerrors.IsAlreadyExists(err))
+	s.Contains(err.Error(), childID1.GetValue())
+	result, err := testResPoolOps.GetResult(ctx, collidingID.GetValue())
+	s.NoError(err)
+	s.Nil(result)
+
+	// A retried creation of an existing resource pool keeps its name.
+	s.Error(testResPoolOps.Create(ctx, childID1, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	}, "peloton"))
+	err = testResPoolOps.Create(ctx, collidingID, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	}, "peloton")
+	s.True(yarpcerrors.IsAlreadyExists(err))
+
+	// A resource pool created before the names were indexed is a sibling
+	// too, and the name is not left claimed by the rejected pool.
+	legacyID := &peloton.ResourcePoolID{Value: uuid.New()}
+	legacyConfig := &respool.ResourcePoolConfig{
+		Name:   "legacy",
+		Parent: s.respoolId1,
+	}
+	createTime := time.Now().UTC()
+	legacyObj, err := newResPoolObject(
+		legacyID, legacyConfig, createTime, createTime, "peloton")
+	s.NoError(err)
+	s.NoError(testStore.oClient.CreateIfNotExists(ctx, legacyObj))
+	err = testResPoolOps.Create(ctx, collidingID, &respool.ResourcePoolConfig{
+		Name:   "legacy",
+		Parent: s.respoolId1,
+	}, "peloton")
+	s.True(yarpcerrors.IsAlreadyExists(err))
+	s.Contains(err.Error(), legacyID.GetValue())
+	owner, err := testResPoolOps.(*resPoolOps).getNameOwner(
+		ctx, s.respoolId1.GetValue(), "legacy")
+	s.NoError(err)
+	s.Empty(owner)
+
+	// The same name can be used under a different parent.
+	s.NoError(testResPoolOps.Create(ctx, collidingID, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: childID2,
+	}, "peloton"))
+
+	// A resource pool without a parent is a sibling of the children of
+	// the root resource pool.
+	err = testResPoolOps.Create(ctx, s.respoolId2, &respool.ResourcePoolConfig{
+		Name:   s.resPoolConfig.GetName(),
+		Parent: &peloton.ResourcePoolID{Value: common.RootResPoolID},
+	}, "peloton")
+	s.Error(err)
+	s.True(yarpcerrors.IsAlreadyExists(err))
+
+	// A resource pool cannot be renamed to the name of a sibling.
+	err = testResPoolOps.Update(ctx, childID2, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	})
+	s.Error(err)
+	s.True(yarpcerrors.IsAlreadyExists(err))
+
+	// The name of a deleted resource pool can be reused.
+	s.NoError(testResPoolOps.Delete(ctx, childID1))
+	s.NoError(testResPoolOps.Update(ctx, childID2, &respool.ResourcePoolConfig{
+		Name:   "child1",
+		Parent: s.respoolId1,
+	}))
+	s.NoError(testResPoolOps.Create(ctx, childID1, &respool.ResourcePoolConfig{
+		Name:   "child2",
+		Parent: s.respoolId1,
+	}, "peloton"))
+
+	// clean up the created respools
+	for _, id := range []*peloton.ResourcePoolID{
+		s.respoolId1, childID1, childID2, collidingID, legacyID,
+	} {
+		s.NoError(testResPoolOps.Delete(ctx, id))
+	}
+}
+
 // TestUpdateResourcePool tests updating a resource pool from store.
 func (s *ResPoolsObjectTestSuite) TestUpdateResourcePool() {
 	testResPoolOps := NewResPoolOps(testStore)
@@ -123,8 +298,12 @@ func (s *ResPoolsObjectTestSuite) TestRespoolOpsClientFail() {
 		Return(errors.New("create failed"))
 	mockClient.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(errors.New("update failed")).AnyTimes()
-	mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
-		Return(nil, errors.New("get failed"))
+	gomock.InOrder(
+		mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+			Return(nil, errors.New("get failed")),
+		mockClient.EXPECT().Get(gomock.Any(), gomock.Any()).
+			Return(nil, nil),
+	)
 	mockClient.EXPECT().Delete(gomock.Any(), gomock.Any()).
 		Return(errors.New("delete failed"))
 
@@ -34,6 +34,7 @@ const (
 // Init to add the secret object instance to the global list of storage objects
 func init() {
 	Objs = append(Objs, &SecretInfoObject{})
+	Objs = append(Objs, &SecretInfoByJobObject{})
 }
 
 // SecretInfoObject corresponds to a peloton secret. All fields should be exported.
@@ -59,6 +60,27 @@ type SecretInfoObject struct {
 	Valid bool `column:"name=valid"`
 }
 
+// SecretInfoByJobObject corresponds to a row in the mv_secret_info_by_job
+// materialized view, which indexes the secret_info table by job ID.
+type SecretInfoByJobObject struct {
+	// DB specific annotations
+	base.Object `cassandra:"name=mv_secret_info_by_job, primaryKey=((job_id), secret_id, valid)"`
+	// JobID of the job for which the secret is created
+	JobID string `column:"name=job_id"`
+	// SecretID is the ID of the secret
+	SecretID string `column:"name=secret_id"`
+	// This flag indicates that the secret is valid or invalid
+	Valid bool `column:"name=valid"`
+	// Container mount path of this secret
+	Path string `column:"name=path"`
+	// Secret Data (base64 encoded string)
+	Data string `column:"name=data"`
+	// Creation time of the secret
+	CreationTime time.Time `column:"name=creation_time"`
+	// Version of this secret
+	Version int64 `column:"name=version"`
+}
+
 // transform will convert all the value from DB into the corresponding type
 // in ORM object to be interpreted by base store client
 func (o *SecretInfoObject) transform(row map[string]interface{}) {
@@ -87,6 +109,12 @@ type SecretInfoOps interface {
 		secretID string,
 	) (*SecretInfoObject, error)
 
+	// GetSecretsForJob retrieves the valid SecretInfoObjects of a job.
+	GetSecretsForJob(
+		ctx context.Context,
+		jobID string,
+	) ([]*SecretInfoObject, error)
+
 	// Update modifies the SecretInfoObject in the table.
 	UpdateSecretData(
 		ctx context.Context,
@@ -182,6 +210,33 @@ func (s *secretInfoOps) GetSecret(
 	return secretInfoObject, nil
 }
 
+// GetSecretsForJob gets all the valid secret objects of a job from db.
+// Invalidated secrets are not returned.
+func (s *secretInfoOps) GetSecretsForJob(
+	ctx context.Context,
+	jobID string,
+) ([]*SecretInfoObject, error) {
+	rows, err := s.store.oClient.GetAll(ctx, &SecretInfoByJobObject{
+		JobID: jobID,
+	})
+	if err != nil {
+		s.store.metrics.OrmJobMetrics.SecretInfoGetForJobFail.Inc(1)
+		return nil, err
+	}
+
+	var secrets []*SecretInfoObject
+	for _, row := range rows {
+		secretInfoObject := &SecretInfoObject{}
+		secretInfoObject.transform(row)
+		if !secretInfoObject.Valid {
+			continue
+		}
+		secrets = append(secrets, secretInfoObject)
+	}
+	s.store.metrics.OrmJobMetrics.SecretInfoGetForJob.Inc(1)
+	return secrets, nil
+}
+
 // UpdateSecretData updates a secret data in db
 func (s *secretInfoOps) UpdateSecretData(
 	ctx context.Context,
@@ -17,6 +17,7 @@ package objects
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"testing"
 	"time"
 
@@ -85,3 +86,46 @@ func (suite *SecretInfoObjectTestSuite) TestSecretInfoOps() {
 	suite.Error(err)
 	suite.True(yarpcerrors.IsNotFound(err))
 }
+
+// TestGetSecretsForJob tests getting the valid secrets of a job.
+func (suite *SecretInfoObjectTestSuite) TestGetSecretsForJob() {
+	db := NewSecretInfoOps(testStore)
+	ctx := context.Background()
+
+	jobID := uuid.New()
+	now := time.Now().UTC()
+	var secretIDs []string
+	for i := 0; i < 3; i++ {
+		secretID := uuid.New()
+		data := base64.StdEncoding.EncodeToString(
+			[]byte(fmt.Sprintf("secret-%d", i)))
+		suite.NoError(db.CreateSecret(
+			ctx, jobID, now, secretID, data, fmt.Sprintf("/path/%d", i)))
+		secretIDs = append(secretIDs, secretID)
+	}
+
+	// secrets of another job are not returned
+	suite.NoError(db.CreateSecret(
+		ctx, uuid.New(), now, uuid.New(), "other", "/other/path"))
+
+	// invalidate the first secret
+	invalid, err := db.GetSecret(ctx, secretIDs[0])
+	suite.NoError(err)
+	suite.NoError(db.DeleteSecret(ctx, secretIDs[0]))
+	invalid.Valid = false
+	suite.NoError(testStore.oClient.Create(ctx, invalid))
+
+	secrets, err := db.GetSecretsForJob(ctx, jobID)
+	suite.NoError(err)
+	var ids []string
+	for _, secret := range secrets {
+		suite.True(secret.Valid)
+		suite.Equal(jobID, secret.JobID)
+		ids = append(ids, secret.SecretID)
+	}
+	suite.ElementsMatch(secretIDs[1:], ids)
+
+	secrets, err = db.GetSecretsForJob(ctx, uuid.New())
+	suite.NoError(err)
+	suite.Empty(secrets)
+}
@@ -40,6 +40,14 @@ var Objs []base.Object
 type Store struct {
 	oClient orm.Client
 	metrics *pelotonstore.Metrics
+
+	// jobConfigCache caches job configs read from the DB. It is nil if
+	// caching is disabled.
+	jobConfigCache *jobConfigCache
+
+	// jobStateHistorySize is the maximum number of state transitions kept
+	// in the state history of a job.
+	jobStateHistorySize int
 }
 
 // NewCassandraStore creates a new Cassandra storage client
@@ -51,16 +59,23 @@ func NewCassandraStore(
 	if err != nil {
 		return nil, err
 	}
-	// TODO: Load up all objects automatically instead of explicitly adding
-	// them here. Might need to add some Go init() magic to do this.
+	// All the objects add themselves to Objs from their init method.
 	oclient, err := orm.NewClient(connector, Objs...)
 	if err != nil {
 		return nil, err
 	}
-	return &Store{
-		oClient: oclient,
-		metrics: pelotonstore.NewMetrics(scope),
-	}, nil
+	store := &Store{
+		oClient:             oclient,
+		metrics:             pelotonstore.NewMetrics(scope),
+		jobStateHistorySize: config.JobStateHistorySize,
+	}
+	if config.JobConfigCacheSize > 0 {
+		store.jobConfigCache, err = newJobConfigCache(config.JobConfigCacheSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
 }
 
 // GenerateTestCassandraConfig generates a test config for local C* client
@@ -17,6 +17,7 @@ package orm
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"github.com/uber/peloton/pkg/storage/objects/base"
 
@@ -30,12 +31,35 @@ type Client interface {
 	CreateIfNotExists(ctx context.Context, e base.Object) error
 	// Create creates the storage object in the database
 	Create(ctx context.Context, e base.Object) error
+	// CreateWithTTL creates the storage object in the database, which
+	// expires after ttl
+	CreateWithTTL(ctx context.Context, e base.Object, ttl time.Duration) error
 	// Get gets the storage object from the database
 	Get(ctx context.Context, e base.Object, fieldsToRead ...string) (
 		map[string]interface{}, error)
 	// GetAll gets all the storage objects for the partition key from the
 	// database
 	GetAll(ctx context.Context, e base.Object) ([]map[string]interface{}, error)
+	// GetAllOrdered gets at most limit storage objects for the partition
+	// key from the database, sorted by the clustering column orderBy in
+	// ascending order if asc is set and descending order otherwise
+	GetAllOrdered(
+		ctx context.Context,
+		e base.Object,
+		orderBy string,
+		asc bool,
+		limit int,
+	) ([]map[string]interface{}, error)
+	// GetAllInTimeRange gets the storage objects for the partition key
+	// from the database, whose timeuuid clustering column is in the
+	// [from, to) time range, sorted by ascending order of the column
+	GetAllInTimeRange(
+		ctx context.Context,
+		e base.Object,
+		column string,
+		from time.Time,
+		to time.Time,
+	) ([]map[string]interface{}, error)
 	// GetAllIter provides an iterative way to fetch all storage objects
 	// for the partition key
 	GetAllIter(ctx context.Context, e base.Object) (Iterator, error)
@@ -45,6 +69,15 @@ type Client interface {
 	// the caller. If not specified, all fields in the object will be updated
 	// to the DB
 	Update(ctx context.Context, e base.Object, fieldsToUpdate ...string) error
+	// UpdateIf updates the storage object in the database, if the columns
+	// of the stored object have the values of conditions. It returns an
+	// Aborted error if the object was not updated.
+	UpdateIf(
+		ctx context.Context,
+		e base.Object,
+		conditions []base.Column,
+		fieldsToUpdate ...string,
+	) error
 	// Delete deletes the storage object from the database
 	Delete(ctx context.Context, e base.Object) error
 }
@@ -109,6 +142,28 @@ func (c *client) Create(ctx context.Context, e base.Object) error {
 	return c.connector.Create(ctx, &table.Definition, table.GetRowFromObject(e))
 }
 
+// CreateWithTTL creates the storage object in the database, which expires
+// after ttl
+func (c *client) CreateWithTTL(
+	ctx context.Context,
+	e base.Object,
+	ttl time.Duration,
+) error {
+	// lookup if a table exists for this object, return error if not found
+	table, err := c.getTable(e)
+	if err != nil {
+		return err
+	}
+
+	// Tell the connector to create a row in the DB using this row
+	return c.connector.CreateWithTTL(
+		ctx,
+		&table.Definition,
+		table.GetRowFromObject(e),
+		ttl,
+	)
+}
+
 // Get fetches an base by primary key, The base provided must contain
 // values for all components of its primary key for the operation to succeed.
 func (c *client) Get(
@@ -159,6 +214,55 @@ func (c *client) GetAll(
 	return rows, nil
 }
 
+// GetAllOrdered fetches at most limit base objects for the given partition
+// key and clustering keys, sorted by the clustering column orderBy.
+// The base object provided must contain the value of its partition key
+func (c *client) GetAllOrdered(
+	ctx context.Context,
+	e base.Object,
+	orderBy string,
+	asc bool,
+	limit int,
+) ([]map[string]interface{}, error) {
+
+	// lookup if a table exists for this object, return error if not found
+	table, err := c.getTable(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// build a partition and clustering key row from storage object
+	keyRow := table.GetKeyRowFromObject(e)
+
+	return c.connector.GetAllOrdered(
+		ctx, &table.Definition, keyRow, orderBy, asc, limit)
+}
+
+// GetAllInTimeRange fetches the base objects for the given partition key
+// and clustering keys, whose timeuuid clustering column is in the
+// [from, to) time range, sorted by ascending order of the column.
+// The base object provided must contain the value of its partition key
+func (c *client) GetAllInTimeRange(
+	ctx context.Context,
+	e base.Object,
+	column string,
+	from time.Time,
+	to time.Time,
+) ([]map[string]interface{}, error) {
+
+	// lookup if a table exists for this object, return error if not found
+	table, err := c.getTable(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// build a partition and clustering key row from storage object
+	keyRow := table.GetKeyRowFromObject(e)
+
+	return c.connector.GetAllInTimeRange(
+		ctx, &table.Definition, keyRow, column, from, to)
+}
+
 // GetAllIter fetches a list of base objects for the given partition key
 // using an iterator. The base object provided must contain the value of
 // its partition key
@@ -201,6 +305,32 @@ func (c *client) Update(
 	return c.connector.Update(ctx, &table.Definition, row, keyRow)
 }
 
+// UpdateIf updates the storage object in the database if the conditions
+// on its columns hold
+func (c *client) UpdateIf(
+	ctx context.Context,
+	e base.Object,
+	conditions []base.Column,
+	fieldsToUpdate ...string,
+) error {
+	// lookup if a table exists for this object, return error if not found
+	table, err := c.getTable(e)
+	if err != nil {
+		return err
+	}
+
+	// translate the storage object into a row (list of column)
+	row := table.GetRowFromObject(e, fieldsToUpdate...)
+
+	// build a primary key row from storage object
+	keyRow := table.GetKeyRowFromObject(e)
+
+	// Tell the connector to update a row in the DB using this row, if the
+	// conditions hold
+	return c.connector.UpdateIf(
+		ctx, &table.Definition, row, keyRow, conditions)
+}
+
 // Delete deletes the storage object in the database
 func (c *client) Delete(ctx context.Context, e base.Object) error {
 	// lookup if a table exists for this object, return error if not found
@@ -17,6 +17,7 @@ package orm_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/uber/peloton/pkg/storage/objects/base"
 	"github.com/uber/peloton/pkg/storage/orm"
@@ -151,6 +152,29 @@ func (suite *ORMTestSuite) TestClientCreate() {
 	suite.Error(err)
 }
 
+// TestClientCreateWithTTL tests client create with TTL operation on valid
+// and invalid entities
+func (suite *ORMTestSuite) TestClientCreateWithTTL() {
+	defer suite.ctrl.Finish()
+	conn := ormmocks.NewMockConnector(suite.ctrl)
+
+	conn.EXPECT().CreateWithTTL(
+		suite.ctx, gomock.Any(), gomock.Any(), time.Hour).
+		Do(func(_ context.Context, _ *base.Definition, row []base.Column,
+			_ time.Duration) {
+			suite.ensureRowsEqual(row, testRow)
+		}).Return(nil)
+
+	client, err := orm.NewClient(conn, &ValidObject{})
+	suite.NoError(err)
+
+	err = client.CreateWithTTL(suite.ctx, testValidObject, time.Hour)
+	suite.NoError(err)
+
+	err = client.CreateWithTTL(suite.ctx, &InvalidObject1{}, time.Hour)
+	suite.Error(err)
+}
+
 // TestClientGet tests client get operation on valid and invalid entities
 func (suite *ORMTestSuite) TestClientGet() {
 	defer suite.ctrl.Finish()
@@ -220,6 +244,69 @@ func (suite *ORMTestSuite) TestClientGetAll() {
 	suite.Error(err)
 }
 
+// TestClientGetAllOrdered tests client GetAllOrdered operation on valid and
+// invalid entities
+func (suite *ORMTestSuite) TestClientGetAllOrdered() {
+	defer suite.ctrl.Finish()
+	conn := ormmocks.NewMockConnector(suite.ctrl)
+
+	// ValidObject instance with only primary key set
+	e := &ValidObject{
+		ID: uint64(1),
+	}
+
+	conn.EXPECT().GetAllOrdered(
+		suite.ctx, gomock.Any(), gomock.Any(), "name", false, 2).
+		Do(func(_ context.Context, _ *base.Definition,
+			row []base.Column, _ string, _ bool, _ int) {
+			suite.Equal("id", row[0].Name)
+			suite.Equal(e.ID, row[0].Value)
+		}).Return(testRows, nil)
+
+	client, err := orm.NewClient(conn, &ValidObject{})
+	suite.NoError(err)
+
+	objs, err := client.GetAllOrdered(suite.ctx, e, "name", false, 2)
+	suite.NoError(err)
+	suite.Len(objs, 2)
+
+	_, err = client.GetAllOrdered(suite.ctx, &InvalidObject1{}, "name", false, 2)
+	suite.Error(err)
+}
+
+// TestClientGetAllInTimeRange tests client GetAllInTimeRange operation on
+// valid and invalid entities
+func (suite *ORMTestSuite) TestClientGetAllInTimeRange() {
+	defer suite.ctrl.Finish()
+	conn := ormmocks.NewMockConnector(suite.ctrl)
+	from := time.Now()
+	to := from.Add(time.Hour)
+
+	// ValidObject instance with only primary key set
+	e := &ValidObject{
+		ID: uint64(1),
+	}
+
+	conn.EXPECT().GetAllInTimeRange(
+		suite.ctx, gomock.Any(), gomock.Any(), "name", from, to).
+		Do(func(_ context.Context, _ *base.Definition,
+			row []base.Column, _ string, _ time.Time, _ time.Time) {
+			suite.Equal("id", row[0].Name)
+			suite.Equal(e.ID, row[0].Value)
+		}).Return(testRows, nil)
+
+	client, err := orm.NewClient(conn, &ValidObject{})
+	suite.NoError(err)
+
+	objs, err := client.GetAllInTimeRange(suite.ctx, e, "name", from, to)
+	suite.NoError(err)
+	suite.Len(objs, 2)
+
+	_, err = client.GetAllInTimeRange(
+		suite.ctx, &InvalidObject1{}, "name", from, to)
+	suite.Error(err)
+}
+
 // TestClientGetAllIter tests client GetAllIter operation on valid and
 // invalid entities
 func (suite *ORMTestSuite) TestClientGetAllIter() {
@@ -300,6 +387,34 @@ func (suite *ORMTestSuite) TestClientUpdate() {
 	suite.Error(err)
 }
 
+// TestClientUpdateIf tests client CAS update operation on valid and
+// invalid entities
+func (suite *ORMTestSuite) TestClientUpdateIf() {
+	defer suite.ctrl.Finish()
+	conn := ormmocks.NewMockConnector(suite.ctrl)
+	conditions := []base.Column{{Name: "name", Value: "old"}}
+
+	conn.EXPECT().UpdateIf(
+		suite.ctx, gomock.Any(), gomock.Any(), gomock.Any(), conditions).
+		Do(func(_ context.Context, _ *base.Definition,
+			row []base.Column, keyRow []base.Column, _ []base.Column) {
+			suite.Equal("data", row[0].Name)
+			suite.Equal("testdata", row[0].Value)
+			suite.Equal("id", keyRow[0].Name)
+			suite.Equal(uint64(1), keyRow[0].Value)
+		}).Return(nil)
+
+	client, err := orm.NewClient(conn, &ValidObject{})
+	suite.NoError(err)
+
+	// Update Data field in testValidObject if Name is old
+	err = client.UpdateIf(suite.ctx, testValidObject, conditions, "Data")
+	suite.NoError(err)
+
+	err = client.UpdateIf(suite.ctx, &InvalidObject1{}, conditions)
+	suite.Error(err)
+}
+
 // TestClientDelete tests client delete operation on valid and invalid entities
 func (suite *ORMTestSuite) TestClientDelete() {
 	defer suite.ctrl.Finish()
@@ -16,6 +16,7 @@ package orm
 
 import (
 	"context"
+	"time"
 
 	"github.com/uber/peloton/pkg/storage/objects/base"
 )
@@ -33,6 +34,15 @@ type Connector interface {
 	// Create creates a row in the DB for the base object
 	Create(ctx context.Context, e *base.Definition, values []base.Column) error
 
+	// CreateWithTTL creates a row in the DB for the base object, which
+	// expires after ttl
+	CreateWithTTL(
+		ctx context.Context,
+		e *base.Definition,
+		values []base.Column,
+		ttl time.Duration,
+	) error
+
 	// Get fetches a row by primary key of base object
 	Get(
 		ctx context.Context,
@@ -49,6 +59,30 @@ type Connector interface {
 		keys []base.Column,
 	) ([]map[string]interface{}, error)
 
+	// GetAllOrdered fetches at most limit base objects for the partition
+	// key and the given clustering keys, sorted by the clustering column
+	// orderBy in ascending order if asc is set and descending otherwise
+	GetAllOrdered(
+		ctx context.Context,
+		e *base.Definition,
+		keys []base.Column,
+		orderBy string,
+		asc bool,
+		limit int,
+	) ([]map[string]interface{}, error)
+
+	// GetAllInTimeRange fetches the base objects for the partition key
+	// and the given clustering keys, whose timeuuid clustering column is
+	// in the [from, to) time range, sorted by ascending order of the column
+	GetAllInTimeRange(
+		ctx context.Context,
+		e *base.Definition,
+		keys []base.Column,
+		column string,
+		from time.Time,
+		to time.Time,
+	) ([]map[string]interface{}, error)
+
 	GetAllIter(
 		ctx context.Context,
 		e *base.Definition,
@@ -63,6 +97,17 @@ type Connector interface {
 		keys []base.Column,
 	) error
 
+	// UpdateIf updates a row in the DB for the base object if the columns
+	// of the row have the values of ifCols, and returns an Aborted error
+	// otherwise
+	UpdateIf(
+		ctx context.Context,
+		e *base.Definition,
+		values []base.Column,
+		keys []base.Column,
+		ifCols []base.Column,
+	) error
+
 	// Delete deletes a row from the DB for the base object
 	Delete(ctx context.Context, e *base.Definition, keys []base.Column) error
 }